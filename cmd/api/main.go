@@ -3,18 +3,70 @@ package main
 import (
 	"MoneyPilot/internal/api"
 	"MoneyPilot/internal/config"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/migrations"
 	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/tracing"
+	"context"
 	"log"
+	"log/slog"
+	"os"
 )
 
 func main() {
-	cfg := config.Load()
-	db := storage.NewPostgres(cfg.PostgresDSN)
-	redis := storage.NewRedis(cfg.RedisAddr)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("[config] %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, os.Args[2:])
+		return
+	}
+
+	logger, err := logging.New(logging.Config{Level: cfg.Log.Level, Levels: cfg.Log.Levels, Format: cfg.Log.Format}, os.Stdout)
+	if err != nil {
+		log.Fatalf("[logging] %v", err)
+	}
+	// оставшиеся вызовы стандартного log идут через тот же обработчик
+	slog.SetDefault(logger)
+	logger.Info("config loaded", "file", os.Getenv("CONFIG_FILE"))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.ServiceName)
+	if err != nil {
+		fatal(logger, "tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	db, err := storage.NewPostgres(cfg.Postgres.DSN, logger)
+	if err != nil {
+		fatal(logger, "postgres unavailable", err)
+	}
 	defer db.Close()
+	redis, err := storage.NewRedis(cfg.Redis.Addr, logger)
+	if err != nil {
+		fatal(logger, "redis unavailable", err)
+	}
 	defer redis.Close()
 
-	router := api.NewRouter(db, "secret", redis)
-	log.Printf("🚀 Server running on port %s\n", cfg.ServerPort)
-	router.Run(":" + cfg.ServerPort)
+	if cfg.Postgres.AutoMigrate {
+		m, err := migrations.NewMigrator(db, logger)
+		if err != nil {
+			fatal(logger, "migrator init failed", err)
+		}
+		if err := m.Up(); err != nil {
+			fatal(logger, "auto-migrate failed", err)
+		}
+	}
+
+	router := api.NewRouter(db, redis, cfg, logger)
+	logger.Info("server running", "port", cfg.Server.Port)
+	if err := router.Run(":" + cfg.Server.Port); err != nil {
+		fatal(logger, "server stopped", err)
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, logging.Err(err))
+	os.Exit(1)
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"MoneyPilot/internal/config"
+	"MoneyPilot/internal/migrations"
+	"MoneyPilot/internal/storage"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [N]       revert the last N migrations (default 1, "all" reverts everything)
+  status         show current version and pending migrations
+  force VERSION  set the version and clear the dirty flag without running SQL`
+
+// runMigrate — подкоманда `api migrate ...`
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	// логгер ещё не настроен: сообщения идут через стандартный slog в stderr
+	db, err := storage.NewPostgres(cfg.Postgres.DSN, slog.Default())
+	if err != nil {
+		log.Fatalf("[migrate] %v", err)
+	}
+	defer db.Close()
+
+	m, err := migrations.NewMigrator(db, slog.Default())
+	if err != nil {
+		log.Fatalf("[migrate] %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if args[1] == "all" {
+				steps = 0
+			} else if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("[migrate] invalid step count %q", args[1])
+			}
+		}
+		err = m.Down(steps)
+	case "status":
+		var st *migrations.Status
+		if st, err = m.Status(); err == nil {
+			fmt.Printf("version: %d (dirty: %t)\n", st.Version, st.Dirty)
+			for _, mg := range st.Applied {
+				fmt.Printf("  [x] %04d_%s\n", mg.Version, mg.Name)
+			}
+			for _, mg := range st.Pending {
+				fmt.Printf("  [ ] %04d_%s\n", mg.Version, mg.Name)
+			}
+		}
+	case "force":
+		if len(args) < 2 {
+			log.Fatal("[migrate] force requires a version")
+		}
+		v, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil {
+			log.Fatalf("[migrate] invalid version %q", args[1])
+		}
+		err = m.Force(v)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("[migrate] %s failed: %v", args[0], err)
+	}
+}
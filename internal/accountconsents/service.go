@@ -1,12 +1,16 @@
 package accountconsents
 
 import (
+	"MoneyPilot/internal/audit"
 	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/logging"
 	"MoneyPilot/internal/storage"
+	"context"
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -15,18 +19,24 @@ import (
 
 // Handler хранит зависимости: репозиторий, сервис токенов и реестр банков
 type ConsentHandler struct {
-	Repo        *storage.Repository
+	Repo        storage.Store
 	TokenSvc    *bankapi.TokenService
 	BankClients map[string]*bankapi.BankClient
 	HTTPClient  *http.Client
+	Audit       *audit.Service
+	ConsentTTL  time.Duration // срок действия согласия на счета
+	Log         *slog.Logger
 }
 
-func NewConsentHandler(repo *storage.Repository, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient) *ConsentHandler {
+func NewConsentHandler(repo storage.Store, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient, httpClient *http.Client, auditSvc *audit.Service, logger *slog.Logger) *ConsentHandler {
 	return &ConsentHandler{
 		Repo:        repo,
 		TokenSvc:    ts,
 		BankClients: clients,
-		HTTPClient:  &http.Client{Timeout: 15 * time.Second},
+		HTTPClient:  httpClient,
+		Audit:       auditSvc,
+		ConsentTTL:  90 * 24 * time.Hour,
+		Log:         logging.For(logger, "accountconsents"),
 	}
 }
 
@@ -44,7 +54,6 @@ type CreateConsentRequest struct {
 func (h *ConsentHandler) CreateConsent(c *gin.Context) {
 	// 1) авторизация: берем user_id из контекста
 	userID := c.GetInt("user_id")
-	log.Println(userID)
 	if userID == 0 {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
@@ -129,7 +138,7 @@ func (h *ConsentHandler) CreateConsent(c *gin.Context) {
 		"client_id":            user.ClientID,
 		"permissions":          []string{"ReadAccountsDetail", "ReadBalances", "ReadTransactionsDetail"},
 		"reason":               "Агрегация счетов для HackAPI",
-		"requesting_bank":      "team081",
+		"requesting_bank":      bankClient.ClientID,
 		"requesting_bank_name": "MoneyPilot",
 	}
 	bodyBytes, _ := json.Marshal(payload)
@@ -165,7 +174,7 @@ func (h *ConsentHandler) CreateConsent(c *gin.Context) {
 	var autoApproved bool
 
 	if err := json.Unmarshal(respBody, &bankResp); err != nil {
-		log.Printf("warning: can't parse bank response: %v body=%s", err, string(respBody))
+		h.Log.WarnContext(c.Request.Context(), "can't parse bank response", "bank", bankCode, logging.Err(err), logging.Body(respBody))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Unmarshal failed", "details": err.Error()})
 		return
 	}
@@ -205,15 +214,21 @@ func (h *ConsentHandler) CreateConsent(c *gin.Context) {
 	}
 
 	// 11) Сохраняем согласие в БД
-	expiresAt := time.Now().Add(90 * 24 * time.Hour)
-	saveErr := h.Repo.SaveAccountConsentByClientIdAndBank(user.ClientID, bankCode, idToSave, "team081", []string{"ReadAccountsDetail", "ReadBalances", "ReadTransactionsDetail"}, status, expiresAt)
+	expiresAt := time.Now().Add(h.ConsentTTL)
+	saveErr := h.Repo.SaveAccountConsentByClientIdAndBank(user.ClientID, bankCode, idToSave, bankClient.ClientID, []string{"ReadAccountsDetail", "ReadBalances", "ReadTransactionsDetail"}, status, expiresAt)
 	if saveErr != nil {
-		log.Printf("error saving consent: %v", saveErr)
+		h.Log.ErrorContext(c.Request.Context(), "failed to save consent", "bank", bankCode, logging.Err(saveErr))
 		// не скрываем ошибку от клиента: говорим 500, т.к. данные не сохранились
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save consent", "details": saveErr.Error()})
 		return
 	}
 
+	h.Audit.RecordAction(c.Request.Context(), audit.ActionConsentCreate, bankCode, idToSave, map[string]interface{}{
+		"consent_type":  "account",
+		"status":        status,
+		"auto_approved": autoApproved,
+	})
+
 	// 12) Отдаём ответ клиенту
 	c.JSON(http.StatusOK, gin.H{
 		"status":        status,
@@ -223,20 +238,108 @@ func (h *ConsentHandler) CreateConsent(c *gin.Context) {
 	})
 }
 
+// DELETE /api/account-consent/:consent_id
+// Requires header X-Bank-Code: <vbank|sbank|abank>
+// Отзывает согласие клиента в банке и помечает его в БД как revoked.
+func (h *ConsentHandler) RevokeConsent(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	consentID := c.Param("consent_id")
+	bankCode := c.GetHeader("X-Bank-Code")
+	if bankCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Bank-Code header required"})
+		return
+	}
+	bankClient, ok := h.BankClients[bankCode]
+	if !ok || bankClient == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown bank code"})
+		return
+	}
+	ctx := c.Request.Context()
+	repo := h.Repo.WithContext(ctx)
+
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load user", "details": err.Error()})
+		return
+	}
+	consents, err := repo.GetValidAccountConsentsByUserIDAndBank(userID, bankCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load consents", "details": err.Error()})
+		return
+	}
+	owned := false
+	for _, cons := range consents {
+		if cons.ConsentID == consentID {
+			owned = true
+			break
+		}
+	}
+	if !owned {
+		c.JSON(http.StatusNotFound, gin.H{"error": "consent not found"})
+		return
+	}
+
+	if h.TokenSvc == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token service not configured"})
+		return
+	}
+	tokenObj, err := h.TokenSvc.GetValidToken(bankClient)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to obtain bank token", "details": err.Error()})
+		return
+	}
+
+	target := strings.TrimRight(bankClient.BaseURL, "/") + "/account-consents/" + url.PathEscape(consentID) + "?client_id=" + url.QueryEscape(user.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, target, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request", "details": err.Error()})
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "bank request failed", "details": err.Error()})
+		return
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// 404 — банк уже не знает согласие: у нас его всё равно нужно закрыть
+	if (resp.StatusCode < 200 || resp.StatusCode >= 300) && resp.StatusCode != http.StatusNotFound {
+		c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), respBody)
+		return
+	}
+
+	if err := repo.UpdateAccountConsentStatusByConsentID(consentID, "revoked"); err != nil {
+		h.Log.ErrorContext(ctx, "failed to mark consent revoked", "bank", bankCode, "consent_id", consentID, logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update consent", "details": err.Error()})
+		return
+	}
+
+	h.Audit.RecordAction(ctx, audit.ActionConsentRevoke, bankCode, consentID, map[string]interface{}{
+		"consent_type": "account",
+		"status_code":  resp.StatusCode,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "revoked", "consent_id": consentID, "bank": bankCode})
+}
+
 // StartPoller runs PollPendingConsents periodically in a background goroutine.
 // Callers should provide a stop channel which will stop the goroutine when closed.
 func (h *ConsentHandler) StartPoller(interval time.Duration, stopCh <-chan struct{}) {
-	log.Println("НАчало работы StartPoller")
 	ticker := time.NewTicker(interval)
 	go func() {
 		defer ticker.Stop()
-		log.Printf("consent poller started, interval=%s", interval)
+		h.Log.Info("consent poller started", "interval", interval)
 		for {
 			select {
 			case <-ticker.C:
 				h.PollPendingConsents()
 			case <-stopCh:
-				log.Println("consent poller stopping")
+				h.Log.Info("consent poller stopping")
 				return
 			}
 		}
@@ -250,65 +353,61 @@ func (h *ConsentHandler) StartPoller(interval time.Duration, stopCh <-chan struc
 func (h *ConsentHandler) PollPendingConsents() {
 	consents, err := h.Repo.GetPendingAccountConsents()
 	if err != nil {
-		log.Printf("poll: failed to load pending consents: %v", err)
+		h.Log.Error("failed to load pending consents", logging.Err(err))
 		return
 	}
 
 	for _, c := range consents {
+		log := h.Log.With("consent_id", c.ConsentID)
 		if c.BankCode == nil || *c.BankCode == "" {
-			log.Printf("poll: skipping consent %s: bank code missing", c.ConsentID)
+			log.Warn("skipping consent: bank code missing")
 			continue
 		}
+		log = log.With("bank", *c.BankCode)
 
 		bankClient, ok := h.BankClients[*c.BankCode]
 		if !ok || bankClient == nil {
-			log.Printf("poll: unknown bank code for consent %s: %s", c.ConsentID, *c.BankCode)
+			log.Warn("unknown bank code")
 			continue
 		}
 
 		// Obtain bank token
 		tokenObj, tErr := h.TokenSvc.GetValidToken(bankClient)
 		if tErr != nil {
-			log.Printf("poll: failed to get token for bank %s: %v", *c.BankCode, tErr)
+			log.Error("failed to get token", logging.Err(tErr))
 			continue
 		}
 		if tokenObj == nil || tokenObj.Token == "" {
-			log.Printf("poll: empty token for bank %s", *c.BankCode)
+			log.Error("empty token")
 			continue
 		}
 
 		target := strings.TrimRight(bankClient.BaseURL, "/") + "/account-consents/" + c.ConsentID
-		req, err := http.NewRequest(http.MethodGet, target, nil)
+		req, err := http.NewRequestWithContext(audit.WithUserID(context.Background(), c.UserID), http.MethodGet, target, nil)
 		if err != nil {
-			log.Printf("poll: failed to build request for %s: %v", c.ConsentID, err)
+			log.Error("failed to build request", logging.Err(err))
 			continue
 		}
 		req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
 		req.Header.Set("Accept", "application/json")
-		req.Header.Set("X-Fapi-Interaction-Id", "team081")
-		// include interaction id header if we have requesting bank info
-		// if c.RequestingBank != nil && *c.RequestingBank != "" {
-		// 	req.Header.Set("X-Fapi-Interaction-Id", *c.RequestingBank)
-		// } else {
-		// 	req.Header.Set("X-Fapi-Interaction-Id", "team081")
-		// }
+		// X-Fapi-Interaction-Id проставляет общий банковский транспорт
 
 		resp, err := h.HTTPClient.Do(req)
 		if err != nil {
-			log.Printf("poll: bank request failed for %s: %v", c.ConsentID, err)
+			log.Error("bank request failed", logging.Err(err))
 			continue
 		}
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
 
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			log.Printf("poll: bank returned non-2xx for %s: %d body=%s", c.ConsentID, resp.StatusCode, string(body))
+			log.Warn("unexpected status", "status", resp.StatusCode, logging.Body(body))
 			continue
 		}
 
 		var bankResp map[string]json.RawMessage
 		if err := json.Unmarshal(body, &bankResp); err != nil {
-			log.Printf("poll: unmarshal failed for %s: %v body=%s", c.ConsentID, err, string(body))
+			log.Error("unmarshal failed", logging.Err(err), logging.Body(body))
 			continue
 		}
 
@@ -323,16 +422,15 @@ func (h *ConsentHandler) PollPendingConsents() {
 					json.Unmarshal(v, &status)
 					got = true
 				}
-				log.Println(status)
 				// if bank returned a final consentId here, consider updating stored id
 				var finalConsentId string
 				json.Unmarshal(dataMap["consentId"], &finalConsentId)
 				if finalConsentId != "" && finalConsentId != c.ConsentID {
 					// update DB to use final consent id (best-effort)
 					if uErr := h.Repo.UpdateAccountConsentIDAndStatus(c.ConsentID, finalConsentId, "approved"); uErr != nil {
-						log.Printf("poll: failed to update DB for %s -> final %s: %v", c.ConsentID, finalConsentId, uErr)
+						log.Error("failed to update consent", "final_consent_id", finalConsentId, logging.Err(uErr))
 					} else {
-						log.Printf("poll: consent %s updated to approved (final id %s)", c.ConsentID, finalConsentId)
+						log.Info("consent approved", "final_consent_id", finalConsentId)
 					}
 					continue
 				}
@@ -340,18 +438,18 @@ func (h *ConsentHandler) PollPendingConsents() {
 		}
 
 		if !got {
-			log.Printf("poll: consent %s no status found in response (body=%s)", c.ConsentID, string(body))
+			log.Warn("no status in response", logging.Body(body))
 			continue
 		}
 
 		if strings.EqualFold(status, "authorised") || strings.EqualFold(status, "authorized") {
 			if uErr := h.Repo.UpdateAccountConsentStatusByConsentID(c.ConsentID, "approved"); uErr != nil {
-				log.Printf("poll: failed to update DB for %s: %v", c.ConsentID, uErr)
+				log.Error("failed to update consent", logging.Err(uErr))
 			} else {
-				log.Printf("poll: consent %s updated to approved", c.ConsentID)
+				log.Info("consent approved")
 			}
 		} else {
-			log.Printf("poll: consent %s status=%s (no update)", c.ConsentID, status)
+			log.Debug("consent not approved yet", "status", status)
 		}
 	}
 }
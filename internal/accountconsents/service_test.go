@@ -0,0 +1,96 @@
+package accountconsents
+
+import (
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRevokeConsent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		consentID  string
+		bankStatus int
+		code       int
+		status     string // статус согласия в БД после запроса
+		audited    bool
+	}{
+		{name: "revoked", consentID: "acc-1", bankStatus: http.StatusNoContent, code: http.StatusOK, status: "revoked", audited: true},
+		{name: "unknown at bank", consentID: "acc-1", bankStatus: http.StatusNotFound, code: http.StatusOK, status: "revoked", audited: true},
+		{name: "bank error", consentID: "acc-1", bankStatus: http.StatusInternalServerError, code: http.StatusInternalServerError, status: "approved"},
+		{name: "foreign consent", consentID: "acc-2", bankStatus: http.StatusNoContent, code: http.StatusNotFound, status: "approved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/auth/bank-token", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"access_token":"token"}`))
+			})
+			mux.HandleFunc("/account-consents/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					deleted = r.URL.Path + "?" + r.URL.RawQuery
+				}
+				w.WriteHeader(tt.bankStatus)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			repo := memory.New()
+			bank := repo.AddBank(storage.Bank{Code: "vbank"})
+			other := repo.AddBank(storage.Bank{Code: "abank"})
+			u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &bank.ID})
+			repo.AddUser(storage.User{ClientID: "team-2", BankID: &other.ID})
+			expires := time.Now().Add(time.Hour)
+			repo.SaveAccountConsentByClientIdAndBank("team-1", "vbank", "acc-1", "team", nil, "approved", expires)
+			repo.SaveAccountConsentByClientIdAndBank("team-2", "abank", "acc-2", "team", nil, "approved", expires)
+
+			// Redis недоступен: токен каждый раз берётся у тестового банка
+			rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+			defer rdb.Close()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			banks := map[string]*bankapi.BankClient{"vbank": {Name: "vbank", BaseURL: srv.URL}}
+			h := NewConsentHandler(repo, bankapi.NewTokenService(rdb, logger), banks, srv.Client(), audit.NewService(repo, logger), logger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/api/account-consent/"+tt.consentID, nil)
+			c.Request = c.Request.WithContext(audit.WithUserID(c.Request.Context(), u.ID))
+			c.Request.Header.Set("X-Bank-Code", "vbank")
+			c.Params = gin.Params{{Key: "consent_id", Value: tt.consentID}}
+			c.Set("user_id", u.ID)
+			h.RevokeConsent(c)
+
+			if w.Code != tt.code {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if tt.code != http.StatusNotFound && deleted != "/account-consents/acc-1?client_id=team-1" {
+				t.Errorf("bank DELETE = %q", deleted)
+			}
+			consents, _ := repo.GetValidAccountConsentsByUserID(u.ID)
+			if len(consents) != 1 || consents[0].Status != tt.status {
+				t.Errorf("consents = %+v, want status %q", consents, tt.status)
+			}
+
+			entries, _ := repo.ListAuditEntries(storage.AuditFilter{Action: audit.ActionConsentRevoke})
+			if got := len(entries) == 1; got != tt.audited {
+				t.Fatalf("revoke entries = %d, want audited=%v", len(entries), tt.audited)
+			}
+			if tt.audited && (entries[0].UserID == nil || *entries[0].UserID != u.ID || *entries[0].ConsentID != "acc-1") {
+				t.Errorf("entry = %+v", entries[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConvertAmounts переводит суммы ответа банка (data.balance[], data.transaction[]) в currency
+// по курсу на дату записи; исходная сумма остаётся в originalAmount
+func (s *Service) ConvertAmounts(payload map[string]interface{}, currency string) error {
+	data, _ := payload["data"].(map[string]interface{})
+	for _, key := range []string{"balance", "transaction"} {
+		items, _ := data[key].([]interface{})
+		for _, item := range items {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			amount, _ := entry["amount"].(map[string]interface{})
+			from, _ := amount["currency"].(string)
+			if from == "" || strings.EqualFold(from, currency) {
+				continue
+			}
+			value, err := number(amount["amount"])
+			if err != nil {
+				return fmt.Errorf("%s amount: %w", key, err)
+			}
+			converted, err := s.FX.Convert(value, strings.ToUpper(from), currency, entryDate(entry))
+			if err != nil {
+				return err
+			}
+			entry["originalAmount"] = amount
+			entry["amount"] = map[string]interface{}{
+				"amount":   strconv.FormatFloat(converted, 'f', 2, 64),
+				"currency": currency,
+			}
+		}
+	}
+	return nil
+}
+
+// number — банки отдают суммы строкой ("123.45"), но встречаются и числа
+func number(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case string:
+		return strconv.ParseFloat(n, 64)
+	case float64:
+		return n, nil
+	case json.Number:
+		return n.Float64()
+	}
+	return 0, fmt.Errorf("unexpected value %v", v)
+}
+
+// entryDate — дата проводки операции или дата остатка; без неё — сегодняшний курс
+func entryDate(entry map[string]interface{}) time.Time {
+	for _, key := range []string{"bookingDateTime", "valueDateTime", "dateTime"} {
+		if v, ok := entry[key].(string); ok {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Now()
+}
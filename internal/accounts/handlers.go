@@ -1,7 +1,10 @@
 package accounts
 
 import (
+	"MoneyPilot/internal/fx"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +19,7 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// ListAccounts — эндпоинт GET /api/accounts
+// ListAccounts — эндпоинт GET /api/accounts: счета банков и ручные счета (source: manual)
 // Требует JWT (middleware добавляет user_id в контекст)
 func (h *Handler) ListAccounts(c *gin.Context) {
 	userID := c.GetInt("user_id")
@@ -25,7 +28,7 @@ func (h *Handler) ListAccounts(c *gin.Context) {
 		return
 	}
 
-	accounts, err := h.service.FetchAllUserAccounts(userID)
+	accounts, err := h.service.ListAccounts(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch accounts", "details": err.Error()})
 		return
@@ -51,11 +54,18 @@ func (h *Handler) GetAccountBalance(c *gin.Context) {
 		return
 	}
 
-	data, err := h.service.FetchAccountBalance(userID, bankCode, accountID)
+	currency, ok := currencyParam(c)
+	if !ok {
+		return
+	}
+	data, err := h.service.FetchAccountBalance(c.Request.Context(), userID, bankCode, accountID)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch balance", "details": err.Error()})
 		return
 	}
+	if !h.convert(c, data, currency) {
+		return
+	}
 
 	c.JSON(http.StatusOK, data)
 }
@@ -73,12 +83,19 @@ func (h *Handler) GetAccountTransactions(c *gin.Context) {
 	to := c.Query("to_booking_date_time")
 	page := c.DefaultQuery("page", "1")
 	limit := c.DefaultQuery("limit", "50")
+	currency, ok := currencyParam(c)
+	if !ok {
+		return
+	}
 
-	data, err := h.service.FetchAccountTransactions(userID, bankCode, accountID, from, to, page, limit)
+	data, err := h.service.FetchAccountTransactions(c.Request.Context(), userID, bankCode, accountID, from, to, page, limit)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch transactions", "details": err.Error()})
 		return
 	}
+	if !h.convert(c, data, currency) {
+		return
+	}
 
 	c.JSON(http.StatusOK, data)
 }
@@ -93,7 +110,7 @@ func (h *Handler) GetAccountDetails(c *gin.Context) {
 	accountID := c.Param("account_id")
 	bankCode := c.GetHeader("X-Bank-Code")
 
-	data, err := h.service.FetchAccountDetails(userID, bankCode, accountID)
+	data, err := h.service.FetchAccountDetails(c.Request.Context(), userID, bankCode, accountID)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch account details", "details": err.Error()})
 		return
@@ -101,3 +118,29 @@ func (h *Handler) GetAccountDetails(c *gin.Context) {
 
 	c.JSON(http.StatusOK, data)
 }
+
+// currencyParam — ?currency= для балансов и операций: суммы пересчитываются в эту валюту
+func currencyParam(c *gin.Context) (string, bool) {
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency != "" && !fx.ValidCurrency(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+		return "", false
+	}
+	return currency, true
+}
+
+func (h *Handler) convert(c *gin.Context, data map[string]interface{}, currency string) bool {
+	if currency == "" {
+		return true
+	}
+	err := h.service.ConvertAmounts(data, currency)
+	switch {
+	case errors.Is(err, fx.ErrNoRate):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to convert amounts", "details": err.Error()})
+		return false
+	}
+	return true
+}
@@ -2,29 +2,31 @@ package accounts
 
 import (
 	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/fx"
 	"MoneyPilot/internal/storage"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
-	"time"
 )
 
 type Service struct {
-	repo     *storage.Repository
-	tokenSvc *bankapi.TokenService
-	banks    map[string]*bankapi.BankClient
-	http     *http.Client
+	repo       storage.Store
+	tokenSvc   *bankapi.TokenService
+	banks      map[string]*bankapi.BankClient
+	HTTPClient *http.Client
+	FX         *fx.Service // пересчёт сумм в валюту клиента (?currency=)
 }
 
-func NewService(repo *storage.Repository, ts *bankapi.TokenService, banks map[string]*bankapi.BankClient) *Service {
+func NewService(repo storage.Store, ts *bankapi.TokenService, banks map[string]*bankapi.BankClient, httpClient *http.Client) *Service {
 	return &Service{
-		repo:     repo,
-		tokenSvc: ts,
-		banks:    banks,
-		http:     &http.Client{Timeout: 15 * time.Second},
+		repo:       repo,
+		tokenSvc:   ts,
+		banks:      banks,
+		HTTPClient: httpClient,
 	}
 }
 
@@ -37,19 +39,24 @@ type BankAccount struct {
 	Currency       string `json:"currency"`
 	Status         string `json:"status"`
 	Owner          string `json:"owner,omitempty"`
+	Number         string `json:"account_number,omitempty"`
+	Source         string `json:"source"` // api | manual
+
+	// только у ручных счетов: остаток ведёт пользователь
+	Balance     *float64 `json:"balance,omitempty"`
+	Institution string   `json:"institution,omitempty"`
 }
 
 // FetchAllUserAccounts получает счета со всех банков, на которые есть согласие
-func (s *Service) FetchAllUserAccounts(userID int) ([]BankAccount, error) {
-	consents, err := s.repo.GetValidAccountConsentsByUserID(userID)
+func (s *Service) FetchAllUserAccounts(ctx context.Context, userID int) ([]BankAccount, error) {
+	repo := s.repo.WithContext(ctx)
+	consents, err := repo.GetValidAccountConsentsByUserID(userID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to load consents: %w", err)
 	}
 
 	var allAccounts []BankAccount
-	requestingBank := os.Getenv("LOGIN_HAC")
-
 	for _, consent := range consents {
 		bankClient := s.banks[*consent.BankCode]
 		if bankClient == nil {
@@ -60,14 +67,14 @@ func (s *Service) FetchAllUserAccounts(userID int) ([]BankAccount, error) {
 		if err != nil {
 			continue
 		}
-		user, _ := s.repo.GetUserByID(consent.UserID)
+		user, _ := repo.GetUserByID(consent.UserID)
 		url := strings.TrimRight(bankClient.BaseURL, "/") + "/accounts?client_id=" + user.ClientID
-		req, _ := http.NewRequest("GET", url, nil)
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 		req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
-		req.Header.Set("X-Requesting-Bank", requestingBank)
+		req.Header.Set("X-Requesting-Bank", bankClient.ClientID)
 		req.Header.Set("X-Consent-Id", consent.ConsentID)
 
-		resp, err := s.http.Do(req)
+		resp, err := s.HTTPClient.Do(req)
 		if err != nil {
 			continue
 		}
@@ -89,7 +96,8 @@ func (s *Service) FetchAllUserAccounts(userID int) ([]BankAccount, error) {
 					AccountSubType string `json:"accountSubType"`
 					Nickname       string `json:"nickname"`
 					Account        []struct {
-						Name string `json:"name"`
+						Name           string `json:"name"`
+						Identification string `json:"identification"`
 					} `json:"account"`
 				} `json:"account"`
 			} `json:"data"`
@@ -108,9 +116,11 @@ func (s *Service) FetchAllUserAccounts(userID int) ([]BankAccount, error) {
 				Currency:       a.Currency,
 				Status:         a.Status,
 				Nickname:       a.Nickname,
+				Source:         storage.SourceAPI,
 			}
 			if len(a.Account) > 0 {
 				acc.Owner = a.Account[0].Name
+				acc.Number = a.Account[0].Identification
 			}
 			allAccounts = append(allAccounts, acc)
 		}
@@ -119,27 +129,121 @@ func (s *Service) FetchAllUserAccounts(userID int) ([]BankAccount, error) {
 	return allAccounts, nil
 }
 
-func (s *Service) FetchAccountBalance(userID int, bankCode, accountID string) (map[string]interface{}, error) {
-	return s.proxyBankRequest(userID, bankCode, "/accounts/"+accountID+"/balances")
+// ListAccounts — счета из банков по согласиям и ручные счета клиента (source: manual)
+func (s *Service) ListAccounts(ctx context.Context, userID int) ([]BankAccount, error) {
+	res, err := s.FetchAllUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.repo.WithContext(ctx).GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range stored {
+		if a.Source != storage.SourceManual {
+			continue
+		}
+		acc := BankAccount{
+			BankCode:    storage.ManualBankCode,
+			AccountID:   strconv.Itoa(a.ID),
+			AccountType: a.AccountType,
+			Currency:    a.Currency,
+			Status:      a.Status,
+			Source:      storage.SourceManual,
+			Balance:     &a.Balance,
+		}
+		// account_number ручного счёта — manual:<client_id>:<номер>
+		if parts := strings.SplitN(a.AccountNumber, ":", 3); len(parts) == 3 {
+			acc.Number = parts[2]
+		}
+		if a.Nickname != nil {
+			acc.Nickname = *a.Nickname
+		}
+		if a.Institution != nil {
+			acc.Institution = *a.Institution
+		}
+		res = append(res, acc)
+	}
+	return res, nil
+}
+
+func (s *Service) FetchAccountBalance(ctx context.Context, userID int, bankCode, accountID string) (map[string]interface{}, error) {
+	return s.proxyBankRequest(ctx, userID, bankCode, "/accounts/"+accountID+"/balances")
+}
+
+// Balances — доступный и учтённый остаток счёта, разобранные из ответа банка
+type Balances struct {
+	Available *float64
+	Booked    *float64
+	Currency  string
+}
+
+// FetchBalances запрашивает остатки и разбирает их: типы *Available и *Booked,
+// Debit даёт отрицательную сумму.
+func (s *Service) FetchBalances(ctx context.Context, userID int, bankCode, accountID string) (*Balances, error) {
+	raw, err := s.FetchAccountBalance(ctx, userID, bankCode, accountID)
+	if err != nil {
+		return nil, err
+	}
+	buf, _ := json.Marshal(raw)
+	var parsed struct {
+		Data struct {
+			Balance []struct {
+				Type   string `json:"type"`
+				Amount struct {
+					Amount   string `json:"amount"`
+					Currency string `json:"currency"`
+				} `json:"amount"`
+				CreditDebitIndicator string `json:"creditDebitIndicator"`
+			} `json:"balance"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf, &parsed); err != nil {
+		return nil, fmt.Errorf("unexpected balance response: %w", err)
+	}
+	if len(parsed.Data.Balance) == 0 {
+		return nil, fmt.Errorf("bank %s returned no balances for account %s", bankCode, accountID)
+	}
+
+	res := &Balances{}
+	for _, b := range parsed.Data.Balance {
+		amount, err := strconv.ParseFloat(b.Amount.Amount, 64)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(b.CreditDebitIndicator, "Debit") {
+			amount = -amount
+		}
+		if res.Currency == "" {
+			res.Currency = b.Amount.Currency
+		}
+		switch {
+		case strings.HasSuffix(b.Type, "Available") && res.Available == nil:
+			res.Available = &amount
+		case strings.HasSuffix(b.Type, "Booked") && res.Booked == nil:
+			res.Booked = &amount
+		}
+	}
+	return res, nil
 }
 
-func (s *Service) FetchAccountTransactions(userID int, bankCode, accountID, from, to, page, limit string) (map[string]interface{}, error) {
+func (s *Service) FetchAccountTransactions(ctx context.Context, userID int, bankCode, accountID, from, to, page, limit string) (map[string]interface{}, error) {
 	path := fmt.Sprintf("/accounts/%s/transactions?from_booking_date_time=%s&to_booking_date_time=%s&page=%s&limit=%s",
 		accountID, from, to, page, limit)
-	return s.proxyBankRequest(userID, bankCode, path)
+	return s.proxyBankRequest(ctx, userID, bankCode, path)
 }
 
-func (s *Service) FetchAccountDetails(userID int, bankCode, accountID string) (map[string]interface{}, error) {
-	return s.proxyBankRequest(userID, bankCode, "/accounts/"+accountID)
+func (s *Service) FetchAccountDetails(ctx context.Context, userID int, bankCode, accountID string) (map[string]interface{}, error) {
+	return s.proxyBankRequest(ctx, userID, bankCode, "/accounts/"+accountID)
 }
 
-func (s *Service) proxyBankRequest(userID int, bankCode, path string) (map[string]interface{}, error) {
+func (s *Service) proxyBankRequest(ctx context.Context, userID int, bankCode, path string) (map[string]interface{}, error) {
 	// user, err := s.repo.GetUserByID(userID)
 	// if err != nil {
 	// 	return nil, fmt.Errorf("user not found: %w", err)
 	// }
 
-	consents, err := s.repo.GetValidAccountConsentsByUserIDAndBank(userID, bankCode)
+	consents, err := s.repo.WithContext(ctx).GetValidAccountConsentsByUserIDAndBank(userID, bankCode)
 	if err != nil || len(consents) == 0 {
 		return nil, fmt.Errorf("no valid consent found for bank %s", bankCode)
 	}
@@ -156,12 +260,12 @@ func (s *Service) proxyBankRequest(userID int, bankCode, path string) (map[strin
 	}
 
 	url := strings.TrimRight(bank.BaseURL, "/") + path
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 	req.Header.Set("Authorization", "Bearer "+token.Token)
-	req.Header.Set("X-Requesting-Bank", os.Getenv("LOGIN_HAC"))
+	req.Header.Set("X-Requesting-Bank", bank.ClientID)
 	req.Header.Set("X-Consent-Id", consent.ConsentID)
 
-	resp, err := s.http.Do(req)
+	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,62 @@
+package affordability
+
+import (
+	"MoneyPilot/internal/fx"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Check — POST /api/affordability {"amount":15000,"currency":"RUB","date":"2025-12-01","category":"Electronics"}
+// Вердикт yes|caution|no, счёт, с которого лучше платить, и разбор проверок.
+func (h *Handler) Check(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Amount   float64 `json:"amount" binding:"required"`
+		Currency string  `json:"currency"`
+		Date     string  `json:"date"`
+		Category string  `json:"category"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	req := Request{Amount: body.Amount, Currency: body.Currency, Category: body.Category}
+	if body.Date != "" {
+		d, err := time.Parse("2006-01-02", body.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+		req.Date = d
+	}
+
+	res, err := h.Service.Check(userID, req, time.Now())
+	if errors.Is(err, ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, fx.ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "missing exchange rate", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check affordability", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,289 @@
+package affordability
+
+import (
+	"MoneyPilot/internal/budgets"
+	"MoneyPilot/internal/forecast"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/money"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Вердикты
+const (
+	Yes     = "yes"     // хватает на счёте и в бюджете, порог не нарушается
+	Caution = "caution" // хватает, но выходит за бюджет или ниже порога до следующего поступления
+	No      = "no"      // ни на одном счёте не хватает до следующего поступления
+)
+
+// noIncomeHorizon — насколько вперёд смотреть, если регулярных поступлений не найдено
+const noIncomeHorizon = 30
+
+var ErrInvalidRequest = errors.New("invalid request")
+
+type Request struct {
+	Amount   float64
+	Currency string // пусто — базовая валюта
+	Date     time.Time
+	Category string
+}
+
+// AccountCheck — что останется на счёте, если платить с него
+type AccountCheck struct {
+	ID               int     `json:"id"`
+	AccountID        string  `json:"account_id"`
+	BankCode         string  `json:"bank"`
+	Currency         string  `json:"currency"`
+	Available        float64 `json:"available"`         // сейчас
+	BalanceOnDate    float64 `json:"balance_on_date"`   // прогноз на дату покупки
+	UpcomingOutflows float64 `json:"upcoming_outflows"` // регулярные списания до следующего поступления
+	LowestBefore     float64 `json:"lowest_before_income"`
+	Amount           float64 `json:"amount"` // сумма покупки в валюте счёта
+	LowestAfter      float64 `json:"lowest_after_purchase"`
+	Threshold        float64 `json:"threshold"`
+	Affordable       bool    `json:"affordable"`
+	AboveThreshold   bool    `json:"above_threshold"`
+	headroom         float64 // LowestAfter - Threshold в валюте ответа
+}
+
+type BudgetCheck struct {
+	BudgetID  int     `json:"budget_id"`
+	Name      string  `json:"name"`
+	Currency  string  `json:"currency"`
+	Remaining float64 `json:"remaining"`
+	Amount    float64 `json:"amount"` // сумма покупки в валюте бюджета
+	Exceeded  bool    `json:"exceeded"`
+}
+
+// Reason — один шаг рассуждения
+type Reason struct {
+	Check   string `json:"check"` // balance | recurring | budget | threshold
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+type Result struct {
+	Verdict     string         `json:"verdict"`
+	Amount      float64        `json:"amount"`
+	Currency    string         `json:"currency"`
+	Date        time.Time      `json:"date"`
+	Category    string         `json:"category,omitempty"`
+	NextIncome  *time.Time     `json:"next_income,omitempty"`
+	BestAccount *AccountCheck  `json:"best_account,omitempty"`
+	Accounts    []AccountCheck `json:"accounts"`
+	Budgets     []BudgetCheck  `json:"budgets"`
+	Reasons     []Reason       `json:"reasons"`
+}
+
+type Service struct {
+	Forecast *forecast.Service
+	Budgets  *budgets.Service
+	FX       *fx.Service
+}
+
+func NewService(forecastSvc *forecast.Service, budgetSvc *budgets.Service, fxSvc *fx.Service) *Service {
+	return &Service{Forecast: forecastSvc, Budgets: budgetSvc, FX: fxSvc}
+}
+
+// Check оценивает покупку на дату req.Date по прогнозу остатков и бюджетам клиента
+func (s *Service) Check(userID int, req Request, now time.Time) (*Result, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidRequest)
+	}
+	today := money.TruncateDay(now)
+	date := today
+	if !req.Date.IsZero() {
+		date = money.TruncateDay(req.Date)
+	}
+	if date.Before(today) {
+		return nil, fmt.Errorf("%w: date must not be in the past", ErrInvalidRequest)
+	}
+	ahead := int(date.Sub(today).Hours() / 24)
+	if ahead >= 90 {
+		return nil, fmt.Errorf("%w: date must be within 90 days", ErrInvalidRequest)
+	}
+	// самый короткий прогноз, который покрывает дату и горизонт поиска поступления
+	days := 30
+	for days < ahead+noIncomeHorizon && days < 90 {
+		days += 30
+	}
+
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		var err error
+		if currency, err = s.FX.BaseCurrency(userID); err != nil {
+			return nil, err
+		}
+	}
+	if !fx.ValidCurrency(currency) {
+		return nil, fmt.Errorf("%w: invalid currency %q", ErrInvalidRequest, currency)
+	}
+
+	f, err := s.Forecast.Project(userID, days, now)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Amount:   req.Amount,
+		Currency: currency,
+		Date:     date,
+		Category: req.Category,
+		Accounts: []AccountCheck{},
+		Budgets:  []BudgetCheck{},
+		Reasons:  []Reason{},
+	}
+
+	// горизонт — следующее регулярное поступление после даты покупки
+	horizon := date.AddDate(0, 0, noIncomeHorizon)
+	for _, fl := range f.Flows {
+		if fl.Source == "recurring" && fl.Amount > 0 && fl.Date.After(date) {
+			d := fl.Date
+			res.NextIncome = &d
+			horizon = d
+			break
+		}
+	}
+
+	for _, af := range f.Accounts {
+		ac, err := s.checkAccount(af, f.Flows, req.Amount, currency, date, horizon, now)
+		if err != nil {
+			return nil, err
+		}
+		res.Accounts = append(res.Accounts, ac)
+		if ac.Affordable && (res.BestAccount == nil || ac.headroom > res.BestAccount.headroom) {
+			best := ac
+			res.BestAccount = &best
+		}
+	}
+
+	if req.Category != "" {
+		if res.Budgets, err = s.checkBudgets(userID, req.Category, req.Amount, currency, date, now); err != nil {
+			return nil, err
+		}
+	}
+
+	s.explain(res)
+	return res, nil
+}
+
+func (s *Service) checkAccount(af forecast.AccountForecast, flows []forecast.Flow, amount float64, currency string, date, horizon, now time.Time) (AccountCheck, error) {
+	ac := AccountCheck{
+		ID:            af.ID,
+		AccountID:     af.AccountID,
+		BankCode:      af.BankCode,
+		Currency:      af.Currency,
+		Available:     af.Balance,
+		BalanceOnDate: af.Balance,
+		Threshold:     af.Threshold,
+	}
+	var err error
+	if ac.Amount, err = money.Convert(s.FX, amount, currency, af.Currency, now); err != nil {
+		return ac, err
+	}
+	ac.Amount = money.Round(ac.Amount)
+
+	// остаток на конец дня покупки и минимум после него до дня следующего поступления
+	for _, p := range af.Points {
+		if p.Date.Equal(date) {
+			ac.BalanceOnDate = p.Balance
+		}
+	}
+	ac.LowestBefore = ac.BalanceOnDate
+	for _, p := range af.Points {
+		if p.Date.After(date) && p.Date.Before(horizon) && p.Balance < ac.LowestBefore {
+			ac.LowestBefore = p.Balance
+		}
+	}
+	for _, fl := range flows {
+		if fl.AccountID == af.ID && fl.Amount < 0 && !fl.Date.Before(date) && fl.Date.Before(horizon) {
+			ac.UpcomingOutflows += -fl.Amount
+		}
+	}
+	ac.UpcomingOutflows = money.Round(ac.UpcomingOutflows)
+
+	ac.LowestAfter = money.Round(ac.LowestBefore - ac.Amount)
+	ac.Affordable = ac.LowestAfter >= 0 && ac.BalanceOnDate >= ac.Amount
+	ac.AboveThreshold = ac.LowestAfter >= ac.Threshold
+	if ac.headroom, err = money.Convert(s.FX, ac.LowestAfter-ac.Threshold, af.Currency, currency, now); err != nil {
+		return ac, err
+	}
+	return ac, nil
+}
+
+// checkBudgets — бюджеты по категории покупки в периоде, на который приходится дата покупки
+func (s *Service) checkBudgets(userID int, category string, amount float64, currency string, date, now time.Time) ([]BudgetCheck, error) {
+	progress, err := s.Budgets.ListAt(userID, date)
+	if err != nil {
+		return nil, err
+	}
+	res := []BudgetCheck{}
+	for _, p := range progress {
+		if p.Category == nil || !strings.EqualFold(*p.Category, category) {
+			continue
+		}
+		inBudget, err := money.Convert(s.FX, amount, currency, p.Currency, now)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, BudgetCheck{
+			BudgetID:  p.ID,
+			Name:      p.Name,
+			Currency:  p.Currency,
+			Remaining: p.Remaining,
+			Amount:    money.Round(inBudget),
+			Exceeded:  inBudget > p.Remaining,
+		})
+	}
+	return res, nil
+}
+
+// explain выставляет вердикт и собирает объяснение
+func (s *Service) explain(res *Result) {
+	until := "in the next 30 days"
+	if res.NextIncome != nil {
+		until = "before the next income on " + res.NextIncome.Format("2006-01-02")
+	}
+
+	best := res.BestAccount
+	if best == nil {
+		res.Verdict = No
+		res.Reasons = append(res.Reasons, Reason{Check: "balance", OK: false,
+			Message: fmt.Sprintf("no account keeps a non-negative balance %s after paying %.2f %s", until, res.Amount, res.Currency)})
+		return
+	}
+
+	res.Verdict = Yes
+	res.Reasons = append(res.Reasons, Reason{Check: "balance", OK: true,
+		Message: fmt.Sprintf("%s %s has %.2f %s on %s", best.BankCode, best.AccountID, best.BalanceOnDate, best.Currency, res.Date.Format("2006-01-02"))})
+	res.Reasons = append(res.Reasons, Reason{Check: "recurring", OK: true,
+		Message: fmt.Sprintf("%.2f %s of recurring payments are due %s; the lowest balance after the purchase is %.2f %s",
+			best.UpcomingOutflows, best.Currency, until, best.LowestAfter, best.Currency)})
+
+	if best.Threshold > 0 {
+		r := Reason{Check: "threshold", OK: best.AboveThreshold,
+			Message: fmt.Sprintf("the balance stays above the %.2f %s threshold", best.Threshold, best.Currency)}
+		if !best.AboveThreshold {
+			res.Verdict = Caution
+			r.Message = fmt.Sprintf("the balance drops below the %.2f %s threshold", best.Threshold, best.Currency)
+		}
+		res.Reasons = append(res.Reasons, r)
+	}
+
+	if res.Category != "" && len(res.Budgets) == 0 {
+		res.Reasons = append(res.Reasons, Reason{Check: "budget", OK: true,
+			Message: fmt.Sprintf("no budget for category %s", res.Category)})
+	}
+	for _, b := range res.Budgets {
+		r := Reason{Check: "budget", OK: !b.Exceeded,
+			Message: fmt.Sprintf("budget %q has %.2f %s left", b.Name, b.Remaining, b.Currency)}
+		if b.Exceeded {
+			res.Verdict = Caution
+			r.Message = fmt.Sprintf("budget %q has only %.2f %s left, the purchase exceeds it by %.2f",
+				b.Name, b.Remaining, b.Currency, money.Round(b.Amount-b.Remaining))
+		}
+		res.Reasons = append(res.Reasons, r)
+	}
+}
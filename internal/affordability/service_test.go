@@ -0,0 +1,88 @@
+package affordability
+
+import (
+	"MoneyPilot/internal/budgets"
+	"MoneyPilot/internal/forecast"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/recurring"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func str(v string) *string { return &v }
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		req       Request
+		threshold float64
+		verdict   string
+		err       error
+	}{
+		{name: "affordable", req: Request{Amount: 3000}, verdict: Yes},
+		{name: "no budget for category", req: Request{Amount: 3000, Category: "travel"}, verdict: Yes},
+		{name: "budget exceeded", req: Request{Amount: 1800, Category: "groceries"}, verdict: Caution},
+		{name: "budget of the purchase month", req: Request{Amount: 1800, Category: "groceries", Date: date("2024-07-05")}, verdict: Yes},
+		{name: "below threshold", req: Request{Amount: 3000}, threshold: 95000, verdict: Caution},
+		{name: "not enough", req: Request{Amount: 200000}, verdict: No},
+		{name: "zero amount", req: Request{}, err: ErrInvalidRequest},
+		{name: "past date", req: Request{Amount: 100, Date: date("2024-06-01")}, err: ErrInvalidRequest},
+		{name: "too far", req: Request{Amount: 100, Date: date("2024-09-30")}, err: ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.New()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			fxSvc := fx.NewService(repo, repo, nil, logger)
+			forecastSvc := forecast.NewService(repo, repo, recurring.NewService(repo, repo), fxSvc, nil)
+			budgetSvc := budgets.NewService(repo, repo, fxSvc, nil, logger)
+			s := NewService(forecastSvc, budgetSvc, fxSvc)
+
+			u := repo.AddUser(storage.User{ClientID: "team-1"})
+			acc := &storage.Account{UserID: u.ID, AccountNumber: "40817", Currency: "RUB", Balance: 100000}
+			if err := repo.UpsertAccount(acc); err != nil {
+				t.Fatal(err)
+			}
+			// 500 за 5 дней истории — 100 в день; от бюджета в июне остаётся 1500
+			if err := repo.InsertTransaction(&storage.Transaction{AccountID: acc.ID, Amount: -500, BookingDate: date("2024-06-05"), Category: str("groceries")}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := budgetSvc.Create(u.ID, storage.Budget{Category: str("groceries"), Amount: 2000}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.threshold > 0 {
+				if _, err := forecastSvc.SetThreshold(u.ID, tt.threshold, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			res, err := s.Check(u.ID, tt.req, now)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if res.Verdict != tt.verdict {
+				t.Errorf("verdict = %s, want %s: %+v", res.Verdict, tt.verdict, res.Reasons)
+			}
+			if tt.verdict != No && (res.BestAccount == nil || res.BestAccount.ID != acc.ID) {
+				t.Errorf("best account = %+v, want %d", res.BestAccount, acc.ID)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// HealthCheck — GET /healthz: процесс жив и обслуживает запросы. Зависимости проверяет /readyz.
 func HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
@@ -2,61 +2,123 @@ package api
 
 import (
 	"database/sql"
+	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"MoneyPilot/internal/accountconsents"
 	"MoneyPilot/internal/accounts"
+	"MoneyPilot/internal/affordability"
+	"MoneyPilot/internal/api/handlers"
+	"MoneyPilot/internal/audit"
 	"MoneyPilot/internal/auth"
+	"MoneyPilot/internal/balances"
 	bankapi "MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/budgets"
+	"MoneyPilot/internal/catalog"
+	"MoneyPilot/internal/categories"
+	"MoneyPilot/internal/config"
+	"MoneyPilot/internal/export"
+	"MoneyPilot/internal/forecast"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/health"
+	"MoneyPilot/internal/imports"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/manualaccounts"
+	"MoneyPilot/internal/metrics"
+	"MoneyPilot/internal/payments"
 	"MoneyPilot/internal/poller"
 	"MoneyPilot/internal/productagreements"
 	"MoneyPilot/internal/productconsents"
+	"MoneyPilot/internal/recommendations"
+	"MoneyPilot/internal/recurring"
+	"MoneyPilot/internal/reports"
+	"MoneyPilot/internal/requestid"
+	"MoneyPilot/internal/savings"
 	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/transactions"
 	"MoneyPilot/internal/websockets"
 )
 
-func NewRouter(db *sql.DB, jwtSecret string, rdb *redis.Client) *gin.Engine {
-	r := gin.Default()
+func NewRouter(db *sql.DB, rdb *redis.Client, cfg *config.Config, logger *slog.Logger) *gin.Engine {
+	jwtSecret := cfg.Auth.JWTSecret
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(func(req *http.Request) bool {
+		// скрейпы Prometheus, healthcheck-и и долгие WebSocket-подключения не трейсим
+		switch req.URL.Path {
+		case "/metrics", "/ws", "/healthz", "/readyz":
+			return false
+		}
+		return true
+	})))
+	r.Use(requestid.Middleware(), metrics.Middleware(), logging.Middleware(logger))
+	r.GET("/metrics", metrics.Handler())
+	r.GET("/healthz", handlers.HealthCheck)
+	metrics.RegisterDB(db, "postgres")
 
 	// --- Настройка CORS ---
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"},
+		AllowOrigins:     cfg.Server.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Bank-Code"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Bank-Code", requestid.Header},
+		ExposeHeaders:    []string{"Content-Length", requestid.Header},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// --- Инициализация зависимостей ---
-	repo := storage.NewRepository(db)
-	ts := bankapi.NewTokenService(rdb)
+	repo := storage.NewRepository(db, logger)
+	bankapi.Configure(bankClients(cfg))
+	ts := bankapi.NewTokenService(rdb, logger)
+	auditService := audit.NewService(repo, logger)
+	auditHandler := audit.NewHandler(auditService)
 
-	authService := auth.NewAuthService(db, jwtSecret)
+	// --- Общий HTTP-клиент к банкам: таймауты, повторы, circuit breaker, rate limit, аудит ---
+	bankTransport := bankapi.NewTransport(
+		transportConfig(cfg.Transport),
+		bankapi.Banks,
+		audit.NewTransport(http.DefaultTransport, auditService, bankapi.Banks),
+		logger,
+	)
+	bankHTTP := bankapi.NewHTTPClient(bankTransport)
+	for _, b := range bankapi.Banks {
+		b.HTTPClient = bankHTTP
+	}
+
+	authService := auth.NewAuthService(repo, jwtSecret)
 	authHandler := auth.NewHandler(authService)
 	r.POST("/api/auth/login", authHandler.Login)
 
 	apiGroup := r.Group("/api")
 	secured := apiGroup.Group("")
-	secured.Use(auth.DecodeToken([]byte(jwtSecret)))
+	secured.Use(auth.DecodeToken([]byte(jwtSecret)), audit.ContextMiddleware())
 
 	// --- Handlers ---
-	consentHandler := accountconsents.NewConsentHandler(repo, ts, bankapi.Banks)
-	accountService := accounts.NewService(repo, ts, bankapi.Banks)
+	consentHandler := accountconsents.NewConsentHandler(repo, ts, bankapi.Banks, bankHTTP, auditService, logger)
+	consentHandler.ConsentTTL = cfg.Consents.TTL
+	accountService := accounts.NewService(repo, ts, bankapi.Banks, bankHTTP)
 	accountHandler := accounts.NewHandler(accountService)
 
-	productConsentsService := productconsents.NewService(repo, ts, bankapi.Banks)
+	productConsentsService := productconsents.NewService(repo, ts, bankapi.Banks, bankHTTP, auditService, logger)
+	productConsentsService.ConsentTTL = cfg.Consents.TTL
 	productConsentsHandler := productconsents.NewHandler(productConsentsService)
 
-	productAgreementService := productagreements.NewService(repo, ts, bankapi.Banks)
-	productAgreementHandler := productagreements.NewHandler(productAgreementService)
 	// --- WebSocket Hub ---
-	wsHub := websockets.NewHub()
-	r.GET("/ws", wsHub.HandleConnection)
+	wsHub := websockets.NewHub(logger)
+	r.GET("/ws", auth.OptionalToken([]byte(jwtSecret)), wsHub.HandleConnection)
+
+	productAgreementService := productagreements.NewService(repo, ts, bankapi.Banks, bankHTTP, auditService, wsHub, logger)
+	productAgreementHandler := productagreements.NewHandler(productAgreementService)
+
+	// --- Каталог продуктов банков ---
+	catalogService := catalog.NewService(repo, ts, bankapi.Banks, bankHTTP, logger)
+	catalogHandler := catalog.NewHandler(catalogService)
 
 	// --- Репозитории для Poller ---
 	AccountRepo := poller.AccountConsentRepoAdapter{
@@ -71,13 +133,89 @@ func NewRouter(db *sql.DB, jwtSecret string, rdb *redis.Client) *gin.Engine {
 		[]poller.ConsentRepo{&AccountRepo, &ProductRepo},
 		ts,
 		bankapi.Banks,
+		bankHTTP,
 		wsHub, // уведомления через WebSocket
+		logger,
 	)
 	stopCh := make(chan struct{})
-	pl.Start(5*time.Second, stopCh)
+	pl.Start(cfg.Poller.Interval, stopCh)
+
+	// --- Готовность: Postgres, Redis, heartbeat поллера, токены банков ---
+	healthService := health.NewService(db, rdb, pl, ts, bankTransport, bankapi.Banks, health.Config{
+		Timeout:          cfg.Health.Timeout,
+		PollerStaleAfter: cfg.Health.PollerStaleAfter,
+		BankCacheTTL:     cfg.Health.BankCacheTTL,
+	}, logger)
+	r.GET("/readyz", health.NewHandler(healthService).Readyz)
+
+	// --- Курсы валют ---
+	fxService := fx.NewService(repo, repo, newFXProvider(cfg), logger)
+	fxHandler := fx.NewHandler(fxService)
+	accountService.FX = fxService
+	fxService.Start(cfg.Jobs.FXRates, stopCh)
+	catalogService.Start(cfg.Jobs.Catalog, stopCh)
+	productAgreementService.Start(cfg.Jobs.ProductTracker, stopCh)
+
+	// --- История балансов ---
+	balanceService := balances.NewService(repo, repo, accountService, bankapi.Banks, fxService, logger)
+	balanceHandler := balances.NewHandler(balanceService)
+	balanceService.Start(cfg.Jobs.BalanceSnapshots, stopCh)
+
+	// --- Операции и категории ---
+	categoryService := categories.NewService(repo, repo, logger)
+	categoryHandler := categories.NewHandler(categoryService)
+	txSyncer := transactions.NewSyncer(repo, accountService, categoryService, bankapi.Banks, logger)
+	txHandler := transactions.NewHandler(txSyncer, fxService)
+
+	// --- Бюджеты: уведомления о порогах после синхронизации операций ---
+	budgetService := budgets.NewService(repo, repo, fxService, wsHub, logger)
+	budgetHandler := budgets.NewHandler(budgetService)
+	txSyncer.AddListener(budgetService)
+	txSyncer.Start(cfg.Jobs.Transactions, stopCh)
+
+	recurringService := recurring.NewService(repo, repo)
+	recurringHandler := recurring.NewHandler(recurringService)
+
+	// --- Прогноз остатков ---
+	forecastService := forecast.NewService(repo, repo, recurringService, fxService, bankapi.Banks)
+	forecastHandler := forecast.NewHandler(forecastService)
+	affordabilityService := affordability.NewService(forecastService, budgetService, fxService)
+	affordabilityHandler := affordability.NewHandler(affordabilityService)
+
+	// --- Выбор счёта для оплаты ---
+	recommendationService := recommendations.NewService(repo, repo, productAgreementService, fxService, bankapi.Banks, logger)
+	recommendationHandler := recommendations.NewHandler(recommendationService)
+
+	// --- Цели накоплений и автопополнение ---
+	paymentService := payments.NewService(repo, repo, ts, bankapi.Banks, bankHTTP, auditService)
+	savingsService := savings.NewService(repo, repo, accountService, productAgreementService, recurringService, paymentService, fxService, wsHub, bankapi.Banks, logger)
+	savingsHandler := savings.NewHandler(savingsService)
+	forecastService.AddSource(savingsService)
+	savingsService.Start(cfg.Jobs.Savings, stopCh)
+
+	// --- Выгрузка выписок ---
+	exportService := export.NewService(repo, repo, bankapi.Banks, wsHub, logger)
+	exportService.Dir = cfg.Export.Dir
+	exportHandler := export.NewHandler(exportService)
+
+	// --- Ежемесячные отчёты ---
+	reportService := reports.NewService(repo, repo, balanceService, budgetService, recurringService, fxService, wsHub, logger)
+	reportHandler := reports.NewHandler(reportService)
+	if cfg.Jobs.MonthlyReports {
+		reportService.Start(cfg.Jobs.Reports, stopCh)
+	}
+
+	// --- Ручные счета и активы ---
+	manualAccountService := manualaccounts.NewService(repo, repo, repo, categoryService, txSyncer, fxService)
+	manualAccountHandler := manualaccounts.NewHandler(manualAccountService)
+
+	// --- Импорт выписок банков без API ---
+	importService := imports.NewService(repo, repo, categoryService, txSyncer, fxService)
+	importHandler := imports.NewHandler(importService)
 
 	// --- Маршруты ---
 	secured.POST("/account-consent", consentHandler.CreateConsent)
+	secured.DELETE("/account-consent/:consent_id", consentHandler.RevokeConsent)
 
 	// 👇 Добавляем маршруты для product consents
 	secured.POST("/product-consents/request", productConsentsHandler.CreateConsent)
@@ -89,9 +227,149 @@ func NewRouter(db *sql.DB, jwtSecret string, rdb *redis.Client) *gin.Engine {
 	secured.GET("/accounts/:account_id/balances", accountHandler.GetAccountBalance)
 	secured.GET("/accounts/:account_id/transactions", accountHandler.GetAccountTransactions)
 	secured.GET("/accounts/:account_id/details", accountHandler.GetAccountDetails)
+	secured.GET("/accounts/:account_id/balance-history", balanceHandler.GetBalanceHistory)
+	secured.GET("/networth", balanceHandler.GetNetWorth)
 
 	secured.GET("/products", productAgreementHandler.ListProducts)
+	secured.POST("/products", productAgreementHandler.OpenProduct)
 	secured.GET("/products/:agreement_id", productAgreementHandler.GetProductDetails)
+	secured.GET("/products/:agreement_id/closure-quote", productAgreementHandler.ClosureQuote)
 	secured.DELETE("/products/:agreement_id", productAgreementHandler.DeleteProduct)
+
+	secured.GET("/catalog", catalogHandler.ListCatalog)
+	secured.POST("/catalog/sync", catalogHandler.Sync)
+
+	secured.GET("/transactions", txHandler.ListTransactions)
+	secured.POST("/transactions/sync", txHandler.Sync)
+	secured.PUT("/transactions/:transaction_id/category", categoryHandler.OverrideCategory)
+
+	secured.GET("/categories", categoryHandler.ListCategories)
+	secured.GET("/categories/rules", categoryHandler.ListRules)
+	secured.POST("/categories/rules", categoryHandler.CreateRule)
+	secured.DELETE("/categories/rules/:rule_id", categoryHandler.DeleteRule)
+	secured.POST("/categories/recategorize", categoryHandler.Recategorize)
+
+	secured.GET("/budgets", budgetHandler.ListBudgets)
+	secured.POST("/budgets", budgetHandler.CreateBudget)
+	secured.PUT("/budgets/:budget_id", budgetHandler.UpdateBudget)
+	secured.DELETE("/budgets/:budget_id", budgetHandler.DeleteBudget)
+
+	secured.GET("/recurring", recurringHandler.ListRecurring)
+	secured.PUT("/recurring/:series_id", recurringHandler.UpdateRecurring)
+
+	secured.GET("/forecast", forecastHandler.GetForecast)
+	secured.GET("/forecast/threshold", forecastHandler.GetThreshold)
+	secured.PUT("/forecast/threshold", forecastHandler.SetThreshold)
+	secured.DELETE("/forecast/threshold", forecastHandler.DeleteThreshold)
+	secured.POST("/affordability", affordabilityHandler.Check)
+
+	secured.POST("/recommendations/payment-source", recommendationHandler.PaymentSource)
+	secured.GET("/recommendations/cashback-rules", recommendationHandler.ListCashbackRules)
+	secured.POST("/recommendations/cashback-rules", recommendationHandler.CreateCashbackRule)
+	secured.DELETE("/recommendations/cashback-rules/:rule_id", recommendationHandler.DeleteCashbackRule)
+
+	secured.GET("/savings/goals", savingsHandler.ListGoals)
+	secured.POST("/savings/goals", savingsHandler.CreateGoal)
+	secured.GET("/savings/goals/:goal_id", savingsHandler.GetGoal)
+	secured.DELETE("/savings/goals/:goal_id", savingsHandler.DeleteGoal)
+	secured.POST("/savings/goals/:goal_id/rules", savingsHandler.CreateRule)
+	secured.GET("/savings/rules", savingsHandler.ListRules)
+	secured.DELETE("/savings/rules/:rule_id", savingsHandler.DeleteRule)
+	secured.GET("/savings/transfers", savingsHandler.ListTransfers)
+	secured.POST("/savings/transfers/:transfer_id/approve", savingsHandler.ApproveTransfer)
+	secured.POST("/savings/transfers/:transfer_id/reject", savingsHandler.RejectTransfer)
+	secured.POST("/savings/run", savingsHandler.Run)
+
+	secured.GET("/export/transactions", exportHandler.ExportTransactions)
+	secured.POST("/export/jobs", exportHandler.CreateJob)
+	secured.GET("/export/jobs/:job_id", exportHandler.GetJob)
+	secured.GET("/export/jobs/:job_id/download", exportHandler.DownloadJob)
+
+	secured.GET("/reports/monthly/:month", reportHandler.GetMonthly)
+
+	secured.GET("/manual-accounts", manualAccountHandler.List)
+	secured.POST("/manual-accounts", manualAccountHandler.Create)
+	secured.PUT("/manual-accounts/:account_id", manualAccountHandler.Update)
+	secured.DELETE("/manual-accounts/:account_id", manualAccountHandler.Delete)
+	secured.GET("/manual-accounts/:account_id/valuations", manualAccountHandler.ListValuations)
+	secured.POST("/manual-accounts/:account_id/valuations", manualAccountHandler.AddValuation)
+	secured.POST("/manual-accounts/:account_id/transactions", manualAccountHandler.AddTransaction)
+	secured.DELETE("/manual-accounts/:account_id/transactions/:transaction_id", manualAccountHandler.DeleteTransaction)
+
+	secured.POST("/imports", importHandler.Import)
+	secured.GET("/imports/presets", importHandler.ListPresets)
+
+	// --- Валюты ---
+	secured.GET("/fx/rates", fxHandler.ListRates)
+	secured.POST("/fx/rates", fxHandler.AddRates)
+	secured.GET("/fx/convert", fxHandler.Convert)
+	secured.GET("/fx/base-currency", fxHandler.GetBaseCurrency)
+	secured.PUT("/fx/base-currency", fxHandler.SetBaseCurrency)
+
+	// --- Журнал аудита ---
+	secured.GET("/audit", auditHandler.ListEntries)
 	return r
 }
+
+// newFXProvider выбирает источник курсов по FX_PROVIDER
+func newFXProvider(cfg *config.Config) fx.Provider {
+	switch cfg.FX.Provider {
+	case "file":
+		return &fx.FileProvider{Path: cfg.FX.RatesFile}
+	case "http":
+		return &fx.HTTPProvider{URL: cfg.FX.RatesURL, Base: cfg.FX.Base, HTTPClient: &http.Client{Timeout: 10 * time.Second}}
+	}
+	return fx.ManualProvider{}
+}
+
+// bankClients — реестр банков из конфигурации; у всех банков общие учётные данные команды
+func bankClients(cfg *config.Config) map[string]*bankapi.BankClient {
+	clients := make(map[string]*bankapi.BankClient, len(cfg.Banks))
+	for code, b := range cfg.Banks {
+		clients[code] = &bankapi.BankClient{
+			Name:         b.Name,
+			BaseURL:      b.BaseURL,
+			ClientID:     cfg.Team.ClientID,
+			ClientSecret: cfg.Team.ClientSecret,
+		}
+	}
+	return clients
+}
+
+func transportConfig(t config.TransportConfig) bankapi.TransportConfig {
+	defaults := bankapi.BankLimits{
+		Timeout:          t.Timeout,
+		RatePerSecond:    t.RatePerSecond,
+		Burst:            t.Burst,
+		BreakerThreshold: t.BreakerThreshold,
+		BreakerCooldown:  t.BreakerCooldown,
+	}
+	// transport.banks.<code> задаёт только отличия: остальное берётся из общих ограничений
+	perBank := make(map[string]bankapi.BankLimits, len(t.Banks))
+	for code, o := range t.Banks {
+		l := defaults
+		if o.Timeout > 0 {
+			l.Timeout = o.Timeout
+		}
+		if o.RatePerSecond > 0 {
+			l.RatePerSecond = o.RatePerSecond
+		}
+		if o.Burst > 0 {
+			l.Burst = o.Burst
+		}
+		if o.BreakerThreshold > 0 {
+			l.BreakerThreshold = o.BreakerThreshold
+		}
+		if o.BreakerCooldown > 0 {
+			l.BreakerCooldown = o.BreakerCooldown
+		}
+		perBank[code] = l
+	}
+	return bankapi.TransportConfig{
+		Defaults:    defaults,
+		PerBank:     perBank,
+		MaxRetries:  t.MaxRetries,
+		BaseBackoff: t.BaseBackoff,
+		MaxBackoff:  t.MaxBackoff,
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/config"
+	"testing"
+	"time"
+)
+
+func TestTransportConfig(t *testing.T) {
+	c := config.Default().Transport
+	c.Banks = map[string]config.BankLimitsConfig{
+		"sbank": {RatePerSecond: 1, Timeout: 20 * time.Second},
+	}
+	got := transportConfig(c)
+
+	defaults := bankapi.BankLimits{Timeout: 10 * time.Second, RatePerSecond: 5, Burst: 10, BreakerThreshold: 5, BreakerCooldown: 30 * time.Second}
+	if got.Defaults != defaults {
+		t.Errorf("defaults = %+v, want %+v", got.Defaults, defaults)
+	}
+	// незаданные поля переопределения берутся из общих ограничений
+	sbank := defaults
+	sbank.RatePerSecond, sbank.Timeout = 1, 20*time.Second
+	if got.PerBank["sbank"] != sbank {
+		t.Errorf("sbank = %+v, want %+v", got.PerBank["sbank"], sbank)
+	}
+	if _, ok := got.PerBank["vbank"]; ok {
+		t.Error("vbank has no override and must use defaults")
+	}
+	if got.MaxRetries != 2 || got.BaseBackoff != 200*time.Millisecond || got.MaxBackoff != 5*time.Second {
+		t.Errorf("retries = %d, %s, %s", got.MaxRetries, got.BaseBackoff, got.MaxBackoff)
+	}
+}
@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+type userCtxKey struct{}
+
+// WithUserID привязывает пользователя к контексту исходящих запросов
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userCtxKey{}, userID)
+}
+
+// UserIDFromContext возвращает пользователя из контекста (0 — неизвестен)
+func UserIDFromContext(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	id, _ := ctx.Value(userCtxKey{}).(int)
+	return id
+}
+
+// ContextMiddleware переносит user_id из gin-контекста (его ставит auth.DecodeToken)
+// в context.Context запроса, чтобы сервисы и банковские вызовы знали, от чьего имени работают.
+func ContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.GetInt("user_id"); userID != 0 {
+			c.Request = c.Request.WithContext(WithUserID(c.Request.Context(), userID))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"MoneyPilot/internal/storage"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListEntries — GET /api/audit
+// Обычный пользователь видит только свою историю.
+// Администратор видит всё и может отфильтровать по ?user_id=.
+func (h *Handler) ListEntries(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	f := storage.AuditFilter{
+		Kind:     c.Query("kind"),
+		Action:   c.Query("action"),
+		BankCode: c.Query("bank"),
+	}
+	f.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "100"))
+	f.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	for param, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(param); v != "" {
+			t, err := parseTime(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param, "details": err.Error()})
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	if c.GetString("role") == "admin" {
+		if v := c.Query("user_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+				return
+			}
+			f.UserID = &id
+		}
+	} else {
+		f.UserID = &userID
+	}
+
+	entries, err := h.Service.List(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load audit log", "details": err.Error()})
+		return
+	}
+	total, err := h.Service.Count(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count audit entries", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "entries": entries})
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListEntriesTotal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := memory.New()
+	s := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, userID := range []int{1, 1, 1, 2} {
+		s.RecordAction(WithUserID(context.Background(), userID), ActionConsentCreate, "vbank", "c", nil)
+	}
+
+	tests := []struct {
+		name    string
+		role    string
+		query   string
+		total   int
+		entries int
+	}{
+		{name: "first page", query: "?limit=2", total: 3, entries: 2},
+		{name: "last page", query: "?limit=2&offset=2", total: 3, entries: 1},
+		{name: "past the end", query: "?offset=10", total: 3, entries: 0},
+		{name: "admin sees all", role: "admin", query: "?limit=1", total: 4, entries: 1},
+		{name: "admin filter", role: "admin", query: "?user_id=2", total: 1, entries: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/audit"+tt.query, nil)
+			c.Set("user_id", 1)
+			c.Set("role", tt.role)
+			NewHandler(s).ListEntries(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("code = %d: %s", w.Code, w.Body)
+			}
+			var resp struct {
+				Total   int                  `json:"total"`
+				Entries []storage.AuditEntry `json:"entries"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Total != tt.total || len(resp.Entries) != tt.entries {
+				t.Errorf("total = %d, entries = %d; want %d, %d", resp.Total, len(resp.Entries), tt.total, tt.entries)
+			}
+		})
+	}
+}
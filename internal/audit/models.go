@@ -0,0 +1,32 @@
+package audit
+
+import "time"
+
+// Типы записей журнала
+const (
+	KindBankCall   = "bank_call"
+	KindUserAction = "user_action"
+)
+
+// Действия пользователя, которые попадают в журнал
+const (
+	ActionConsentCreate = "consent.create"
+	ActionConsentRevoke = "consent.revoke"
+	ActionProductOpen   = "product.open"
+	ActionProductClose  = "product.close"
+	ActionTransfer      = "transfer.create"
+)
+
+// BankCall — метаданные одного исходящего запроса к банку.
+// Тела запросов/ответов и токены сюда не попадают.
+type BankCall struct {
+	BankCode   string
+	Method     string
+	Endpoint   string
+	ConsentID  string
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+
+	InteractionID string // X-Fapi-Interaction-Id конкретной попытки
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/requestid"
+	"MoneyPilot/internal/storage"
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// Service пишет и читает журнал аудита.
+// Методы записи безопасно вызывать на nil — тогда аудит просто выключен.
+type Service struct {
+	Repo storage.AuditRepository
+	Log  *slog.Logger
+}
+
+func NewService(repo storage.AuditRepository, logger *slog.Logger) *Service {
+	return &Service{Repo: repo, Log: logging.For(logger, "audit")}
+}
+
+// RecordBankCall фиксирует исходящий вызов банковского API
+func (s *Service) RecordBankCall(ctx context.Context, call BankCall) {
+	if s == nil {
+		return
+	}
+	latency := call.Latency.Milliseconds()
+	e := &storage.AuditEntry{
+		Kind:      KindBankCall,
+		UserID:    optInt(UserIDFromContext(ctx)),
+		Action:    "bank." + call.Method,
+		BankCode:  optString(call.BankCode),
+		Method:    optString(call.Method),
+		Endpoint:  optString(call.Endpoint),
+		ConsentID: optString(call.ConsentID),
+		LatencyMs: &latency,
+		RequestID: optString(requestid.FromContext(ctx)),
+	}
+	if call.StatusCode != 0 {
+		e.StatusCode = &call.StatusCode
+	}
+	details := map[string]string{}
+	if call.InteractionID != "" {
+		details["interaction_id"] = call.InteractionID
+	}
+	if call.Err != nil {
+		details["error"] = call.Err.Error()
+	}
+	if len(details) > 0 {
+		e.Details, _ = json.Marshal(details)
+	}
+	s.insert(ctx, e)
+}
+
+// RecordAction фиксирует чувствительное действие пользователя (создание/отзыв согласия, закрытие продукта, перевод).
+// details не должен содержать секретов.
+func (s *Service) RecordAction(ctx context.Context, action, bankCode, consentID string, details map[string]interface{}) {
+	if s == nil {
+		return
+	}
+	e := &storage.AuditEntry{
+		Kind:      KindUserAction,
+		UserID:    optInt(UserIDFromContext(ctx)),
+		Action:    action,
+		BankCode:  optString(bankCode),
+		ConsentID: optString(consentID),
+		RequestID: optString(requestid.FromContext(ctx)),
+	}
+	if len(details) > 0 {
+		e.Details, _ = json.Marshal(details)
+	}
+	s.insert(ctx, e)
+}
+
+// List возвращает записи журнала по фильтру
+func (s *Service) List(f storage.AuditFilter) ([]storage.AuditEntry, error) {
+	return s.Repo.ListAuditEntries(f)
+}
+
+// Count возвращает число записей по фильтру без учёта страницы
+func (s *Service) Count(f storage.AuditFilter) (int, error) {
+	return s.Repo.CountAuditEntries(f)
+}
+
+func (s *Service) insert(ctx context.Context, e *storage.AuditEntry) {
+	if err := s.Repo.InsertAuditEntry(e); err != nil {
+		s.Log.ErrorContext(ctx, "failed to write entry", "kind", e.Kind, "action", e.Action, logging.Err(err))
+	}
+}
+
+func optString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optInt(i int) *int {
+	if i == 0 {
+		return nil
+	}
+	return &i
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// consentHeaders — заголовки, в которых банки получают идентификатор согласия
+var consentHeaders = []string{"X-Consent-Id", "X-Product-Agreement-Consent-Id", "X-Payment-Consent-Id"}
+
+// Transport — http.RoundTripper, который пишет в журнал каждый вызов банковского API.
+// В журнал попадают только банк, метод, путь (без query — там client_id/secret), согласие,
+// статус и задержка.
+type Transport struct {
+	Next   http.RoundTripper
+	Audit  *Service
+	byHost map[string]string
+}
+
+func NewTransport(next http.RoundTripper, svc *Service, banks map[string]*bankapi.BankClient) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	byHost := make(map[string]string, len(banks))
+	for code, b := range banks {
+		if u, err := url.Parse(b.BaseURL); err == nil {
+			byHost[u.Host] = code
+		}
+	}
+	return &Transport{Next: next, Audit: svc, byHost: byHost}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.Next.RoundTrip(req)
+
+	call := BankCall{
+		BankCode: t.byHost[req.URL.Host],
+		Method:   req.Method,
+		Endpoint: req.URL.Path,
+		Latency:  time.Since(start),
+		Err:      err,
+
+		InteractionID: req.Header.Get("X-Fapi-Interaction-Id"),
+	}
+	for _, h := range consentHeaders {
+		if v := req.Header.Get(h); v != "" {
+			call.ConsentID = v
+			break
+		}
+	}
+	if resp != nil {
+		call.StatusCode = resp.StatusCode
+	}
+	t.Audit.RecordBankCall(req.Context(), call)
+	return resp, err
+}
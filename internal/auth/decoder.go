@@ -44,7 +44,37 @@ func DecodeToken(secret []byte) gin.HandlerFunc {
 
 		// сохраняем user_id в контекст Gin
 		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
 
 		c.Next()
 	}
 }
+
+// OptionalToken — для WebSocket: браузер не может передать заголовок, поэтому токен
+// принимается и из ?token=. Без токена запрос пропускается анонимно.
+func OptionalToken(secret []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenStr := c.Query("token")
+		if h := c.GetHeader("Authorization"); tokenStr == "" && strings.HasPrefix(h, "Bearer ") {
+			tokenStr = strings.TrimPrefix(h, "Bearer ")
+		}
+		if tokenStr == "" {
+			c.Next()
+			return
+		}
+
+		token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
+			return secret, nil
+		})
+		if err != nil || !token.Valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			c.Abort()
+			return
+		}
+		if claims, ok := token.Claims.(*Claims); ok {
+			c.Set("user_id", claims.UserID)
+			c.Set("role", claims.Role)
+		}
+		c.Next()
+	}
+}
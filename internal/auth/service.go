@@ -2,7 +2,6 @@ package auth
 
 import (
 	"MoneyPilot/internal/storage"
-	"database/sql"
 	"errors"
 	"time"
 
@@ -10,26 +9,26 @@ import (
 )
 
 type AuthService struct {
-	DB        *sql.DB
+	Repo      storage.Store
 	JWTSecret []byte
 }
 
 type Claims struct {
-	UserID int `json:"user_id"`
+	UserID int    `json:"user_id"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(db *sql.DB, secret string) *AuthService {
+func NewAuthService(repo storage.Store, secret string) *AuthService {
 	return &AuthService{
-		DB:        db,
+		Repo:      repo,
 		JWTSecret: []byte(secret),
 	}
 }
 
 func (s *AuthService) Authenticate(email, password, bank string) (string, error) {
 	// Use repository helper to resolve user within the context of the requested bank.
-	repo := storage.NewRepository(s.DB)
-	user, err := repo.GetUserByClientIDAndBank(email, bank)
+	user, err := s.Repo.GetUserByClientIDAndBank(email, bank)
 	// log.Println(user)
 	if err != nil {
 		// hide detailed DB errors from caller
@@ -43,6 +42,7 @@ func (s *AuthService) Authenticate(email, password, bank string) (string, error)
 
 	claims := Claims{
 		UserID: user.ID,
+		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 		},
@@ -53,9 +53,7 @@ func (s *AuthService) Authenticate(email, password, bank string) (string, error)
 }
 
 func (s *AuthService) AuthenticateConsent(email, bank string) (bool, error) {
-	rep := storage.NewRepository(s.DB)
-
-	cons, err := rep.GetValidAccountConsentsByEmailAndBank(email, bank)
+	cons, err := s.Repo.GetValidAccountConsentsByEmailAndBank(email, bank)
 
 	if err != nil {
 		return false, err
@@ -0,0 +1,142 @@
+package balances
+
+import (
+	"MoneyPilot/internal/fx"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetNetWorth — GET /api/networth?from=&to=&interval=day|week|month&currency=
+// По умолчанию — последние 30 дней по дням в базовой валюте пользователя.
+func (h *Handler) GetNetWorth(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	from, to, interval, ok := parseRange(c)
+	if !ok {
+		return
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency != "" && !fx.ValidCurrency(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+		return
+	}
+
+	nw, err := h.Service.NetWorth(userID, from, to, interval, currency)
+	if errors.Is(err, ErrRangeTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, fx.ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "missing exchange rate", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build net worth", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, nw)
+}
+
+// GetBalanceHistory — GET /api/accounts/:account_id/balance-history (банк в X-Bank-Code)
+func (h *Handler) GetBalanceHistory(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	bankCode := c.GetHeader("X-Bank-Code")
+	if bankCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Bank-Code header required"})
+		return
+	}
+
+	from, to, interval, ok := parseRange(c)
+	if !ok {
+		return
+	}
+
+	series, err := h.Service.AccountHistory(userID, bankCode, c.Param("account_id"), from, to, interval)
+	if errors.Is(err, ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+	if errors.Is(err, ErrRangeTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load balance history", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "interval": interval, "account": series})
+}
+
+// parseRange разбирает from/to/interval и сам отвечает 400 при ошибке
+func parseRange(c *gin.Context) (time.Time, time.Time, Interval, bool) {
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := parseEnd(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to", "details": err.Error()})
+			return time.Time{}, time.Time{}, "", false
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -30)
+	if v := c.Query("from"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from", "details": err.Error()})
+			return time.Time{}, time.Time{}, "", false
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return time.Time{}, time.Time{}, "", false
+	}
+	interval, err := ParseInterval(c.Query("interval"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return time.Time{}, time.Time{}, "", false
+	}
+	return from, to, interval, true
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// parseEnd — конец диапазона не включается, поэтому дата без времени
+// означает конец этого дня: to=2024-03-31 включает срезы за 31 марта
+func parseEnd(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return t, err
+	}
+	return t.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,56 @@
+package balances
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.RFC3339, s)
+		return d
+	}
+	tests := []struct {
+		name     string
+		query    string
+		from, to time.Time
+		ok       bool
+	}{
+		{name: "date-only to covers that day", query: "from=2024-03-01&to=2024-03-31",
+			from: day("2024-03-01T00:00:00Z"), to: day("2024-04-01T00:00:00Z"), ok: true},
+		{name: "timestamp to is exclusive as given", query: "from=2024-03-01&to=2024-03-31T12:00:00Z",
+			from: day("2024-03-01T00:00:00Z"), to: day("2024-03-31T12:00:00Z"), ok: true},
+		{name: "default from is 30 days before to", query: "to=2024-03-31",
+			from: day("2024-03-02T00:00:00Z"), to: day("2024-04-01T00:00:00Z"), ok: true},
+		{name: "same day", query: "from=2024-03-31&to=2024-03-31",
+			from: day("2024-03-31T00:00:00Z"), to: day("2024-04-01T00:00:00Z"), ok: true},
+		{name: "from after to", query: "from=2024-04-02&to=2024-03-31"},
+		{name: "bad to", query: "to=31.03.2024"},
+		{name: "bad interval", query: "to=2024-03-31&interval=hour"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/networth?"+tt.query, nil)
+			from, to, _, ok := parseRange(c)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v (%s)", ok, tt.ok, w.Body)
+			}
+			if !ok {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("code = %d, want 400", w.Code)
+				}
+				return
+			}
+			if !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("range = %s..%s, want %s..%s", from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
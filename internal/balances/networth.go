@@ -0,0 +1,197 @@
+package balances
+
+import (
+	"MoneyPilot/internal/storage"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Interval — шаг временного ряда
+type Interval string
+
+const (
+	Day   Interval = "day"
+	Week  Interval = "week"
+	Month Interval = "month"
+)
+
+// ErrRangeTooLarge — в ряду получилось бы больше maxPoints точек
+var ErrRangeTooLarge = fmt.Errorf("range too large: more than %d points, use a wider interval", maxPoints)
+
+// maxPoints ограничивает длину ряда, чтобы from=2000-01-01&interval=day не строил бесконечный ответ
+const maxPoints = 1000
+
+func ParseInterval(v string) (Interval, error) {
+	switch Interval(v) {
+	case "", Day:
+		return Day, nil
+	case Week, Month:
+		return Interval(v), nil
+	}
+	return "", fmt.Errorf("interval must be day, week or month")
+}
+
+// Point — значение на начало периода: последний известный срез до его конца
+type Point struct {
+	Date      time.Time `json:"date"`
+	Available float64   `json:"available"`
+	Booked    float64   `json:"booked"`
+}
+
+type AccountSeries struct {
+	AccountID string  `json:"account_id"`
+	BankCode  string  `json:"bank"`
+	Currency  string  `json:"currency"`
+	Nickname  *string `json:"nickname,omitempty"`
+	Points    []Point `json:"points"`
+}
+
+// NetWorth — итоги и ряды по банкам в валюте Currency; ряды счетов — в валюте счёта
+type NetWorth struct {
+	Currency string             `json:"currency"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Interval Interval           `json:"interval"`
+	Total    []Point            `json:"total"`
+	Banks    map[string][]Point `json:"banks"`
+	Accounts []AccountSeries    `json:"accounts"`
+}
+
+// accountInfo — то, что нужно про счёт для подписи ряда
+type accountInfo struct {
+	storage.Account
+	BankCode string
+}
+
+// buckets возвращает начала периодов, покрывающих [from, to)
+func buckets(from, to time.Time, interval Interval) ([]time.Time, error) {
+	start := truncate(from, interval)
+	var res []time.Time
+	for t := start; t.Before(to); t = next(t, interval) {
+		res = append(res, t)
+		if len(res) > maxPoints {
+			return nil, ErrRangeTooLarge
+		}
+	}
+	return res, nil
+}
+
+func truncate(t time.Time, interval Interval) time.Time {
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	switch interval {
+	case Week:
+		// неделя начинается с понедельника
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+func next(t time.Time, interval Interval) time.Time {
+	switch interval {
+	case Week:
+		return t.AddDate(0, 0, 7)
+	case Month:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// converter переводит сумму из валюты currency в валюту итогов на дату on
+type converter func(amount float64, currency string, on time.Time) (float64, error)
+
+// buildSeries раскладывает срезы по периодам. Значение периода — последний срез до его конца
+// (или до to для текущего периода); счёт без срезов к этому моменту в точку не попадает.
+// snaps должны быть отсортированы по taken_at.
+func buildSeries(accs []accountInfo, snaps []storage.BalanceSnapshot, from, to time.Time, interval Interval, conv converter) (*NetWorth, error) {
+	starts, err := buckets(from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	byAccount := map[int][]storage.BalanceSnapshot{}
+	for _, s := range snaps {
+		byAccount[s.AccountID] = append(byAccount[s.AccountID], s)
+	}
+
+	nw := &NetWorth{
+		From:     from,
+		To:       to,
+		Interval: interval,
+		Total:    make([]Point, len(starts)),
+		Banks:    map[string][]Point{},
+		Accounts: []AccountSeries{},
+	}
+	for i, st := range starts {
+		nw.Total[i].Date = st
+	}
+
+	for _, acc := range accs {
+		history := byAccount[acc.ID]
+		series := AccountSeries{BankCode: acc.BankCode, Currency: acc.Currency, Nickname: acc.Nickname, Points: []Point{}}
+		if acc.ExternalID != nil {
+			series.AccountID = *acc.ExternalID
+		}
+		bank := nw.Banks[acc.BankCode]
+		if bank == nil {
+			bank = make([]Point, len(starts))
+			for i, st := range starts {
+				bank[i].Date = st
+			}
+		}
+
+		j := -1 // индекс последнего среза до конца текущего периода
+		for i, st := range starts {
+			end := next(st, interval)
+			if end.After(to) {
+				end = to
+			}
+			for j+1 < len(history) && !history[j+1].TakenAt.After(end) {
+				j++
+			}
+			if j < 0 {
+				continue
+			}
+			p := Point{Date: st, Available: value(history[j].Available), Booked: value(history[j].Booked)}
+			series.Points = append(series.Points, p)
+
+			currency := acc.Currency
+			if history[j].Currency != nil && *history[j].Currency != "" {
+				currency = *history[j].Currency
+			}
+			available, err := conv(p.Available, currency, end)
+			if err != nil {
+				return nil, err
+			}
+			booked, err := conv(p.Booked, currency, end)
+			if err != nil {
+				return nil, err
+			}
+			bank[i].Available += available
+			bank[i].Booked += booked
+			nw.Total[i].Available += available
+			nw.Total[i].Booked += booked
+		}
+		nw.Banks[acc.BankCode] = bank
+		nw.Accounts = append(nw.Accounts, series)
+	}
+
+	sort.Slice(nw.Accounts, func(a, b int) bool {
+		if nw.Accounts[a].BankCode != nw.Accounts[b].BankCode {
+			return nw.Accounts[a].BankCode < nw.Accounts[b].BankCode
+		}
+		return nw.Accounts[a].AccountID < nw.Accounts[b].AccountID
+	})
+	return nw, nil
+}
+
+func value(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
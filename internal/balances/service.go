@@ -0,0 +1,228 @@
+package balances
+
+import (
+	"MoneyPilot/internal/accounts"
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Service снимает балансы счетов по расписанию и строит по ним историю
+type Service struct {
+	Repo     storage.Store
+	History  storage.BalanceRepository
+	Accounts *accounts.Service
+	Banks    map[string]*bankapi.BankClient
+	FX       *fx.Service
+	Log      *slog.Logger
+}
+
+func NewService(repo storage.Store, history storage.BalanceRepository, accountSvc *accounts.Service, banks map[string]*bankapi.BankClient, fxSvc *fx.Service, logger *slog.Logger) *Service {
+	return &Service{Repo: repo, History: history, Accounts: accountSvc, Banks: banks, FX: fxSvc, Log: logging.For(logger, "balances")}
+}
+
+// Start запускает фоновый снимок балансов: сразу и затем раз в interval
+func (s *Service) Start(interval time.Duration, stopCh <-chan struct{}) {
+	s.Log.Info("snapshot job started", "interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.SnapshotAll(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				s.SnapshotAll(context.Background())
+			case <-stopCh:
+				s.Log.Info("stopping")
+				return
+			}
+		}
+	}()
+}
+
+// SnapshotAll снимает балансы всех клиентов с действующим согласием на счета
+func (s *Service) SnapshotAll(ctx context.Context) {
+	userIDs, err := s.Repo.GetUserIDsWithValidAccountConsents()
+	if err != nil {
+		s.Log.ErrorContext(ctx, "failed to load users", logging.Err(err))
+		return
+	}
+	for _, userID := range userIDs {
+		n, err := s.SnapshotUser(audit.WithUserID(ctx, userID), userID)
+		if err != nil {
+			s.Log.ErrorContext(ctx, "snapshot failed", "user_id", userID, logging.Err(err))
+			continue
+		}
+		s.Log.InfoContext(ctx, "snapshots taken", "user_id", userID, "count", n)
+	}
+}
+
+// SnapshotUser сохраняет счета клиента и текущие остатки по ним.
+// Ошибка по одному счёту не останавливает остальные.
+func (s *Service) SnapshotUser(ctx context.Context, userID int) (int, error) {
+	bankAccounts, err := s.Accounts.FetchAllUserAccounts(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	taken := time.Now()
+	saved := 0
+	for _, ba := range bankAccounts {
+		bal, err := s.Accounts.FetchBalances(ctx, userID, ba.BankCode, ba.AccountID)
+		if err != nil {
+			s.Log.ErrorContext(ctx, "failed to fetch balances", "bank", ba.BankCode, "account", ba.AccountID, logging.Err(err))
+			continue
+		}
+		acc, err := s.saveAccount(userID, ba, bal)
+		if err != nil {
+			s.Log.ErrorContext(ctx, "failed to save account", "bank", ba.BankCode, "account", ba.AccountID, logging.Err(err))
+			continue
+		}
+
+		snap := storage.BalanceSnapshot{
+			AccountID: acc.ID,
+			Available: bal.Available,
+			Booked:    bal.Booked,
+			TakenAt:   taken,
+		}
+		if bal.Currency != "" {
+			snap.Currency = &bal.Currency
+		}
+		if err := s.History.InsertBalanceSnapshot(&snap); err != nil {
+			s.Log.ErrorContext(ctx, "failed to insert snapshot", "bank", ba.BankCode, "account", ba.AccountID, logging.Err(err))
+			continue
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// saveAccount обновляет запись accounts по (банк, accountId) и кэширует доступный остаток
+func (s *Service) saveAccount(userID int, ba accounts.BankAccount, bal *accounts.Balances) (*storage.Account, error) {
+	bank, err := s.Repo.GetBankByCode(ba.BankCode)
+	if err != nil {
+		return nil, fmt.Errorf("bank %s: %w", ba.BankCode, err)
+	}
+	// у клиента своя запись users на каждый банк
+	owner, err := s.Repo.GetUserByUserIDAndBank(userID, ba.BankCode)
+	if err != nil {
+		return nil, fmt.Errorf("user for bank %s: %w", ba.BankCode, err)
+	}
+
+	externalID := ba.AccountID
+	number := ba.Number
+	if number == "" {
+		number = ba.BankCode + ":" + ba.AccountID
+	}
+	acc := storage.Account{
+		UserID:        owner.ID,
+		BankID:        bank.ID,
+		ExternalID:    &externalID,
+		AccountNumber: number,
+		AccountType:   ba.AccountSubType,
+		Currency:      ba.Currency,
+		Status:        ba.Status,
+	}
+	if ba.Nickname != "" {
+		acc.Nickname = &ba.Nickname
+	}
+	if bal.Available != nil {
+		acc.Balance = *bal.Available
+	}
+	if err := s.Repo.UpsertAccount(&acc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// NetWorth строит ряд суммарных остатков клиента по всем банкам в валюте currency
+// (пустая — базовая валюта пользователя)
+func (s *Service) NetWorth(userID int, from, to time.Time, interval Interval, currency string) (*NetWorth, error) {
+	accs, err := s.clientAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		if currency, err = s.FX.BaseCurrency(userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.series(accs, from, to, interval, currency)
+}
+
+// AccountHistory — ряд остатков одного счёта; счёт должен принадлежать клиенту
+func (s *Service) AccountHistory(userID int, bankCode, accountID string, from, to time.Time, interval Interval) (*AccountSeries, error) {
+	accs, err := s.clientAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range accs {
+		if a.BankCode == bankCode && a.ExternalID != nil && *a.ExternalID == accountID {
+			nw, err := s.series([]accountInfo{a}, from, to, interval, a.Currency)
+			if err != nil {
+				return nil, err
+			}
+			return &nw.Accounts[0], nil
+		}
+	}
+	return nil, ErrAccountNotFound
+}
+
+var ErrAccountNotFound = errors.New("account not found")
+
+func (s *Service) series(accs []accountInfo, from, to time.Time, interval Interval, currency string) (*NetWorth, error) {
+	ids := make([]int, len(accs))
+	for i, a := range accs {
+		ids[i] = a.ID
+	}
+	var snaps []storage.BalanceSnapshot
+	if len(ids) > 0 {
+		var err error
+		if snaps, err = s.History.GetBalanceSnapshots(ids, from, to); err != nil {
+			return nil, err
+		}
+	}
+	conv := func(amount float64, cur string, on time.Time) (float64, error) {
+		if amount == 0 || cur == "" {
+			return amount, nil
+		}
+		return s.FX.Convert(amount, cur, currency, on)
+	}
+	nw, err := buildSeries(accs, snaps, from, to, interval, conv)
+	if err != nil {
+		return nil, err
+	}
+	nw.Currency = currency
+	return nw, nil
+}
+
+// clientAccounts — сохранённые счета клиента во всех банках с кодом банка
+func (s *Service) clientAccounts(userID int) ([]accountInfo, error) {
+	stored, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	codes := map[int]string{}
+	for code := range s.Banks {
+		if b, err := s.Repo.GetBankByCode(code); err == nil {
+			codes[b.ID] = code
+		}
+	}
+	// ручные счета (импорт выписок) — отдельной группой
+	if b, err := s.Repo.GetBankByCode(storage.ManualBankCode); err == nil {
+		codes[b.ID] = storage.ManualBankCode
+	}
+	res := make([]accountInfo, 0, len(stored))
+	for _, a := range stored {
+		res = append(res, accountInfo{Account: a, BankCode: codes[a.BankID]})
+	}
+	return res, nil
+}
@@ -6,12 +6,13 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 )
 
 func GetToken() (*ActiveToken, error) {
 	client := &http.Client{}
-	baseUrl := buildURLWithParams("https://vbank.open.bankingapi.ru/auth/bank-token", map[string]string{"client_id": "team081", "client_secret": "ddslFory8voO3gxZ2CEaQnHzLfv4HVzo"})
+	baseUrl := buildURLWithParams("https://vbank.open.bankingapi.ru/auth/bank-token", map[string]string{"client_id": os.Getenv("LOGIN_HAC"), "client_secret": os.Getenv("PASSWORD_HAC")})
 	req, err := http.NewRequest("POST", baseUrl, nil)
 	if err != nil {
 		log.Println("Error to get token:", err.Error())
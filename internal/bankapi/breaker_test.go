@@ -0,0 +1,65 @@
+package bankapi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	b := newCircuitBreaker(2, cooldown)
+
+	b.Record(false)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("one failure below threshold: %v", err)
+	}
+	b.Record(false)
+	if !b.Open() {
+		t.Fatal("breaker should open after threshold failures")
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open breaker: err = %v, want ErrCircuitOpen", err)
+	}
+
+	// после cooldown — ровно один пробный запрос
+	time.Sleep(cooldown + 5*time.Millisecond)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe after cooldown: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second request while probing: err = %v, want ErrCircuitOpen", err)
+	}
+
+	// неудачный пробный запрос снова размыкает предохранитель
+	b.Record(false)
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("failed probe: err = %v, want ErrCircuitOpen", err)
+	}
+
+	// удачный пробный запрос замыкает его и сбрасывает счётчик
+	time.Sleep(cooldown + 5*time.Millisecond)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("second probe: %v", err)
+	}
+	b.Record(true)
+	if b.Open() {
+		t.Fatal("breaker should close after a successful probe")
+	}
+	b.Record(false)
+	for i := 0; i < 3; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("closed breaker, request %d: %v", i, err)
+		}
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.Record(false)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("threshold 0 disables the breaker: %v", err)
+	}
+}
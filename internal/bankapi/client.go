@@ -14,6 +14,7 @@ type BankClient struct {
 	BaseURL      string
 	ClientID     string
 	ClientSecret string
+	HTTPClient   *http.Client // общий банковский клиент; nil — http.DefaultClient
 }
 
 func (b *BankClient) GetToken() (*ActiveToken, error) {
@@ -28,7 +29,11 @@ func (b *BankClient) GetToken() (*ActiveToken, error) {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
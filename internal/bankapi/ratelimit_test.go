@@ -0,0 +1,59 @@
+package bankapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(20, 2) // токен раз в 50ms
+
+	// запас burst расходуется без ожидания
+	for i := 0; i < 2; i++ {
+		if err := waitFor(b, 5*time.Millisecond); err != nil {
+			t.Fatalf("burst token %d: %v", i, err)
+		}
+	}
+	if err := waitFor(b, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("empty bucket: err = %v, want DeadlineExceeded", err)
+	}
+
+	// за 60ms набегает один токен
+	time.Sleep(60 * time.Millisecond)
+	if err := waitFor(b, 5*time.Millisecond); err != nil {
+		t.Fatalf("refilled token: %v", err)
+	}
+	if err := waitFor(b, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("after refill: err = %v, want DeadlineExceeded", err)
+	}
+
+	// Wait дожидается следующего токена
+	start := time.Now()
+	if err := waitFor(b, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Errorf("Wait returned after %s, want about 50ms", waited)
+	}
+}
+
+func TestTokenBucketCapsAtBurst(t *testing.T) {
+	b := newTokenBucket(100, 2)
+	time.Sleep(50 * time.Millisecond) // хватило бы на 5 токенов, но запас — 2
+	for i := 0; i < 2; i++ {
+		if err := waitFor(b, 2*time.Millisecond); err != nil {
+			t.Fatalf("token %d: %v", i, err)
+		}
+	}
+	if err := waitFor(b, 2*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("tokens above burst: err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func waitFor(b *tokenBucket, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return b.Wait(ctx)
+}
@@ -1,22 +1,13 @@
 package bankapi
 
-var Banks = map[string]*BankClient{
-	"vbank": {
-		Name:         "VBank",
-		BaseURL:      "https://vbank.open.bankingapi.ru",
-		ClientID:     "team081",
-		ClientSecret: "ddslFory8voO3gxZ2CEaQnHzLfv4HVzo",
-	},
-	"abank": {
-		Name:         "ABank",
-		BaseURL:      "https://abank.open.bankingapi.ru",
-		ClientID:     "team081",
-		ClientSecret: "ddslFory8voO3gxZ2CEaQnHzLfv4HVzo",
-	},
-	"sbank": {
-		Name:         "SBank",
-		BaseURL:      "https://sbank.open.bankingapi.ru",
-		ClientID:     "team081",
-		ClientSecret: "ddslFory8voO3gxZ2CEaQnHzLfv4HVzo",
-	},
+import "maps"
+
+// Banks — клиенты банков по коду. Заполняется из конфигурации через Configure
+// до создания сервисов: они держат ссылку на эту карту.
+var Banks = map[string]*BankClient{}
+
+// Configure заменяет содержимое реестра банков
+func Configure(clients map[string]*BankClient) {
+	clear(Banks)
+	maps.Copy(Banks, clients)
 }
@@ -1,9 +1,12 @@
 package bankapi
 
 import (
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/metrics"
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -11,10 +14,11 @@ import (
 
 type TokenService struct {
 	rdb *redis.Client
+	log *slog.Logger
 }
 
-func NewTokenService(rdb *redis.Client) *TokenService {
-	return &TokenService{rdb: rdb}
+func NewTokenService(rdb *redis.Client, logger *slog.Logger) *TokenService {
+	return &TokenService{rdb: rdb, log: logging.For(logger, "bankapi")}
 }
 
 func (s *TokenService) GetValidToken(bank *BankClient) (*ActiveToken, error) {
@@ -33,16 +37,22 @@ func (s *TokenService) GetValidToken(bank *BankClient) (*ActiveToken, error) {
 		}
 	}
 	// Иначе — запрашиваем новый
+	// метки банков в метриках — коды из Banks, а они совпадают с названием в нижнем регистре
+	code := strings.ToLower(bank.Name)
 	token, err := bank.GetToken()
 	if err != nil {
+		metrics.TokenRefreshes.WithLabelValues(code, "error").Inc()
+		s.log.Error("token refresh failed", "bank", code, logging.Err(err))
 		return nil, err
 	}
+	metrics.TokenRefreshes.WithLabelValues(code, "ok").Inc()
+	s.log.Debug("token refreshed", "bank", code)
 
 	// Кэшируем с TTL = 24 часа
 	raw, _ := json.Marshal(token)
 	err = s.rdb.Set(ctx, "token:"+bank.Name, raw, 24*time.Hour).Err()
 	if err != nil {
-		log.Println("[Redis] cannot save token:", err)
+		s.log.Warn("cannot cache token", "bank", code, logging.Err(err))
 	}
 
 	return token, nil
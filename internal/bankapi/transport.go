@@ -0,0 +1,357 @@
+package bankapi
+
+import (
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/metrics"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BankLimits — ограничения исходящих запросов к одному банку
+type BankLimits struct {
+	Timeout          time.Duration // таймаут одной попытки (вместе с чтением тела)
+	RatePerSecond    float64       // token bucket: запросов в секунду
+	Burst            int           // token bucket: запас
+	BreakerThreshold int           // подряд неудачных запросов до размыкания
+	BreakerCooldown  time.Duration // сколько ждать перед пробным запросом
+}
+
+// TransportConfig — настройки общего транспорта к банкам
+type TransportConfig struct {
+	Defaults    BankLimits
+	PerBank     map[string]BankLimits // переопределения по коду банка
+	MaxRetries  int                   // повторы для идемпотентных запросов
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Defaults: BankLimits{
+			Timeout:          10 * time.Second,
+			RatePerSecond:    5,
+			Burst:            10,
+			BreakerThreshold: 5,
+			BreakerCooldown:  30 * time.Second,
+		},
+		MaxRetries:  2,
+		BaseBackoff: 200 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+	}
+}
+
+// Transport — общий http.RoundTripper для всех запросов к банкам:
+// per-bank таймауты, token bucket, circuit breaker, повторы идемпотентных методов
+// с jitter-backoff и учётом Retry-After, FAPI-заголовки.
+type Transport struct {
+	Next   http.RoundTripper
+	Config TransportConfig
+	Log    *slog.Logger
+
+	byHost map[string]string
+	mu     sync.Mutex
+	state  map[string]*bankState
+}
+
+type bankState struct {
+	limits  BankLimits
+	limiter *tokenBucket
+	breaker *circuitBreaker
+}
+
+func NewTransport(cfg TransportConfig, banks map[string]*BankClient, next http.RoundTripper, logger *slog.Logger) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	byHost := make(map[string]string, len(banks))
+	for code, b := range banks {
+		if u, err := url.Parse(b.BaseURL); err == nil {
+			byHost[u.Host] = code
+		}
+	}
+	return &Transport{Next: next, Config: cfg, Log: logging.For(logger, "bankapi"), byHost: byHost, state: map[string]*bankState{}}
+}
+
+// NewHTTPClient — клиент, который стоит передавать во все сервисы, ходящие в банки.
+// Таймауты задаются транспортом по банку, поэтому у самого клиента таймаута нет.
+func NewHTTPClient(t *Transport) *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// BreakerOpen сообщает, разомкнут ли предохранитель банка
+func (t *Transport) BreakerOpen(bankCode string) bool {
+	return t.bank(bankCode).breaker.Open()
+}
+
+func (t *Transport) bank(code string) *bankState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.state[code]
+	if st == nil {
+		limits := t.Config.Defaults
+		if override, ok := t.Config.PerBank[code]; ok {
+			limits = override
+		}
+		st = &bankState{
+			limits:  limits,
+			limiter: newTokenBucket(limits.RatePerSecond, limits.Burst),
+			breaker: newCircuitBreaker(limits.BreakerThreshold, limits.BreakerCooldown),
+		}
+		t.state[code] = st
+	}
+	return st
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	code := t.byHost[req.URL.Host]
+	st := t.bank(code)
+	endpoint := metrics.Endpoint(req.URL.Path)
+
+	attempts := 1
+	if isIdempotent(req) {
+		attempts += t.Config.MaxRetries
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := t.backoff(attempt, resp)
+			if resp != nil {
+				drain(resp)
+			}
+			if err := sleepCtx(req.Context(), delay); err != nil {
+				return nil, err
+			}
+			if req, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := st.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		if err := st.breaker.Allow(); err != nil {
+			metrics.BankErrors.WithLabelValues(code, endpoint, "breaker_open").Inc()
+			t.Log.WarnContext(req.Context(), "circuit breaker open", "bank", code, "endpoint", endpoint)
+			return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+		}
+
+		start := time.Now()
+		resp, err = t.attempt(req, code, endpoint, st.limits.Timeout)
+		t.observe(req, code, endpoint, attempt, start, resp, err)
+		st.breaker.Record(err == nil && resp.StatusCode < 500)
+
+		if !retryable(resp, err) || req.Context().Err() != nil {
+			break
+		}
+	}
+	return resp, err
+}
+
+// attempt отправляет один запрос со своим таймаутом, спаном и свежими FAPI-заголовками.
+// В запрос добавляется traceparent, чтобы банк (или прокси перед ним) мог продолжить трейс.
+func (t *Transport) attempt(req *http.Request, code, endpoint string, timeout time.Duration) (*http.Response, error) {
+	ctx, span := otel.Tracer("MoneyPilot/internal/bankapi").Start(req.Context(), "bank "+req.Method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("bank.code", code),
+			attribute.String("bank.endpoint", endpoint),
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			// без query: там бывают client_id и прочие идентификаторы
+			semconv.URLFull(req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		))
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	out := req.Clone(ctx)
+	out.Header.Set("X-Fapi-Interaction-Id", newInteractionID())
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(out.Header))
+
+	resp, err := t.Next.RoundTrip(out)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		cancel()
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	// таймаут должен действовать и на чтение тела, поэтому отменяем контекст при Close; спан закрывается там же
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: func() {
+		cancel()
+		span.End()
+	}}
+	return resp, nil
+}
+
+// observe пишет длительность и ошибки одной попытки в метрики и лог; request_id берётся из контекста запроса
+func (t *Transport) observe(req *http.Request, code, endpoint string, attempt int, start time.Time, resp *http.Response, err error) {
+	elapsed := time.Since(start)
+	status := "error"
+	level := slog.LevelDebug
+	switch {
+	case err == nil:
+		status = strconv.Itoa(resp.StatusCode)
+		if resp.StatusCode >= 500 {
+			metrics.BankErrors.WithLabelValues(code, endpoint, "5xx").Inc()
+			level = slog.LevelWarn
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		metrics.BankErrors.WithLabelValues(code, endpoint, "timeout").Inc()
+		level = slog.LevelWarn
+	default:
+		metrics.BankErrors.WithLabelValues(code, endpoint, "network").Inc()
+		level = slog.LevelWarn
+	}
+	metrics.BankDuration.WithLabelValues(code, endpoint, status).Observe(elapsed.Seconds())
+
+	attrs := []slog.Attr{
+		slog.String("bank", code),
+		slog.String("method", req.Method),
+		slog.String("endpoint", endpoint),
+		slog.String("status", status),
+		slog.Int("attempt", attempt+1),
+		slog.Duration("duration", elapsed),
+	}
+	if err != nil {
+		attrs = append(attrs, logging.Err(err))
+	}
+	t.Log.LogAttrs(req.Context(), level, "bank request", attrs...)
+}
+
+// backoff — экспоненциальная задержка с full jitter; Retry-After банка имеет приоритет
+func (t *Transport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if d > t.Config.MaxBackoff {
+				d = t.Config.MaxBackoff
+			}
+			return d
+		}
+	}
+	max := t.Config.BaseBackoff << (attempt - 1)
+	if max <= 0 || max > t.Config.MaxBackoff {
+		max = t.Config.MaxBackoff
+	}
+	if max <= 0 {
+		return 0
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return max
+	}
+	return time.Duration(n.Int64())
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return req.Header.Get("Idempotency-Key") != "" && (req.Body == nil || req.GetBody != nil)
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		d := time.Until(at)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// rewind готовит запрос к повтору: тело нужно получить заново
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	out := req.Clone(req.Context())
+	out.Body = body
+	return out, nil
+}
+
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// newInteractionID — UUID v4 для X-Fapi-Interaction-Id
+func newInteractionID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
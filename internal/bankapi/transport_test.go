@@ -0,0 +1,224 @@
+package bankapi
+
+import (
+	"MoneyPilot/internal/metrics"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: "", ok: false},
+		{header: "3", want: 3 * time.Second, ok: true},
+		{header: "0", want: 0, ok: true},
+		{header: "-1", ok: false},
+		{header: "soon", ok: false},
+		{header: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), want: 0, ok: true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v; want %s, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if got, ok := retryAfter(future); !ok || got < 8*time.Second || got > 10*time.Second {
+		t.Errorf("retryAfter(date in 10s) = %s, %v", got, ok)
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	tr := &Transport{Config: TransportConfig{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 2 * time.Second}}
+	resp := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{v}}}
+	}
+	if got := tr.backoff(1, resp("1")); got != time.Second {
+		t.Errorf("Retry-After: 1 → %s, want 1s", got)
+	}
+	if got := tr.backoff(1, resp("120")); got != 2*time.Second {
+		t.Errorf("Retry-After above MaxBackoff → %s, want 2s", got)
+	}
+	for attempt := 1; attempt <= 3; attempt++ {
+		limit := 10 * time.Millisecond << (attempt - 1)
+		if got := tr.backoff(attempt, resp("later")); got < 0 || got >= limit {
+			t.Errorf("attempt %d without valid Retry-After: %s, want jitter below %s", attempt, got, limit)
+		}
+	}
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		status int
+		header string
+		hits   int32
+		code   int
+	}{
+		{name: "retry after 503", method: http.MethodGet, status: http.StatusServiceUnavailable, header: "0", hits: 2, code: http.StatusOK},
+		{name: "retry after 429", method: http.MethodGet, status: http.StatusTooManyRequests, header: "0", hits: 2, code: http.StatusOK},
+		{name: "no retry for POST", method: http.MethodPost, status: http.StatusServiceUnavailable, header: "0", hits: 1, code: http.StatusServiceUnavailable},
+		{name: "no retry for 400", method: http.MethodGet, status: http.StatusBadRequest, hits: 1, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&hits, 1) == 1 {
+					if tt.header != "" {
+						w.Header().Set("Retry-After", tt.header)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			cfg := DefaultTransportConfig()
+			cfg.BaseBackoff, cfg.MaxBackoff = time.Millisecond, time.Second
+			tr := NewTransport(cfg, map[string]*BankClient{"vbank": {BaseURL: srv.URL}}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			var body io.Reader
+			if tt.method == http.MethodPost {
+				body = strings.NewReader("{}")
+			}
+			req, _ := http.NewRequest(tt.method, srv.URL+"/accounts", body)
+			resp, err := NewHTTPClient(tr).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.code || atomic.LoadInt32(&hits) != tt.hits {
+				t.Errorf("status %d after %d requests; want %d after %d", resp.StatusCode, hits, tt.code, tt.hits)
+			}
+		})
+	}
+}
+
+func TestTransportPerBankLimits(t *testing.T) {
+	cfg := DefaultTransportConfig()
+	cfg.PerBank = map[string]BankLimits{"sbank": {Timeout: time.Second, RatePerSecond: 1, Burst: 1, BreakerThreshold: 1, BreakerCooldown: time.Minute}}
+	tr := NewTransport(cfg, nil, nil, nil)
+
+	if got := tr.bank("sbank").limits; got != cfg.PerBank["sbank"] {
+		t.Errorf("sbank limits = %+v, want override", got)
+	}
+	if got := tr.bank("vbank").limits; got != cfg.Defaults {
+		t.Errorf("vbank limits = %+v, want defaults", got)
+	}
+
+	// порог 1: одна неудача размыкает предохранитель только у sbank
+	tr.bank("sbank").breaker.Record(false)
+	tr.bank("vbank").breaker.Record(false)
+	if !tr.BreakerOpen("sbank") || tr.BreakerOpen("vbank") {
+		t.Errorf("breaker open: sbank %v, vbank %v; want true, false", tr.BreakerOpen("sbank"), tr.BreakerOpen("vbank"))
+	}
+}
+
+func TestTransportMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cfg := DefaultTransportConfig()
+	cfg.MaxRetries = 1
+	cfg.BaseBackoff, cfg.MaxBackoff = time.Millisecond, time.Millisecond
+	tr := NewTransport(cfg, map[string]*BankClient{"mbank": {BaseURL: srv.URL}}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	failed := metrics.BankErrors.WithLabelValues("mbank", "/accounts/:id/balances", "5xx")
+	before := testutil.ToFloat64(failed)
+
+	// 500 не повторяется, поэтому ошибка одна и серия одна на все счета
+	for _, id := range []string{"acc-1", "acc-2"} {
+		resp, err := NewHTTPClient(tr).Get(srv.URL + "/accounts/" + id + "/balances")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if got := testutil.ToFloat64(failed) - before; got != 2 {
+		t.Errorf("bank errors = %v, want 2", got)
+	}
+}
+
+func TestTransportTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+	}))
+	defer srv.Close()
+	tr := NewTransport(DefaultTransportConfig(), map[string]*BankClient{"vbank": {BaseURL: srv.URL}}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "handler")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/accounts/acc-1?client_id=team-1", nil)
+	resp, err := NewHTTPClient(tr).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() // спан закрывается вместе с телом ответа
+	parent.End()
+
+	var span sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "bank GET /accounts/:id" {
+			span = s
+		}
+	}
+	if span == nil {
+		t.Fatalf("no bank span among %d ended spans", len(recorder.Ended()))
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() || span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span parent %s kind %s, want child of the handler span", span.Parent().SpanID(), span.SpanKind())
+	}
+	attrs := map[attribute.Key]string{}
+	for _, a := range span.Attributes() {
+		attrs[a.Key] = a.Value.Emit()
+	}
+	want := map[attribute.Key]string{
+		"bank.code":                 "vbank",
+		"bank.endpoint":             "/accounts/:id",
+		"http.request.method":       "GET",
+		"http.response.status_code": "200",
+		"url.full":                  srv.URL + "/accounts/acc-1", // без query
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("attribute %s = %q, want %q", k, attrs[k], v)
+		}
+	}
+
+	// банк получает trace context спана запроса, а не обработчика
+	if want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"; traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+}
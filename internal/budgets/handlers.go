@@ -0,0 +1,140 @@
+package budgets
+
+import (
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/storage"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+type budgetRequest struct {
+	Name     string  `json:"name"`
+	Period   string  `json:"period"`
+	Category *string `json:"category"`
+	Merchant *string `json:"merchant"`
+	Amount   float64 `json:"amount" binding:"required"`
+	Currency string  `json:"currency"`
+	Rollover bool    `json:"rollover"`
+}
+
+func (r budgetRequest) budget() storage.Budget {
+	return storage.Budget{
+		Name:     r.Name,
+		Period:   r.Period,
+		Category: r.Category,
+		Merchant: r.Merchant,
+		Amount:   r.Amount,
+		Currency: r.Currency,
+		Rollover: r.Rollover,
+	}
+}
+
+// ListBudgets — GET /api/budgets: бюджеты с потрачено/осталось/прогнозом за текущий период
+func (h *Handler) ListBudgets(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	progress, err := h.Service.List(userID)
+	if errors.Is(err, fx.ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "missing exchange rate", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load budgets", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(progress), "budgets": progress})
+}
+
+// CreateBudget — POST /api/budgets
+func (h *Handler) CreateBudget(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req budgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	b, err := h.Service.Create(userID, req.budget())
+	if errors.Is(err, ErrInvalidBudget) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create budget", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, b)
+}
+
+// UpdateBudget — PUT /api/budgets/:budget_id
+func (h *Handler) UpdateBudget(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("budget_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid budget_id"})
+		return
+	}
+	var req budgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	b := req.budget()
+	b.ID = id
+	updated, err := h.Service.Update(userID, b)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidBudget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update budget", "details": err.Error()})
+	default:
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteBudget — DELETE /api/budgets/:budget_id
+func (h *Handler) DeleteBudget(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("budget_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid budget_id"})
+		return
+	}
+	err = h.Service.Delete(userID, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete budget", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
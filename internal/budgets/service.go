@@ -0,0 +1,380 @@
+package budgets
+
+import (
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/websockets"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	Week  = "week"
+	Month = "month"
+)
+
+// Thresholds — пороги уведомлений, % от лимита
+var Thresholds = []int{50, 80, 100}
+
+var (
+	ErrNotFound      = errors.New("budget not found")
+	ErrInvalidBudget = errors.New("invalid budget")
+)
+
+type Service struct {
+	Repo    storage.Store
+	Budgets storage.BudgetRepository
+	FX      *fx.Service
+	Hub     *websockets.WebSocketHub
+	Log     *slog.Logger
+}
+
+func NewService(repo storage.Store, budgets storage.BudgetRepository, fxSvc *fx.Service, hub *websockets.WebSocketHub, logger *slog.Logger) *Service {
+	return &Service{Repo: repo, Budgets: budgets, FX: fxSvc, Hub: hub, Log: logging.For(logger, "budgets")}
+}
+
+// Progress — состояние бюджета в текущем периоде
+type Progress struct {
+	storage.Budget
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	CarriedOver float64   `json:"carried_over"` // перенос из прошлого периода, может быть отрицательным
+	Limit       float64   `json:"limit"`
+	Spent       float64   `json:"spent"`
+	Remaining   float64   `json:"remaining"`
+	Projected   float64   `json:"projected"` // расход к концу периода при текущем темпе
+	PercentUsed float64   `json:"percent_used"`
+}
+
+// PeriodBounds — начало и конец периода, содержащего t (неделя с понедельника), в UTC
+func PeriodBounds(period string, t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	y, m, d := t.Date()
+	if period == Week {
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	}
+	start := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+func (s *Service) List(userID int) ([]Progress, error) {
+	return s.ListAt(userID, time.Now())
+}
+
+// ListAt — бюджеты в периоде, содержащем at (для отчётов за прошлые месяцы)
+func (s *Service) ListAt(userID int, at time.Time) ([]Progress, error) {
+	budgets, err := s.Budgets.ListBudgets(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.progress(userID, budgets, at)
+}
+
+func (s *Service) Create(userID int, b storage.Budget) (*storage.Budget, error) {
+	b.UserID = userID
+	if err := s.validate(userID, &b); err != nil {
+		return nil, err
+	}
+	if err := s.Budgets.InsertBudget(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (s *Service) Update(userID int, b storage.Budget) (*storage.Budget, error) {
+	existing, err := s.Budgets.GetBudget(userID, b.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.UserID, b.CreatedAt = existing.UserID, existing.CreatedAt
+	if err := s.validate(userID, &b); err != nil {
+		return nil, err
+	}
+	if err := s.Budgets.UpdateBudget(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (s *Service) Delete(userID, id int) error {
+	err := s.Budgets.DeleteBudget(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *Service) validate(userID int, b *storage.Budget) error {
+	b.Name = strings.TrimSpace(b.Name)
+	if b.Period == "" {
+		b.Period = Month
+	}
+	if b.Period != Week && b.Period != Month {
+		return fmt.Errorf("%w: period must be week or month", ErrInvalidBudget)
+	}
+	b.Category = trimmed(b.Category)
+	b.Merchant = trimmed(b.Merchant)
+	if b.Category == nil && b.Merchant == nil {
+		return fmt.Errorf("%w: category or merchant is required", ErrInvalidBudget)
+	}
+	if b.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidBudget)
+	}
+	if b.Currency == "" {
+		cur, err := s.FX.BaseCurrency(userID)
+		if err != nil {
+			return err
+		}
+		b.Currency = cur
+	}
+	b.Currency = strings.ToUpper(b.Currency)
+	if !fx.ValidCurrency(b.Currency) {
+		return fmt.Errorf("%w: invalid currency", ErrInvalidBudget)
+	}
+	if b.Name == "" {
+		if b.Category != nil {
+			b.Name = *b.Category
+		} else {
+			b.Name = *b.Merchant
+		}
+	}
+	return nil
+}
+
+func trimmed(v *string) *string {
+	if v == nil || strings.TrimSpace(*v) == "" {
+		return nil
+	}
+	t := strings.TrimSpace(*v)
+	return &t
+}
+
+// progress считает бюджеты на момент now по операциям клиента во всех банках
+func (s *Service) progress(userID int, budgets []storage.Budget, now time.Time) ([]Progress, error) {
+	res := make([]Progress, 0, len(budgets))
+	if len(budgets) == 0 {
+		return res, nil
+	}
+
+	// операции за самый ранний нужный период: прошлый месяц — для переноса остатка
+	earliest, _ := PeriodBounds(Month, now)
+	earliest = earliest.AddDate(0, -1, 0)
+	weekStart, _ := PeriodBounds(Week, now)
+	if w := weekStart.AddDate(0, 0, -7); w.Before(earliest) {
+		earliest = w
+	}
+	txs, err := s.clientTransactions(userID, earliest, now.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range budgets {
+		start, end := PeriodBounds(b.Period, now)
+		p := Progress{Budget: b, PeriodStart: start, PeriodEnd: end, Limit: b.Amount}
+
+		if b.Rollover {
+			prevStart, _ := PeriodBounds(b.Period, start.Add(-time.Nanosecond))
+			prevSpent, err := s.spent(b, txs, prevStart, start)
+			if err != nil {
+				return nil, err
+			}
+			// перенос только из прошлого периода, без накопления по цепочке
+			if !b.CreatedAt.After(start) {
+				p.CarriedOver = money.Round(b.Amount - prevSpent)
+				p.Limit += p.CarriedOver
+			}
+		}
+
+		if p.Spent, err = s.spent(b, txs, start, end); err != nil {
+			return nil, err
+		}
+		p.Remaining = money.Round(p.Limit - p.Spent)
+		p.Projected = money.Round(project(p.Spent, start, end, now))
+		if p.Limit > 0 {
+			p.PercentUsed = money.Round(p.Spent / p.Limit * 100)
+		} else if p.Spent > 0 {
+			p.PercentUsed = 100
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// spent — сумма расходов (операций со знаком минус), подходящих под бюджет, в валюте бюджета
+func (s *Service) spent(b storage.Budget, txs []storage.Transaction, from, to time.Time) (float64, error) {
+	total := 0.0
+	for i := range txs {
+		t := &txs[i]
+		if t.Amount >= 0 || t.BookingDate.Before(from) || !t.BookingDate.Before(to) || !matches(b, t) {
+			continue
+		}
+		amount := -t.Amount
+		if t.Currency != nil && *t.Currency != "" && *t.Currency != b.Currency {
+			converted, err := s.FX.Convert(amount, *t.Currency, b.Currency, t.BookingDate)
+			if err != nil {
+				return 0, err
+			}
+			amount = converted
+		}
+		total += amount
+	}
+	return money.Round(total), nil
+}
+
+func matches(b storage.Budget, t *storage.Transaction) bool {
+	if b.Category != nil && (t.Category == nil || !strings.EqualFold(*t.Category, *b.Category)) {
+		return false
+	}
+	if b.Merchant != nil {
+		m := strings.ToLower(*b.Merchant)
+		inCounterparty := t.Counterparty != nil && strings.Contains(strings.ToLower(*t.Counterparty), m)
+		inDescription := t.Description != nil && strings.Contains(strings.ToLower(*t.Description), m)
+		if !inCounterparty && !inDescription {
+			return false
+		}
+	}
+	return true
+}
+
+// project — линейная экстраполяция расхода на весь период; прошедшее время считается минимум за сутки
+func project(spent float64, start, end, now time.Time) float64 {
+	if !now.Before(end) {
+		return spent
+	}
+	elapsed := now.Sub(start)
+	if elapsed < 24*time.Hour {
+		elapsed = 24 * time.Hour
+	}
+	return spent * float64(end.Sub(start)) / float64(elapsed)
+}
+
+func (s *Service) clientTransactions(userID int, from, to time.Time) ([]storage.Transaction, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accs) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(accs))
+	currency := make(map[int]*string, len(accs))
+	for i := range accs {
+		ids[i] = accs[i].ID
+		currency[accs[i].ID] = &accs[i].Currency
+	}
+	txs, err := s.Repo.GetTransactionsByAccountIDs(ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+	// операция без валюты — в валюте своего счёта, иначе spent сложит её без пересчёта
+	for i := range txs {
+		if txs[i].Currency == nil || *txs[i].Currency == "" {
+			txs[i].Currency = currency[txs[i].AccountID]
+		}
+	}
+	return txs, nil
+}
+
+// Alert — сообщение в WebSocket о пересечении порога бюджета
+type Alert struct {
+	Type        string    `json:"type"` // budget_alert
+	BudgetID    int       `json:"budget_id"`
+	Name        string    `json:"name"`
+	Threshold   int       `json:"threshold"`
+	Spent       float64   `json:"spent"`
+	Limit       float64   `json:"limit"`
+	Currency    string    `json:"currency"`
+	PeriodStart time.Time `json:"period_start"`
+}
+
+// TransactionsSynced реализует transactions.Listener: после синхронизации проверяет,
+// не пересекли ли новые операции пороги 50/80/100%. Каждый порог уходит один раз за период.
+func (s *Service) TransactionsSynced(ctx context.Context, userID int, txs []storage.Transaction) {
+	budgets, err := s.Budgets.ListBudgets(userID)
+	if err != nil {
+		s.Log.ErrorContext(ctx, "failed to load budgets", "user_id", userID, logging.Err(err))
+		return
+	}
+	now := time.Now()
+
+	var affected []storage.Budget
+	for _, b := range budgets {
+		start, end := PeriodBounds(b.Period, now)
+		for i := range txs {
+			t := &txs[i]
+			if t.Amount < 0 && !t.BookingDate.Before(start) && t.BookingDate.Before(end) && matches(b, t) {
+				affected = append(affected, b)
+				break
+			}
+		}
+	}
+	if len(affected) == 0 {
+		return
+	}
+
+	progress, err := s.progress(userID, affected, now)
+	if err != nil {
+		s.Log.ErrorContext(ctx, "failed to compute progress", "user_id", userID, logging.Err(err))
+		return
+	}
+	for _, p := range progress {
+		if alert := s.crossed(p); alert != nil {
+			s.notify(userID, alert)
+		}
+	}
+}
+
+// crossed отмечает все достигнутые пороги и возвращает уведомление о самом высоком новом
+func (s *Service) crossed(p Progress) *Alert {
+	var alert *Alert
+	for _, th := range Thresholds {
+		if p.PercentUsed < float64(th) {
+			break
+		}
+		fresh, err := s.Budgets.MarkBudgetAlert(p.ID, p.PeriodStart, th)
+		if err != nil {
+			s.Log.Error("failed to mark alert", "budget_id", p.ID, "threshold", th, logging.Err(err))
+			return nil
+		}
+		if fresh {
+			alert = &Alert{
+				Type:        "budget_alert",
+				BudgetID:    p.ID,
+				Name:        p.Name,
+				Threshold:   th,
+				Spent:       p.Spent,
+				Limit:       p.Limit,
+				Currency:    p.Currency,
+				PeriodStart: p.PeriodStart,
+			}
+		}
+	}
+	return alert
+}
+
+func (s *Service) notify(userID int, alert *Alert) {
+	if s.Hub == nil {
+		return
+	}
+	ids, err := s.Repo.GetClientUserIDs(userID)
+	if err != nil {
+		s.Log.Error("notify failed", "user_id", userID, logging.Err(err))
+		return
+	}
+	msg, _ := json.Marshal(alert)
+	s.Hub.SendToUsers(ids, string(msg))
+	s.Log.Info("budget threshold crossed", "budget_id", alert.BudgetID, "threshold", alert.Threshold)
+}
@@ -0,0 +1,180 @@
+package budgets
+
+import (
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func str(v string) *string { return &v }
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func newTestService(t *testing.T) (*Service, *memory.Store, storage.User) {
+	t.Helper()
+	repo := memory.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	fxSvc := fx.NewService(repo, repo, nil, logger)
+	if err := fxSvc.AddRates([]storage.FXRate{{RateDate: date("2024-01-01"), Base: "USD", Quote: "RUB", Rate: 90}}); err != nil {
+		t.Fatal(err)
+	}
+	u := repo.AddUser(storage.User{ClientID: "team-1"})
+	return NewService(repo, repo, fxSvc, nil, logger), repo, u
+}
+
+func TestPeriodBounds(t *testing.T) {
+	tests := []struct {
+		period     string
+		at         time.Time
+		start, end string
+	}{
+		{Month, time.Date(2024, 5, 16, 12, 0, 0, 0, time.UTC), "2024-05-01", "2024-06-01"},
+		{Month, time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), "2024-12-01", "2025-01-01"},
+		{Week, time.Date(2024, 5, 16, 12, 0, 0, 0, time.UTC), "2024-05-13", "2024-05-20"},
+		{Week, time.Date(2024, 5, 19, 23, 0, 0, 0, time.UTC), "2024-05-13", "2024-05-20"},
+		{Week, time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), "2024-05-20", "2024-05-27"},
+	}
+	for _, tt := range tests {
+		start, end := PeriodBounds(tt.period, tt.at)
+		if !start.Equal(date(tt.start)) || !end.Equal(date(tt.end)) {
+			t.Errorf("PeriodBounds(%s, %s) = %s..%s, want %s..%s", tt.period, tt.at, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestCreateValidation(t *testing.T) {
+	s, _, u := newTestService(t)
+	tests := []struct {
+		name   string
+		budget storage.Budget
+		ok     bool
+	}{
+		{name: "category", budget: storage.Budget{Category: str("groceries"), Amount: 100}, ok: true},
+		{name: "merchant", budget: storage.Budget{Merchant: str(" Магнит "), Period: Week, Amount: 100, Currency: "usd"}, ok: true},
+		{name: "no category or merchant", budget: storage.Budget{Merchant: str(" "), Amount: 100}},
+		{name: "zero amount", budget: storage.Budget{Category: str("groceries")}},
+		{name: "bad period", budget: storage.Budget{Category: str("groceries"), Period: "year", Amount: 100}},
+		{name: "bad currency", budget: storage.Budget{Category: str("groceries"), Amount: 100, Currency: "RUBL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := s.Create(u.ID, tt.budget)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidBudget) {
+					t.Errorf("err = %v, want ErrInvalidBudget", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Name == "" || b.Period == "" || len(b.Currency) != 3 {
+				t.Errorf("defaults not filled: %+v", b)
+			}
+		})
+	}
+
+	b, _ := s.Create(u.ID, storage.Budget{Category: str(" groceries "), Amount: 100})
+	if b.Name != "groceries" || b.Period != Month || b.Currency != "RUB" {
+		t.Errorf("budget = %+v, want name groceries, month, RUB", b)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	s, repo, u := newTestService(t)
+	rub := &storage.Account{UserID: u.ID, AccountNumber: "40817-rub", Currency: "RUB"}
+	usd := &storage.Account{UserID: u.ID, AccountNumber: "40817-usd", Currency: "USD"}
+	for _, a := range []*storage.Account{rub, usd} {
+		if err := repo.UpsertAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tx := range []storage.Transaction{
+		{AccountID: rub.ID, Amount: -3000, BookingDate: date("2024-04-10"), Category: str("groceries")},
+		{AccountID: rub.ID, Amount: -1000, BookingDate: date("2024-05-02"), Category: str("Groceries")},
+		{AccountID: usd.ID, Amount: -10, BookingDate: date("2024-05-05"), Category: str("groceries")},
+		{AccountID: rub.ID, Amount: 500, BookingDate: date("2024-05-06"), Category: str("groceries")},
+		{AccountID: rub.ID, Amount: -700, BookingDate: date("2024-05-07"), Category: str("restaurants")},
+		{AccountID: rub.ID, Amount: -600, BookingDate: date("2024-05-14"), Category: str("groceries"), Counterparty: str("Магнит у дома")},
+		{AccountID: rub.ID, Amount: -100, BookingDate: date("2024-06-01"), Category: str("groceries")},
+	} {
+		if err := repo.InsertTransaction(&tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	budgets := []storage.Budget{
+		{ID: 1, Name: "food", Period: Month, Category: str("groceries"), Amount: 5000, Currency: "RUB", Rollover: true, CreatedAt: date("2024-01-01")},
+		{ID: 2, Name: "magnit", Period: Week, Merchant: str("магнит"), Amount: 1000, Currency: "RUB"},
+		{ID: 3, Name: "new", Period: Month, Category: str("groceries"), Amount: 5000, Currency: "RUB", Rollover: true, CreatedAt: date("2024-05-10")},
+	}
+	got, err := s.progress(u.ID, budgets, date("2024-05-16"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Progress{
+		// 1000 + 10 USD × 90 + 600; перенос 5000 − 3000 за апрель
+		{PeriodStart: date("2024-05-01"), PeriodEnd: date("2024-06-01"), CarriedOver: 2000, Limit: 7000, Spent: 2500, Remaining: 4500, Projected: 5166.67, PercentUsed: 35.71},
+		{PeriodStart: date("2024-05-13"), PeriodEnd: date("2024-05-20"), Limit: 1000, Spent: 600, Remaining: 400, Projected: 1400, PercentUsed: 60},
+		// создан в середине периода — переноса нет
+		{PeriodStart: date("2024-05-01"), PeriodEnd: date("2024-06-01"), Limit: 5000, Spent: 2500, Remaining: 2500, Projected: 5166.67, PercentUsed: 50},
+	}
+	for i, w := range want {
+		p := got[i]
+		w.Budget = p.Budget
+		if p != w {
+			t.Errorf("%s:\n got %+v\nwant %+v", p.Name, p, w)
+		}
+	}
+}
+
+func TestCrossed(t *testing.T) {
+	s, _, u := newTestService(t)
+	b, err := s.Create(u.ID, storage.Budget{Category: str("groceries"), Amount: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := date("2024-05-01")
+	progress := func(percent float64) Progress {
+		return Progress{Budget: *b, PeriodStart: start, PercentUsed: percent}
+	}
+
+	steps := []struct {
+		percent float64
+		want    int // порог уведомления, 0 — без уведомления
+	}{
+		{percent: 30},
+		{percent: 85, want: 80},
+		{percent: 90},
+		{percent: 120, want: 100},
+		{percent: 120},
+	}
+	for _, st := range steps {
+		alert := s.crossed(progress(st.percent))
+		switch {
+		case st.want == 0 && alert != nil:
+			t.Errorf("%v%%: alert %d, want none", st.percent, alert.Threshold)
+		case st.want != 0 && (alert == nil || alert.Threshold != st.want):
+			t.Errorf("%v%%: alert = %+v, want threshold %d", st.percent, alert, st.want)
+		}
+	}
+
+	// новый период — пороги снова доступны
+	p := progress(55)
+	p.PeriodStart = date("2024-06-01")
+	if alert := s.crossed(p); alert == nil || alert.Threshold != 50 {
+		t.Errorf("next period alert = %+v, want threshold 50", alert)
+	}
+}
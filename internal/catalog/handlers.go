@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListCatalog — GET /api/catalog?type=deposit&bank=vbank&term=12&amount=100000&currency=RUB
+// Продукты всех банков по убыванию эффективной доходности.
+func (h *Handler) ListCatalog(c *gin.Context) {
+	f := Filter{
+		Type:     c.Query("type"),
+		Bank:     c.Query("bank"),
+		Currency: c.Query("currency"),
+	}
+	if v := c.Query("term"); v != "" {
+		term, err := strconv.Atoi(v)
+		if err != nil || term <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "term must be a positive number of months"})
+			return
+		}
+		f.TermMonths = &term
+	}
+	if v := c.Query("amount"); v != "" {
+		amount, err := strconv.ParseFloat(v, 64)
+		if err != nil || amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+			return
+		}
+		f.Amount = &amount
+	}
+
+	offers, err := h.Service.List(f)
+	if errors.Is(err, ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load catalog", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(offers), "products": offers})
+}
+
+// Sync — POST /api/catalog/sync (только admin): обновить каталог, не дожидаясь планировщика
+func (h *Handler) Sync(c *gin.Context) {
+	if c.GetString("role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+	n, err := h.Service.Sync(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "catalog sync failed", "details": err.Error(), "synced": n})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"synced": n})
+}
@@ -0,0 +1,348 @@
+package catalog
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidFilter — неизвестный банк или некорректный параметр фильтра
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Service синхронизирует витрины продуктов банков в таблицу products и сравнивает их между банками
+type Service struct {
+	Repo        storage.Store
+	TokenSvc    *bankapi.TokenService
+	BankClients map[string]*bankapi.BankClient
+	HTTPClient  *http.Client
+	Log         *slog.Logger
+}
+
+func NewService(repo storage.Store, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient, httpClient *http.Client, logger *slog.Logger) *Service {
+	return &Service{Repo: repo, TokenSvc: ts, BankClients: clients, HTTPClient: httpClient, Log: logging.For(logger, "catalog")}
+}
+
+// Filter — условия выборки каталога; пустые поля не ограничивают
+type Filter struct {
+	Type       string
+	Bank       string
+	Currency   string
+	TermMonths *int
+	Amount     *float64 // сумма вложения: должна укладываться в min_amount..max_amount
+}
+
+// Offer — продукт каталога с доходностью для сравнения между банками
+type Offer struct {
+	storage.Product
+	Bank           string   `json:"bank"`
+	EffectiveYield *float64 `json:"effective_yield"`           // годовая доходность с учётом капитализации, %
+	ExpectedIncome *float64 `json:"expected_income,omitempty"` // доход за срок на сумму из фильтра
+}
+
+// Start синхронизирует каталог сразу и затем раз в interval
+func (s *Service) Start(interval time.Duration, stopCh <-chan struct{}) {
+	s.Log.Info("sync started", "interval", interval)
+
+	sync := func() {
+		n, err := s.Sync(context.Background())
+		if err != nil {
+			s.Log.Error("sync failed", logging.Err(err))
+		}
+		s.Log.Info("synced", "products", n)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		sync()
+		for {
+			select {
+			case <-ticker.C:
+				sync()
+			case <-stopCh:
+				s.Log.Info("stopping")
+				return
+			}
+		}
+	}()
+}
+
+// Sync загружает витрины всех банков. Ошибка одного банка не мешает остальным:
+// его продукты остаются в каталоге в том виде, в каком были загружены в прошлый раз.
+func (s *Service) Sync(ctx context.Context) (int, error) {
+	codes := make([]string, 0, len(s.BankClients))
+	for code := range s.BankClients {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	total := 0
+	var errs []error
+	for _, code := range codes {
+		n, err := s.SyncBank(ctx, code)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", code, err))
+			continue
+		}
+		total += n
+	}
+	return total, errors.Join(errs...)
+}
+
+// SyncBank сохраняет витрину одного банка; продукты, которых в ней больше нет, становятся неактивными.
+// Пустая витрина — скорее сбой банка, чем закрытие всех продуктов: каталог тогда не трогается.
+func (s *Service) SyncBank(ctx context.Context, bankCode string) (int, error) {
+	bank, err := s.Repo.GetBankByCode(bankCode)
+	if err != nil {
+		return 0, err
+	}
+	listing, err := s.fetch(ctx, bankCode)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	keep := make([]string, 0, len(listing))
+	for _, item := range listing {
+		if item.ProductID == "" {
+			continue
+		}
+		p := item.product(bank.ID, now)
+		if err := s.Repo.UpsertProduct(&p); err != nil {
+			return 0, err
+		}
+		keep = append(keep, p.ProductID)
+	}
+	if len(keep) == 0 {
+		s.Log.WarnContext(ctx, "empty product listing, catalog left as is", "bank", bankCode)
+		return 0, nil
+	}
+	gone, err := s.Repo.DeactivateMissingProducts(bank.ID, keep)
+	if err != nil {
+		return 0, err
+	}
+	if gone > 0 {
+		s.Log.Info("products removed from listing", "bank", bankCode, "count", gone)
+	}
+	return len(keep), nil
+}
+
+// fetch — GET /products: публичная витрина банка
+func (s *Service) fetch(ctx context.Context, bankCode string) ([]listingItem, error) {
+	bankClient := s.BankClients[bankCode]
+	if bankClient == nil {
+		return nil, fmt.Errorf("unknown bank code %s", bankCode)
+	}
+	tokenObj, err := s.TokenSvc.GetValidToken(bankClient)
+	if err != nil {
+		return nil, err
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, bankClient.BaseURL+"/products", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("x-requesting-bank", bankClient.ClientID)
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bank returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			Product []listingItem `json:"product"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data.Product, nil
+}
+
+// List — активные продукты каталога по фильтру, по убыванию эффективной доходности
+func (s *Service) List(f Filter) ([]Offer, error) {
+	var bankID *int
+	if f.Bank != "" {
+		if _, ok := s.BankClients[f.Bank]; !ok {
+			return nil, fmt.Errorf("%w: unknown bank %q", ErrInvalidFilter, f.Bank)
+		}
+		bank, err := s.Repo.GetBankByCode(f.Bank)
+		if err != nil {
+			return nil, err
+		}
+		bankID = &bank.ID
+	}
+	products, err := s.Repo.ListProducts(bankID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := map[int]string{}
+	for code := range s.BankClients {
+		if b, err := s.Repo.GetBankByCode(code); err == nil {
+			codes[b.ID] = code
+		}
+	}
+
+	offers := []Offer{}
+	for _, p := range products {
+		if !p.IsActive || !f.match(p) {
+			continue
+		}
+		o := Offer{Product: p, EffectiveYield: effectiveYield(p)}
+		if p.BankID != nil {
+			o.Bank = codes[*p.BankID]
+		}
+		if f.Amount != nil {
+			o.ExpectedIncome = expectedIncome(p, *f.Amount)
+		}
+		offers = append(offers, o)
+	}
+
+	sort.SliceStable(offers, func(i, j int) bool {
+		a, b := offers[i].EffectiveYield, offers[j].EffectiveYield
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a > *b
+	})
+	return offers, nil
+}
+
+func (f Filter) match(p storage.Product) bool {
+	if f.Type != "" && (p.ProductType == nil || !strings.EqualFold(*p.ProductType, f.Type)) {
+		return false
+	}
+	if f.Currency != "" && p.Currency != nil && !strings.EqualFold(*p.Currency, f.Currency) {
+		return false
+	}
+	if f.TermMonths != nil && (p.TermMonths == nil || *p.TermMonths != *f.TermMonths) {
+		return false
+	}
+	if f.Amount != nil {
+		if p.MinAmount != nil && *f.Amount < *p.MinAmount {
+			return false
+		}
+		if p.MaxAmount != nil && *p.MaxAmount > 0 && *f.Amount > *p.MaxAmount {
+			return false
+		}
+	}
+	return true
+}
+
+// effectiveYield — годовая доходность: при ежемесячной капитализации (1 + r/12)^12 − 1, иначе номинальная ставка
+func effectiveYield(p storage.Product) *float64 {
+	if p.InterestRate == nil {
+		return nil
+	}
+	y := *p.InterestRate
+	if p.Capitalization {
+		y = (math.Pow(1+y/100/12, 12) - 1) * 100
+	}
+	y = money.Round(y)
+	return &y
+}
+
+// expectedIncome — проценты за срок продукта на amount; без срока считается год
+func expectedIncome(p storage.Product, amount float64) *float64 {
+	if p.InterestRate == nil {
+		return nil
+	}
+	months := 12
+	if p.TermMonths != nil && *p.TermMonths > 0 {
+		months = *p.TermMonths
+	}
+	r := *p.InterestRate / 100
+	var income float64
+	if p.Capitalization {
+		income = amount * (math.Pow(1+r/12, float64(months)) - 1)
+	} else {
+		income = amount * r * float64(months) / 12
+	}
+	income = money.Round(income)
+	return &income
+}
+
+// listingItem — продукт из витрины банка
+type listingItem struct {
+	ProductID        string  `json:"productId"`
+	ProductType      string  `json:"productType"`
+	ProductName      string  `json:"productName"`
+	Description      string  `json:"description"`
+	InterestRate     *number `json:"interestRate"`
+	MinAmount        *number `json:"minAmount"`
+	MaxAmount        *number `json:"maxAmount"`
+	TermMonths       *number `json:"termMonths"`
+	Currency         string  `json:"currency"`
+	Capitalization   bool    `json:"capitalization"`
+	EarlyClosureRate *number `json:"earlyClosureRate"`
+}
+
+func (it listingItem) product(bankID int, now time.Time) storage.Product {
+	p := storage.Product{
+		ProductID:        it.ProductID,
+		BankID:           &bankID,
+		ProductType:      optional(strings.ToLower(it.ProductType)),
+		Name:             optional(it.ProductName),
+		Description:      optional(it.Description),
+		InterestRate:     it.InterestRate.float(),
+		MinAmount:        it.MinAmount.float(),
+		MaxAmount:        it.MaxAmount.float(),
+		Currency:         optional(strings.ToUpper(it.Currency)),
+		Capitalization:   it.Capitalization,
+		EarlyClosureRate: it.EarlyClosureRate.float(),
+		SyncedAt:         &now,
+	}
+	if t := it.TermMonths.float(); t != nil {
+		months := int(*t)
+		p.TermMonths = &months
+	}
+	return p
+}
+
+// number — ставка или сумма: банки отдают их и числом, и строкой
+type number float64
+
+func (n *number) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*n = number(v)
+	return nil
+}
+
+func (n *number) float() *float64 {
+	if n == nil {
+		return nil
+	}
+	v := float64(*n)
+	return &v
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func float(v float64) *float64 { return &v }
+func integer(v int) *int       { return &v }
+func str(v string) *string     { return &v }
+
+func TestYieldAndIncome(t *testing.T) {
+	tests := []struct {
+		name    string
+		product storage.Product
+		amount  float64
+		yield   *float64
+		income  *float64
+	}{
+		{name: "no rate", product: storage.Product{TermMonths: integer(6)}, amount: 100000},
+		{name: "simple", product: storage.Product{InterestRate: float(12), TermMonths: integer(6)}, amount: 100000, yield: float(12), income: float(6000)},
+		{name: "monthly capitalization", product: storage.Product{InterestRate: float(12), TermMonths: integer(6), Capitalization: true}, amount: 100000, yield: float(12.68), income: float(6152.02)},
+		{name: "no term is a year", product: storage.Product{InterestRate: float(12)}, amount: 100000, yield: float(12), income: float(12000)},
+		{name: "zero term is a year", product: storage.Product{InterestRate: float(12), TermMonths: integer(0), Capitalization: true}, amount: 100000, yield: float(12.68), income: float(12682.5)},
+		{name: "long term", product: storage.Product{InterestRate: float(10), TermMonths: integer(24)}, amount: 50000, yield: float(10), income: float(10000)},
+	}
+	eq := func(a, b *float64) bool { return (a == nil && b == nil) || (a != nil && b != nil && *a == *b) }
+	show := func(v *float64) interface{} {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveYield(tt.product); !eq(got, tt.yield) {
+				t.Errorf("effectiveYield = %v, want %v", show(got), show(tt.yield))
+			}
+			if got := expectedIncome(tt.product, tt.amount); !eq(got, tt.income) {
+				t.Errorf("expectedIncome = %v, want %v", show(got), show(tt.income))
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	repo := memory.New()
+	vbank, abank := repo.AddBank(storage.Bank{Code: "vbank"}), repo.AddBank(storage.Bank{Code: "abank"})
+	products := []storage.Product{
+		{BankID: &vbank.ID, ProductID: "dep-simple", ProductType: str("deposit"), InterestRate: float(12), TermMonths: integer(12), Currency: str("RUB")},
+		{BankID: &abank.ID, ProductID: "dep-cap", ProductType: str("deposit"), InterestRate: float(12), TermMonths: integer(12), Currency: str("RUB"), Capitalization: true},
+		{BankID: &abank.ID, ProductID: "dep-big", ProductType: str("deposit"), InterestRate: float(15), TermMonths: integer(12), MinAmount: float(1e6)},
+		{BankID: &vbank.ID, ProductID: "dep-norate", ProductType: str("deposit"), TermMonths: integer(12)},
+		{BankID: &vbank.ID, ProductID: "dep-usd", ProductType: str("deposit"), InterestRate: float(4), TermMonths: integer(12), Currency: str("USD")},
+		{BankID: &vbank.ID, ProductID: "dep-old", ProductType: str("deposit"), InterestRate: float(20), TermMonths: integer(12)},
+		{BankID: &vbank.ID, ProductID: "dep-short", ProductType: str("deposit"), InterestRate: float(13), TermMonths: integer(3)},
+		{BankID: &vbank.ID, ProductID: "card", ProductType: str("card")},
+	}
+	for i := range products {
+		if err := repo.UpsertProduct(&products[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// dep-old пропал из витрины vbank
+	if _, err := repo.DeactivateMissingProducts(vbank.ID, []string{"dep-simple", "dep-norate", "dep-usd", "dep-short", "card"}); err != nil {
+		t.Fatal(err)
+	}
+	banks := map[string]*bankapi.BankClient{"vbank": {Name: "vbank"}, "abank": {Name: "abank"}}
+	s := NewService(repo, nil, banks, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "by yield, unrated last", filter: Filter{Type: "DEPOSIT", Currency: "rub", TermMonths: integer(12), Amount: float(100000)}, want: []string{"dep-cap", "dep-simple", "dep-norate"}},
+		{name: "amount within limits", filter: Filter{Type: "deposit", TermMonths: integer(12), Amount: float(2e6), Currency: "RUB"}, want: []string{"dep-big", "dep-cap", "dep-simple", "dep-norate"}},
+		{name: "bank", filter: Filter{Bank: "abank"}, want: []string{"dep-big", "dep-cap"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offers, err := s.List(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, o := range offers {
+				got = append(got, o.ProductID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("offers = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("offers = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	offers, _ := s.List(Filter{Bank: "abank", Amount: float(100000)})
+	for _, o := range offers {
+		if o.Bank != "abank" {
+			t.Errorf("%s bank = %q", o.ProductID, o.Bank)
+		}
+		if o.ProductID == "dep-cap" && (o.ExpectedIncome == nil || *o.ExpectedIncome != 12682.5) {
+			t.Errorf("dep-cap income = %v, want 12682.5", o.ExpectedIncome)
+		}
+	}
+	if _, err := s.List(Filter{Bank: "zbank"}); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("unknown bank: err = %v, want ErrInvalidFilter", err)
+	}
+}
+
+func TestListingNumbers(t *testing.T) {
+	var it listingItem
+	err := json.Unmarshal([]byte(`{"productId":"d1","productType":"Deposit","interestRate":"8.5","minAmount":1000,"termMonths":"6","currency":"rub","maxAmount":null}`), &it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := it.product(1, time.Now())
+	if *p.InterestRate != 8.5 || *p.MinAmount != 1000 || p.MaxAmount != nil || *p.TermMonths != 6 || *p.Currency != "RUB" || *p.ProductType != "deposit" {
+		t.Errorf("product = %+v", p)
+	}
+	if err := json.Unmarshal([]byte(`{"interestRate":"high"}`), &it); err == nil {
+		t.Error("invalid rate accepted")
+	}
+}
@@ -0,0 +1,69 @@
+package categories
+
+import "regexp"
+
+// Категории встроенного набора
+const (
+	Groceries     = "groceries"
+	Restaurants   = "restaurants"
+	Transport     = "transport"
+	Fuel          = "fuel"
+	Health        = "health"
+	Utilities     = "utilities"
+	Telecom       = "telecom"
+	Entertainment = "entertainment"
+	Shopping      = "shopping"
+	Travel        = "travel"
+	Education     = "education"
+	Cash          = "cash"
+	Transfers     = "transfers"
+	Income        = "income"
+	Other         = "other"
+)
+
+// Known — категории, которые знает встроенный набор (пользователь может заводить свои)
+var Known = []string{
+	Groceries, Restaurants, Transport, Fuel, Health, Utilities, Telecom, Entertainment,
+	Shopping, Travel, Education, Cash, Transfers, Income, Other,
+}
+
+// Defaults — встроенные правила, применяются после пользовательских в этом порядке
+var Defaults = []*Rule{
+	mcc(Groceries, "5411", "5422", "5441", "5451", "5462", "5499"),
+	mcc(Restaurants, "5812", "5813", "5814"),
+	mcc(Transport, "4111", "4112", "4121", "4131", "4789"),
+	mcc(Fuel, "5541", "5542", "5983"),
+	mcc(Health, "5122", "5912", "8011", "8021", "8062", "8071", "8099"),
+	mcc(Utilities, "4900"),
+	mcc(Telecom, "4812", "4814", "4816", "4899"),
+	mcc(Entertainment, "5815", "5816", "5817", "5818", "7832", "7841", "7922", "7991", "7996"),
+	mcc(Shopping, "5311", "5331", "5399", "5651", "5661", "5691", "5699", "5732", "5734", "5945"),
+	mcc(Travel, "3000", "4411", "4511", "4722", "7011"),
+	mcc(Education, "8211", "8220", "8241", "8244", "8299"),
+	mcc(Cash, "6010", "6011"),
+
+	desc(Income, `зарплат|аванс|salary|payroll|заработн`, "credit"),
+	desc(Transfers, `перевод|transfer|сбп|p2p`, ""),
+	desc(Groceries, `пятёрочка|пятерочка|перекрёсток|перекресток|магнит|ашан|лента|вкусвилл|grocery|supermarket`, "debit"),
+	desc(Restaurants, `кафе|ресторан|кофе|coffee|cafe|restaurant|бургер|пицц`, "debit"),
+	desc(Transport, `такси|taxi|метро|metro|транспорт|яндекс go|uber|каршеринг`, "debit"),
+	desc(Fuel, `азс|лукойл|роснефть|газпромнефть|shell|fuel`, "debit"),
+	desc(Health, `аптек|pharmacy|клиник|clinic|стоматолог`, "debit"),
+	desc(Utilities, `жкх|коммунал|электроэнерг|водоканал|utility`, "debit"),
+	desc(Telecom, `мтс|билайн|мегафон|теле2|интернет|связь`, "debit"),
+	desc(Entertainment, `кино|cinema|театр|концерт|подписк|netflix|spotify|steam|развлечен`, "debit"),
+	desc(Cash, `снятие наличн|банкомат|atm`, "debit"),
+	desc(Income, `пополнение|зачисление|кэшбэк|cashback|процент`, "credit"),
+}
+
+func mcc(category string, codes ...string) *Rule {
+	r := &Rule{Category: category, MCC: map[string]bool{}}
+	for _, c := range codes {
+		r.MCC[c] = true
+	}
+	return r
+}
+
+func desc(category, pattern, direction string) *Rule {
+	return &Rule{Category: category, Description: regexp.MustCompile("(?i)" + pattern), Direction: direction}
+}
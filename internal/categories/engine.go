@@ -0,0 +1,65 @@
+package categories
+
+import (
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/storage"
+	"log/slog"
+)
+
+// Источники категории операции
+const (
+	SourceDefault = "default" // встроенное правило или Other
+	SourceRule    = "rule"    // пользовательское правило
+	SourceManual  = "manual"  // ручная правка, правила её не перезаписывают
+)
+
+// Matcher — правила одного клиента: сначала пользовательские, затем встроенные
+type Matcher struct {
+	User []*Rule
+}
+
+// NewMatcher компилирует правила клиента; некорректные правила пропускаются
+func NewMatcher(rules []storage.CategoryRule, log *slog.Logger) *Matcher {
+	m := &Matcher{}
+	for _, cr := range rules {
+		r, err := Compile(cr)
+		if err != nil {
+			log.Warn("skip invalid rule", "rule_id", cr.ID, logging.Err(err))
+			continue
+		}
+		m.User = append(m.User, r)
+	}
+	return m
+}
+
+// Categorize возвращает категорию и её источник
+func (m *Matcher) Categorize(t *storage.Transaction) (string, string) {
+	for _, r := range m.User {
+		if r.Match(t) {
+			return r.Category, SourceRule
+		}
+	}
+	for _, r := range Defaults {
+		if r.Match(t) {
+			return r.Category, SourceDefault
+		}
+	}
+	if t.Amount > 0 {
+		return Income, SourceDefault
+	}
+	return Other, SourceDefault
+}
+
+// Apply заполняет категорию операции, если она не задана вручную.
+// Возвращает true, если категория изменилась.
+func (m *Matcher) Apply(t *storage.Transaction) bool {
+	if t.CategorySource != nil && *t.CategorySource == SourceManual {
+		return false
+	}
+	category, source := m.Categorize(t)
+	if t.Category != nil && *t.Category == category && t.CategorySource != nil && *t.CategorySource == source {
+		return false
+	}
+	t.Category, t.CategorySource = &category, &source
+	return true
+}
@@ -0,0 +1,112 @@
+package categories
+
+import (
+	"MoneyPilot/internal/storage"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func str(v string) *string     { return &v }
+func float(v float64) *float64 { return &v }
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name string
+		rule storage.CategoryRule
+		ok   bool
+	}{
+		{name: "mcc", rule: storage.CategoryRule{Category: "food", MCCCodes: []string{"5411"}}, ok: true},
+		{name: "no category", rule: storage.CategoryRule{Category: " ", MCCCodes: []string{"5411"}}},
+		{name: "no condition", rule: storage.CategoryRule{Category: "food"}},
+		{name: "bad regex", rule: storage.CategoryRule{Category: "food", DescriptionRegex: str("(")}},
+		{name: "min above max", rule: storage.CategoryRule{Category: "food", MinAmount: float(10), MaxAmount: float(5)}},
+		{name: "bad direction", rule: storage.CategoryRule{Category: "food", MinAmount: float(1), Direction: str("up")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.rule); (err == nil) != tt.ok {
+				t.Errorf("err = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	tx := storage.Transaction{Amount: -250, MCC: str("5812"), Counterparty: str("Coffee House"), Description: str("Оплата кофе")}
+	tests := []struct {
+		name string
+		rule storage.CategoryRule
+		want bool
+	}{
+		{name: "mcc", rule: storage.CategoryRule{MCCCodes: []string{"5812", "5813"}}, want: true},
+		{name: "other mcc", rule: storage.CategoryRule{MCCCodes: []string{"5411"}}},
+		{name: "counterparty ignores case", rule: storage.CategoryRule{Counterparty: str("coffee")}, want: true},
+		{name: "description regex", rule: storage.CategoryRule{DescriptionRegex: str("КОФЕ")}, want: true},
+		{name: "amount by absolute value", rule: storage.CategoryRule{MinAmount: float(200), MaxAmount: float(300)}, want: true},
+		{name: "amount too small", rule: storage.CategoryRule{MinAmount: float(300)}},
+		{name: "debit", rule: storage.CategoryRule{MCCCodes: []string{"5812"}, Direction: str("debit")}, want: true},
+		{name: "credit", rule: storage.CategoryRule{MCCCodes: []string{"5812"}, Direction: str("credit")}},
+		{name: "all conditions", rule: storage.CategoryRule{MCCCodes: []string{"5812"}, Counterparty: str("tea")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Category = "coffee"
+			r, err := Compile(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Match(&tx); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := NewMatcher([]storage.CategoryRule{
+		{ID: 1, Category: "invalid"},
+		{ID: 2, Category: "coffee", Counterparty: str("coffee")},
+	}, log)
+	if len(m.User) != 1 {
+		t.Fatalf("compiled rules = %d, want 1", len(m.User))
+	}
+
+	tests := []struct {
+		name     string
+		tx       storage.Transaction
+		category string
+		source   string
+	}{
+		{name: "user rule before defaults", tx: storage.Transaction{Amount: -100, MCC: str("5812"), Counterparty: str("Coffee House")}, category: "coffee", source: SourceRule},
+		{name: "default mcc", tx: storage.Transaction{Amount: -100, MCC: str("5411")}, category: Groceries, source: SourceDefault},
+		{name: "default description", tx: storage.Transaction{Amount: 50000, Description: str("Зарплата за май")}, category: Income, source: SourceDefault},
+		{name: "unknown credit", tx: storage.Transaction{Amount: 100}, category: Income, source: SourceDefault},
+		{name: "unknown debit", tx: storage.Transaction{Amount: -100}, category: Other, source: SourceDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, source := m.Categorize(&tt.tx)
+			if category != tt.category || source != tt.source {
+				t.Errorf("Categorize = %s/%s, want %s/%s", category, source, tt.category, tt.source)
+			}
+		})
+	}
+}
+
+func TestApplyKeepsManual(t *testing.T) {
+	m := &Matcher{}
+	tx := storage.Transaction{Amount: -100, MCC: str("5411"), Category: str("gifts"), CategorySource: str(SourceManual)}
+	if m.Apply(&tx) || *tx.Category != "gifts" {
+		t.Errorf("manual category overwritten: %s", *tx.Category)
+	}
+
+	tx = storage.Transaction{Amount: -100, MCC: str("5411")}
+	if !m.Apply(&tx) || *tx.Category != Groceries {
+		t.Fatalf("category = %v, want groceries", tx.Category)
+	}
+	if m.Apply(&tx) {
+		t.Error("Apply reported a change for an unchanged category")
+	}
+}
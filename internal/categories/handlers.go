@@ -0,0 +1,160 @@
+package categories
+
+import (
+	"MoneyPilot/internal/storage"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListCategories — GET /api/categories: встроенные категории
+func (h *Handler) ListCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"categories": Known})
+}
+
+// ListRules — GET /api/categories/rules
+func (h *Handler) ListRules(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	rules, err := h.Service.ListRules(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load rules", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(rules), "rules": rules})
+}
+
+type ruleRequest struct {
+	Category         string   `json:"category" binding:"required"`
+	MCCCodes         []string `json:"mcc_codes"`
+	Counterparty     *string  `json:"counterparty"`
+	DescriptionRegex *string  `json:"description_regex"`
+	MinAmount        *float64 `json:"min_amount"`
+	MaxAmount        *float64 `json:"max_amount"`
+	Direction        *string  `json:"direction"`
+	Priority         int      `json:"priority"`
+}
+
+// CreateRule — POST /api/categories/rules
+// После сохранения все операции клиента (кроме ручных) перекатегоризируются.
+func (h *Handler) CreateRule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req ruleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	rule, n, err := h.Service.CreateRule(userID, storage.CategoryRule{
+		Category:         req.Category,
+		MCCCodes:         req.MCCCodes,
+		Counterparty:     req.Counterparty,
+		DescriptionRegex: req.DescriptionRegex,
+		MinAmount:        req.MinAmount,
+		MaxAmount:        req.MaxAmount,
+		Direction:        req.Direction,
+		Priority:         req.Priority,
+	})
+	if errors.Is(err, ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule", "details": err.Error()})
+		return
+	}
+	if rule == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save rule", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "rule saved, recategorization failed", "details": err.Error(), "rule": rule})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"rule": rule, "recategorized": n})
+}
+
+// DeleteRule — DELETE /api/categories/rules/:rule_id
+func (h *Handler) DeleteRule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	ruleID, err := strconv.Atoi(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule_id"})
+		return
+	}
+	n, err := h.Service.DeleteRule(userID, ruleID)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete rule", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": ruleID, "recategorized": n})
+}
+
+// OverrideCategory — PUT /api/transactions/:transaction_id/category {"category":"..."}
+// Ручная категория запоминается как правило для того же контрагента.
+func (h *Handler) OverrideCategory(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	txID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction_id"})
+		return
+	}
+	var req struct {
+		Category string `json:"category" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	t, n, err := h.Service.Override(userID, txID, req.Category)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update category", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"transaction": t, "recategorized": n})
+}
+
+// Recategorize — POST /api/categories/recategorize
+func (h *Handler) Recategorize(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	n, err := h.Service.Recategorize(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to recategorize", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recategorized": n})
+}
@@ -0,0 +1,85 @@
+package categories
+
+import (
+	"MoneyPilot/internal/storage"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Rule — скомпилированное правило: все заданные условия должны выполниться
+type Rule struct {
+	ID           int // 0 — встроенное правило
+	Category     string
+	MCC          map[string]bool
+	Counterparty string // подстрока, без учёта регистра
+	Description  *regexp.Regexp
+	MinAmount    *float64 // по модулю суммы
+	MaxAmount    *float64
+	Direction    string // debit | credit | ""
+}
+
+// Compile проверяет и компилирует пользовательское правило
+func Compile(cr storage.CategoryRule) (*Rule, error) {
+	r := &Rule{ID: cr.ID, Category: strings.TrimSpace(cr.Category)}
+	if r.Category == "" {
+		return nil, fmt.Errorf("category is required")
+	}
+	if len(cr.MCCCodes) > 0 {
+		r.MCC = map[string]bool{}
+		for _, code := range cr.MCCCodes {
+			r.MCC[strings.TrimSpace(code)] = true
+		}
+	}
+	if cr.Counterparty != nil {
+		r.Counterparty = strings.ToLower(strings.TrimSpace(*cr.Counterparty))
+	}
+	if cr.DescriptionRegex != nil && *cr.DescriptionRegex != "" {
+		re, err := regexp.Compile("(?i)" + *cr.DescriptionRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid description_regex: %w", err)
+		}
+		r.Description = re
+	}
+	r.MinAmount, r.MaxAmount = cr.MinAmount, cr.MaxAmount
+	if r.MinAmount != nil && r.MaxAmount != nil && *r.MinAmount > *r.MaxAmount {
+		return nil, fmt.Errorf("min_amount is greater than max_amount")
+	}
+	if cr.Direction != nil {
+		r.Direction = strings.ToLower(*cr.Direction)
+		if r.Direction != "debit" && r.Direction != "credit" && r.Direction != "" {
+			return nil, fmt.Errorf("direction must be debit or credit")
+		}
+	}
+	if r.MCC == nil && r.Counterparty == "" && r.Description == nil && r.MinAmount == nil && r.MaxAmount == nil {
+		return nil, fmt.Errorf("rule needs at least one of mcc_codes, counterparty, description_regex, min_amount, max_amount")
+	}
+	return r, nil
+}
+
+func (r *Rule) Match(t *storage.Transaction) bool {
+	if r.MCC != nil && (t.MCC == nil || !r.MCC[*t.MCC]) {
+		return false
+	}
+	if r.Counterparty != "" && (t.Counterparty == nil || !strings.Contains(strings.ToLower(*t.Counterparty), r.Counterparty)) {
+		return false
+	}
+	if r.Description != nil && (t.Description == nil || !r.Description.MatchString(*t.Description)) {
+		return false
+	}
+	amount := math.Abs(t.Amount)
+	if r.MinAmount != nil && amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && amount > *r.MaxAmount {
+		return false
+	}
+	switch r.Direction {
+	case "debit":
+		return t.Amount < 0
+	case "credit":
+		return t.Amount > 0
+	}
+	return true
+}
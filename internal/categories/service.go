@@ -0,0 +1,189 @@
+package categories
+
+import (
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/storage"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// learnedPriority — правило из ручной правки важнее обычных пользовательских (100)
+const learnedPriority = 50
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrInvalidRule = errors.New("invalid rule")
+)
+
+type Service struct {
+	Repo  storage.Store
+	Rules storage.CategoryRepository
+	Log   *slog.Logger
+}
+
+func NewService(repo storage.Store, rules storage.CategoryRepository, logger *slog.Logger) *Service {
+	return &Service{Repo: repo, Rules: rules, Log: logging.For(logger, "categories")}
+}
+
+// Matcher загружает правила клиента
+func (s *Service) Matcher(userID int) (*Matcher, error) {
+	rules, err := s.Rules.ListCategoryRules(userID)
+	if err != nil {
+		return nil, err
+	}
+	return NewMatcher(rules, s.Log), nil
+}
+
+func (s *Service) ListRules(userID int) ([]storage.CategoryRule, error) {
+	return s.Rules.ListCategoryRules(userID)
+}
+
+// CreateRule сохраняет правило и перекатегоризирует операции клиента
+func (s *Service) CreateRule(userID int, cr storage.CategoryRule) (*storage.CategoryRule, int, error) {
+	cr.UserID = userID
+	cr.Learned = false
+	if cr.Priority == 0 {
+		cr.Priority = 100
+	}
+	if _, err := Compile(cr); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if err := s.Rules.InsertCategoryRule(&cr); err != nil {
+		return nil, 0, err
+	}
+	n, err := s.Recategorize(userID)
+	return &cr, n, err
+}
+
+func (s *Service) DeleteRule(userID, ruleID int) (int, error) {
+	if err := s.Rules.DeleteCategoryRule(userID, ruleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	return s.Recategorize(userID)
+}
+
+// Override вручную задаёт категорию операции и запоминает её как правило
+// для того же контрагента (или того же описания), затем перекатегоризирует остальные операции.
+func (s *Service) Override(userID, transactionID int, category string) (*storage.Transaction, int, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return nil, 0, fmt.Errorf("category is required")
+	}
+	t, err := s.ownedTransaction(userID, transactionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.Repo.UpdateTransactionCategory(t.ID, category, SourceManual); err != nil {
+		return nil, 0, err
+	}
+	source := SourceManual
+	t.Category, t.CategorySource = &category, &source
+
+	if err := s.learn(userID, t, category); err != nil {
+		return nil, 0, err
+	}
+	n, err := s.Recategorize(userID)
+	return t, n, err
+}
+
+// learn создаёт или обновляет выученное правило по контрагенту или точному описанию
+func (s *Service) learn(userID int, t *storage.Transaction, category string) error {
+	learned := storage.CategoryRule{UserID: userID, Category: category, Priority: learnedPriority, Learned: true}
+	switch {
+	case t.Counterparty != nil && strings.TrimSpace(*t.Counterparty) != "":
+		cp := strings.TrimSpace(*t.Counterparty)
+		learned.Counterparty = &cp
+	case t.Description != nil && strings.TrimSpace(*t.Description) != "":
+		re := "^" + regexp.QuoteMeta(strings.TrimSpace(*t.Description)) + "$"
+		learned.DescriptionRegex = &re
+	default:
+		return nil
+	}
+
+	rules, err := s.Rules.ListCategoryRules(userID)
+	if err != nil {
+		return err
+	}
+	for _, cr := range rules {
+		if cr.Learned && sameCondition(cr.Counterparty, learned.Counterparty) && sameCondition(cr.DescriptionRegex, learned.DescriptionRegex) {
+			return s.Rules.UpdateCategoryRuleCategory(cr.ID, category)
+		}
+	}
+	return s.Rules.InsertCategoryRule(&learned)
+}
+
+func sameCondition(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return strings.EqualFold(*a, *b)
+}
+
+// Recategorize пересчитывает категории всех операций клиента, кроме ручных.
+// Возвращает число изменённых операций.
+func (s *Service) Recategorize(userID int) (int, error) {
+	m, err := s.Matcher(userID)
+	if err != nil {
+		return 0, err
+	}
+	txs, err := s.clientTransactions(userID)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range txs {
+		t := &txs[i]
+		if !m.Apply(t) {
+			continue
+		}
+		if err := s.Repo.UpdateTransactionCategory(t.ID, *t.Category, *t.CategorySource); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+func (s *Service) clientTransactions(userID int) ([]storage.Transaction, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accs) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(accs))
+	for i, a := range accs {
+		ids[i] = a.ID
+	}
+	return s.Repo.GetTransactionsByAccountIDs(ids, time.Time{}, time.Now().AddDate(100, 0, 0))
+}
+
+// ownedTransaction загружает операцию, если её счёт принадлежит клиенту
+func (s *Service) ownedTransaction(userID, transactionID int) (*storage.Transaction, error) {
+	t, err := s.Repo.GetTransactionByID(transactionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range accs {
+		if a.ID == t.AccountID {
+			return t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
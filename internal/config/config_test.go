@@ -0,0 +1,264 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// valid — конфигурация, проходящая Validate
+func valid() *Config {
+	c := Default()
+	c.Postgres.DSN = "postgres://localhost/moneypilot"
+	c.Auth.JWTSecret = "0123456789abcdef"
+	c.Team.ClientID = "team-1"
+	c.Team.ClientSecret = "secret"
+	return c
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTransportBanks(t *testing.T) {
+	c := valid()
+	path := writeFile(t, "config.yaml", `
+transport:
+  rate_per_second: 3
+  banks:
+    sbank: {rate_per_second: 1, timeout: 20s}
+`)
+	if err := c.loadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Transport.RatePerSecond != 3 || c.Transport.Burst != 10 {
+		t.Errorf("transport = %+v, want rate 3 and default burst", c.Transport)
+	}
+	want := BankLimitsConfig{RatePerSecond: 1, Timeout: 20 * time.Second}
+	if got := c.Transport.Banks["sbank"]; got != want {
+		t.Errorf("transport.banks.sbank = %+v, want %+v", got, want)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Transport.Banks["xbank"] = BankLimitsConfig{Burst: -1}
+	err := c.Validate()
+	for _, field := range []string{"transport.banks.xbank: unknown bank code", "transport.banks.xbank: limits must not be negative"} {
+		if err == nil || !strings.Contains(err.Error(), field) {
+			t.Errorf("Validate() = %v, want %q", err, field)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string // подстроки ошибки: каждое неверное поле перечислено
+	}{
+		{"defaults without secrets", func(c *Config) { *c = *Default() }, []string{
+			"postgres.dsn: required (set it in the config file, POSTGRES_DSN or POSTGRES_DSN_FILE)",
+			"auth.jwt_secret: required", "team.client_id: required", "team.client_secret: required",
+		}},
+		{"port", func(c *Config) { c.Server.Port = "http" }, []string{`server.port: must be a port number, got "http"`}},
+		{"port range", func(c *Config) { c.Server.Port = "70000" }, []string{"server.port"}},
+		{"cors", func(c *Config) { c.Server.CORSOrigins = []string{"localhost:5173"} }, []string{`server.cors_origins: "localhost:5173" is not an http(s) origin`}},
+		{"no cors", func(c *Config) { c.Server.CORSOrigins = nil }, []string{"server.cors_origins: at least one origin is required"}},
+		{"blank dsn", func(c *Config) { c.Postgres.DSN = "  " }, []string{"postgres.dsn: required"}},
+		{"short jwt secret", func(c *Config) { c.Auth.JWTSecret = "short" }, []string{"auth.jwt_secret: must be at least 16 characters"}},
+		{"no banks", func(c *Config) { c.Banks = nil }, []string{"banks: at least one bank is required"}},
+		{"bank name", func(c *Config) { c.Banks["vbank"] = BankConfig{Name: "Other", BaseURL: "https://vbank.example"} }, []string{`banks.vbank.name: must match the bank code case-insensitively, got "Other"`}},
+		{"bank url", func(c *Config) { c.Banks["vbank"] = BankConfig{Name: "VBank", BaseURL: "vbank.example"} }, []string{"banks.vbank.base_url"}},
+		{"transport", func(c *Config) {
+			c.Transport.Timeout, c.Transport.Burst, c.Transport.MaxRetries = 0, 0, -1
+			c.Transport.BaseBackoff = time.Minute
+		}, []string{
+			"transport.timeout: must be a positive duration, got 0s", "transport.burst: must be at least 1, got 0",
+			"transport.max_retries: must not be negative, got -1", "transport.base_backoff: must not exceed max_backoff (5s)",
+		}},
+		{"job interval", func(c *Config) { c.Jobs.Reports = -time.Hour }, []string{"jobs.reports: must be a positive duration, got -1h0m0s"}},
+		{"fx provider", func(c *Config) { c.FX.Provider = "cbr" }, []string{`fx.provider: must be manual, file or http, got "cbr"`}},
+		{"fx file", func(c *Config) { c.FX.Provider, c.FX.RatesFile = "file", "" }, []string{"fx.rates_file: required"}},
+		{"fx url", func(c *Config) { c.FX.Provider, c.FX.RatesURL = "http", "ftp://rates" }, []string{"fx.rates_url"}},
+		{"fx base", func(c *Config) { c.FX.Base = "rub" }, []string{`fx.base: must be an ISO 4217 code like RUB, got "rub"`}},
+		{"health", func(c *Config) { c.Health.BankCacheTTL = 0 }, []string{"health.bank_cache_ttl"}},
+		{"ml url", func(c *Config) { c.ML.URL = "" }, []string{"ml.url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.change(c)
+			err := c.Validate()
+			if err == nil {
+				t.Fatal("want error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v\nwant %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("SERVER_PORT", "9090")
+	t.Setenv("CORS_ORIGINS", "https://a.example, ,https://b.example")
+	t.Setenv("AUTO_MIGRATE", "true")
+	t.Setenv("BANK_RATE_PER_SECOND", "2.5")
+	t.Setenv("BANK_BURST", "3")
+	t.Setenv("POLL_INTERVAL", "30s")
+	t.Setenv("FX_BASE", "EUR")
+
+	c := valid()
+	if err := c.applyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != "9090" || !c.Postgres.AutoMigrate || c.FX.Base != "EUR" {
+		t.Errorf("port %s, auto-migrate %v, fx base %s", c.Server.Port, c.Postgres.AutoMigrate, c.FX.Base)
+	}
+	if got := strings.Join(c.Server.CORSOrigins, " "); got != "https://a.example https://b.example" {
+		t.Errorf("cors origins = %q", got)
+	}
+	if c.Transport.RatePerSecond != 2.5 || c.Transport.Burst != 3 || c.Poller.Interval != 30*time.Second {
+		t.Errorf("rate %v, burst %d, poll interval %s", c.Transport.RatePerSecond, c.Transport.Burst, c.Poller.Interval)
+	}
+	// не заданные в окружении поля остаются прежними
+	if c.Redis.Addr != "localhost:6379" || c.Transport.Timeout != 10*time.Second {
+		t.Errorf("redis %s, timeout %s", c.Redis.Addr, c.Transport.Timeout)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	t.Setenv("AUTO_MIGRATE", "yes please")
+	t.Setenv("BANK_BURST", "ten")
+	t.Setenv("BANK_TIMEOUT", "10")
+
+	err := valid().applyEnv()
+	for _, want := range []string{
+		`AUTO_MIGRATE: invalid boolean "yes please"`,
+		`BANK_BURST: invalid integer "ten"`,
+		`BANK_TIMEOUT: invalid duration "10"`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("applyEnv() = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestApplyEnvFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string // значение "@file:<содержимое>" заменяется путём к файлу с этим содержимым
+		check func(c *Config) string
+		err   string
+	}{
+		{
+			name: "secret from file without trailing newline",
+			env:  map[string]string{"JWT_SECRET_FILE": "@file:from-file-0123456789\r\n", "PASSWORD_HAC_FILE": "@file:s3cr3t\n"},
+			check: func(c *Config) string {
+				if c.Auth.JWTSecret != "from-file-0123456789" || c.Team.ClientSecret != "s3cr3t" {
+					return c.Auth.JWTSecret + " / " + c.Team.ClientSecret
+				}
+				return ""
+			},
+		},
+		{
+			name: "any field from file",
+			env:  map[string]string{"POSTGRES_DSN_FILE": "@file:postgres://db/moneypilot\n", "BANK_BURST_FILE": "@file:7\n"},
+			check: func(c *Config) string {
+				if c.Postgres.DSN != "postgres://db/moneypilot" || c.Transport.Burst != 7 {
+					return c.Postgres.DSN
+				}
+				return ""
+			},
+		},
+		{
+			name: "both set",
+			env:  map[string]string{"JWT_SECRET": "inline-0123456789", "JWT_SECRET_FILE": "@file:from-file-0123456789"},
+			err:  "JWT_SECRET and JWT_SECRET_FILE are both set",
+		},
+		{
+			name: "missing file",
+			env:  map[string]string{"PASSWORD_HAC_FILE": filepath.Join(t.TempDir(), "missing")},
+			err:  "PASSWORD_HAC_FILE:",
+		},
+		{
+			name: "invalid value in file",
+			env:  map[string]string{"BANK_BURST_FILE": "@file:many\n"},
+			err:  `BANK_BURST: invalid integer "many"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				if content, ok := strings.CutPrefix(v, "@file:"); ok {
+					v = writeFile(t, strings.ToLower(k), content)
+				}
+				t.Setenv(k, v)
+			}
+			c := valid()
+			err := c.applyEnv()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("applyEnv() = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.check(c); got != "" {
+				t.Errorf("unexpected values: %s", got)
+			}
+		})
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[server]
+port = "9000"
+
+[poller]
+interval = "15s"
+
+[banks.vbank]
+name = "VBank"
+base_url = "http://vbank.local"
+`)
+	c := valid()
+	if err := c.loadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != "9000" || c.Poller.Interval != 15*time.Second {
+		t.Errorf("port %s, poll interval %s", c.Server.Port, c.Poller.Interval)
+	}
+	// список банков из файла заменяет умолчания
+	if len(c.Banks) != 1 || c.Banks["vbank"].BaseURL != "http://vbank.local" {
+		t.Errorf("banks = %+v", c.Banks)
+	}
+
+	tests := map[string]string{
+		"unknown key":        writeFile(t, "unknown.yaml", "server:\n  prot: 9000\n"),
+		"unsupported format": writeFile(t, "config.json", `{"server":{"port":"9000"}}`),
+		"missing file":       filepath.Join(t.TempDir(), "missing.yaml"),
+	}
+	for name, path := range tests {
+		if err := valid().loadFile(path); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
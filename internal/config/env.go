@@ -0,0 +1,171 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// binding — переменная окружения и поле, которое она переопределяет
+type binding struct {
+	env string
+	set func(string) error
+}
+
+func (c *Config) bindings() []binding {
+	return []binding{
+		{"SERVER_PORT", str(&c.Server.Port)},
+		{"CORS_ORIGINS", list(&c.Server.CORSOrigins)},
+		{"POSTGRES_DSN", str(&c.Postgres.DSN)},
+		{"AUTO_MIGRATE", boolean(&c.Postgres.AutoMigrate)},
+		{"REDIS_ADDR", str(&c.Redis.Addr)},
+		{"JWT_SECRET", str(&c.Auth.JWTSecret)},
+		{"LOGIN_HAC", str(&c.Team.ClientID)},
+		{"PASSWORD_HAC", str(&c.Team.ClientSecret)},
+
+		{"BANK_TIMEOUT", duration(&c.Transport.Timeout)},
+		{"BANK_RATE_PER_SECOND", float(&c.Transport.RatePerSecond)},
+		{"BANK_BURST", integer(&c.Transport.Burst)},
+		{"BANK_BREAKER_THRESHOLD", integer(&c.Transport.BreakerThreshold)},
+		{"BANK_BREAKER_COOLDOWN", duration(&c.Transport.BreakerCooldown)},
+		{"BANK_MAX_RETRIES", integer(&c.Transport.MaxRetries)},
+		{"BANK_BASE_BACKOFF", duration(&c.Transport.BaseBackoff)},
+		{"BANK_MAX_BACKOFF", duration(&c.Transport.MaxBackoff)},
+
+		{"CONSENT_TTL", duration(&c.Consents.TTL)},
+		{"POLL_INTERVAL", duration(&c.Poller.Interval)},
+
+		{"BALANCE_SNAPSHOT_INTERVAL", duration(&c.Jobs.BalanceSnapshots)},
+		{"FX_SYNC_INTERVAL", duration(&c.Jobs.FXRates)},
+		{"TX_SYNC_INTERVAL", duration(&c.Jobs.Transactions)},
+		{"SAVINGS_INTERVAL", duration(&c.Jobs.Savings)},
+		{"CATALOG_SYNC_INTERVAL", duration(&c.Jobs.Catalog)},
+		{"PRODUCT_TRACKER_INTERVAL", duration(&c.Jobs.ProductTracker)},
+		{"REPORTS_INTERVAL", duration(&c.Jobs.Reports)},
+		{"MONTHLY_REPORTS", boolean(&c.Jobs.MonthlyReports)},
+
+		{"EXPORT_DIR", str(&c.Export.Dir)},
+
+		{"FX_PROVIDER", str(&c.FX.Provider)},
+		{"FX_RATES_FILE", str(&c.FX.RatesFile)},
+		{"FX_RATES_URL", str(&c.FX.RatesURL)},
+		{"FX_BASE", str(&c.FX.Base)},
+
+		{"HEALTH_TIMEOUT", duration(&c.Health.Timeout)},
+		{"HEALTH_POLLER_STALE_AFTER", duration(&c.Health.PollerStaleAfter)},
+		{"HEALTH_BANK_CACHE_TTL", duration(&c.Health.BankCacheTTL)},
+
+		{"LOG_LEVEL", str(&c.Log.Level)},
+		{"LOG_LEVELS", str(&c.Log.Levels)},
+		{"LOG_FORMAT", str(&c.Log.Format)},
+
+		{"OTEL_TRACES_EXPORTER", str(&c.Tracing.Exporter)},
+		{"OTEL_SERVICE_NAME", str(&c.Tracing.ServiceName)},
+
+		{"ML_URL", str(&c.ML.URL)},
+	}
+}
+
+// applyEnv переопределяет поля из окружения. Вместо значения можно передать путь к файлу
+// в <ИМЯ>_FILE (Docker/Kubernetes secrets); концевой перевод строки отбрасывается.
+func (c *Config) applyEnv() error {
+	var errs []error
+	for _, b := range c.bindings() {
+		val, ok, err := lookup(b.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := b.set(val); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func lookup(key string) (string, bool, error) {
+	val, ok := os.LookupEnv(key)
+	path, fromFile := os.LookupEnv(key + "_FILE")
+	switch {
+	case ok && fromFile:
+		return "", false, fmt.Errorf("%s and %s_FILE are both set", key, key)
+	case fromFile:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	return val, ok, nil
+}
+
+func str(p *string) func(string) error {
+	return func(v string) error {
+		*p = v
+		return nil
+	}
+}
+
+// list — значения через запятую
+func list(p *[]string) func(string) error {
+	return func(v string) error {
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+		return nil
+	}
+}
+
+func boolean(p *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*p = b
+		return nil
+	}
+}
+
+func integer(p *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func float(p *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*p = f
+		return nil
+	}
+}
+
+// duration — в формате Go: 90s, 5m, 2160h
+func duration(p *time.Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (expected e.g. 30s, 5m, 24h)", v)
+		}
+		*p = d
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate проверяет конфигурацию целиком и возвращает все ошибки сразу.
+// Уровни логов и экспортёр трейсов разбирают logging.New и tracing.Setup — там же и их ошибки.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+	required := func(field, env, val string) {
+		if strings.TrimSpace(val) == "" {
+			fail(field, "required (set it in the config file, %s or %s_FILE)", env, env)
+		}
+	}
+	positive := func(field string, d time.Duration) {
+		if d <= 0 {
+			fail(field, "must be a positive duration, got %s", d)
+		}
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		fail("server.port", "must be a port number, got %q", c.Server.Port)
+	}
+	if len(c.Server.CORSOrigins) == 0 {
+		fail("server.cors_origins", "at least one origin is required")
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		if !httpURL(origin) {
+			fail("server.cors_origins", "%q is not an http(s) origin", origin)
+		}
+	}
+
+	required("postgres.dsn", "POSTGRES_DSN", c.Postgres.DSN)
+	required("redis.addr", "REDIS_ADDR", c.Redis.Addr)
+	required("auth.jwt_secret", "JWT_SECRET", c.Auth.JWTSecret)
+	if s := c.Auth.JWTSecret; s != "" && len(s) < 16 {
+		fail("auth.jwt_secret", "must be at least 16 characters")
+	}
+	required("team.client_id", "LOGIN_HAC", c.Team.ClientID)
+	required("team.client_secret", "PASSWORD_HAC", c.Team.ClientSecret)
+
+	if len(c.Banks) == 0 {
+		fail("banks", "at least one bank is required")
+	}
+	codes := make([]string, 0, len(c.Banks))
+	for code := range c.Banks {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		b := c.Banks[code]
+		// по названию банка строятся ключи токенов в Redis и метки метрик
+		if strings.ToLower(b.Name) != code {
+			fail("banks."+code+".name", "must match the bank code case-insensitively, got %q", b.Name)
+		}
+		if !httpURL(b.BaseURL) {
+			fail("banks."+code+".base_url", "must be an http(s) URL, got %q", b.BaseURL)
+		}
+	}
+
+	t := c.Transport
+	positive("transport.timeout", t.Timeout)
+	positive("transport.breaker_cooldown", t.BreakerCooldown)
+	positive("transport.base_backoff", t.BaseBackoff)
+	positive("transport.max_backoff", t.MaxBackoff)
+	if t.RatePerSecond <= 0 {
+		fail("transport.rate_per_second", "must be positive, got %v", t.RatePerSecond)
+	}
+	if t.Burst < 1 {
+		fail("transport.burst", "must be at least 1, got %d", t.Burst)
+	}
+	if t.BreakerThreshold < 1 {
+		fail("transport.breaker_threshold", "must be at least 1, got %d", t.BreakerThreshold)
+	}
+	if t.MaxRetries < 0 {
+		fail("transport.max_retries", "must not be negative, got %d", t.MaxRetries)
+	}
+	if t.BaseBackoff > t.MaxBackoff {
+		fail("transport.base_backoff", "must not exceed max_backoff (%s)", t.MaxBackoff)
+	}
+	overrides := make([]string, 0, len(t.Banks))
+	for code := range t.Banks {
+		overrides = append(overrides, code)
+	}
+	sort.Strings(overrides)
+	for _, code := range overrides {
+		b, field := t.Banks[code], "transport.banks."+code
+		if _, ok := c.Banks[code]; !ok {
+			fail(field, "unknown bank code")
+		}
+		if b.Timeout < 0 || b.BreakerCooldown < 0 || b.RatePerSecond < 0 || b.Burst < 0 || b.BreakerThreshold < 0 {
+			fail(field, "limits must not be negative")
+		}
+	}
+
+	positive("consents.ttl", c.Consents.TTL)
+	positive("poller.interval", c.Poller.Interval)
+	positive("jobs.balance_snapshots", c.Jobs.BalanceSnapshots)
+	positive("jobs.fx_rates", c.Jobs.FXRates)
+	positive("jobs.transactions", c.Jobs.Transactions)
+	positive("jobs.savings", c.Jobs.Savings)
+	positive("jobs.catalog", c.Jobs.Catalog)
+	positive("jobs.product_tracker", c.Jobs.ProductTracker)
+	positive("jobs.reports", c.Jobs.Reports)
+	required("export.dir", "EXPORT_DIR", c.Export.Dir)
+
+	switch c.FX.Provider {
+	case "manual":
+	case "file":
+		required("fx.rates_file", "FX_RATES_FILE", c.FX.RatesFile)
+	case "http":
+		if !httpURL(c.FX.RatesURL) {
+			fail("fx.rates_url", "must be an http(s) URL, got %q", c.FX.RatesURL)
+		}
+	default:
+		fail("fx.provider", "must be manual, file or http, got %q", c.FX.Provider)
+	}
+	if len(c.FX.Base) != 3 || strings.ToUpper(c.FX.Base) != c.FX.Base {
+		fail("fx.base", "must be an ISO 4217 code like RUB, got %q", c.FX.Base)
+	}
+
+	positive("health.timeout", c.Health.Timeout)
+	positive("health.poller_stale_after", c.Health.PollerStaleAfter)
+	positive("health.bank_cache_ttl", c.Health.BankCacheTTL)
+
+	if !httpURL(c.ML.URL) {
+		fail("ml.url", "must be an http(s) URL, got %q", c.ML.URL)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	return nil
+}
+
+func httpURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
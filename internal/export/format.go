@@ -0,0 +1,275 @@
+package export
+
+import (
+	"MoneyPilot/internal/storage"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+)
+
+// account — счёт выписки с кодом банка
+type account struct {
+	storage.Account
+	Bank string
+}
+
+// formatter пишет выписку поблочно: операции приходят сгруппированными по счетам
+type formatter interface {
+	begin(from, to time.Time) error
+	beginAccount(acc account) error
+	transaction(acc account, t storage.Transaction) error
+	endAccount(acc account) error
+	end() error
+}
+
+func newFormatter(format string, w io.Writer) (formatter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvFormatter{w: csv.NewWriter(w)}, nil
+	case FormatOFX:
+		return &ofxFormatter{w: w}, nil
+	case FormatQIF:
+		return &qifFormatter{w: w}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalid, format)
+}
+
+// ContentType и расширение файла для формата
+func ContentType(format string) (string, string) {
+	switch format {
+	case FormatOFX:
+		return "application/x-ofx", "ofx"
+	case FormatQIF:
+		return "application/qif", "qif"
+	}
+	return "text/csv; charset=utf-8", "csv"
+}
+
+// --- CSV ---
+
+type csvFormatter struct {
+	w *csv.Writer
+}
+
+func (f *csvFormatter) begin(from, to time.Time) error {
+	return f.w.Write([]string{"date", "bank", "account_id", "account_number", "currency", "amount",
+		"description", "counterparty", "category", "mcc", "transaction_id"})
+}
+
+func (f *csvFormatter) beginAccount(acc account) error { return nil }
+
+func (f *csvFormatter) transaction(acc account, t storage.Transaction) error {
+	return f.w.Write([]string{
+		t.BookingDate.Format("2006-01-02"),
+		acc.Bank,
+		deref(acc.ExternalID),
+		acc.AccountNumber,
+		currency(acc, t),
+		amount(t.Amount),
+		deref(t.Description),
+		deref(t.Counterparty),
+		deref(t.Category),
+		deref(t.MCC),
+		fitID(t),
+	})
+}
+
+func (f *csvFormatter) endAccount(acc account) error {
+	f.w.Flush()
+	return f.w.Error()
+}
+
+func (f *csvFormatter) end() error {
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// --- OFX 2.1.1: по блоку STMTTRNRS с BANKACCTFROM на каждый счёт ---
+
+type ofxFormatter struct {
+	w        io.Writer
+	from, to time.Time
+	trnUID   int
+	err      error
+}
+
+func (f *ofxFormatter) printf(format string, args ...interface{}) {
+	if f.err == nil {
+		_, f.err = fmt.Fprintf(f.w, format, args...)
+	}
+}
+
+func (f *ofxFormatter) begin(from, to time.Time) error {
+	f.from, f.to = from, to
+	f.printf("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	f.printf("<?OFX OFXHEADER=\"200\" VERSION=\"211\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n")
+	f.printf("<OFX>\n<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	f.printf("<DTSERVER>%s</DTSERVER><LANGUAGE>RUS</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n", ofxTime(time.Now()))
+	f.printf("<BANKMSGSRSV1>\n")
+	return f.err
+}
+
+func (f *ofxFormatter) beginAccount(acc account) error {
+	f.trnUID++
+	f.printf("<STMTTRNRS><TRNUID>%d</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n", f.trnUID)
+	f.printf("<STMTRS><CURDEF>%s</CURDEF>\n", xmlText(acc.Currency))
+	f.printf("<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>%s</ACCTTYPE></BANKACCTFROM>\n",
+		xmlText(acc.Bank), xmlText(accountID(acc)), ofxAccountType(acc.AccountType))
+	f.printf("<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxTime(f.from), ofxTime(f.to))
+	return f.err
+}
+
+func (f *ofxFormatter) transaction(acc account, t storage.Transaction) error {
+	trnType := "CREDIT"
+	if t.Amount < 0 {
+		trnType = "DEBIT"
+	}
+	name := deref(t.Counterparty)
+	if name == "" {
+		name = deref(t.Description)
+	}
+	f.printf("<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID>",
+		trnType, ofxTime(t.BookingDate), amount(t.Amount), xmlText(fitID(t)))
+	if name != "" {
+		f.printf("<NAME>%s</NAME>", xmlText(truncate(name, 32)))
+	}
+	if memo := deref(t.Description); memo != "" {
+		f.printf("<MEMO>%s</MEMO>", xmlText(truncate(memo, 255)))
+	}
+	f.printf("</STMTTRN>\n")
+	return f.err
+}
+
+func (f *ofxFormatter) endAccount(acc account) error {
+	f.printf("</BANKTRANLIST>\n<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n</STMTRS></STMTTRNRS>\n",
+		amount(acc.Balance), ofxTime(time.Now()))
+	return f.err
+}
+
+func (f *ofxFormatter) end() error {
+	f.printf("</BANKMSGSRSV1>\n</OFX>\n")
+	return f.err
+}
+
+// --- QIF: блок !Account и !Type:Bank на каждый счёт ---
+
+type qifFormatter struct {
+	w   io.Writer
+	err error
+}
+
+func (f *qifFormatter) printf(format string, args ...interface{}) {
+	if f.err == nil {
+		_, f.err = fmt.Fprintf(f.w, format, args...)
+	}
+}
+
+func (f *qifFormatter) begin(from, to time.Time) error { return nil }
+
+func (f *qifFormatter) beginAccount(acc account) error {
+	qifType := "Bank"
+	if strings.Contains(strings.ToLower(acc.AccountType), "card") && strings.Contains(strings.ToLower(acc.AccountType), "credit") {
+		qifType = "CCard"
+	}
+	f.printf("!Account\nN%s %s\nT%s\n^\n!Type:%s\n", acc.Bank, qifLine(accountID(acc)), qifType, qifType)
+	return f.err
+}
+
+func (f *qifFormatter) transaction(acc account, t storage.Transaction) error {
+	f.printf("D%s\nT%s\n", t.BookingDate.Format("01/02/2006"), amount(t.Amount))
+	if p := deref(t.Counterparty); p != "" {
+		f.printf("P%s\n", qifLine(p))
+	}
+	if m := deref(t.Description); m != "" {
+		f.printf("M%s\n", qifLine(m))
+	}
+	if c := deref(t.Category); c != "" {
+		f.printf("L%s\n", qifLine(c))
+	}
+	f.printf("^\n")
+	return f.err
+}
+
+func (f *qifFormatter) endAccount(acc account) error { return f.err }
+
+func (f *qifFormatter) end() error { return f.err }
+
+// --- helpers ---
+
+// amount — сумма со знаком: расход отрицательный
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func currency(acc account, t storage.Transaction) string {
+	if t.Currency != nil && *t.Currency != "" {
+		return *t.Currency
+	}
+	return acc.Currency
+}
+
+func accountID(acc account) string {
+	if acc.AccountNumber != "" {
+		return acc.AccountNumber
+	}
+	return deref(acc.ExternalID)
+}
+
+// fitID — идентификатор операции в банке, для операций без него — внутренний
+func fitID(t storage.Transaction) string {
+	if t.ExternalID != nil && *t.ExternalID != "" {
+		return *t.ExternalID
+	}
+	return "mp-" + strconv.Itoa(t.ID)
+}
+
+func ofxAccountType(accountType string) string {
+	t := strings.ToLower(accountType)
+	switch {
+	case strings.Contains(t, "saving"), strings.Contains(t, "deposit"):
+		return "SAVINGS"
+	case strings.Contains(t, "credit"):
+		return "CREDITLINE"
+	}
+	return "CHECKING"
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func xmlText(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// qifLine — QIF построчный, перевод строки внутри поля сломает запись
+func qifLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,170 @@
+package export
+
+import (
+	"MoneyPilot/internal/imports"
+	"MoneyPilot/internal/storage"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ptr(s string) *string { return &s }
+
+var (
+	testAccount = account{
+		Account: storage.Account{ID: 1, AccountNumber: "40817810000000000001", AccountType: "Personal", Currency: "RUB", Balance: 1234.5},
+		Bank:    "vbank",
+	}
+	testTransactions = []storage.Transaction{
+		{
+			ID: 10, ExternalID: ptr("tx-1"), Amount: -150.5, Description: ptr("Coffee\nto go"),
+			Counterparty: ptr("Cafe & Co"), Category: ptr("Рестораны"), MCC: ptr("5814"),
+			BookingDate: time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			ID: 11, Amount: 1000, Currency: ptr("USD"),
+			BookingDate: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+)
+
+func write(t *testing.T, format string, acc account, txs []storage.Transaction) string {
+	t.Helper()
+	var buf bytes.Buffer
+	f, err := newFormatter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	if err := f.begin(from, to); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.beginAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range txs {
+		if err := f.transaction(acc, tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.endAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.end(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestQIF(t *testing.T) {
+	creditCard := testAccount
+	creditCard.AccountType = "Credit Card"
+	tests := []struct {
+		name string
+		acc  account
+		txs  []storage.Transaction
+		want string
+	}{
+		{
+			name: "bank account",
+			acc:  testAccount,
+			txs:  testTransactions,
+			want: "!Account\nNvbank 40817810000000000001\nTBank\n^\n!Type:Bank\n" +
+				"D03/01/2024\nT-150.50\nPCafe & Co\nMCoffee to go\nLРестораны\n^\n" +
+				"D03/02/2024\nT1000.00\n^\n",
+		},
+		{
+			name: "credit card",
+			acc:  creditCard,
+			want: "!Account\nNvbank 40817810000000000001\nTCCard\n^\n!Type:CCard\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := write(t, FormatQIF, tt.acc, tt.txs); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSV(t *testing.T) {
+	got := write(t, FormatCSV, testAccount, testTransactions)
+	want := "date,bank,account_id,account_number,currency,amount,description,counterparty,category,mcc,transaction_id\n" +
+		"2024-03-01,vbank,,40817810000000000001,RUB,-150.50,\"Coffee\nto go\",Cafe & Co,Рестораны,5814,tx-1\n" +
+		"2024-03-02,vbank,,40817810000000000001,USD,1000.00,,,,,mp-11\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOFX(t *testing.T) {
+	out := write(t, FormatOFX, testAccount, testTransactions)
+	for _, want := range []string{
+		"<CURDEF>RUB</CURDEF>",
+		"<ACCTID>40817810000000000001</ACCTID>",
+		"<FITID>tx-1</FITID>",
+		"<FITID>mp-11</FITID>",
+		"<NAME>Cafe &amp; Co</NAME>",
+		"<BALAMT>1234.50</BALAMT>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in\n%s", want, out)
+		}
+	}
+}
+
+// Выгруженный OFX должен читаться импортом MoneyPilot без потерь
+func TestOFXRoundTrip(t *testing.T) {
+	st, err := imports.Parse([]byte(write(t, FormatOFX, testAccount, testTransactions)), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Format != imports.FormatOFX || st.AccountNumber != testAccount.AccountNumber || st.Currency != "RUB" {
+		t.Errorf("statement = %s %s %s", st.Format, st.AccountNumber, st.Currency)
+	}
+	if st.ClosingBalance == nil || *st.ClosingBalance != testAccount.Balance {
+		t.Errorf("closing balance = %v, want %v", st.ClosingBalance, testAccount.Balance)
+	}
+	if len(st.Rows) != len(testTransactions) {
+		t.Fatalf("got %d rows, want %d", len(st.Rows), len(testTransactions))
+	}
+	for i, tx := range testTransactions {
+		r := st.Rows[i]
+		if r.ID != fitID(tx) || r.Amount != tx.Amount || !r.Date.Equal(tx.BookingDate) {
+			t.Errorf("row %d = %+v, want %s %v %v", i, r, fitID(tx), tx.Amount, tx.BookingDate)
+		}
+	}
+}
+
+func TestParseEnd(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"2024-03-31", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2024-12-31", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2024-03-31T12:00:00Z", time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), false},
+		{"31.03.2024", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseEnd(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseEnd(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseEnd(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := fileName(from, to, "qif"), "transactions_20240301_20240331.qif"; got != want {
+		t.Errorf("fileName = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"MoneyPilot/internal/logging"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ExportTransactions — GET /api/export/transactions?format=csv|ofx|qif&from=&to=&accounts=1,2
+// Выписка пишется в ответ по мере чтения из БД. Диапазон длиннее MaxSyncRange — через POST /api/export/jobs.
+func (h *Handler) ExportTransactions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	req, ok := parseRequest(c)
+	if !ok {
+		return
+	}
+	if req.To.Sub(req.From) > MaxSyncRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date range is too large", "details": "use POST /api/export/jobs for ranges over a year"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.Service.accounts(userID, req.AccountIDs); err != nil {
+		h.error(c, err)
+		return
+	}
+
+	contentType, ext := ContentType(req.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName(req.From, req.To, ext)))
+	c.Status(http.StatusOK)
+
+	// заголовки уже отправлены: ошибку посреди выгрузки можно только залогировать
+	if _, err := h.Service.Write(c.Request.Context(), c.Writer, userID, req); err != nil {
+		h.Service.Log.ErrorContext(c.Request.Context(), "export interrupted", "user_id", userID, logging.Err(err))
+	}
+}
+
+// CreateJob — POST /api/export/jobs {"format":"ofx","from":"2020-01-01","to":"2025-01-01","accounts":[1,2]}
+// Фоновая выгрузка; о готовности приходит WebSocket-сообщение export_ready.
+func (h *Handler) CreateJob(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Format   string `json:"format" binding:"required"`
+		From     string `json:"from" binding:"required"`
+		To       string `json:"to"`
+		Accounts []int  `json:"accounts"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	req := Request{Format: strings.ToLower(body.Format), To: time.Now(), AccountIDs: body.Accounts}
+	var err error
+	if req.From, err = parseTime(body.From); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from", "details": err.Error()})
+		return
+	}
+	if body.To != "" {
+		if req.To, err = parseEnd(body.To); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to", "details": err.Error()})
+			return
+		}
+	}
+
+	job, err := h.Service.StartJob(userID, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJob — GET /api/export/jobs/:job_id
+func (h *Handler) GetJob(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	job, err := h.Service.Job(userID, c.Param("job_id"))
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadJob — GET /api/export/jobs/:job_id/download
+func (h *Handler) DownloadJob(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	job, path, err := h.Service.File(userID, c.Param("job_id"))
+	if errors.Is(err, ErrInvalid) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	contentType, ext := ContentType(job.Format)
+	c.Header("Content-Type", contentType)
+	c.FileAttachment(path, fileName(job.From, job.To, ext))
+}
+
+// fileName — в имени файла последний включённый день, а не исключающая граница to
+func fileName(from, to time.Time, ext string) string {
+	return fmt.Sprintf("transactions_%s_%s.%s", from.Format("20060102"), to.Add(-time.Nanosecond).Format("20060102"), ext)
+}
+
+func (h *Handler) error(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "export failed", "details": err.Error()})
+	}
+}
+
+// parseRequest разбирает query: по умолчанию CSV за последний месяц по всем счетам
+func parseRequest(c *gin.Context) (Request, bool) {
+	req := Request{Format: strings.ToLower(c.DefaultQuery("format", FormatCSV)), To: time.Now()}
+	req.From = req.To.AddDate(0, -1, 0)
+	for param, dst := range map[string]*time.Time{"from": &req.From, "to": &req.To} {
+		parse := parseTime
+		if param == "to" {
+			parse = parseEnd
+		}
+		if v := c.Query(param); v != "" {
+			t, err := parse(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param, "details": err.Error()})
+				return req, false
+			}
+			*dst = t
+		}
+	}
+	if v := c.Query("accounts"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "accounts must be a comma-separated list of account ids"})
+				return req, false
+			}
+			req.AccountIDs = append(req.AccountIDs, id)
+		}
+	}
+	return req, true
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// parseEnd — конец периода не включается в выборку, поэтому дата без времени
+// означает конец этого дня: to=2024-03-31 выгружает и 31 марта
+func parseEnd(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return t, err
+	}
+	return t.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,327 @@
+package export
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/requestid"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/websockets"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxSyncRange — диапазон, который отдаётся сразу; длиннее — через фоновое задание
+const MaxSyncRange = 366 * 24 * time.Hour
+
+// jobTTL — сколько хранится готовый файл задания
+const jobTTL = 24 * time.Hour
+
+var (
+	ErrInvalid  = errors.New("invalid export request")
+	ErrNotFound = errors.New("export job not found")
+)
+
+// Request — параметры выписки; пустой AccountIDs — все счета клиента во всех банках
+type Request struct {
+	Format     string
+	From, To   time.Time
+	AccountIDs []int
+}
+
+// Job — фоновая выгрузка большого диапазона
+type Job struct {
+	ID         string     `json:"id"`
+	UserID     int        `json:"-"`
+	Status     string     `json:"status"` // running | done | failed
+	Format     string     `json:"format"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	Rows       int        `json:"rows"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	path string
+}
+
+type Service struct {
+	Repo   storage.Store
+	Export storage.ExportRepository
+	Banks  map[string]*bankapi.BankClient
+	Hub    *websockets.WebSocketHub
+	Dir    string // куда пишутся файлы заданий
+	Log    *slog.Logger
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func NewService(repo storage.Store, export storage.ExportRepository, banks map[string]*bankapi.BankClient, hub *websockets.WebSocketHub, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Export: export,
+		Banks:  banks,
+		Hub:    hub,
+		Dir:    filepath.Join(os.TempDir(), "moneypilot-exports"),
+		Log:    logging.For(logger, "export"),
+		jobs:   map[string]*Job{},
+	}
+}
+
+// Validate проверяет формат и диапазон
+func (r Request) Validate() error {
+	if _, err := newFormatter(r.Format, io.Discard); err != nil {
+		return err
+	}
+	if !r.From.Before(r.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalid)
+	}
+	return nil
+}
+
+// Write пишет выписку в w по мере чтения операций из БД и возвращает число операций
+func (s *Service) Write(ctx context.Context, w io.Writer, userID int, req Request) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+	accs, err := s.accounts(userID, req.AccountIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	buf := bufio.NewWriterSize(w, 32*1024)
+	f, err := newFormatter(req.Format, buf)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.begin(req.From, req.To); err != nil {
+		return 0, err
+	}
+
+	// операции приходят по возрастанию account_id, как и accs: счета без операций тоже попадают в выписку
+	next, rows := 0, 0
+	openUntil := func(accountID int) error {
+		for next < len(accs) && accs[next].ID <= accountID {
+			if next > 0 {
+				if err := f.endAccount(accs[next-1]); err != nil {
+					return err
+				}
+			}
+			if err := f.beginAccount(accs[next]); err != nil {
+				return err
+			}
+			next++
+		}
+		return nil
+	}
+
+	ids := make([]int, len(accs))
+	for i, a := range accs {
+		ids[i] = a.ID
+	}
+	err = s.Export.StreamTransactions(ids, req.From, req.To, func(t storage.Transaction) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := openUntil(t.AccountID); err != nil {
+			return err
+		}
+		rows++
+		return f.transaction(accs[next-1], t)
+	})
+	if err != nil {
+		return rows, err
+	}
+	if len(accs) > 0 {
+		if err := openUntil(accs[len(accs)-1].ID); err != nil {
+			return rows, err
+		}
+		if err := f.endAccount(accs[len(accs)-1]); err != nil {
+			return rows, err
+		}
+	}
+	if err := f.end(); err != nil {
+		return rows, err
+	}
+	return rows, buf.Flush()
+}
+
+// accounts — счета клиента по возрастанию id; ids ограничивает выборку и должны принадлежать клиенту
+func (s *Service) accounts(userID int, ids []int) ([]account, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	codes := map[int]string{}
+	for code := range s.Banks {
+		if b, err := s.Repo.GetBankByCode(code); err == nil {
+			codes[b.ID] = code
+		}
+	}
+
+	wanted := map[int]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	res := make([]account, 0, len(accs))
+	for _, a := range accs {
+		if len(ids) > 0 && !wanted[a.ID] {
+			continue
+		}
+		delete(wanted, a.ID)
+		res = append(res, account{Account: a, Bank: codes[a.BankID]})
+	}
+	// оставшиеся id не принадлежат клиенту
+	for id := range wanted {
+		return nil, fmt.Errorf("%w: account %d not found", ErrInvalid, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+// StartJob запускает выгрузку в файл; по готовности клиент получает WebSocket-сообщение export_ready
+func (s *Service) StartJob(userID int, req Request) (*Job, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.accounts(userID, req.AccountIDs); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	s.prune(time.Now())
+
+	job := &Job{
+		ID:        requestid.New(),
+		UserID:    userID,
+		Status:    "running",
+		Format:    req.Format,
+		From:      req.From,
+		To:        req.To,
+		CreatedAt: time.Now(),
+	}
+	_, ext := ContentType(req.Format)
+	job.path = filepath.Join(s.Dir, job.ID+"."+ext)
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.mu.Unlock()
+
+	go s.run(job, req)
+	snapshot := *job
+	return &snapshot, nil
+}
+
+func (s *Service) run(job *Job, req Request) {
+	rows, err := s.writeFile(job.path, job.UserID, req)
+
+	s.mu.Lock()
+	now := time.Now()
+	job.Rows, job.FinishedAt = rows, &now
+	job.Status = "done"
+	if err != nil {
+		job.Status, job.Error = "failed", err.Error()
+		os.Remove(job.path)
+		s.Log.Error("job failed", "job_id", job.ID, "user_id", job.UserID, logging.Err(err))
+	}
+	snapshot := *job
+	s.mu.Unlock()
+
+	s.notify(snapshot)
+}
+
+func (s *Service) writeFile(path string, userID int, req Request) (int, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := s.Write(context.Background(), f, userID, req)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return rows, err
+}
+
+// Job — задание клиента; задания других клиентов не видны
+func (s *Service) Job(userID int, id string) (*Job, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	var snapshot Job
+	if ok {
+		snapshot = *job
+	}
+	s.mu.Unlock()
+
+	if !ok || !s.sameClient(userID, snapshot.UserID) {
+		return nil, ErrNotFound
+	}
+	return &snapshot, nil
+}
+
+// File — путь к готовому файлу задания
+func (s *Service) File(userID int, id string) (*Job, string, error) {
+	job, err := s.Job(userID, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if job.Status != "done" {
+		return job, "", fmt.Errorf("%w: job is %s", ErrInvalid, job.Status)
+	}
+	return job, job.path, nil
+}
+
+// sameClient — записи users одного клиента в разных банках видят задания друг друга
+func (s *Service) sameClient(userID, owner int) bool {
+	if userID == owner {
+		return true
+	}
+	ids, err := s.Repo.GetClientUserIDs(userID)
+	if err != nil {
+		return false
+	}
+	for _, id := range ids {
+		if id == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// prune удаляет задания и файлы старше jobTTL
+func (s *Service) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobTTL {
+			os.Remove(job.path)
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *Service) notify(job Job) {
+	if s.Hub == nil {
+		return
+	}
+	ids, err := s.Repo.GetClientUserIDs(job.UserID)
+	if err != nil {
+		s.Log.Error("notify failed", "job_id", job.ID, "user_id", job.UserID, logging.Err(err))
+		return
+	}
+	msg, _ := json.Marshal(struct {
+		Type string `json:"type"` // export_ready
+		Job  Job    `json:"job"`
+	}{Type: "export_ready", Job: job})
+	s.Hub.SendToUsers(ids, string(msg))
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	repo := memory.New()
+	vbank, abank := repo.AddBank(storage.Bank{Code: "vbank"}), repo.AddBank(storage.Bank{Code: "abank"})
+	u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &vbank.ID})
+	// тот же клиент в другом банке: его счета тоже попадают в выписку
+	ua := repo.AddUser(storage.User{ClientID: "team-1", BankID: &abank.ID})
+	other := repo.AddUser(storage.User{ClientID: "team-2", BankID: &vbank.ID})
+
+	accs := map[string]*storage.Account{
+		"main":  {UserID: u.ID, BankID: vbank.ID, ExternalID: ptr("acc-1"), AccountNumber: "1", Currency: "RUB"},
+		"empty": {UserID: u.ID, BankID: vbank.ID, ExternalID: ptr("acc-2"), AccountNumber: "2", Currency: "RUB"},
+		"abank": {UserID: ua.ID, BankID: abank.ID, ExternalID: ptr("acc-3"), AccountNumber: "3", Currency: "USD"},
+		"other": {UserID: other.ID, BankID: vbank.ID, ExternalID: ptr("acc-4"), AccountNumber: "4", Currency: "RUB"},
+	}
+	for _, name := range []string{"main", "empty", "abank", "other"} {
+		if err := repo.UpsertAccount(accs[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	for _, tx := range []storage.Transaction{
+		{AccountID: accs["main"].ID, ExternalID: ptr("tx-2"), Amount: -20, BookingDate: day(5)},
+		{AccountID: accs["main"].ID, ExternalID: ptr("tx-1"), Amount: -10, BookingDate: day(2)},
+		{AccountID: accs["main"].ID, ExternalID: ptr("tx-old"), Amount: -1, BookingDate: day(1).AddDate(0, -1, 0)},
+		{AccountID: accs["abank"].ID, ExternalID: ptr("tx-3"), Amount: 30, BookingDate: day(3)},
+		{AccountID: accs["other"].ID, ExternalID: ptr("tx-4"), Amount: 40, BookingDate: day(4)},
+	} {
+		tx := tx
+		if err := repo.InsertTransaction(&tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	banks := map[string]*bankapi.BankClient{"vbank": {Name: "vbank"}, "abank": {Name: "abank"}}
+	s := NewService(repo, repo, banks, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	header := "date,bank,account_id,account_number,currency,amount,description,counterparty,category,mcc,transaction_id\n"
+	tests := []struct {
+		name     string
+		accounts []int
+		want     string
+		rows     int
+		err      error
+	}{
+		{
+			// операции по возрастанию счёта и даты, чужие и вне периода не попадают
+			name: "all accounts",
+			want: header +
+				"2024-03-02,vbank,acc-1,1,RUB,-10.00,,,,,tx-1\n" +
+				"2024-03-05,vbank,acc-1,1,RUB,-20.00,,,,,tx-2\n" +
+				"2024-03-03,abank,acc-3,3,USD,30.00,,,,,tx-3\n",
+			rows: 3,
+		},
+		{
+			name:     "selected account",
+			accounts: []int{accs["abank"].ID},
+			want:     header + "2024-03-03,abank,acc-3,3,USD,30.00,,,,,tx-3\n",
+			rows:     1,
+		},
+		{
+			name:     "account without transactions",
+			accounts: []int{accs["empty"].ID},
+			want:     header,
+		},
+		{
+			name:     "other client's account",
+			accounts: []int{accs["other"].ID},
+			err:      ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			rows, err := s.Write(context.Background(), &buf, u.ID, Request{Format: FormatCSV, From: day(1), To: day(31), AccountIDs: tt.accounts})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if rows != tt.rows || buf.String() != tt.want {
+				t.Errorf("got %d rows\n%s\nwant %d rows\n%s", rows, buf.String(), tt.rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestValidate(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		req  Request
+		err  bool
+	}{
+		{"valid", Request{Format: FormatQIF, From: from, To: from.AddDate(0, 1, 0)}, false},
+		{"unknown format", Request{Format: "xls", From: from, To: from.AddDate(0, 1, 0)}, true},
+		{"empty range", Request{Format: FormatCSV, From: from, To: from}, true},
+		{"reversed range", Request{Format: FormatCSV, From: from, To: from.AddDate(0, 0, -1)}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.req.Validate(); (err != nil) != tt.err {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.err)
+		}
+	}
+}
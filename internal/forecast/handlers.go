@@ -0,0 +1,105 @@
+package forecast
+
+import (
+	"MoneyPilot/internal/fx"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetForecast — GET /api/forecast?days=30|60|90
+// Остатки по счетам и в сумме на каждый день с предупреждениями о минусе и пороге.
+func (h *Handler) GetForecast(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || !ValidDays(days) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidDays.Error()})
+		return
+	}
+
+	f, err := h.Service.Project(userID, days, time.Now())
+	if errors.Is(err, fx.ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "missing exchange rate", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build forecast", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// GetThreshold — GET /api/forecast/threshold
+func (h *Handler) GetThreshold(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	t, err := h.Service.Threshold(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load threshold", "details": err.Error()})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "threshold not set"})
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// SetThreshold — PUT /api/forecast/threshold {"amount":5000,"currency":"RUB"}
+func (h *Handler) SetThreshold(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req struct {
+		Amount   *float64 `json:"amount" binding:"required"`
+		Currency string   `json:"currency"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	t, err := h.Service.SetThreshold(userID, *req.Amount, req.Currency)
+	if errors.Is(err, ErrInvalidThreshold) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save threshold", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// DeleteThreshold — DELETE /api/forecast/threshold: предупреждать только об уходе в минус
+func (h *Handler) DeleteThreshold(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if err := h.Service.DeleteThreshold(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete threshold", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true})
+}
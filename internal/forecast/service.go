@@ -0,0 +1,356 @@
+package forecast
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/recurring"
+	"MoneyPilot/internal/storage"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// spendWindow — за сколько дней считается средний нерегулярный расход
+const spendWindow = 90
+
+var (
+	ErrInvalidDays      = errors.New("days must be 30, 60 or 90")
+	ErrInvalidThreshold = errors.New("invalid threshold")
+)
+
+// Flow — ожидаемое движение по счёту; Amount со знаком и в валюте счёта
+type Flow struct {
+	AccountID   int       `json:"account_id"`
+	Date        time.Time `json:"date"`
+	Amount      float64   `json:"amount"`
+	Source      string    `json:"source"` // recurring | scheduled
+	Description string    `json:"description"`
+}
+
+// ScheduledSource — запланированные переводы (например, переводы в копилку),
+// которые прогноз учитывает наравне с регулярными платежами
+type ScheduledSource interface {
+	ScheduledFlows(userID int, from, to time.Time) ([]Flow, error)
+}
+
+type Point struct {
+	Date    time.Time `json:"date"`
+	Balance float64   `json:"balance"`
+}
+
+type AccountForecast struct {
+	ID         int     `json:"id"`
+	AccountID  string  `json:"account_id"`
+	BankCode   string  `json:"bank"`
+	Currency   string  `json:"currency"`
+	Nickname   *string `json:"nickname,omitempty"`
+	Balance    float64 `json:"balance"`
+	DailySpend float64 `json:"daily_discretionary_spend"`
+	Threshold  float64 `json:"threshold"` // порог клиента в валюте счёта
+	Points     []Point `json:"points"`
+	Lowest     Point   `json:"lowest"`
+}
+
+// Warning — первый день, когда остаток счёта уходит ниже нуля или порога
+type Warning struct {
+	Type      string    `json:"type"` // negative | below_threshold
+	ID        int       `json:"id"`
+	AccountID string    `json:"account_id"`
+	BankCode  string    `json:"bank"`
+	Date      time.Time `json:"date"`
+	Balance   float64   `json:"balance"`
+	Threshold float64   `json:"threshold"`
+	Currency  string    `json:"currency"`
+}
+
+// Forecast — остатки на конец каждого дня; Total — в валюте Currency по сегодняшним курсам
+type Forecast struct {
+	Currency  string                     `json:"currency"`
+	Days      int                        `json:"days"`
+	From      time.Time                  `json:"from"`
+	Threshold *storage.ForecastThreshold `json:"threshold,omitempty"`
+	Total     []Point                    `json:"total"`
+	Accounts  []AccountForecast          `json:"accounts"`
+	Flows     []Flow                     `json:"flows"`
+	Warnings  []Warning                  `json:"warnings"`
+}
+
+type Service struct {
+	Repo       storage.Store
+	Thresholds storage.ForecastRepository
+	Recurring  *recurring.Service
+	FX         *fx.Service
+	Banks      map[string]*bankapi.BankClient
+	sources    []ScheduledSource
+}
+
+func NewService(repo storage.Store, thresholds storage.ForecastRepository, recurringSvc *recurring.Service, fxSvc *fx.Service, banks map[string]*bankapi.BankClient) *Service {
+	return &Service{Repo: repo, Thresholds: thresholds, Recurring: recurringSvc, FX: fxSvc, Banks: banks}
+}
+
+// AddSource подключает источник запланированных переводов
+func (s *Service) AddSource(src ScheduledSource) {
+	s.sources = append(s.sources, src)
+}
+
+func ValidDays(days int) bool {
+	return days == 30 || days == 60 || days == 90
+}
+
+// Project строит прогноз клиента на days дней вперёд от now
+func (s *Service) Project(userID, days int, now time.Time) (*Forecast, error) {
+	if !ValidDays(days) {
+		return nil, ErrInvalidDays
+	}
+	currency, err := s.FX.BaseCurrency(userID)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := s.Threshold(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := money.TruncateDay(now)
+	end := today.AddDate(0, 0, days)
+	f := &Forecast{
+		Currency:  currency,
+		Days:      days,
+		From:      today,
+		Threshold: threshold,
+		Total:     make([]Point, days),
+		Accounts:  []AccountForecast{},
+		Flows:     []Flow{},
+		Warnings:  []Warning{},
+	}
+	for i := range f.Total {
+		f.Total[i].Date = today.AddDate(0, 0, i+1)
+	}
+
+	stored, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	// активы и долги без движения денег в прогноз не входят
+	accs := stored[:0]
+	for _, a := range stored {
+		if a.Spendable() {
+			accs = append(accs, a)
+		}
+	}
+	if len(accs) == 0 {
+		return f, nil
+	}
+	codes := s.bankCodes()
+
+	series, err := s.Recurring.Active(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	flows := recurringFlows(series, today, end)
+	for _, src := range s.sources {
+		scheduled, err := src.ScheduledFlows(userID, today, end)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, scheduled...)
+	}
+	sort.SliceStable(flows, func(i, j int) bool { return flows[i].Date.Before(flows[j].Date) })
+
+	spend, err := s.dailySpend(accs, series, today)
+	if err != nil {
+		return nil, err
+	}
+
+	byAccount := map[int][]Flow{}
+	for _, fl := range flows {
+		byAccount[fl.AccountID] = append(byAccount[fl.AccountID], fl)
+	}
+
+	for _, acc := range accs {
+		af := AccountForecast{
+			ID:         acc.ID,
+			BankCode:   codes[acc.BankID],
+			Currency:   acc.Currency,
+			Nickname:   acc.Nickname,
+			Balance:    acc.Balance,
+			DailySpend: money.Round(spend[acc.ID]),
+			Points:     make([]Point, days),
+		}
+		if acc.ExternalID != nil {
+			af.AccountID = *acc.ExternalID
+		}
+		if threshold != nil {
+			if af.Threshold, err = money.Convert(s.FX, threshold.Amount, threshold.Currency, acc.Currency, now); err != nil {
+				return nil, err
+			}
+		}
+
+		balance := acc.Balance
+		accFlows := byAccount[acc.ID]
+		k := 0
+		af.Lowest = Point{Date: today, Balance: money.Round(balance)}
+		var negative, below bool
+		for i := 0; i < days; i++ {
+			day := today.AddDate(0, 0, i+1)
+			// просроченные в пределах grace платежи ожидаются в первый же день
+			for k < len(accFlows) && accFlows[k].Date.Before(day.AddDate(0, 0, 1)) {
+				balance += accFlows[k].Amount
+				k++
+			}
+			balance -= spend[acc.ID]
+			p := Point{Date: day, Balance: money.Round(balance)}
+			af.Points[i] = p
+			if p.Balance < af.Lowest.Balance {
+				af.Lowest = p
+			}
+
+			if p.Balance < 0 && !negative {
+				negative = true
+				f.Warnings = append(f.Warnings, warning("negative", af, p))
+			}
+			if threshold != nil && p.Balance < af.Threshold && !below && !negative {
+				below = true
+				f.Warnings = append(f.Warnings, warning("below_threshold", af, p))
+			}
+
+			total, err := money.Convert(s.FX, p.Balance, acc.Currency, currency, now)
+			if err != nil {
+				return nil, err
+			}
+			f.Total[i].Balance += total
+		}
+		f.Flows = append(f.Flows, accFlows...)
+		f.Accounts = append(f.Accounts, af)
+	}
+
+	for i := range f.Total {
+		f.Total[i].Balance = money.Round(f.Total[i].Balance)
+	}
+	sort.SliceStable(f.Flows, func(i, j int) bool { return f.Flows[i].Date.Before(f.Flows[j].Date) })
+	sort.SliceStable(f.Warnings, func(i, j int) bool { return f.Warnings[i].Date.Before(f.Warnings[j].Date) })
+	return f, nil
+}
+
+// Threshold — порог клиента или nil, если не задан
+func (s *Service) Threshold(userID int) (*storage.ForecastThreshold, error) {
+	t, err := s.Thresholds.GetForecastThreshold(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return t, err
+}
+
+// SetThreshold задаёт порог; пустая валюта — базовая валюта пользователя
+func (s *Service) SetThreshold(userID int, amount float64, currency string) (*storage.ForecastThreshold, error) {
+	if amount < 0 {
+		return nil, fmt.Errorf("%w: amount must not be negative", ErrInvalidThreshold)
+	}
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		var err error
+		if currency, err = s.FX.BaseCurrency(userID); err != nil {
+			return nil, err
+		}
+	}
+	if !fx.ValidCurrency(currency) {
+		return nil, fmt.Errorf("%w: invalid currency %q", ErrInvalidThreshold, currency)
+	}
+	t := &storage.ForecastThreshold{UserID: userID, Amount: amount, Currency: currency}
+	if err := s.Thresholds.SetForecastThreshold(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *Service) DeleteThreshold(userID int) error {
+	return s.Thresholds.DeleteForecastThreshold(userID)
+}
+
+// recurringFlows раскладывает регулярные серии по ожидаемым датам до end
+func recurringFlows(series []recurring.Series, today, end time.Time) []Flow {
+	var flows []Flow
+	for _, sr := range series {
+		for _, d := range sr.Upcoming(end) {
+			if d.Before(today) {
+				d = today
+			}
+			flows = append(flows, Flow{
+				AccountID:   sr.AccountID,
+				Date:        money.TruncateDay(d),
+				Amount:      sr.NextAmount,
+				Source:      "recurring",
+				Description: sr.Name,
+			})
+		}
+	}
+	return flows
+}
+
+// dailySpend — средний дневной расход по счёту без операций регулярных серий. Расход делится
+// на spendWindow дней, а у счёта с более короткой историей — на дни с его первой операции.
+func (s *Service) dailySpend(accs []storage.Account, series []recurring.Series, today time.Time) (map[int]float64, error) {
+	ids := make([]int, len(accs))
+	for i, a := range accs {
+		ids[i] = a.ID
+	}
+	txs, err := s.Repo.GetTransactionsByAccountIDs(ids, today.AddDate(0, 0, -spendWindow), today)
+	if err != nil {
+		return nil, err
+	}
+	skip := map[int]bool{}
+	for _, sr := range series {
+		for _, id := range sr.TransactionIDs {
+			skip[id] = true
+		}
+	}
+	sums := map[int]float64{}
+	for _, t := range txs {
+		if t.Amount >= 0 || skip[t.ID] {
+			continue
+		}
+		sums[t.AccountID] += -t.Amount
+	}
+	res := map[int]float64{}
+	for id, sum := range sums {
+		first, err := s.Repo.FirstBookingDate(id)
+		if err != nil {
+			return nil, err
+		}
+		days := float64(spendWindow)
+		if first != nil {
+			// хотя бы сутки: операции за сегодня — это расход одного дня
+			days = math.Max(1, math.Min(days, today.Sub(money.TruncateDay(*first)).Hours()/24))
+		}
+		res[id] = sum / days
+	}
+	return res, nil
+}
+
+func (s *Service) bankCodes() map[int]string {
+	codes := map[int]string{}
+	for code := range s.Banks {
+		if b, err := s.Repo.GetBankByCode(code); err == nil {
+			codes[b.ID] = code
+		}
+	}
+	return codes
+}
+
+func warning(kind string, af AccountForecast, p Point) Warning {
+	return Warning{
+		Type:      kind,
+		ID:        af.ID,
+		AccountID: af.AccountID,
+		BankCode:  af.BankCode,
+		Date:      p.Date,
+		Balance:   p.Balance,
+		Threshold: af.Threshold,
+		Currency:  af.Currency,
+	}
+}
@@ -0,0 +1,107 @@
+package forecast
+
+import (
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/recurring"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func str(v string) *string { return &v }
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestProject(t *testing.T) {
+	repo := memory.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	fxSvc := fx.NewService(repo, repo, nil, logger)
+	if err := fxSvc.AddRates([]storage.FXRate{{RateDate: date("2024-01-01"), Base: "USD", Quote: "RUB", Rate: 90}}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(repo, repo, recurring.NewService(repo, repo), fxSvc, nil)
+	u := repo.AddUser(storage.User{ClientID: "team-1"})
+
+	// рублёвый счёт открыт 10 дней назад, долларовый — с историей дольше окна
+	fresh := &storage.Account{UserID: u.ID, AccountNumber: "rub", Currency: "RUB", Balance: 10000}
+	old := &storage.Account{UserID: u.ID, AccountNumber: "usd", Currency: "USD", Balance: 1000}
+	asset := &storage.Account{UserID: u.ID, AccountNumber: "flat", Currency: "RUB", Balance: 1e7, AccountType: storage.AccountTypeAsset}
+	for _, a := range []*storage.Account{fresh, old, asset} {
+		if err := repo.UpsertAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tx := range []storage.Transaction{
+		{AccountID: fresh.ID, Amount: -1000, BookingDate: date("2024-05-31")},
+		{AccountID: fresh.ID, Amount: -1000, BookingDate: date("2024-06-05")},
+		{AccountID: fresh.ID, Amount: 5000, BookingDate: date("2024-06-06")},
+		{AccountID: old.ID, Amount: -40, BookingDate: date("2023-12-01")},
+		{AccountID: old.ID, Amount: -90, BookingDate: date("2024-04-01")},
+		// подписка: в регулярных платежах, а не в среднем расходе
+		{AccountID: old.ID, Amount: -5, BookingDate: date("2024-03-15"), Counterparty: str("Netflix")},
+		{AccountID: old.ID, Amount: -5, BookingDate: date("2024-04-15"), Counterparty: str("Netflix")},
+		{AccountID: old.ID, Amount: -5, BookingDate: date("2024-05-15"), Counterparty: str("Netflix")},
+	} {
+		if err := repo.InsertTransaction(&tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.SetThreshold(u.ID, 8000, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := s.Project(u.ID, 30, time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Accounts) != 2 {
+		t.Fatalf("accounts = %d, want 2 without the asset", len(f.Accounts))
+	}
+	rub, usd := f.Accounts[0], f.Accounts[1]
+	if rub.ID != fresh.ID {
+		rub, usd = usd, rub
+	}
+
+	// 2000 за 10 дней истории, а не за 90 дней окна
+	if rub.DailySpend != 200 {
+		t.Errorf("rub daily spend = %v, want 200", rub.DailySpend)
+	}
+	if usd.DailySpend != 1 {
+		t.Errorf("usd daily spend = %v, want 1", usd.DailySpend)
+	}
+	if got := rub.Points[10]; !got.Date.Equal(date("2024-06-21")) || got.Balance != 7800 {
+		t.Errorf("rub day 11 = %+v, want 7800 on 2024-06-21", got)
+	}
+	if got := usd.Points[4]; !got.Date.Equal(date("2024-06-15")) || got.Balance != 990 {
+		t.Errorf("usd day 5 = %+v, want 990 after the subscription", got)
+	}
+	if usd.Lowest.Balance != 965 {
+		t.Errorf("usd lowest = %+v, want 965", usd.Lowest)
+	}
+	if f.Currency != "RUB" || f.Total[0].Balance != 9800+999*90 {
+		t.Errorf("total day 1 = %v %s, want %v RUB", f.Total[0].Balance, f.Currency, 9800+999*90)
+	}
+	if len(f.Flows) != 1 || f.Flows[0].Amount != -5 || !f.Flows[0].Date.Equal(date("2024-06-15")) {
+		t.Errorf("flows = %+v, want one subscription on 2024-06-15", f.Flows)
+	}
+	if len(f.Warnings) != 1 || f.Warnings[0].Type != "below_threshold" || !f.Warnings[0].Date.Equal(date("2024-06-21")) {
+		t.Errorf("warnings = %+v, want below_threshold on 2024-06-21", f.Warnings)
+	}
+}
+
+func TestProjectInvalidDays(t *testing.T) {
+	s := &Service{}
+	if _, err := s.Project(1, 45, time.Now()); !errors.Is(err, ErrInvalidDays) {
+		t.Errorf("err = %v, want ErrInvalidDays", err)
+	}
+}
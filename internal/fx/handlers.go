@@ -0,0 +1,171 @@
+package fx
+
+import (
+	"MoneyPilot/internal/storage"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListRates — GET /api/fx/rates?date=YYYY-MM-DD
+// Последние известные на дату курсы по всем парам.
+func (h *Handler) ListRates(c *gin.Context) {
+	on := time.Now()
+	if v := c.Query("date"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date", "details": err.Error()})
+			return
+		}
+		on = t
+	}
+	rates, err := h.Service.ListRates(on)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load rates", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"date": on.Format("2006-01-02"), "total": len(rates), "rates": rates})
+}
+
+type rateInput struct {
+	Date  string  `json:"date"`
+	Base  string  `json:"base" binding:"required"`
+	Quote string  `json:"quote" binding:"required"`
+	Rate  float64 `json:"rate" binding:"required"`
+}
+
+// AddRates — POST /api/fx/rates (только admin)
+// Ручной ввод курсов: {"rates":[{"date":"2025-01-31","base":"USD","quote":"RUB","rate":98.5}]}
+func (h *Handler) AddRates(c *gin.Context) {
+	if c.GetString("role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+	var req struct {
+		Rates []rateInput `json:"rates" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	rates := make([]storage.FXRate, 0, len(req.Rates))
+	for _, in := range req.Rates {
+		fr := storage.FXRate{Base: in.Base, Quote: in.Quote, Rate: in.Rate}
+		if in.Date != "" {
+			t, err := time.Parse("2006-01-02", in.Date)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date", "details": err.Error()})
+				return
+			}
+			fr.RateDate = t
+		}
+		rates = append(rates, fr)
+	}
+	if err := h.Service.AddRates(rates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to save rates", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"saved": len(rates)})
+}
+
+// Convert — GET /api/fx/convert?amount=&from=&to=&date=
+// to по умолчанию — базовая валюта пользователя.
+func (h *Handler) Convert(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+		return
+	}
+	from := strings.ToUpper(c.Query("from"))
+	if !ValidCurrency(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from currency"})
+		return
+	}
+	to := strings.ToUpper(c.Query("to"))
+	if to == "" {
+		if to, err = h.Service.BaseCurrency(userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load base currency", "details": err.Error()})
+			return
+		}
+	}
+	on := time.Now()
+	if v := c.Query("date"); v != "" {
+		if on, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date", "details": err.Error()})
+			return
+		}
+	}
+
+	rate, err := h.Service.Rate(from, to, on)
+	if errors.Is(err, ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to convert", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"amount":    amount,
+		"from":      from,
+		"to":        to,
+		"date":      on.Format("2006-01-02"),
+		"rate":      rate,
+		"converted": amount * rate,
+	})
+}
+
+// GetBaseCurrency — GET /api/fx/base-currency
+func (h *Handler) GetBaseCurrency(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	cur, err := h.Service.BaseCurrency(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load base currency", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"currency": cur})
+}
+
+// SetBaseCurrency — PUT /api/fx/base-currency {"currency":"USD"}
+func (h *Handler) SetBaseCurrency(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req struct {
+		Currency string `json:"currency" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	if err := h.Service.SetBaseCurrency(userID, req.Currency); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to set base currency", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"currency": strings.ToUpper(req.Currency)})
+}
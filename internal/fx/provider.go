@@ -0,0 +1,140 @@
+package fx
+
+import (
+	"MoneyPilot/internal/storage"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider — источник курсов валют
+type Provider interface {
+	Name() string
+	// Rates возвращает курсы на день day. Провайдер может вернуть и больше (например, всю историю из файла).
+	Rates(ctx context.Context, day time.Time) ([]storage.FXRate, error)
+}
+
+// ManualProvider ничего не загружает: курсы вносятся через POST /api/fx/rates
+type ManualProvider struct{}
+
+func (ManualProvider) Name() string { return "manual" }
+
+func (ManualProvider) Rates(context.Context, time.Time) ([]storage.FXRate, error) { return nil, nil }
+
+// FileProvider читает CSV для офлайн-работы: date,base,quote,rate
+// (2025-01-31,USD,RUB,98.5). Пустые строки и строки с # пропускаются, заголовок допускается.
+type FileProvider struct {
+	Path string
+}
+
+func (p *FileProvider) Name() string { return "file" }
+
+func (p *FileProvider) Rates(ctx context.Context, day time.Time) ([]storage.FXRate, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = 4
+	r.TrimLeadingSpace = true
+
+	var rates []storage.FXRate
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(rec[0], "date") {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", p.Path, line, err)
+		}
+		if date.After(day) {
+			continue
+		}
+		rate, err := strconv.ParseFloat(rec[3], 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("%s:%d: bad rate %q", p.Path, line, rec[3])
+		}
+		rates = append(rates, storage.FXRate{
+			RateDate: date,
+			Base:     strings.ToUpper(rec[1]),
+			Quote:    strings.ToUpper(rec[2]),
+			Rate:     rate,
+		})
+	}
+	return rates, nil
+}
+
+// HTTPProvider загружает курсы с HTTP API в формате
+// {"base":"RUB","date":"2025-01-31","rates":{"USD":0.0102,...}} (как у frankfurter.app / exchangerate.host).
+// В URL подставляются {date} и {base}, например https://api.frankfurter.app/{date}?from={base}
+type HTTPProvider struct {
+	URL        string
+	Base       string
+	HTTPClient *http.Client
+}
+
+func (p *HTTPProvider) Name() string { return "http" }
+
+func (p *HTTPProvider) Rates(ctx context.Context, day time.Time) ([]storage.FXRate, error) {
+	url := strings.NewReplacer("{date}", day.Format("2006-01-02"), "{base}", p.Base).Replace(p.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fx provider returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Base  string             `json:"base"`
+		Date  string             `json:"date"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode fx response: %w", err)
+	}
+	date := day
+	if t, err := time.Parse("2006-01-02", body.Date); err == nil {
+		date = t
+	}
+	base := strings.ToUpper(body.Base)
+	if base == "" {
+		base = strings.ToUpper(p.Base)
+	}
+
+	rates := make([]storage.FXRate, 0, len(body.Rates))
+	for quote, rate := range body.Rates {
+		if rate <= 0 {
+			continue
+		}
+		rates = append(rates, storage.FXRate{RateDate: date, Base: base, Quote: strings.ToUpper(quote), Rate: rate})
+	}
+	return rates, nil
+}
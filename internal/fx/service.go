@@ -0,0 +1,241 @@
+package fx
+
+import (
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRate — курса на дату нет ни напрямую, ни через кросс-курс
+var ErrNoRate = errors.New("no fx rate")
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// pivots — валюты, через которые считается кросс-курс, если прямого нет
+var pivots = []string{"RUB", "USD", "EUR"}
+
+// maxCached — после стольких записей кэш курсов сбрасывается целиком
+const maxCached = 10000
+
+type Service struct {
+	Repo     storage.FXRateRepository
+	Users    storage.UserRepository
+	Provider Provider
+	Log      *slog.Logger
+
+	mu    sync.Mutex
+	cache map[string]float64
+}
+
+func NewService(repo storage.FXRateRepository, users storage.UserRepository, provider Provider, logger *slog.Logger) *Service {
+	if provider == nil {
+		provider = ManualProvider{}
+	}
+	return &Service{Repo: repo, Users: users, Provider: provider, Log: logging.For(logger, "fx"), cache: map[string]float64{}}
+}
+
+// ValidCurrency — трёхбуквенный код ISO 4217 в верхнем регистре
+func ValidCurrency(code string) bool {
+	return currencyCode.MatchString(code)
+}
+
+// BaseCurrency — валюта, в которой пользователю показываются итоги
+func (s *Service) BaseCurrency(userID int) (string, error) {
+	u, err := s.Users.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if u.BaseCurrency == "" {
+		return "RUB", nil
+	}
+	return u.BaseCurrency, nil
+}
+
+func (s *Service) SetBaseCurrency(userID int, currency string) error {
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return fmt.Errorf("invalid currency %q", currency)
+	}
+	return s.Users.SetUserBaseCurrency(userID, currency)
+}
+
+// Convert переводит amount из from в to по курсу на дату on
+func (s *Service) Convert(amount float64, from, to string, on time.Time) (float64, error) {
+	rate, err := s.Rate(from, to, on)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+// Rate — сколько to стоит 1 from на дату on. Ищется прямой курс, обратный и кросс через pivots.
+func (s *Service) Rate(from, to string, on time.Time) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+	day := on.Format("2006-01-02")
+	key := day + " " + from + " " + to
+
+	s.mu.Lock()
+	rate, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return rate, nil
+	}
+
+	rate, err := s.lookup(from, to, on)
+	if err != nil {
+		return 0, fmt.Errorf("%w %s→%s on %s", err, from, to, day)
+	}
+
+	s.mu.Lock()
+	if len(s.cache) >= maxCached {
+		s.cache = map[string]float64{}
+	}
+	s.cache[key] = rate
+	s.mu.Unlock()
+	return rate, nil
+}
+
+func (s *Service) lookup(from, to string, on time.Time) (float64, error) {
+	if rate, err := s.pair(from, to, on); !errors.Is(err, ErrNoRate) {
+		return rate, err
+	}
+	for _, pivot := range pivots {
+		if pivot == from || pivot == to {
+			continue
+		}
+		a, err := s.pair(from, pivot, on)
+		if errors.Is(err, ErrNoRate) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		b, err := s.pair(pivot, to, on)
+		if errors.Is(err, ErrNoRate) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return a * b, nil
+	}
+	return 0, ErrNoRate
+}
+
+// pair ищет прямой или обратный курс
+func (s *Service) pair(from, to string, on time.Time) (float64, error) {
+	fr, err := s.Repo.GetFXRate(from, to, on)
+	if err == nil {
+		return fr.Rate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	fr, err = s.Repo.GetFXRate(to, from, on)
+	if err == nil {
+		return 1 / fr.Rate, nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNoRate
+	}
+	return 0, err
+}
+
+// AddRates сохраняет курсы, внесённые вручную
+func (s *Service) AddRates(rates []storage.FXRate) error {
+	for i := range rates {
+		fr := &rates[i]
+		fr.Base, fr.Quote = strings.ToUpper(fr.Base), strings.ToUpper(fr.Quote)
+		if !ValidCurrency(fr.Base) || !ValidCurrency(fr.Quote) || fr.Base == fr.Quote {
+			return fmt.Errorf("invalid pair %s/%s", fr.Base, fr.Quote)
+		}
+		if fr.Rate <= 0 {
+			return fmt.Errorf("rate for %s/%s must be positive", fr.Base, fr.Quote)
+		}
+		if fr.RateDate.IsZero() {
+			fr.RateDate = time.Now()
+		}
+		if fr.Source == "" {
+			fr.Source = "manual"
+		}
+	}
+	if err := s.Repo.UpsertFXRates(rates); err != nil {
+		return err
+	}
+	s.resetCache()
+	return nil
+}
+
+func (s *Service) ListRates(on time.Time) ([]storage.FXRate, error) {
+	return s.Repo.ListFXRates(on)
+}
+
+// Sync загружает курсы у провайдера на день day
+func (s *Service) Sync(ctx context.Context, day time.Time) (int, error) {
+	rates, err := s.Provider.Rates(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+	if len(rates) == 0 {
+		return 0, nil
+	}
+	for i := range rates {
+		if rates[i].Source == "" {
+			rates[i].Source = s.Provider.Name()
+		}
+	}
+	if err := s.Repo.UpsertFXRates(rates); err != nil {
+		return 0, err
+	}
+	s.resetCache()
+	return len(rates), nil
+}
+
+// Start загружает курсы сразу и затем раз в interval
+func (s *Service) Start(interval time.Duration, stopCh <-chan struct{}) {
+	if _, ok := s.Provider.(ManualProvider); ok {
+		s.Log.Info("manual provider, rates are entered via API")
+		return
+	}
+	s.Log.Info("sync started", "provider", s.Provider.Name(), "interval", interval)
+
+	sync := func() {
+		n, err := s.Sync(context.Background(), time.Now())
+		if err != nil {
+			s.Log.Error("sync failed", logging.Err(err))
+			return
+		}
+		s.Log.Info("synced", "rates", n)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		sync()
+		for {
+			select {
+			case <-ticker.C:
+				sync()
+			case <-stopCh:
+				s.Log.Info("stopping")
+				return
+			}
+		}
+	}()
+}
+
+func (s *Service) resetCache() {
+	s.mu.Lock()
+	s.cache = map[string]float64{}
+	s.mu.Unlock()
+}
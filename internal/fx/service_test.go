@@ -0,0 +1,103 @@
+package fx
+
+import (
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	repo := memory.New()
+	s := NewService(repo, repo, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	err := s.AddRates([]storage.FXRate{
+		{RateDate: day("2024-03-01"), Base: "usd", Quote: "rub", Rate: 90},
+		{RateDate: day("2024-06-01"), Base: "USD", Quote: "RUB", Rate: 100},
+		{RateDate: day("2024-03-01"), Base: "EUR", Quote: "RUB", Rate: 110},
+		{RateDate: day("2024-03-01"), Base: "USD", Quote: "KZT", Rate: 450},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRate(t *testing.T) {
+	s := newTestService(t)
+	tests := []struct {
+		name     string
+		from, to string
+		on       time.Time
+		want     float64
+		err      error
+	}{
+		{name: "same currency", from: "RUB", to: "rub", on: day("2020-01-01"), want: 1},
+		{name: "direct", from: "USD", to: "RUB", on: day("2024-04-15"), want: 90},
+		{name: "latest on date", from: "USD", to: "RUB", on: day("2024-06-15"), want: 100},
+		{name: "inverse", from: "RUB", to: "EUR", on: day("2024-04-15"), want: 1.0 / 110},
+		{name: "cross via RUB", from: "EUR", to: "USD", on: day("2024-04-15"), want: 110.0 / 90},
+		{name: "cross via USD", from: "RUB", to: "KZT", on: day("2024-04-15"), want: 450.0 / 90},
+		{name: "before first quote", from: "USD", to: "RUB", on: day("2024-01-15"), err: ErrNoRate},
+		{name: "unknown currency", from: "GBP", to: "RUB", on: day("2024-04-15"), err: ErrNoRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Rate(tt.from, tt.to, tt.on)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddRatesResetsCache(t *testing.T) {
+	s := newTestService(t)
+	if _, err := s.Rate("GBP", "RUB", day("2024-04-15")); !errors.Is(err, ErrNoRate) {
+		t.Fatalf("err = %v, want ErrNoRate", err)
+	}
+	if got, _ := s.Rate("USD", "RUB", day("2024-04-15")); got != 90 {
+		t.Fatalf("rate = %v, want 90", got)
+	}
+	if err := s.AddRates([]storage.FXRate{
+		{RateDate: day("2024-03-01"), Base: "USD", Quote: "RUB", Rate: 92},
+		{RateDate: day("2024-03-01"), Base: "GBP", Quote: "RUB", Rate: 115},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Rate("USD", "RUB", day("2024-04-15")); got != 92 {
+		t.Errorf("rate after reload = %v, want 92", got)
+	}
+	if got, err := s.Rate("GBP", "RUB", day("2024-04-15")); err != nil || got != 115 {
+		t.Errorf("new pair = %v, %v; want 115", got, err)
+	}
+}
+
+func TestAddRatesValidation(t *testing.T) {
+	s := newTestService(t)
+	for _, fr := range []storage.FXRate{
+		{Base: "USD", Quote: "usd", Rate: 1},
+		{Base: "US", Quote: "RUB", Rate: 90},
+		{Base: "USD", Quote: "RUB", Rate: 0},
+		{Base: "USD", Quote: "RUB", Rate: -90},
+	} {
+		if err := s.AddRates([]storage.FXRate{fr}); err == nil {
+			t.Errorf("AddRates(%+v) succeeded, want error", fr)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Readyz — GET /readyz: 200 при ok и degraded, 503 при down
+func (h *Handler) Readyz(c *gin.Context) {
+	r := h.Service.Check(c.Request.Context())
+	code := http.StatusOK
+	if r.Status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, r)
+}
@@ -0,0 +1,189 @@
+package health
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/poller"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Состояния проверок
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // API отвечает, но часть функций недоступна
+	StatusDown     = "down"
+)
+
+// Check — результат проверки одной зависимости
+type Check struct {
+	Status    string     `json:"status"`
+	LatencyMS *int64     `json:"latency_ms,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	LastCycle *time.Time `json:"last_cycle,omitempty"` // poller
+	CheckedAt *time.Time `json:"checked_at,omitempty"` // банки: результат кэшируется
+}
+
+// Report — ответ /readyz. Down только при недоступных Postgres или Redis;
+// отставший поллер и проблемы с банками дают degraded.
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+	Banks  map[string]Check `json:"banks"`
+}
+
+type Config struct {
+	Timeout          time.Duration // таймаут пинга Postgres и Redis
+	PollerStaleAfter time.Duration // сколько можно не видеть цикла поллера
+	BankCacheTTL     time.Duration // как часто заново проверять токены банков
+}
+
+type Service struct {
+	DB        *sql.DB
+	Redis     *redis.Client
+	Poller    *poller.Poller
+	TokenSvc  *bankapi.TokenService
+	Transport *bankapi.Transport
+	Banks     map[string]*bankapi.BankClient
+	Config    Config
+	Log       *slog.Logger
+
+	mu      sync.Mutex
+	banks   map[string]Check
+	banksAt time.Time
+	last    string // статус прошлой проверки, чтобы логировать только смену
+}
+
+func NewService(db *sql.DB, rdb *redis.Client, pl *poller.Poller, ts *bankapi.TokenService, transport *bankapi.Transport,
+	banks map[string]*bankapi.BankClient, cfg Config, logger *slog.Logger) *Service {
+	return &Service{
+		DB:        db,
+		Redis:     rdb,
+		Poller:    pl,
+		TokenSvc:  ts,
+		Transport: transport,
+		Banks:     banks,
+		Config:    cfg,
+		Log:       logging.For(logger, "health"),
+	}
+}
+
+// Check проверяет зависимости; токены банков берутся из кэша, если он свежее BankCacheTTL
+func (s *Service) Check(ctx context.Context) Report {
+	r := Report{
+		Status: StatusOK,
+		Checks: map[string]Check{
+			"postgres": s.ping(ctx, s.DB.PingContext),
+			"redis": s.ping(ctx, func(ctx context.Context) error {
+				return s.Redis.Ping(ctx).Err()
+			}),
+			"poller": s.poller(time.Now()),
+		},
+		Banks: s.bankChecks(),
+	}
+
+	for _, c := range r.Checks {
+		if c.Status != StatusOK {
+			r.Status = StatusDegraded
+		}
+	}
+	for _, c := range r.Banks {
+		if c.Status != StatusOK {
+			r.Status = StatusDegraded
+		}
+	}
+	if r.Checks["postgres"].Status == StatusDown || r.Checks["redis"].Status == StatusDown {
+		r.Status = StatusDown
+	}
+
+	s.mu.Lock()
+	changed := s.last != "" && s.last != r.Status
+	s.last = r.Status
+	s.mu.Unlock()
+	if changed {
+		s.Log.WarnContext(ctx, "readiness changed", "status", r.Status)
+	}
+	return r
+}
+
+func (s *Service) ping(ctx context.Context, ping func(context.Context) error) Check {
+	ctx, cancel := context.WithTimeout(ctx, s.Config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	ms := time.Since(start).Milliseconds()
+	if err != nil {
+		return Check{Status: StatusDown, LatencyMS: &ms, Error: err.Error()}
+	}
+	return Check{Status: StatusOK, LatencyMS: &ms}
+}
+
+func (s *Service) poller(now time.Time) Check {
+	if s.Poller == nil {
+		return Check{Status: StatusDown, Error: "poller is not configured"}
+	}
+	last := s.Poller.LastCycle()
+	if last.IsZero() {
+		return Check{Status: StatusDown, Error: "poller is not running"}
+	}
+	c := Check{Status: StatusOK, LastCycle: &last}
+	if age := now.Sub(last); age > s.Config.PollerStaleAfter {
+		c.Status = StatusDegraded
+		c.Error = fmt.Sprintf("no poll cycle for %s", age.Round(time.Second))
+	}
+	return c
+}
+
+// bankChecks — может ли API получить токен каждого банка. Проверки идут параллельно;
+// пока идёт проверка, остальные запросы /readyz ждут её результата.
+func (s *Service) bankChecks() map[string]Check {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.banks != nil && time.Since(s.banksAt) < s.Config.BankCacheTTL {
+		return s.banks
+	}
+
+	now := time.Now()
+	res := make(map[string]Check, len(s.Banks))
+	var (
+		wg  sync.WaitGroup
+		rmu sync.Mutex
+	)
+	for code, bank := range s.Banks {
+		wg.Add(1)
+		go func(code string, bank *bankapi.BankClient) {
+			defer wg.Done()
+			c := s.bank(code, bank)
+			c.CheckedAt = &now
+			rmu.Lock()
+			res[code] = c
+			rmu.Unlock()
+		}(code, bank)
+	}
+	wg.Wait()
+
+	s.banks, s.banksAt = res, now
+	return res
+}
+
+func (s *Service) bank(code string, bank *bankapi.BankClient) Check {
+	// при разомкнутом предохранителе банк не дёргаем: запрос токена всё равно не пройдёт
+	if s.Transport != nil && s.Transport.BreakerOpen(code) {
+		return Check{Status: StatusDegraded, Error: "circuit breaker open"}
+	}
+	start := time.Now()
+	_, err := s.TokenSvc.GetValidToken(bank)
+	ms := time.Since(start).Milliseconds()
+	if err != nil {
+		// текст ошибки может содержать ответ банка — он уже в логе TokenService
+		return Check{Status: StatusDown, LatencyMS: &ms, Error: "token unavailable"}
+	}
+	return Check{Status: StatusOK, LatencyMS: &ms}
+}
@@ -0,0 +1,241 @@
+package health
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/poller"
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// pingDriver — драйвер database/sql без базы: DSN "down" не проходит ping
+type pingDriver struct{}
+
+type pingConn struct{ down bool }
+
+func (pingDriver) Open(dsn string) (driver.Conn, error) { return pingConn{down: dsn == "down"}, nil }
+
+func (c pingConn) Ping(ctx context.Context) error {
+	if c.down {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (pingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (pingConn) Close() error                        { return nil }
+func (pingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("health-ping", pingDriver{})
+}
+
+// redisServer отвечает PONG на PING и OK на остальное; HELLO не поддерживается, клиент переходит на RESP2
+func redisServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveRESP(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveRESP(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var args []string
+		line, err := r.ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, "*") {
+			return
+		}
+		n := 0
+		for _, c := range strings.TrimSpace(line[1:]) {
+			n = n*10 + int(c-'0')
+		}
+		for i := 0; i < n; i++ {
+			if _, err := r.ReadString('\n'); err != nil { // $<длина>
+				return
+			}
+			arg, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			args = append(args, strings.TrimSpace(arg))
+		}
+		reply := "+OK\r\n"
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "HELLO":
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// bankServer выдаёт токен или отвечает 500; /accounts всегда отвечает 500
+func bankServer(t *testing.T, ok bool) *bankapi.BankClient {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/bank-token", func(w http.ResponseWriter, r *http.Request) {
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"access_token":"token"}`))
+	})
+	mux.HandleFunc("/accounts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &bankapi.BankClient{Name: "test", BaseURL: srv.URL, ClientID: "team-1", ClientSecret: "secret"}
+}
+
+func TestCheck(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	redisAddr := redisServer(t)
+
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	running := poller.NewPoller(nil, nil, nil, nil, nil, logger)
+	running.Start(time.Hour, stop)
+
+	tests := []struct {
+		name       string
+		postgres   string
+		redis      string
+		poller     *poller.Poller
+		stale      time.Duration
+		bank       bool
+		breaker    bool
+		want       string
+		wantChecks map[string]string
+		wantBank   string
+	}{
+		{
+			name: "ok", postgres: "ok", redis: redisAddr, poller: running, stale: time.Minute, bank: true,
+			want: StatusOK, wantChecks: map[string]string{"postgres": StatusOK, "redis": StatusOK, "poller": StatusOK}, wantBank: StatusOK,
+		},
+		{
+			// поллер не отчитывался дольше PollerStaleAfter: API работает, согласия не обновляются
+			name: "poller stale", postgres: "ok", redis: redisAddr, poller: running, stale: time.Nanosecond, bank: true,
+			want: StatusDegraded, wantChecks: map[string]string{"poller": StatusDegraded}, wantBank: StatusOK,
+		},
+		{
+			name: "poller not configured", postgres: "ok", redis: redisAddr, stale: time.Minute, bank: true,
+			want: StatusDegraded, wantChecks: map[string]string{"poller": StatusDown}, wantBank: StatusOK,
+		},
+		{
+			name: "bank token unavailable", postgres: "ok", redis: redisAddr, poller: running, stale: time.Minute,
+			want: StatusDegraded, wantChecks: map[string]string{"postgres": StatusOK, "redis": StatusOK}, wantBank: StatusDown,
+		},
+		{
+			name: "bank breaker open", postgres: "ok", redis: redisAddr, poller: running, stale: time.Minute, bank: true, breaker: true,
+			want: StatusDegraded, wantBank: StatusDegraded,
+		},
+		{
+			name: "postgres down", postgres: "down", redis: redisAddr, poller: running, stale: time.Minute, bank: true,
+			want: StatusDown, wantChecks: map[string]string{"postgres": StatusDown, "redis": StatusOK},
+		},
+		{
+			name: "redis down", postgres: "ok", redis: "127.0.0.1:1", poller: running, stale: time.Minute, bank: true,
+			want: StatusDown, wantChecks: map[string]string{"postgres": StatusOK, "redis": StatusDown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("health-ping", tt.postgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+			rdb := redis.NewClient(&redis.Options{Addr: tt.redis, MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+			t.Cleanup(func() { rdb.Close() })
+
+			// Redis токенов недоступен: токен каждый раз берётся у тестового банка
+			tokenRedis := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+			t.Cleanup(func() { tokenRedis.Close() })
+			banks := map[string]*bankapi.BankClient{"vbank": bankServer(t, tt.bank)}
+			cfg := bankapi.DefaultTransportConfig()
+			cfg.Defaults.BreakerThreshold = 1
+			transport := bankapi.NewTransport(cfg, banks, nil, logger)
+			if tt.breaker {
+				// порог 1: один ответ 5xx размыкает предохранитель
+				resp, err := bankapi.NewHTTPClient(transport).Get(banks["vbank"].BaseURL + "/accounts")
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+
+			s := NewService(db, rdb, tt.poller, bankapi.NewTokenService(tokenRedis, logger), transport, banks,
+				Config{Timeout: time.Second, PollerStaleAfter: tt.stale, BankCacheTTL: time.Minute}, logger)
+			r := s.Check(context.Background())
+			if r.Status != tt.want {
+				t.Errorf("status = %s, want %s (checks %+v, banks %+v)", r.Status, tt.want, r.Checks, r.Banks)
+			}
+			for name, want := range tt.wantChecks {
+				if got := r.Checks[name].Status; got != want {
+					t.Errorf("%s = %s (%s), want %s", name, got, r.Checks[name].Error, want)
+				}
+			}
+			if tt.wantBank != "" && r.Banks["vbank"].Status != tt.wantBank {
+				t.Errorf("vbank = %+v, want %s", r.Banks["vbank"], tt.wantBank)
+			}
+		})
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	redisAddr := redisServer(t)
+
+	// без поллера и банков: ok Postgres и Redis дают degraded и 200, недоступный Postgres — down и 503
+	tests := map[string]int{"ok": http.StatusOK, "down": http.StatusServiceUnavailable}
+	for dsn, want := range tests {
+		db, err := sql.Open("health-ping", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		rdb := redis.NewClient(&redis.Options{Addr: redisAddr})
+		defer rdb.Close()
+
+		s := NewService(db, rdb, nil, nil, nil, nil, Config{Timeout: time.Second, PollerStaleAfter: time.Minute}, logger)
+		r := gin.New()
+		r.GET("/readyz", NewHandler(s).Readyz)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if w.Code != want {
+			t.Errorf("postgres %s: status %d, want %d: %s", dsn, w.Code, want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Mapping — соответствие колонок CSV полям операции; колонки задаются именами из заголовка.
+// Сумма — либо одна колонка amount со знаком, либо пара debit/credit.
+type Mapping struct {
+	Delimiter    string   `json:"delimiter"` // пусто — ";", "," или табуляция по заголовку
+	SkipRows     int      `json:"skip_rows"` // строки перед заголовком
+	Date         string   `json:"date"`
+	DateFormats  []string `json:"date_formats"` // layout'ы Go; пусто — распространённые форматы
+	Amount       string   `json:"amount"`
+	Debit        string   `json:"debit"`
+	Credit       string   `json:"credit"`
+	Invert       bool     `json:"invert"` // в файле расход положительный
+	Currency     string   `json:"currency"`
+	Description  string   `json:"description"`
+	Counterparty string   `json:"counterparty"`
+	MCC          string   `json:"mcc"`
+	ID           string   `json:"id"`
+	Status       string   `json:"status"`
+	SkipStatuses []string `json:"skip_statuses"` // строки с таким статусом не импортируются
+}
+
+var defaultDateFormats = []string{
+	"2006-01-02", "2006-01-02 15:04:05", time.RFC3339,
+	"02.01.2006", "02.01.2006 15:04:05", "02.01.2006 15:04", "02.01.06",
+	"02/01/2006",
+}
+
+// Presets — готовые сопоставления для распространённых выгрузок
+var Presets = map[string]*Mapping{
+	// date,amount,currency,description,counterparty,mcc,id — формат выгрузки самого MoneyPilot
+	"generic": {
+		Date: "date", Amount: "amount", Currency: "currency", Description: "description",
+		Counterparty: "counterparty", MCC: "mcc", ID: "id",
+	},
+	// раздельные колонки расхода и прихода, как в большинстве выписок интернет-банков
+	"debit_credit": {
+		Date: "date", Debit: "debit", Credit: "credit", Currency: "currency", Description: "description",
+		Counterparty: "counterparty",
+	},
+	"tinkoff": {
+		Delimiter: ";", Date: "Дата операции", Amount: "Сумма операции", Currency: "Валюта операции",
+		Description: "Описание", Counterparty: "Описание", MCC: "MCC",
+		Status: "Статус", SkipStatuses: []string{"FAILED"},
+		DateFormats: []string{"02.01.2006 15:04:05", "02.01.2006"},
+	},
+	"sberbank": {
+		Delimiter: ";", Date: "Дата операции", Debit: "Сумма списания", Credit: "Сумма зачисления",
+		Currency: "Валюта", Description: "Описание операции", Counterparty: "Контрагент",
+		DateFormats: []string{"02.01.2006", "02.01.2006 15:04"},
+	},
+}
+
+func parseCSV(data []byte, m *Mapping) (*Statement, error) {
+	lines := bytes.SplitN(data, []byte("\n"), m.SkipRows+2)
+	if len(lines) < m.SkipRows+1 {
+		return nil, fmt.Errorf("%w: file is shorter than skip_rows", ErrInvalid)
+	}
+	body := bytes.Join(lines[m.SkipRows:], []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(body))
+	r.Comma = delimiter(m.Delimiter, lines[m.SkipRows])
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalid, err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	col := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		if i, ok := cols[strings.ToLower(name)]; ok {
+			return i
+		}
+		return -1
+	}
+	date, amount, debit, credit := col(m.Date), col(m.Amount), col(m.Debit), col(m.Credit)
+	if date < 0 {
+		return nil, fmt.Errorf("%w: date column %q not found", ErrInvalid, m.Date)
+	}
+	if amount < 0 && debit < 0 && credit < 0 {
+		return nil, fmt.Errorf("%w: amount column not found", ErrInvalid)
+	}
+	currency, description, counterparty, mcc, id, status :=
+		col(m.Currency), col(m.Description), col(m.Counterparty), col(m.MCC), col(m.ID), col(m.Status)
+	formats := m.DateFormats
+	if len(formats) == 0 {
+		formats = defaultDateFormats
+	}
+
+	st := &Statement{}
+	for line := m.SkipRows + 2; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalid, line, err)
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+		if strings.Join(rec, "") == "" {
+			continue
+		}
+		if s := field(status); s != "" && contains(m.SkipStatuses, s) {
+			continue
+		}
+
+		row := Row{
+			ID:           field(id),
+			Currency:     strings.ToUpper(field(currency)),
+			Description:  field(description),
+			Counterparty: field(counterparty),
+			MCC:          field(mcc),
+		}
+		if row.Date, err = parseDate(field(date), formats...); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalid, line, err)
+		}
+		if amount >= 0 {
+			row.Amount, err = parseAmount(field(amount))
+		} else {
+			row.Amount, err = debitCredit(field(debit), field(credit))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: amount: %v", ErrInvalid, line, err)
+		}
+		if m.Invert {
+			row.Amount = -row.Amount
+		}
+		st.Rows = append(st.Rows, row)
+	}
+	return st, nil
+}
+
+// debitCredit — сумма из пары колонок: списание уменьшает остаток
+func debitCredit(debit, credit string) (float64, error) {
+	var total float64
+	if debit != "" {
+		v, err := parseAmount(debit)
+		if err != nil {
+			return 0, err
+		}
+		if v > 0 {
+			v = -v
+		}
+		total += v
+	}
+	if credit != "" {
+		v, err := parseAmount(credit)
+		if err != nil {
+			return 0, err
+		}
+		if v < 0 {
+			v = -v
+		}
+		total += v
+	}
+	if debit == "" && credit == "" {
+		return 0, fmt.Errorf("both debit and credit are empty")
+	}
+	return total, nil
+}
+
+func delimiter(configured string, header []byte) rune {
+	if configured == `\t` || configured == "tab" {
+		return '\t'
+	}
+	if configured != "" {
+		return []rune(configured)[0]
+	}
+	best, count := ',', bytes.Count(header, []byte(","))
+	for _, c := range []rune{';', '\t'} {
+		if n := bytes.Count(header, []byte(string(c))); n > count {
+			best, count = c, n
+		}
+	}
+	return best
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
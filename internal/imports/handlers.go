@@ -0,0 +1,123 @@
+package imports
+
+import (
+	"MoneyPilot/internal/audit"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFileSize — ограничение на размер файла выписки
+const maxFileSize = 10 << 20
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Import — POST /api/imports (multipart/form-data)
+// file — выписка; format=csv|ofx|1c (пусто — по содержимому); для CSV preset и/или mapping (JSON, поверх preset);
+// account_id — загрузить в существующий ручной счёт; name, institution, currency — для нового счёта.
+// Если в файле нет номера счёта, нужен account_id, name или institution: по банку, валюте и названию повторный импорт находит тот же счёт.
+func (h *Handler) Import(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required", "details": err.Error()})
+		return
+	}
+	if file.Size > maxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+		return
+	}
+	mapping, ok := parseMapping(c)
+	if !ok {
+		return
+	}
+	req := Request{
+		Name:        c.PostForm("name"),
+		Institution: c.PostForm("institution"),
+		Currency:    c.PostForm("currency"),
+	}
+	if v := c.PostForm("account_id"); v != "" {
+		if req.AccountID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file", "details": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file", "details": err.Error()})
+		return
+	}
+
+	st, err := Parse(data, strings.ToLower(c.PostForm("format")), mapping)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	res, err := h.Service.Import(audit.WithUserID(c.Request.Context(), userID), userID, st, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// ListPresets — GET /api/imports/presets: готовые сопоставления колонок CSV
+func (h *Handler) ListPresets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"presets": Presets})
+}
+
+func (h *Handler) error(c *gin.Context, err error) {
+	if errors.Is(err, ErrInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import statement", "details": err.Error()})
+}
+
+// parseMapping — preset с полями из mapping поверх; nil, если ни то, ни другое не задано
+func parseMapping(c *gin.Context) (*Mapping, bool) {
+	name, raw := c.PostForm("preset"), c.PostForm("mapping")
+	if name == "" && raw == "" {
+		return nil, true
+	}
+	m := Mapping{}
+	if name != "" {
+		preset, ok := Presets[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown preset " + name})
+			return nil, false
+		}
+		m = *preset
+		m.DateFormats = append([]string(nil), preset.DateFormats...)
+		m.SkipStatuses = append([]string(nil), preset.SkipStatuses...)
+	}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &m); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mapping", "details": err.Error()})
+			return nil, false
+		}
+	}
+	return &m, true
+}
@@ -0,0 +1,157 @@
+package imports
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseOFX читает OFX 1.x (SGML, без закрывающих тегов у полей) и OFX 2.x (XML).
+// В файле ожидается выписка по одному счёту.
+func parseOFX(data []byte) (*Statement, error) {
+	st := &Statement{}
+	var (
+		row        *Row
+		statements int
+		path       []string
+	)
+	parent := func() string {
+		if len(path) == 0 {
+			return ""
+		}
+		return path[len(path)-1]
+	}
+
+	for rest := data; ; {
+		i := bytes.IndexByte(rest, '<')
+		if i < 0 {
+			break
+		}
+		rest = rest[i+1:]
+		j := bytes.IndexByte(rest, '>')
+		if j < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(string(rest[:j])))
+		rest = rest[j+1:]
+		if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+			continue
+		}
+		end := bytes.IndexByte(rest, '<')
+		if end < 0 {
+			end = len(rest)
+		}
+		value := strings.TrimSpace(string(rest[:end]))
+
+		if name, ok := strings.CutPrefix(tag, "/"); ok {
+			// закрываем агрегат вместе с незакрытыми SGML-полями внутри
+			for k := len(path) - 1; k >= 0; k-- {
+				if path[k] == name {
+					path = path[:k]
+					break
+				}
+			}
+			if name == "STMTTRN" && row != nil {
+				st.Rows = append(st.Rows, *row)
+				row = nil
+			}
+			continue
+		}
+		if value == "" {
+			// агрегат
+			path = append(path, tag)
+			switch tag {
+			case "STMTRS", "CCSTMTRS":
+				if statements++; statements > 1 {
+					return nil, fmt.Errorf("%w: file contains several accounts, import them one by one", ErrInvalid)
+				}
+			case "STMTTRN":
+				row = &Row{}
+			}
+			continue
+		}
+
+		var err error
+		switch {
+		case row != nil:
+			err = row.setOFX(tag, value)
+		case tag == "ACCTID":
+			st.AccountNumber = value
+		case tag == "CURDEF":
+			st.Currency = strings.ToUpper(value)
+		case tag == "ORG" && st.Institution == "":
+			st.Institution = value
+		case tag == "DTSTART":
+			st.From, err = parseOFXDate(value)
+		case tag == "DTEND":
+			st.To, err = parseOFXDate(value)
+		case tag == "BALAMT":
+			var v float64
+			if v, err = parseAmount(value); err == nil && parent() == "LEDGERBAL" {
+				st.ClosingBalance = &v
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, tag, err)
+		}
+	}
+	if statements == 0 {
+		return nil, fmt.Errorf("%w: no bank or card statement in OFX", ErrInvalid)
+	}
+	return st, nil
+}
+
+func (r *Row) setOFX(tag, value string) error {
+	var err error
+	switch tag {
+	case "FITID":
+		r.ID = value
+	case "DTPOSTED":
+		r.Date, err = parseOFXDate(value)
+	case "TRNAMT":
+		r.Amount, err = parseAmount(value)
+	case "NAME":
+		r.Counterparty = value
+	case "MEMO":
+		r.Description = value
+	case "SIC":
+		r.MCC = value
+	case "CURSYM":
+		r.Currency = strings.ToUpper(value)
+	}
+	return err
+}
+
+// parseOFXDate — YYYYMMDD[HHMMSS[.XXX]][[+-]H[:TZ]]; без зоны время считается UTC
+func parseOFXDate(v string) (time.Time, error) {
+	offset := 0
+	if i := strings.IndexByte(v, '['); i >= 0 {
+		tz := strings.TrimSuffix(v[i+1:], "]")
+		if k := strings.IndexByte(tz, ':'); k >= 0 {
+			tz = tz[:k]
+		}
+		h, err := strconv.ParseFloat(tz, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad time zone in %q", v)
+		}
+		offset = int(h * 3600)
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, '.'); i >= 0 {
+		v = v[:i]
+	}
+	layout := "20060102150405"
+	if len(v) < len(layout) {
+		if len(v) < 8 {
+			return time.Time{}, fmt.Errorf("bad date %q", v)
+		}
+		layout = layout[:len(v)]
+	}
+	t, err := time.ParseInLocation(layout, v, time.FixedZone("", offset))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
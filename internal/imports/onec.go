@@ -0,0 +1,113 @@
+package imports
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parse1C читает выписку 1CClientBankExchange (kl_to_1c.txt): строки Ключ=Значение,
+// остатки в СекцияРасчСчет и платёжные документы в СекцияДокумент … КонецДокумента.
+func parse1C(data []byte) (*Statement, error) {
+	st := &Statement{Currency: "RUB"}
+	var (
+		doc     map[string]string
+		docs    []map[string]string
+		section bool
+	)
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		key, value, _ := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch {
+		case key == "СекцияДокумент":
+			doc = map[string]string{"Вид": value}
+		case key == "КонецДокумента":
+			if doc != nil {
+				docs = append(docs, doc)
+			}
+			doc = nil
+		case doc != nil:
+			doc[key] = value
+		case key == "СекцияРасчСчет":
+			section = true
+		case key == "КонецРасчСчет":
+			section = false
+		case key == "РасчСчет":
+			if st.AccountNumber != "" && st.AccountNumber != value {
+				return nil, fmt.Errorf("%w: file contains several accounts, import them one by one", ErrInvalid)
+			}
+			st.AccountNumber = value
+		case section && key == "НачальныйОстаток":
+			if v, err := parseAmount(value); err == nil && st.OpeningBalance == nil {
+				st.OpeningBalance = &v
+			}
+		case section && key == "КонечныйОстаток":
+			// при нескольких секциях (по дням) последняя — остаток на конец выписки
+			if v, err := parseAmount(value); err == nil {
+				st.ClosingBalance = &v
+			}
+		case key == "ДатаНачала" && st.From.IsZero():
+			st.From, _ = parseDate(value, "02.01.2006")
+		case key == "ДатаКонца":
+			st.To, _ = parseDate(value, "02.01.2006")
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if st.AccountNumber == "" {
+		return nil, fmt.Errorf("%w: РасчСчет is missing", ErrInvalid)
+	}
+
+	for i, d := range docs {
+		row, err := st.row1C(d)
+		if err != nil {
+			return nil, fmt.Errorf("%w: document %d: %v", ErrInvalid, i+1, err)
+		}
+		st.Rows = append(st.Rows, row)
+	}
+	return st, nil
+}
+
+// row1C — направление определяется по счёту плательщика, контрагент — другая сторона платежа
+func (st *Statement) row1C(d map[string]string) (Row, error) {
+	amount, err := parseAmount(d["Сумма"])
+	if err != nil {
+		return Row{}, err
+	}
+	debit := d["ПлательщикСчет"] == st.AccountNumber
+	if d["ПлательщикСчет"] != st.AccountNumber && d["ПолучательСчет"] != st.AccountNumber {
+		debit = d["ДатаСписано"] != ""
+	}
+
+	row := Row{Description: d["НазначениеПлатежа"]}
+	date := d["ДатаПоступило"]
+	if debit {
+		amount = -amount
+		date = d["ДатаСписано"]
+		row.Counterparty = first(d["Получатель1"], d["Получатель"])
+	} else {
+		row.Counterparty = first(d["Плательщик1"], d["Плательщик"])
+	}
+	row.Amount = amount
+	if row.Date, err = parseDate(first(date, d["Дата"]), "02.01.2006"); err != nil {
+		return Row{}, err
+	}
+	row.ID = fmt.Sprintf("%s/%s/%s/%s", d["Вид"], d["Номер"], d["Дата"], d["Сумма"])
+	return row, nil
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
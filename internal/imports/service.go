@@ -0,0 +1,331 @@
+package imports
+
+import (
+	"MoneyPilot/internal/categories"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/manualaccounts"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/transactions"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Service импортирует выписки банков без API в ручные счета клиента
+type Service struct {
+	Repo       storage.Store
+	History    storage.BalanceRepository
+	Categories *categories.Service
+	Syncer     *transactions.Syncer
+	FX         *fx.Service
+}
+
+func NewService(repo storage.Store, history storage.BalanceRepository, categorySvc *categories.Service, syncer *transactions.Syncer, fxSvc *fx.Service) *Service {
+	return &Service{Repo: repo, History: history, Categories: categorySvc, Syncer: syncer, FX: fxSvc}
+}
+
+// Request — параметры импорта; файл разбирается до вызова Import
+type Request struct {
+	AccountID   int    // 0 — ручной счёт ищется по номеру из выписки или создаётся
+	Name        string // название нового счёта
+	Institution string // банк нового счёта, если его нет в файле
+	Currency    string // валюта нового счёта, если её нет в файле
+}
+
+type Result struct {
+	Account        storage.Account `json:"account"`
+	AccountCreated bool            `json:"account_created"`
+	Format         string          `json:"format"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	Total          int             `json:"total"`
+	Imported       int             `json:"imported"`
+	Duplicates     int             `json:"duplicates"`
+}
+
+// Import сохраняет операции выписки в ручной счёт, пропуская уже загруженные.
+// Остаток счёта берётся из выписки, если она не старше уже загруженных операций,
+// иначе к нему прибавляются новые операции.
+func (s *Service) Import(ctx context.Context, userID int, st *Statement, req Request) (*Result, error) {
+	acc, created, err := s.account(userID, st, req)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := s.Categories.Matcher(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{AccountCreated: created, Format: st.Format, From: st.From, To: st.To, Total: len(st.Rows)}
+
+	txs := s.transactions(acc, st, matcher)
+	var existing []storage.Transaction
+	var last *time.Time
+	if !created {
+		if existing, err = s.Repo.GetTransactionsByAccountIDs([]int{acc.ID}, day(st.From), day(st.To).AddDate(0, 0, 1)); err != nil {
+			return nil, err
+		}
+		if last, err = s.Repo.LastBookingDate(acc.ID); err != nil {
+			return nil, err
+		}
+	}
+	candidates, dups := dedupe(txs, existing)
+
+	var (
+		fresh []storage.Transaction
+		snaps []storage.BalanceSnapshot
+	)
+	err = s.Repo.WithTx(ctx, func(tx storage.Store) error {
+		if created {
+			if err := tx.UpsertAccount(acc); err != nil {
+				return err
+			}
+			for i := range candidates {
+				candidates[i].AccountID = acc.ID
+			}
+		}
+		for _, t := range candidates {
+			inserted, err := tx.InsertTransactionIfNew(&t)
+			if err != nil {
+				return err
+			}
+			if !inserted {
+				dups++
+				continue
+			}
+			fresh = append(fresh, t)
+		}
+		snaps, err = s.updateBalance(tx, acc, st, fresh, last, created)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// срезы пишутся после коммита: новый счёт до него не виден вне транзакции
+	for _, snap := range snaps {
+		if err := s.History.InsertBalanceSnapshot(&snap); err != nil {
+			return nil, err
+		}
+	}
+
+	s.Syncer.Notify(ctx, userID, fresh)
+	res.Account = *acc
+	res.Imported, res.Duplicates = len(fresh), dups
+	return res, nil
+}
+
+// account — ручной счёт клиента для выписки: заданный явно, найденный по номеру или новый (ещё не сохранённый)
+func (s *Service) account(userID int, st *Statement, req Request) (*storage.Account, bool, error) {
+	user, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if req.AccountID != 0 {
+		for i, a := range accs {
+			if a.ID != req.AccountID {
+				continue
+			}
+			if a.Source != storage.SourceManual {
+				return nil, false, fmt.Errorf("%w: account %d is connected via bank API", ErrInvalid, a.ID)
+			}
+			return &accs[i], false, nil
+		}
+		return nil, false, fmt.Errorf("%w: account %d not found", ErrInvalid, req.AccountID)
+	}
+
+	currency := strings.ToUpper(first(req.Currency, st.Currency))
+	if currency == "" {
+		if currency, err = s.FX.BaseCurrency(userID); err != nil {
+			return nil, false, err
+		}
+	}
+	if !fx.ValidCurrency(currency) {
+		return nil, false, fmt.Errorf("%w: invalid currency %q", ErrInvalid, currency)
+	}
+	name := strings.TrimSpace(req.Name)
+	inst := strings.TrimSpace(first(req.Institution, st.Institution))
+
+	key := st.AccountNumber
+	if key == "" {
+		key, err = fingerprint(inst, currency, name)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	number := manualaccounts.Number(user.ClientID, key)
+	for i, a := range accs {
+		switch {
+		case a.AccountNumber == number:
+			return &accs[i], false, nil
+		case st.AccountNumber != "" && a.AccountNumber == st.AccountNumber:
+			return nil, false, fmt.Errorf("%w: account %s is connected via bank API", ErrInvalid, st.AccountNumber)
+		}
+	}
+
+	bank, err := s.Repo.GetBankByCode(storage.ManualBankCode)
+	if err != nil {
+		return nil, false, fmt.Errorf("bank %s: %w", storage.ManualBankCode, err)
+	}
+
+	acc := &storage.Account{
+		UserID:        userID,
+		BankID:        bank.ID,
+		AccountNumber: number,
+		AccountType:   "checking",
+		Currency:      currency,
+		Status:        "active",
+		Source:        storage.SourceManual,
+	}
+	if name != "" {
+		acc.Nickname = &name
+	}
+	if inst != "" {
+		acc.Institution = &inst
+	}
+	return acc, true, nil
+}
+
+// fingerprint заменяет номер счёта, если его нет в файле (большинство CSV и QIF):
+// повторный импорт с тем же банком, валютой и названием попадает в тот же счёт и проходит дедупликацию
+func fingerprint(institution, currency, name string) (string, error) {
+	if institution == "" && name == "" {
+		return "", fmt.Errorf("%w: statement has no account number, pass account_id or name", ErrInvalid)
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(institution + "|" + currency + "|" + name)))
+	return "import-" + hex.EncodeToString(sum[:6]), nil
+}
+
+// transactions переводит строки выписки в операции счёта с категориями.
+// Строкам без идентификатора в файле он строится из даты, суммы и описания;
+// порядковый номер различает одинаковые операции одного дня.
+func (s *Service) transactions(acc *storage.Account, st *Statement, matcher *categories.Matcher) []storage.Transaction {
+	seen := map[string]int{}
+	txs := make([]storage.Transaction, 0, len(st.Rows))
+	for _, r := range st.Rows {
+		id := r.ID
+		if id == "" {
+			key := fmt.Sprintf("%s|%.2f|%s|%s", r.Date.Format("2006-01-02"), r.Amount, r.Description, r.Counterparty)
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, seen[key])))
+			seen[key]++
+			id = hex.EncodeToString(sum[:12])
+		}
+		id = st.Format + ":" + id
+
+		t := storage.Transaction{
+			AccountID:   acc.ID,
+			ExternalID:  &id,
+			Amount:      money.Round(r.Amount),
+			BookingDate: r.Date,
+			Currency:    &acc.Currency,
+		}
+		if r.Currency != "" {
+			cur := r.Currency
+			t.Currency = &cur
+		}
+		t.Description = optional(r.Description)
+		t.Counterparty = optional(r.Counterparty)
+		t.MCC = optional(r.MCC)
+		matcher.Apply(&t)
+		txs = append(txs, t)
+	}
+	return txs
+}
+
+// updateBalance обновляет остаток счёта и возвращает срезы для истории балансов (net worth)
+func (s *Service) updateBalance(tx storage.Store, acc *storage.Account, st *Statement, fresh []storage.Transaction, last *time.Time, created bool) ([]storage.BalanceSnapshot, error) {
+	end := day(st.To).AddDate(0, 0, 1).Add(-time.Second)
+	var snaps []storage.BalanceSnapshot
+	if created && st.OpeningBalance != nil {
+		// новый счёт начинается с входящего остатка выписки, операции сдвигают его
+		acc.Balance = *st.OpeningBalance
+		snaps = append(snaps, s.snapshot(acc, *st.OpeningBalance, day(st.From)))
+	}
+
+	switch {
+	case st.ClosingBalance != nil:
+		snaps = append(snaps, s.snapshot(acc, *st.ClosingBalance, end))
+		// выписка за прошлый период не меняет текущий остаток
+		if last == nil || !last.After(end) {
+			acc.Balance = *st.ClosingBalance
+		}
+	case len(fresh) > 0:
+		for _, t := range fresh {
+			acc.Balance += t.Amount
+		}
+		acc.Balance = money.Round(acc.Balance)
+		snaps = append(snaps, s.snapshot(acc, acc.Balance, time.Now()))
+	case !created || st.OpeningBalance == nil:
+		return snaps, nil
+	}
+	if err := tx.UpsertAccount(acc); err != nil {
+		return nil, err
+	}
+	for i := range snaps {
+		snaps[i].AccountID = acc.ID
+	}
+	return snaps, nil
+}
+
+func (s *Service) snapshot(acc *storage.Account, balance float64, at time.Time) storage.BalanceSnapshot {
+	return storage.BalanceSnapshot{AccountID: acc.ID, Available: &balance, Booked: &balance, Currency: &acc.Currency, TakenAt: at}
+}
+
+// dedupe отбрасывает операции, которые уже есть на счёте: с тем же идентификатором
+// или — для строк, загруженных из другого файла или формата, — с той же датой и суммой
+func dedupe(txs, existing []storage.Transaction) ([]storage.Transaction, int) {
+	incoming := map[string]bool{}
+	for _, t := range txs {
+		incoming[*t.ExternalID] = true
+	}
+	known := map[string]bool{}
+	loose := map[string]int{}
+	for _, e := range existing {
+		if e.ExternalID != nil {
+			known[*e.ExternalID] = true
+			if incoming[*e.ExternalID] {
+				continue
+			}
+		}
+		loose[looseKey(e)]++
+	}
+
+	fresh := make([]storage.Transaction, 0, len(txs))
+	dups := 0
+	for _, t := range txs {
+		if known[*t.ExternalID] {
+			dups++
+			continue
+		}
+		if k := looseKey(t); loose[k] > 0 {
+			loose[k]--
+			dups++
+			continue
+		}
+		fresh = append(fresh, t)
+	}
+	return fresh, dups
+}
+
+func looseKey(t storage.Transaction) string {
+	return fmt.Sprintf("%s|%d", t.BookingDate.Format("2006-01-02"), int64(math.Round(t.Amount*100)))
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func optional(v string) *string {
+	if v = strings.TrimSpace(v); v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,147 @@
+package imports
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	Format1C  = "1c"
+)
+
+var ErrInvalid = errors.New("invalid statement")
+
+// Statement — выписка по одному счёту, разобранная из файла
+type Statement struct {
+	Format         string
+	AccountNumber  string
+	Currency       string
+	Institution    string
+	From, To       time.Time
+	OpeningBalance *float64
+	ClosingBalance *float64
+	Rows           []Row
+}
+
+// Row — операция выписки; расход — отрицательная сумма
+type Row struct {
+	ID           string // идентификатор операции в файле, если формат его даёт (FITID в OFX)
+	Date         time.Time
+	Amount       float64
+	Currency     string
+	Description  string
+	Counterparty string
+	MCC          string
+}
+
+// Parse разбирает файл выписки; пустой format определяется по содержимому
+func Parse(data []byte, format string, mapping *Mapping) (*Statement, error) {
+	data = decode(data)
+	if format == "" {
+		format = Detect(data)
+	}
+	var (
+		st  *Statement
+		err error
+	)
+	switch format {
+	case FormatCSV:
+		if mapping == nil {
+			mapping = Presets["generic"]
+		}
+		st, err = parseCSV(data, mapping)
+	case FormatOFX:
+		st, err = parseOFX(data)
+	case Format1C:
+		st, err = parse1C(data)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalid, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(st.Rows) == 0 {
+		return nil, fmt.Errorf("%w: no transactions found", ErrInvalid)
+	}
+	st.Format = format
+	st.period()
+	return st, nil
+}
+
+// Detect угадывает формат по началу файла
+func Detect(data []byte) string {
+	head := data
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(head), []byte("1CClientBankExchange")):
+		return Format1C
+	case bytes.Contains(head, []byte("OFXHEADER")) || bytes.Contains(bytes.ToUpper(head), []byte("<OFX>")):
+		return FormatOFX
+	default:
+		return FormatCSV
+	}
+}
+
+// period дополняет границы выписки датами операций
+func (st *Statement) period() {
+	for _, r := range st.Rows {
+		if st.From.IsZero() || r.Date.Before(st.From) {
+			st.From = r.Date
+		}
+		if st.To.IsZero() || r.Date.After(st.To) {
+			st.To = r.Date
+		}
+	}
+}
+
+// decode переводит файл в UTF-8: выгрузки российских банков и 1С обычно в Windows-1251
+func decode(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return data
+	}
+	if out, err := charmap.Windows1251.NewDecoder().Bytes(data); err == nil {
+		return out
+	}
+	return data
+}
+
+// parseAmount понимает "1 234,56", "-1234.56", "1,234.56" и "+500"
+func parseAmount(v string) (float64, error) {
+	v = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "'", "").Replace(strings.TrimSpace(v))
+	if v == "" {
+		return 0, errors.New("empty amount")
+	}
+	comma, dot := strings.LastIndex(v, ","), strings.LastIndex(v, ".")
+	switch {
+	case comma >= 0 && dot >= 0 && comma > dot:
+		v = strings.ReplaceAll(v, ".", "")
+		v = strings.Replace(v, ",", ".", 1)
+	case comma >= 0 && dot >= 0:
+		v = strings.ReplaceAll(v, ",", "")
+	case comma >= 0:
+		v = strings.Replace(v, ",", ".", 1)
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+func parseDate(v string, layouts ...string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	for _, l := range layouts {
+		if t, err := time.Parse(l, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", v)
+}
@@ -0,0 +1,462 @@
+package imports
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		err  bool
+	}{
+		{"1234.56", 1234.56, false},
+		{"-1234.56", -1234.56, false},
+		{"+500", 500, false},
+		{"1 234,56", 1234.56, false},
+		{"1\u00a0234,56", 1234.56, false},
+		{"1,234.56", 1234.56, false},
+		{"1.234,56", 1234.56, false},
+		{"1'234.56", 1234.56, false},
+		{"", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseAmount(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAmount(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseOFXDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"20240315", date("2024-03-15 00:00:00"), false},
+		{"20240315120000", date("2024-03-15 12:00:00"), false},
+		{"20240315120000.000", date("2024-03-15 12:00:00"), false},
+		{"20240315120000[+3:MSK]", date("2024-03-15 09:00:00"), false},
+		{"20240315120000.123[-5:EST]", date("2024-03-15 17:00:00"), false},
+		{"20240315000000[0:GMT]", date("2024-03-15 00:00:00"), false},
+		{"20240315093000[+5.5:IST]", date("2024-03-15 04:00:00"), false},
+		{"202403", time.Time{}, true},
+		{"20241315", time.Time{}, true},
+		{"20240315[x:MSK]", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseOFXDate(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseOFXDate(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseOFXDate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1CClientBankExchange\nВерсияФормата=1.03", Format1C},
+		{"OFXHEADER:100\nDATA:OFXSGML", FormatOFX},
+		{`<?xml version="1.0"?><ofx>`, FormatOFX},
+		{"date,amount\n2024-01-01,10", FormatCSV},
+	}
+	for _, tt := range tests {
+		if got := Detect([]byte(tt.in)); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		mapping *Mapping
+		want    []Row
+		err     bool
+	}{
+		{
+			name: "generic preset",
+			data: "date,amount,currency,description,counterparty,mcc,id\n" +
+				"2024-03-01,-150.50,rub,Coffee,Cafe,5814,t1\n" +
+				"\n" +
+				"2024-03-02,1000,RUB,Salary,ACME,,t2\n",
+			want: []Row{
+				{ID: "t1", Date: date("2024-03-01 00:00:00"), Amount: -150.5, Currency: "RUB", Description: "Coffee", Counterparty: "Cafe", MCC: "5814"},
+				{ID: "t2", Date: date("2024-03-02 00:00:00"), Amount: 1000, Currency: "RUB", Description: "Salary", Counterparty: "ACME"},
+			},
+		},
+		{
+			name: "debit and credit columns",
+			data: "date;debit;credit;currency;description;counterparty\n" +
+				"01.03.2024;150,50;;RUB;Coffee;Cafe\n" +
+				"02.03.2024;;1 000,00;RUB;Salary;ACME\n",
+			mapping: Presets["debit_credit"],
+			want: []Row{
+				{Date: date("2024-03-01 00:00:00"), Amount: -150.5, Currency: "RUB", Description: "Coffee", Counterparty: "Cafe"},
+				{Date: date("2024-03-02 00:00:00"), Amount: 1000, Currency: "RUB", Description: "Salary", Counterparty: "ACME"},
+			},
+		},
+		{
+			name: "tinkoff preset skips failed operations",
+			data: "Дата операции;Сумма операции;Валюта операции;Описание;MCC;Статус\n" +
+				"01.03.2024 10:15:00;-150,50;RUB;Кофейня;5814;OK\n" +
+				"01.03.2024 11:00:00;-99,00;RUB;Отказ;5411;FAILED\n",
+			mapping: Presets["tinkoff"],
+			want: []Row{
+				{Date: date("2024-03-01 10:15:00"), Amount: -150.5, Currency: "RUB", Description: "Кофейня", Counterparty: "Кофейня", MCC: "5814"},
+			},
+		},
+		{
+			name:    "skip rows and inverted amounts",
+			data:    "Statement for 2024\nDate\tSum\n2024-03-01\t150.50\n",
+			mapping: &Mapping{SkipRows: 1, Delimiter: "tab", Date: "date", Amount: "sum", Invert: true},
+			want: []Row{
+				{Date: date("2024-03-01 00:00:00"), Amount: -150.5},
+			},
+		},
+		{
+			name:    "missing date column",
+			data:    "when,amount\n2024-03-01,10\n",
+			mapping: Presets["generic"],
+			err:     true,
+		},
+		{
+			name: "bad amount",
+			data: "date,amount\n2024-03-01,ten\n",
+			err:  true,
+		},
+		{
+			name: "bad date",
+			data: "date,amount\n31/31/2024,10\n",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := tt.mapping
+			if mapping == nil {
+				mapping = Presets["generic"]
+			}
+			st, err := parseCSV([]byte(tt.data), mapping)
+			if tt.err {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("error = %v, want ErrInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertRows(t, st.Rows, tt.want)
+		})
+	}
+}
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><FI><ORG>Test Bank</FI></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>usd
+<BANKACCTFROM><ACCTID>40817810000000000001</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20240301
+<DTEND>20240331235959[+3:MSK]
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240305120000[+3:MSK]
+<TRNAMT>-42.10
+<FITID>A1
+<NAME>Grocery
+<MEMO>Weekly shopping
+<SIC>5411
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240310
+<TRNAMT>1000.00
+<FITID>A2
+<NAME>ACME
+<CURRENCY><CURSYM>eur</CURRENCY>
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>2500.55<DTASOF>20240331</LEDGERBAL>
+<AVAILBAL><BALAMT>2400.00<DTASOF>20240331</AVAILBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+    <CURDEF>RUB</CURDEF>
+    <CCACCTFROM><ACCTID>4276</ACCTID></CCACCTFROM>
+    <BANKTRANLIST>
+      <STMTTRN><DTPOSTED>20240302</DTPOSTED><TRNAMT>-1 200,00</TRNAMT><FITID>X1</FITID><NAME>Taxi</NAME></STMTTRN>
+    </BANKTRANLIST>
+  </CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	closing := 2500.55
+	tests := []struct {
+		name string
+		data string
+		want Statement
+		err  bool
+	}{
+		{
+			name: "SGML bank statement",
+			data: ofxSGML,
+			want: Statement{
+				AccountNumber:  "40817810000000000001",
+				Currency:       "USD",
+				Institution:    "Test Bank",
+				From:           date("2024-03-01 00:00:00"),
+				To:             date("2024-03-31 20:59:59"),
+				ClosingBalance: &closing,
+				Rows: []Row{
+					{ID: "A1", Date: date("2024-03-05 09:00:00"), Amount: -42.1, Counterparty: "Grocery", Description: "Weekly shopping", MCC: "5411"},
+					{ID: "A2", Date: date("2024-03-10 00:00:00"), Amount: 1000, Counterparty: "ACME", Currency: "EUR"},
+				},
+			},
+		},
+		{
+			name: "XML card statement",
+			data: ofxXML,
+			want: Statement{
+				AccountNumber: "4276",
+				Currency:      "RUB",
+				Rows: []Row{
+					{ID: "X1", Date: date("2024-03-02 00:00:00"), Amount: -1200, Counterparty: "Taxi"},
+				},
+			},
+		},
+		{
+			name: "several accounts",
+			data: "<OFX><STMTRS><ACCTID>1</STMTRS><STMTRS><ACCTID>2</STMTRS></OFX>",
+			err:  true,
+		},
+		{
+			name: "no statement",
+			data: "<OFX><SIGNONMSGSRSV1></SIGNONMSGSRSV1></OFX>",
+			err:  true,
+		},
+		{
+			name: "bad transaction date",
+			data: "<OFX><STMTRS><STMTTRN><DTPOSTED>2024</STMTTRN></STMTRS></OFX>",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := parseOFX([]byte(tt.data))
+			if tt.err {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("error = %v, want ErrInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStatement(t, st, &tt.want)
+		})
+	}
+}
+
+const statement1C = `1CClientBankExchange
+ВерсияФормата=1.03
+Кодировка=Windows
+ДатаНачала=01.03.2024
+ДатаКонца=31.03.2024
+РасчСчет=40702810000000000001
+СекцияРасчСчет
+ДатаНачала=01.03.2024
+НачальныйОстаток=10000.00
+КонечныйОстаток=9500.00
+КонецРасчСчет
+СекцияРасчСчет
+ДатаНачала=02.03.2024
+НачальныйОстаток=9500.00
+КонечныйОстаток=10500.00
+КонецРасчСчет
+СекцияДокумент=Платежное поручение
+Номер=15
+Дата=01.03.2024
+Сумма=500.00
+ПлательщикСчет=40702810000000000001
+Плательщик=ООО Ромашка
+ПолучательСчет=40702810000000000002
+Получатель=ИНН 7700000000 ООО Поставщик
+Получатель1=ООО Поставщик
+ДатаСписано=01.03.2024
+НазначениеПлатежа=Оплата по счёту 7
+КонецДокумента
+СекцияДокумент=Платежное поручение
+Номер=3
+Дата=29.02.2024
+Сумма=1500.00
+ПлательщикСчет=40702810000000000003
+Плательщик=ООО Клиент
+ПолучательСчет=40702810000000000001
+Получатель=ООО Ромашка
+ДатаПоступило=02.03.2024
+НазначениеПлатежа=Оплата по договору
+КонецДокумента
+КонецФайла
+`
+
+func TestParse1C(t *testing.T) {
+	opening, closing := 10000.0, 10500.0
+	tests := []struct {
+		name string
+		data string
+		want Statement
+		err  bool
+	}{
+		{
+			name: "payments in and out",
+			data: statement1C,
+			want: Statement{
+				AccountNumber:  "40702810000000000001",
+				Currency:       "RUB",
+				From:           date("2024-03-01 00:00:00"),
+				To:             date("2024-03-31 00:00:00"),
+				OpeningBalance: &opening,
+				ClosingBalance: &closing,
+				Rows: []Row{
+					{ID: "Платежное поручение/15/01.03.2024/500.00", Date: date("2024-03-01 00:00:00"), Amount: -500, Description: "Оплата по счёту 7", Counterparty: "ООО Поставщик"},
+					{ID: "Платежное поручение/3/29.02.2024/1500.00", Date: date("2024-03-02 00:00:00"), Amount: 1500, Description: "Оплата по договору", Counterparty: "ООО Клиент"},
+				},
+			},
+		},
+		{
+			name: "several accounts",
+			data: "1CClientBankExchange\nРасчСчет=1\nРасчСчет=2\n",
+			err:  true,
+		},
+		{
+			name: "no account",
+			data: "1CClientBankExchange\nВерсияФормата=1.03\n",
+			err:  true,
+		},
+		{
+			name: "bad amount",
+			data: "1CClientBankExchange\nРасчСчет=1\nСекцияДокумент=Платежное поручение\nСумма=много\nКонецДокумента\n",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := parse1C([]byte(tt.data))
+			if tt.err {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("error = %v, want ErrInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStatement(t, st, &tt.want)
+		})
+	}
+}
+
+// Выгрузки 1С приходят в Windows-1251: Parse должен перекодировать и определить формат сам
+func TestParseWindows1251(t *testing.T) {
+	data, err := charmap.Windows1251.NewEncoder().Bytes([]byte(statement1C))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := Parse(data, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Format != Format1C {
+		t.Errorf("format = %q, want %q", st.Format, Format1C)
+	}
+	if len(st.Rows) != 2 || st.Rows[0].Description != "Оплата по счёту 7" {
+		t.Errorf("rows = %+v", st.Rows)
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	_, err := Parse([]byte("date,amount\n"), FormatCSV, nil)
+	if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), "no transactions") {
+		t.Errorf("error = %v, want no transactions", err)
+	}
+}
+
+func assertStatement(t *testing.T, got, want *Statement) {
+	t.Helper()
+	if got.AccountNumber != want.AccountNumber || got.Currency != want.Currency || got.Institution != want.Institution {
+		t.Errorf("account = %q %q %q, want %q %q %q",
+			got.AccountNumber, got.Currency, got.Institution, want.AccountNumber, want.Currency, want.Institution)
+	}
+	if !got.From.Equal(want.From) || !got.To.Equal(want.To) {
+		t.Errorf("period = %v – %v, want %v – %v", got.From, got.To, want.From, want.To)
+	}
+	assertBalance(t, "opening", got.OpeningBalance, want.OpeningBalance)
+	assertBalance(t, "closing", got.ClosingBalance, want.ClosingBalance)
+	assertRows(t, got.Rows, want.Rows)
+}
+
+func assertBalance(t *testing.T, name string, got, want *float64) {
+	t.Helper()
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s balance = %v, want %v", name, got, want)
+	case *got != *want:
+		t.Errorf("%s balance = %v, want %v", name, *got, *want)
+	}
+}
+
+func assertRows(t *testing.T, got, want []Row) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Date.Equal(w.Date) {
+			t.Errorf("row %d date = %v, want %v", i, g.Date, w.Date)
+		}
+		g.Date, w.Date = time.Time{}, time.Time{}
+		if g != w {
+			t.Errorf("row %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
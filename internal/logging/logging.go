@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"MoneyPilot/internal/requestid"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config — настройки логгера
+type Config struct {
+	Level  string // уровень по умолчанию: debug | info | warn | error
+	Levels string // уровни по пакетам: "poller=debug,bankapi=warn"
+	Format string // json | text
+}
+
+// New — корневой логгер: JSON (или text), уровни по компонентам,
+// request_id и trace_id из контекста, маскирование секретов и персональных данных
+func New(cfg Config, w io.Writer) (*slog.Logger, error) {
+	def, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	levels := map[string]slog.Level{}
+	for _, item := range strings.Split(cfg.Levels, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid log level %q, expected component=level", item)
+		}
+		if levels[strings.TrimSpace(name)], err = parseLevel(value); err != nil {
+			return nil, err
+		}
+	}
+
+	// внутренний обработчик пропускает всё: уровень проверяет handler по компоненту
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redactAttr}
+	var inner slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		inner = slog.NewJSONHandler(w, opts)
+	case "text":
+		inner = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (expected json or text)", cfg.Format)
+	}
+	return slog.New(&handler{inner: inner, def: def, levels: levels, level: def}), nil
+}
+
+// For — логгер компонента (пакета); его имя определяет уровень из Config.Levels
+func For(l *slog.Logger, component string) *slog.Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return l.With(slog.String("component", component))
+}
+
+// Err — атрибут ошибки; текст ошибки тоже проходит маскирование
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
+
+func parseLevel(v string) (slog.Level, error) {
+	var l slog.Level
+	if strings.TrimSpace(v) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(v))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", v)
+	}
+	return l, nil
+}
+
+type handler struct {
+	inner  slog.Handler
+	def    slog.Level
+	levels map[string]slog.Level
+	level  slog.Level // уровень текущего компонента
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := *h
+	for _, a := range attrs {
+		if a.Key != "component" {
+			continue
+		}
+		out.level = h.def
+		if l, ok := h.levels[a.Value.String()]; ok {
+			out.level = l
+		}
+	}
+	out.inner = h.inner.WithAttrs(attrs)
+	return &out
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	out := *h
+	out.inner = h.inner.WithGroup(name)
+	return &out
+}
+
+// maxBody — сколько байт тела ответа банка попадает в лог
+const maxBody = 512
+
+// Body — тело ответа банка для лога. Маскируется целиком до обрезки: иначе значение,
+// разрезанное на границе, не узнаётся правилами и попадает в лог. Режется по границе символа.
+func Body(b []byte) slog.Attr {
+	s := Redact(string(b))
+	if len(s) <= maxBody {
+		return slog.String("body", s)
+	}
+	cut := maxBody
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return slog.String("body", s[:cut]+"…")
+}
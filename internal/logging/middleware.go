@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware пишет по строке на запрос API вместо стандартного логгера gin.
+// Ставится после requestid.Middleware, чтобы request_id попал в запись.
+func Middleware(l *slog.Logger) gin.HandlerFunc {
+	l = For(l, "http")
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		// скрейпы и healthcheck-и идут каждые несколько секунд
+		switch c.Request.URL.Path {
+		case "/metrics", "/healthz", "/readyz":
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		level := slog.LevelInfo
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", c.Writer.Status()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID := c.GetInt("user_id"); userID != 0 {
+			attrs = append(attrs, slog.Int("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		l.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
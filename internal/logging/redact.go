@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// ключи атрибутов (без регистра, "_" и "-"), значения которых не пишутся в лог
+var (
+	secretKeys = map[string]bool{
+		"token": true, "accesstoken": true, "refreshtoken": true, "authorization": true,
+		"secret": true, "clientsecret": true, "password": true, "passwordhash": true, "jwt": true,
+	}
+	accountKeys = map[string]bool{
+		"accountnumber": true, "identification": true, "iban": true, "pan": true, "cardnumber": true,
+	}
+	nameKeys = map[string]bool{
+		"name": true, "fullname": true, "firstname": true, "lastname": true, "middlename": true,
+		"owner": true, "ownername": true, "holder": true, "holdername": true,
+		"counterparty": true, "creditorname": true, "debtorname": true,
+	}
+)
+
+// шаблоны для свободного текста: сообщения, ошибки и тела ответов банков
+var valueRules = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`), "Bearer " + redacted},
+	{regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), redacted},
+	{regexp.MustCompile(`(?i)"(access_token|refresh_token|token|client_secret|password|authorization)"\s*:\s*"[^"]*"`), `"$1":"` + redacted + `"`},
+	{regexp.MustCompile(`(?i)\b(client_secret|access_token|refresh_token|password|token)=[^&\s"]+`), "$1=" + redacted},
+	{regexp.MustCompile(`(?i)"(name|fullName|firstName|lastName|middleName|ownerName|holderName|creditorName|debtorName|counterparty|counterpartyName)"\s*:\s*"[^"]*"`), `"$1":"` + redacted + `"`},
+}
+
+// номера счетов и карт: 16–34 цифры подряд, последние 4 остаются для поиска по логам
+var longDigits = regexp.MustCompile(`\b\d{16,34}\b`)
+
+// Redact маскирует токены, секреты, номера счетов и имена в произвольной строке
+func Redact(s string) string {
+	for _, r := range valueRules {
+		s = r.re.ReplaceAllString(s, r.repl)
+	}
+	return longDigits.ReplaceAllStringFunc(s, maskTail)
+}
+
+// redactAttr — ReplaceAttr обработчика: маскирование по ключу и по содержимому значения
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(a.Key))
+	switch {
+	case secretKeys[key], nameKeys[key]:
+		return slog.String(a.Key, redacted)
+	case accountKeys[key]:
+		return slog.String(a.Key, maskTail(a.Value.String()))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+func maskTail(s string) string {
+	if len(s) <= 4 {
+		return redacted
+	}
+	return "****" + s[len(s)-4:]
+}
@@ -0,0 +1,280 @@
+package manualaccounts
+
+import (
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/storage"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// List — GET /api/manual-accounts
+func (h *Handler) List(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	accs, err := h.Service.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load manual accounts", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(accs), "accounts": accs})
+}
+
+// Create — POST /api/manual-accounts
+// {"name":"Кошелёк","type":"cash","currency":"RUB","balance":5000}
+// {"name":"Квартира","type":"asset","balance":12000000,"date":"2025-01-01"}
+// {"name":"Ипотека","type":"liability","institution":"Банк","balance":8000000} — долг вводится положительным
+func (h *Handler) Create(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req struct {
+		Name        string  `json:"name" binding:"required"`
+		Type        string  `json:"type"`
+		Currency    string  `json:"currency"`
+		Balance     float64 `json:"balance"`
+		Institution *string `json:"institution"`
+		Date        string  `json:"date"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	initial := Valuation{Balance: req.Balance}
+	if !parseDate(c, req.Date, &initial.Date) {
+		return
+	}
+	acc, err := h.Service.Create(userID, storage.Account{
+		AccountType: req.Type,
+		Nickname:    &req.Name,
+		Currency:    req.Currency,
+		Institution: req.Institution,
+	}, initial)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, acc)
+}
+
+// Update — PUT /api/manual-accounts/:account_id {"name":"...","institution":"...","status":"closed"}
+func (h *Handler) Update(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "account_id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Name        *string `json:"name"`
+		Institution *string `json:"institution"`
+		Status      *string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	acc, err := h.Service.Update(userID, id, req.Name, req.Institution, req.Status)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, acc)
+}
+
+// Delete — DELETE /api/manual-accounts/:account_id вместе с операциями и историей
+func (h *Handler) Delete(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "account_id")
+	if !ok {
+		return
+	}
+	if err := h.Service.Delete(userID, id); err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
+
+// ListValuations — GET /api/manual-accounts/:account_id/valuations?from=&to=
+func (h *Handler) ListValuations(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "account_id")
+	if !ok {
+		return
+	}
+	var from time.Time
+	to := time.Now().Add(time.Minute)
+	if !parseDate(c, c.Query("from"), &from) || !parseDate(c, c.Query("to"), &to) {
+		return
+	}
+	vals, err := h.Service.Valuations(userID, id, from, to)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(vals), "valuations": vals})
+}
+
+// AddValuation — POST /api/manual-accounts/:account_id/valuations {"balance":12500000,"date":"2025-06-01"}
+func (h *Handler) AddValuation(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "account_id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Balance *float64 `json:"balance" binding:"required"`
+		Date    string   `json:"date"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	v := Valuation{Balance: *req.Balance}
+	if !parseDate(c, req.Date, &v.Date) {
+		return
+	}
+	acc, err := h.Service.Revalue(userID, id, v)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, acc)
+}
+
+// AddTransaction — POST /api/manual-accounts/:account_id/transactions
+// {"amount":-350,"date":"2025-06-01","description":"Кофе","category":"cafe"}
+func (h *Handler) AddTransaction(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "account_id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Amount       float64 `json:"amount" binding:"required"`
+		Currency     *string `json:"currency"`
+		Date         string  `json:"date"`
+		Description  *string `json:"description"`
+		Counterparty *string `json:"counterparty"`
+		MCC          *string `json:"mcc"`
+		Category     *string `json:"category"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	t := storage.Transaction{
+		Amount:       req.Amount,
+		Currency:     req.Currency,
+		Description:  req.Description,
+		Counterparty: req.Counterparty,
+		MCC:          req.MCC,
+		Category:     req.Category,
+	}
+	if !parseDate(c, req.Date, &t.BookingDate) {
+		return
+	}
+	tx, err := h.Service.AddTransaction(audit.WithUserID(c.Request.Context(), userID), userID, id, t)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, tx)
+}
+
+// DeleteTransaction — DELETE /api/manual-accounts/:account_id/transactions/:transaction_id
+func (h *Handler) DeleteTransaction(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "account_id")
+	if !ok {
+		return
+	}
+	txID, ok := paramID(c, "transaction_id")
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteTransaction(c.Request.Context(), userID, id, txID); err != nil {
+		h.error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": txID})
+}
+
+func (h *Handler) error(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, fx.ErrNoRate):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "manual account operation failed", "details": err.Error()})
+	}
+}
+
+func paramID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+// parseDate — YYYY-MM-DD или RFC3339; пустое значение оставляет dst без изменений
+func parseDate(c *gin.Context, v string, dst *time.Time) bool {
+	if v == "" {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date " + v, "details": "expected YYYY-MM-DD"})
+			return false
+		}
+	}
+	*dst = t
+	return true
+}
@@ -0,0 +1,333 @@
+package manualaccounts
+
+import (
+	"MoneyPilot/internal/categories"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/transactions"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("manual account not found")
+	ErrInvalid  = errors.New("invalid request")
+)
+
+// Types — типы ручных счетов, которые можно завести вручную
+var Types = map[string]bool{
+	"checking":                   true,
+	storage.AccountTypeCash:      true,
+	storage.AccountTypeAsset:     true,
+	storage.AccountTypeLiability: true,
+}
+
+// Service ведёт ручные счета: наличные, активы и долги в банках без API.
+// Остаток задаётся оценкой или меняется ручными операциями; каждое изменение
+// пишется в историю балансов, поэтому счета попадают в net worth наравне с банковскими.
+type Service struct {
+	Repo       storage.Store
+	Manual     storage.ManualAccountRepository
+	History    storage.BalanceRepository
+	Categories *categories.Service
+	Syncer     *transactions.Syncer
+	FX         *fx.Service
+}
+
+func NewService(repo storage.Store, manual storage.ManualAccountRepository, history storage.BalanceRepository,
+	categorySvc *categories.Service, syncer *transactions.Syncer, fxSvc *fx.Service) *Service {
+	return &Service{Repo: repo, Manual: manual, History: history, Categories: categorySvc, Syncer: syncer, FX: fxSvc}
+}
+
+// Number — номер ручного счёта; account_number уникален во всей таблице, поэтому с префиксом клиента
+func Number(clientID, number string) string {
+	if number == "" {
+		buf := make([]byte, 6)
+		rand.Read(buf)
+		number = hex.EncodeToString(buf)
+	}
+	return fmt.Sprintf("%s:%s:%s", storage.SourceManual, clientID, number)
+}
+
+// Valuation — оценка остатка на дату
+type Valuation struct {
+	Balance float64   `json:"balance"`
+	Date    time.Time `json:"date"`
+}
+
+func (s *Service) List(userID int) ([]storage.Account, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := []storage.Account{}
+	for _, a := range accs {
+		if a.Source == storage.SourceManual {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+// Get — ручной счёт клиента
+func (s *Service) Get(userID, id int) (*storage.Account, error) {
+	accs, err := s.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range accs {
+		if accs[i].ID == id {
+			return &accs[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Create заводит ручной счёт; начальный остаток сразу становится первой оценкой
+func (s *Service) Create(userID int, acc storage.Account, initial Valuation) (*storage.Account, error) {
+	acc.AccountType = strings.ToLower(strings.TrimSpace(acc.AccountType))
+	if acc.AccountType == "" {
+		acc.AccountType = storage.AccountTypeCash
+	}
+	if !Types[acc.AccountType] {
+		return nil, fmt.Errorf("%w: type must be one of cash, asset, liability, checking", ErrInvalid)
+	}
+	if acc.Nickname == nil || strings.TrimSpace(*acc.Nickname) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	user, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	bank, err := s.Repo.GetBankByCode(storage.ManualBankCode)
+	if err != nil {
+		return nil, fmt.Errorf("bank %s: %w", storage.ManualBankCode, err)
+	}
+	if acc.Currency == "" {
+		if acc.Currency, err = s.FX.BaseCurrency(userID); err != nil {
+			return nil, err
+		}
+	}
+	acc.Currency = strings.ToUpper(acc.Currency)
+	if !fx.ValidCurrency(acc.Currency) {
+		return nil, fmt.Errorf("%w: invalid currency", ErrInvalid)
+	}
+
+	acc.ID, acc.ExternalID = 0, nil
+	acc.UserID, acc.BankID = userID, bank.ID
+	acc.AccountNumber = Number(user.ClientID, "")
+	acc.Source, acc.Status = storage.SourceManual, "active"
+	acc.Balance = signed(acc, initial.Balance)
+	if err := s.Repo.UpsertAccount(&acc); err != nil {
+		return nil, err
+	}
+	if err := snapshot(s.History, &acc, acc.Balance, initial.Date); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// Update меняет название, банк и статус ручного счёта
+func (s *Service) Update(userID, id int, name, institution, status *string) (*storage.Account, error) {
+	acc, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if name != nil {
+		if strings.TrimSpace(*name) == "" {
+			return nil, fmt.Errorf("%w: name must not be empty", ErrInvalid)
+		}
+		acc.Nickname = name
+	}
+	if institution != nil {
+		acc.Institution = institution
+	}
+	if status != nil {
+		if *status != "active" && *status != "closed" {
+			return nil, fmt.Errorf("%w: status must be active or closed", ErrInvalid)
+		}
+		acc.Status = *status
+	}
+	if err := s.Repo.UpsertAccount(acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+func (s *Service) Delete(userID, id int) error {
+	if _, err := s.Get(userID, id); err != nil {
+		return err
+	}
+	err := s.Manual.DeleteAccount(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Valuations — история остатка ручного счёта за [from, to) и последняя оценка до from
+func (s *Service) Valuations(userID, id int, from, to time.Time) ([]Valuation, error) {
+	if _, err := s.Get(userID, id); err != nil {
+		return nil, err
+	}
+	snaps, err := s.History.GetBalanceSnapshots([]int{id}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Valuation, 0, len(snaps))
+	for _, snap := range snaps {
+		if snap.Available != nil {
+			res = append(res, Valuation{Balance: *snap.Available, Date: snap.TakenAt})
+		}
+	}
+	return res, nil
+}
+
+// Revalue записывает оценку на дату. Текущий остаток меняется, только если оценка не старше последней.
+func (s *Service) Revalue(userID, id int, v Valuation) (*storage.Account, error) {
+	acc, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if v.Date.IsZero() {
+		v.Date = time.Now()
+	}
+	if v.Date.After(time.Now()) {
+		return nil, fmt.Errorf("%w: date must not be in the future", ErrInvalid)
+	}
+	history, err := s.History.GetBalanceSnapshots([]int{id}, time.Time{}, time.Now().Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	balance := signed(*acc, v.Balance)
+	if len(history) == 0 || !v.Date.Before(history[len(history)-1].TakenAt) {
+		acc.Balance = balance
+		if err := s.Repo.UpsertAccount(acc); err != nil {
+			return nil, err
+		}
+	}
+	if err := snapshot(s.History, acc, balance, v.Date); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// AddTransaction сохраняет ручную операцию и сдвигает остаток счёта.
+// Без категории операция категоризируется правилами клиента.
+func (s *Service) AddTransaction(ctx context.Context, userID, id int, t storage.Transaction) (*storage.Transaction, error) {
+	acc, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if acc.AccountType == storage.AccountTypeAsset {
+		return nil, fmt.Errorf("%w: asset value is changed by valuations", ErrInvalid)
+	}
+	if t.Amount == 0 {
+		return nil, fmt.Errorf("%w: amount must not be zero", ErrInvalid)
+	}
+	if t.BookingDate.IsZero() {
+		t.BookingDate = time.Now()
+	}
+	currency := acc.Currency
+	if t.Currency != nil && *t.Currency != "" {
+		currency = strings.ToUpper(*t.Currency)
+		if !fx.ValidCurrency(currency) {
+			return nil, fmt.Errorf("%w: invalid currency", ErrInvalid)
+		}
+	}
+	delta := t.Amount
+	if currency != acc.Currency {
+		if delta, err = s.FX.Convert(t.Amount, currency, acc.Currency, t.BookingDate); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	externalID := storage.SourceManual + ":" + hex.EncodeToString(buf)
+	t.ID, t.AccountID, t.ExternalID, t.Currency = 0, acc.ID, &externalID, &currency
+	t.Amount = money.Round(t.Amount)
+	if t.Category != nil && *t.Category != "" {
+		source := categories.SourceManual
+		t.CategorySource = &source
+	} else {
+		matcher, err := s.Categories.Matcher(userID)
+		if err != nil {
+			return nil, err
+		}
+		matcher.Apply(&t)
+	}
+
+	// остаток сдвигается в базе, а не пересчитывается из прочитанного: параллельные операции не теряются
+	err = s.Repo.WithTx(ctx, func(tx storage.Store) error {
+		if err := tx.InsertTransaction(&t); err != nil {
+			return err
+		}
+		if acc.Balance, err = tx.AddAccountBalance(acc.ID, money.Round(delta)); err != nil {
+			return err
+		}
+		return snapshot(tx, acc, acc.Balance, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Syncer.Notify(ctx, userID, []storage.Transaction{t})
+	return &t, nil
+}
+
+// DeleteTransaction удаляет операцию ручного счёта и возвращает её сумму в остаток
+func (s *Service) DeleteTransaction(ctx context.Context, userID, id, transactionID int) error {
+	acc, err := s.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	t, err := s.Repo.GetTransactionByID(transactionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && t.AccountID != acc.ID) {
+		return fmt.Errorf("%w: transaction %d", ErrNotFound, transactionID)
+	}
+	if err != nil {
+		return err
+	}
+	delta := t.Amount
+	if t.Currency != nil && *t.Currency != acc.Currency {
+		if delta, err = s.FX.Convert(t.Amount, *t.Currency, acc.Currency, t.BookingDate); err != nil {
+			return err
+		}
+	}
+
+	return s.Repo.WithTx(ctx, func(tx storage.Store) error {
+		if err := tx.DeleteTransaction(t.ID); err != nil {
+			return err
+		}
+		if acc.Balance, err = tx.AddAccountBalance(acc.ID, -money.Round(delta)); err != nil {
+			return err
+		}
+		return snapshot(tx, acc, acc.Balance, time.Now())
+	})
+}
+
+func snapshot(history storage.BalanceRepository, acc *storage.Account, balance float64, at time.Time) error {
+	return history.InsertBalanceSnapshot(&storage.BalanceSnapshot{
+		AccountID: acc.ID,
+		Available: &balance,
+		Booked:    &balance,
+		Currency:  &acc.Currency,
+		TakenAt:   at,
+	})
+}
+
+// signed — долг хранится отрицательным остатком, как бы его ни ввёл пользователь
+func signed(acc storage.Account, v float64) float64 {
+	if acc.AccountType == storage.AccountTypeLiability {
+		return -math.Abs(money.Round(v))
+	}
+	return money.Round(v)
+}
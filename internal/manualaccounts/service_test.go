@@ -0,0 +1,252 @@
+package manualaccounts
+
+import (
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"MoneyPilot/internal/transactions"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func str(v string) *string { return &v }
+
+// newTestService заводит клиента с ручным счётом acc и курсами к рублю, действующими с указанной даты
+func newTestService(t *testing.T, acc storage.Account) (*Service, *memory.Store, int, *storage.Account) {
+	t.Helper()
+	repo := memory.New()
+	manual := repo.AddBank(storage.Bank{Code: storage.ManualBankCode})
+	u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &manual.ID})
+	acc.UserID, acc.BankID, acc.AccountNumber = u.ID, manual.ID, Number("team-1", "1")
+	if err := repo.UpsertAccount(&acc); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpsertFXRates([]storage.FXRate{
+		{RateDate: day("2024-03-01"), Base: "USD", Quote: "RUB", Rate: 90},
+		{RateDate: day("2024-06-01"), Base: "USD", Quote: "RUB", Rate: 100},
+		{RateDate: day("2024-03-01"), Base: "RUB", Quote: "EUR", Rate: 0.01}, // только обратный курс
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fxSvc := fx.NewService(repo, repo, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return NewService(repo, repo, repo, nil, &transactions.Syncer{}, fxSvc), repo, u.ID, &acc
+}
+
+// state — текущий остаток, операции и история остатка счёта
+func state(t *testing.T, repo *memory.Store, userID int, acc *storage.Account) (float64, []storage.Transaction, []storage.BalanceSnapshot) {
+	t.Helper()
+	accs, err := repo.GetAccountsByClientUser(userID)
+	if err != nil || len(accs) != 1 {
+		t.Fatalf("accounts = %v, %v", accs, err)
+	}
+	txs, err := repo.GetTransactionsAddedSince(acc.ID, time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snaps, err := repo.GetBalanceSnapshots([]int{acc.ID}, time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accs[0].Balance, txs, snaps
+}
+
+func TestAddTransaction(t *testing.T) {
+	cash := storage.Account{AccountType: storage.AccountTypeCash, Currency: "RUB", Balance: 1000, Source: storage.SourceManual}
+	asset := cash
+	asset.AccountType = storage.AccountTypeAsset
+	errSnapshot := errors.New("snapshot failed")
+
+	tests := []struct {
+		name     string
+		account  storage.Account
+		amount   float64
+		currency *string
+		date     time.Time
+		fail     error   // ошибка InsertBalanceSnapshot
+		balance  float64 // остаток после операции
+		stored   float64 // сумма сохранённой операции в её валюте
+		want     string  // валюта сохранённой операции
+		err      error
+	}{
+		{name: "account currency", account: cash, amount: -150.254, date: day("2024-04-15"), balance: 849.75, stored: -150.25, want: "RUB"},
+		{name: "rate on booking date", account: cash, amount: -10, currency: str("usd"), date: day("2024-04-15"), balance: 100, stored: -10, want: "USD"},
+		{name: "later rate", account: cash, amount: -10, currency: str("USD"), date: day("2024-06-15"), balance: 0, stored: -10, want: "USD"},
+		{name: "inverse rate", account: cash, amount: 5, currency: str("EUR"), date: day("2024-04-15"), balance: 1500, stored: 5, want: "EUR"},
+		{name: "no rate", account: cash, amount: -10, currency: str("GBP"), date: day("2024-04-15"), balance: 1000, err: fx.ErrNoRate},
+		{name: "no rate before first quote", account: cash, amount: -10, currency: str("USD"), date: day("2024-01-15"), balance: 1000, err: fx.ErrNoRate},
+		{name: "invalid currency", account: cash, amount: -10, currency: str("dollars"), balance: 1000, err: ErrInvalid},
+		{name: "zero amount", account: cash, amount: 0, balance: 1000, err: ErrInvalid},
+		{name: "asset", account: asset, amount: -10, balance: 1000, err: ErrInvalid},
+		{name: "rolled back", account: cash, amount: -10, date: day("2024-04-15"), fail: errSnapshot, balance: 1000, err: errSnapshot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, userID, acc := newTestService(t, tt.account)
+			repo.Fail = func(method string) error {
+				if method == "InsertBalanceSnapshot" {
+					return tt.fail
+				}
+				return nil
+			}
+			got, err := s.AddTransaction(context.Background(), userID, acc.ID, storage.Transaction{
+				Amount: tt.amount, Currency: tt.currency, BookingDate: tt.date, Category: str("Продукты"),
+			})
+			balance, txs, snaps := state(t, repo, userID, acc)
+			if balance != tt.balance {
+				t.Errorf("balance = %v, want %v", balance, tt.balance)
+			}
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				if len(txs) != 0 || len(snaps) != 0 {
+					t.Errorf("changes were not rolled back: %d transactions, %d snapshots", len(txs), len(snaps))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(txs) != 1 || txs[0].ID != got.ID {
+				t.Fatalf("transactions = %+v, want only %d", txs, got.ID)
+			}
+			if stored := txs[0]; stored.Amount != tt.stored || stored.Currency == nil || *stored.Currency != tt.want {
+				t.Errorf("stored transaction = %v %v, want %v %s", stored.Amount, stored.Currency, tt.stored, tt.want)
+			}
+			if len(snaps) != 1 || *snaps[0].Booked != tt.balance || *snaps[0].Currency != "RUB" {
+				t.Errorf("snapshots = %+v, want one with balance %v RUB", snaps, tt.balance)
+			}
+		})
+	}
+}
+
+func TestDeleteTransaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		tx      storage.Transaction
+		other   bool // операция другого счёта
+		missing bool
+		balance float64
+		err     error
+	}{
+		{name: "account currency", tx: storage.Transaction{Amount: -150.25, Currency: str("RUB")}, balance: 1150.25},
+		{name: "without currency", tx: storage.Transaction{Amount: 250}, balance: 750},
+		{name: "converted at booking date rate", tx: storage.Transaction{Amount: -10, Currency: str("USD"), BookingDate: day("2024-04-15")}, balance: 1900},
+		{name: "inverse rate", tx: storage.Transaction{Amount: 5, Currency: str("EUR"), BookingDate: day("2024-04-15")}, balance: 500},
+		{name: "no rate", tx: storage.Transaction{Amount: -10, Currency: str("GBP"), BookingDate: day("2024-04-15")}, balance: 1000, err: fx.ErrNoRate},
+		{name: "other account", tx: storage.Transaction{Amount: -10}, other: true, balance: 1000, err: ErrNotFound},
+		{name: "missing", missing: true, balance: 1000, err: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, userID, acc := newTestService(t, storage.Account{
+				AccountType: storage.AccountTypeCash, Currency: "RUB", Balance: 1000, Source: storage.SourceManual,
+			})
+			tt.tx.AccountID = acc.ID
+			if tt.other {
+				other := storage.Account{UserID: userID, BankID: acc.BankID, AccountNumber: "other"}
+				if err := repo.UpsertAccount(&other); err != nil {
+					t.Fatal(err)
+				}
+				tt.tx.AccountID = other.ID
+			}
+			if err := repo.InsertTransaction(&tt.tx); err != nil {
+				t.Fatal(err)
+			}
+			id := tt.tx.ID
+			if tt.missing {
+				id = 9999
+			}
+
+			err := s.DeleteTransaction(context.Background(), userID, acc.ID, id)
+			if got, err := repo.GetTransactionByID(tt.tx.ID); (err == nil) != (tt.err != nil) {
+				t.Errorf("transaction after delete = %v, %v", got, err)
+			}
+			accs, _ := s.List(userID)
+			if len(accs) != 1 || accs[0].Balance != tt.balance {
+				t.Errorf("accounts = %+v, want balance %v", accs, tt.balance)
+			}
+			snaps, _ := repo.GetBalanceSnapshots([]int{acc.ID}, time.Time{}, time.Now().Add(time.Hour))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				if len(snaps) != 0 {
+					t.Errorf("changes were not rolled back: %d snapshots", len(snaps))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snaps) != 1 || *snaps[0].Booked != tt.balance {
+				t.Errorf("snapshots = %+v, want one with balance %v", snaps, tt.balance)
+			}
+		})
+	}
+}
+
+func TestCreateAndRevalue(t *testing.T) {
+	s, _, userID, _ := newTestService(t, storage.Account{AccountType: storage.AccountTypeCash, Currency: "RUB", Source: storage.SourceManual})
+
+	if _, err := s.Create(userID, storage.Account{AccountType: "crypto", Nickname: str("Wallet")}, Valuation{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("unknown type: error = %v, want %v", err, ErrInvalid)
+	}
+	if _, err := s.Create(userID, storage.Account{AccountType: "cash"}, Valuation{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("without name: error = %v, want %v", err, ErrInvalid)
+	}
+
+	// долг хранится отрицательным, как бы его ни ввели
+	loan, err := s.Create(userID, storage.Account{AccountType: " Liability ", Nickname: str("Loan"), Currency: "rub"},
+		Valuation{Balance: 5000, Date: day("2024-01-01")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loan.Balance != -5000 || loan.Currency != "RUB" || loan.Source != storage.SourceManual {
+		t.Errorf("created = %v %s %s", loan.Balance, loan.Currency, loan.Source)
+	}
+
+	if _, err := s.Revalue(userID, loan.ID, Valuation{Balance: 4000, Date: day("2024-03-01")}); err != nil {
+		t.Fatal(err)
+	}
+	// оценка задним числом попадает в историю, но не меняет текущий остаток
+	if _, err := s.Revalue(userID, loan.ID, Valuation{Balance: 4500, Date: day("2024-02-01")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Revalue(userID, loan.ID, Valuation{Balance: 1, Date: time.Now().Add(time.Hour)}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("future valuation: error = %v, want %v", err, ErrInvalid)
+	}
+
+	got, err := s.Get(userID, loan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Balance != -4000 {
+		t.Errorf("balance = %v, want -4000", got.Balance)
+	}
+	history, err := s.Valuations(userID, loan.ID, time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{-5000, -4500, -4000}
+	if len(history) != len(want) {
+		t.Fatalf("valuations = %+v, want %v", history, want)
+	}
+	for i, v := range history {
+		if v.Balance != want[i] {
+			t.Errorf("valuation %d = %v, want %v", i, v.Balance, want[i])
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "moneypilot"
+
+var (
+	// HTTPDuration — входящие запросы API по маршруту (шаблону gin, а не фактическому пути) и статусу
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests handled by the API.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// BankDuration — каждая попытка запроса к банку (повторы считаются отдельно)
+	BankDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "bank_request_duration_seconds",
+		Help:      "Duration of outbound requests to bank APIs.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"bank", "endpoint", "status"})
+
+	// BankErrors — сетевые ошибки, таймауты и ответы 5xx банков
+	BankErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bank_request_errors_total",
+		Help:      "Outbound bank requests that failed with a transport error or a 5xx response.",
+	}, []string{"bank", "endpoint", "reason"})
+
+	TokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bank_token_refreshes_total",
+		Help:      "Bank access token refreshes by result.",
+	}, []string{"bank", "result"})
+
+	// PendingConsents — согласия в статусе pending по типу и возрасту, обновляется каждым циклом поллера
+	PendingConsents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pending_consents",
+		Help:      "Consents waiting for approval at the bank, by type and age.",
+	}, []string{"type", "age"})
+
+	PollerCycle = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "poller_cycle_duration_seconds",
+		Help:      "Duration of one consent poller cycle.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	})
+
+	WebSocketClients = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_clients",
+		Help:      "Connected WebSocket clients.",
+	})
+
+	WebSocketDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_dropped_messages_total",
+		Help:      "WebSocket messages that could not be delivered to a client.",
+	})
+)
+
+// ConsentAges — границы корзин возраста согласий для PendingConsents
+var ConsentAges = []struct {
+	Label string
+	Max   time.Duration
+}{
+	{"lt_5m", 5 * time.Minute},
+	{"lt_1h", time.Hour},
+	{"lt_24h", 24 * time.Hour},
+	{"ge_24h", 0},
+}
+
+func init() {
+	prometheus.MustRegister(HTTPDuration, BankDuration, BankErrors, TokenRefreshes,
+		PendingConsents, PollerCycle, WebSocketClients, WebSocketDropped)
+}
+
+// RegisterDB добавляет статистику пула соединений (sql.DB.Stats) под именем name
+func RegisterDB(db *sql.DB, name string) {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, name))
+	var already prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &already) {
+		panic(err)
+	}
+}
+
+// Handler — GET /metrics в формате Prometheus
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Middleware замеряет длительность запросов API
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveConsents выставляет число pending-согласий типа kind по корзинам возраста
+func ObserveConsents(kind string, created []time.Time) {
+	counts := make(map[string]int, len(ConsentAges))
+	for _, at := range created {
+		age := time.Since(at)
+		for _, b := range ConsentAges {
+			if b.Max == 0 || age < b.Max {
+				counts[b.Label]++
+				break
+			}
+		}
+	}
+	for _, b := range ConsentAges {
+		PendingConsents.WithLabelValues(kind, b.Label).Set(float64(counts[b.Label]))
+	}
+}
+
+// Endpoint — путь запроса к банку без идентификаторов, чтобы не раздувать число серий:
+// /accounts/acc-123/balances → /accounts/:id/balances
+func Endpoint(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, p := range parts {
+		if isID(p) {
+			parts[i] = ":id"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// isID — сегмент с цифрами (номера, UUID, acc-1) считается идентификатором
+func isID(segment string) bool {
+	if len(segment) > 32 {
+		return true
+	}
+	return strings.ContainsAny(segment, "0123456789")
+}
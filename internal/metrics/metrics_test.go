@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"/accounts", "/accounts"},
+		{"/accounts/acc-123/balances", "/accounts/:id/balances"},
+		{"accounts/42/transactions/", "/accounts/:id/transactions"},
+		{"/product-agreements/6f1c2b3a-0d4e-4f5a-9b8c-7d6e5f4a3b2c", "/product-agreements/:id"},
+		{"/account-consents/" + strings.Repeat("x", 40), "/account-consents/:id"},
+		{"/auth/bank-token", "/auth/bank-token"},
+		{"/", "/"},
+	}
+	for _, tt := range tests {
+		if got := Endpoint(tt.in); got != tt.want {
+			t.Errorf("Endpoint(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestObserveConsents(t *testing.T) {
+	now := time.Now()
+	ObserveConsents("test", []time.Time{
+		now.Add(-time.Minute),
+		now.Add(-2 * time.Minute),
+		now.Add(-30 * time.Minute),
+		now.Add(-48 * time.Hour),
+	})
+	want := map[string]float64{"lt_5m": 2, "lt_1h": 1, "lt_24h": 0, "ge_24h": 1}
+	for age, n := range want {
+		if got := testutil.ToFloat64(PendingConsents.WithLabelValues("test", age)); got != n {
+			t.Errorf("pending consents %s = %v, want %v", age, got, n)
+		}
+	}
+
+	// корзины, которые опустели, сбрасываются в ноль
+	ObserveConsents("test", nil)
+	for age := range want {
+		if got := testutil.ToFloat64(PendingConsents.WithLabelValues("test", age)); got != 0 {
+			t.Errorf("pending consents %s after reset = %v, want 0", age, got)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/test-metrics/:id", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+
+	for _, path := range []string{"/test-metrics/1", "/test-metrics/2", "/test-metrics-missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// маршрут — шаблон gin, поэтому оба запроса попадают в одну серию
+	tests := []struct {
+		route, status string
+		want          uint64
+	}{
+		{"/test-metrics/:id", "418", 2},
+		{"unmatched", "404", 1},
+	}
+	for _, tt := range tests {
+		var m dto.Metric
+		if err := HTTPDuration.WithLabelValues(http.MethodGet, tt.route, tt.status).(prometheus.Metric).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetHistogram().GetSampleCount(); got != tt.want {
+			t.Errorf("%s %s: %d observations, want %d", tt.route, tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package migrations
+
+import (
+	"MoneyPilot/internal/logging"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Файлы миграций: sql/<версия>_<название>.up.sql и sql/<версия>_<название>.down.sql
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// advisoryLockID — ключ pg_advisory_lock, чтобы несколько реплик не мигрировали одновременно
+const advisoryLockID = 72_081_2025
+
+// Migration — одна версия схемы
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status — текущее состояние схемы
+type Status struct {
+	Version int64
+	Dirty   bool
+	Applied []Migration
+	Pending []Migration
+}
+
+// ErrDirty — предыдущая миграция упала посередине, нужна ручная проверка и `migrate force`
+var ErrDirty = errors.New("database is dirty, fix the schema manually and run `migrate force <version>`")
+
+type Migrator struct {
+	DB         *sql.DB
+	Log        *slog.Logger
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, logger *slog.Logger) (*Migrator, error) {
+	ms, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Log: logging.For(logger, "migrate"), migrations: ms}, nil
+}
+
+// Migrations возвращает все встроенные миграции по возрастанию версии
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up применяет все ещё не применённые миграции
+func (m *Migrator) Up() error {
+	return m.locked(func() error {
+		current, dirty, err := m.version()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		for _, mg := range m.migrations {
+			if mg.Version <= current {
+				continue
+			}
+			if err := m.apply(mg.Version, mg.Up, mg.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mg.Version, mg.Name, err)
+			}
+			m.Log.Info("migration applied", "version", mg.Version, "migration", mg.Name)
+		}
+		return nil
+	})
+}
+
+// Down откатывает steps последних миграций (steps <= 0 — откатить всё)
+func (m *Migrator) Down(steps int) error {
+	return m.locked(func() error {
+		current, dirty, err := m.version()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mg := m.migrations[i]
+			if mg.Version > current {
+				continue
+			}
+			var prev int64
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+			if err := m.apply(mg.Version, mg.Down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mg.Version, mg.Name, err)
+			}
+			m.Log.Info("migration reverted", "version", mg.Version, "migration", mg.Name)
+			current = prev
+			if steps--; steps == 0 {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// Force выставляет версию и снимает флаг dirty, не выполняя SQL
+func (m *Migrator) Force(version int64) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.locked(func() error {
+		return m.setVersion(m.DB, version, false)
+	})
+}
+
+// Status возвращает текущую версию и списки применённых/ожидающих миграций
+func (m *Migrator) Status() (*Status, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	current, dirty, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{Version: current, Dirty: dirty}
+	for _, mg := range m.migrations {
+		if mg.Version <= current {
+			st.Applied = append(st.Applied, mg)
+		} else {
+			st.Pending = append(st.Pending, mg)
+		}
+	}
+	return st, nil
+}
+
+// apply выполняет SQL одной миграции в транзакции.
+// Перед запуском версия помечается dirty: если процесс упадёт, это будет видно в status.
+func (m *Migrator) apply(version int64, script string, target int64) error {
+	if err := m.setVersion(m.DB, version, true); err != nil {
+		return err
+	}
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.Exec(script); err != nil {
+			return err
+		}
+	}
+	if err := m.setVersion(tx, target, false); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) locked(fn func() error) error {
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, advisoryLockID)
+	return fn()
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL,
+		dirty BOOLEAN NOT NULL DEFAULT false
+	)`)
+	return err
+}
+
+func (m *Migrator) version() (int64, bool, error) {
+	var v int64
+	var dirty bool
+	err := m.DB.QueryRow(`SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&v, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return v, dirty, err
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func (m *Migrator) setVersion(db execer, version int64, dirty bool) error {
+	if _, err := db.Exec(`DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	_, err := db.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, version, dirty)
+	return err
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// load разбирает встроенные файлы в упорядоченный список миграций
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		verStr, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("bad migration file name %q", name)
+		}
+		ver, err := strconv.ParseInt(verStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad migration version in %q: %w", name, err)
+		}
+		data, err := fs.ReadFile(fsys, "sql/"+name)
+		if err != nil {
+			return nil, err
+		}
+		mg := byVersion[ver]
+		if mg == nil {
+			mg = &Migration{Version: ver, Name: title}
+			byVersion[ver] = mg
+		}
+		if direction == "up" {
+			mg.Up = string(data)
+		} else {
+			mg.Down = string(data)
+		}
+	}
+
+	res := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mg.Version, mg.Name)
+		}
+		res = append(res, *mg)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
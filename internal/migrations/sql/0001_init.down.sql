@@ -0,0 +1 @@
+DROP TABLE IF EXISTS product_agreement_consents, product_agreements, products, payments, payment_consents, account_consents, transactions, accounts, users, banks CASCADE;
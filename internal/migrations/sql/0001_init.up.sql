@@ -1,12 +1,8 @@
--- ========================
--- 💾 INIT_DB.SQL
--- ========================
-
--- Очистка при пересоздании
-DROP TABLE IF EXISTS product_agreements, products, payments, payment_consents, account_consents, transactions, accounts, users, banks, product_agreement_consents CASCADE;
+-- Базовая схема (бывший scripts/init_db.sql).
+-- IF NOT EXISTS позволяет принять под управление уже созданную вручную БД.
 
 -- 🏦 Таблица банков
-CREATE TABLE banks (
+CREATE TABLE IF NOT EXISTS banks (
     id SERIAL PRIMARY KEY,
     code VARCHAR(32) UNIQUE NOT NULL,  -- vbank, sbank, abank
     name VARCHAR(128) NOT NULL,
@@ -16,7 +12,7 @@ CREATE TABLE banks (
 );
 
 -- 👤 Пользователи
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     client_id VARCHAR(64) NOT NULL,
     bank_id INT REFERENCES banks(id) ON DELETE CASCADE,
@@ -27,7 +23,7 @@ CREATE TABLE users (
 );
 
 -- 💳 Счета
-CREATE TABLE accounts (
+CREATE TABLE IF NOT EXISTS accounts (
     id SERIAL PRIMARY KEY,
     user_id INT REFERENCES users(id) ON DELETE CASCADE,
     bank_id INT REFERENCES banks(id) ON DELETE CASCADE,
@@ -41,7 +37,7 @@ CREATE TABLE accounts (
 );
 
 -- 📈 Транзакции
-CREATE TABLE transactions (
+CREATE TABLE IF NOT EXISTS transactions (
     id SERIAL PRIMARY KEY,
     account_id INT REFERENCES accounts(id) ON DELETE CASCADE,
     amount NUMERIC(18,2) NOT NULL,
@@ -53,7 +49,7 @@ CREATE TABLE transactions (
 );
 
 -- 🪪 Согласия (доступ к данным)
-CREATE TABLE account_consents (
+CREATE TABLE IF NOT EXISTS account_consents (
     id SERIAL PRIMARY KEY,
     consent_id VARCHAR(64) UNIQUE NOT NULL,
     user_id INT REFERENCES users(id) ON DELETE CASCADE,
@@ -66,7 +62,7 @@ CREATE TABLE account_consents (
 );
 
 -- 💸 Платежные согласия
-CREATE TABLE payment_consents (
+CREATE TABLE IF NOT EXISTS payment_consents (
     id SERIAL PRIMARY KEY,
     consent_id VARCHAR(64) UNIQUE NOT NULL,
     user_id INT REFERENCES users(id),
@@ -81,7 +77,7 @@ CREATE TABLE payment_consents (
 );
 
 -- 💰 Платежи
-CREATE TABLE payments (
+CREATE TABLE IF NOT EXISTS payments (
     id SERIAL PRIMARY KEY,
     payment_id VARCHAR(64) UNIQUE NOT NULL,
     user_id INT REFERENCES users(id),
@@ -95,7 +91,7 @@ CREATE TABLE payments (
 );
 
 -- 🧩 Продукты
-CREATE TABLE products (
+CREATE TABLE IF NOT EXISTS products (
     id SERIAL PRIMARY KEY,
     product_id VARCHAR(64) UNIQUE NOT NULL,
     bank_id INT REFERENCES banks(id),
@@ -110,7 +106,7 @@ CREATE TABLE products (
 );
 
 -- 📜 Договоры по продуктам
-CREATE TABLE product_agreements (
+CREATE TABLE IF NOT EXISTS product_agreements (
     id SERIAL PRIMARY KEY,
     agreement_id VARCHAR(64) UNIQUE NOT NULL,
     user_id INT REFERENCES users(id),
@@ -121,9 +117,7 @@ CREATE TABLE product_agreements (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
-
-
-CREATE TABLE product_agreement_consents (
+CREATE TABLE IF NOT EXISTS product_agreement_consents (
     id SERIAL PRIMARY KEY,
     request_id VARCHAR(64) UNIQUE NOT NULL,
     consent_id VARCHAR(64) UNIQUE NOT NULL,
@@ -140,10 +134,6 @@ CREATE TABLE product_agreement_consents (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
-
-
-
-
-CREATE INDEX idx_users_bank_id ON users(bank_id);
-CREATE INDEX idx_accounts_user_id ON accounts(user_id);
-CREATE INDEX idx_transactions_account_id ON transactions(account_id);
+CREATE INDEX IF NOT EXISTS idx_users_bank_id ON users(bank_id);
+CREATE INDEX IF NOT EXISTS idx_accounts_user_id ON accounts(user_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_account_id ON transactions(account_id);
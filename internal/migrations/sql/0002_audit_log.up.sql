@@ -0,0 +1,31 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'; -- user | admin
+
+-- 🧾 Журнал аудита (только добавление)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,        -- bank_call | user_action
+    user_id INT,                      -- без FK: история должна переживать удаление пользователя
+    action VARCHAR(64) NOT NULL,
+    bank_code VARCHAR(32),
+    method VARCHAR(8),
+    endpoint TEXT,
+    consent_id VARCHAR(64),
+    status_code INT,
+    latency_ms BIGINT,
+    request_id VARCHAR(64),
+    details JSONB,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_no_modify ON audit_log;
+CREATE TRIGGER audit_log_no_modify
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_created ON audit_log(user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS balance_snapshots;
+DROP INDEX IF EXISTS idx_accounts_bank_external;
+ALTER TABLE accounts DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,15 @@
+-- Идентификатор счёта в API банка: по нему синхронизируем счета
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS external_id VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_bank_external ON accounts(bank_id, external_id);
+
+-- 📊 История балансов
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    available NUMERIC(18,2),
+    booked NUMERIC(18,2),
+    currency VARCHAR(8),
+    taken_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_snapshots_account_taken ON balance_snapshots(account_id, taken_at);
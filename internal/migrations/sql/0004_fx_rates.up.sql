@@ -0,0 +1,13 @@
+-- Базовая валюта пользователя: в ней считаются итоги
+ALTER TABLE users ADD COLUMN IF NOT EXISTS base_currency VARCHAR(8) NOT NULL DEFAULT 'RUB';
+
+-- 💱 Курсы валют по дням: 1 base = rate quote
+CREATE TABLE IF NOT EXISTS fx_rates (
+    rate_date DATE NOT NULL,
+    base VARCHAR(8) NOT NULL,
+    quote VARCHAR(8) NOT NULL,
+    rate NUMERIC(20,10) NOT NULL CHECK (rate > 0),
+    source VARCHAR(32) NOT NULL, -- manual | file | http
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (base, quote, rate_date)
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS category_rules;
+DROP INDEX IF EXISTS idx_transactions_account_booking;
+DROP INDEX IF EXISTS idx_transactions_account_external;
+ALTER TABLE transactions DROP COLUMN IF EXISTS category_source;
+ALTER TABLE transactions DROP COLUMN IF EXISTS counterparty;
+ALTER TABLE transactions DROP COLUMN IF EXISTS mcc;
+ALTER TABLE transactions DROP COLUMN IF EXISTS external_id;
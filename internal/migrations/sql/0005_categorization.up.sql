@@ -0,0 +1,25 @@
+-- Поля операций из API банка для синхронизации и категоризации
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_id VARCHAR(128);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS mcc VARCHAR(8);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS category_source VARCHAR(16); -- default | rule | manual
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_account_external ON transactions(account_id, external_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_account_booking ON transactions(account_id, booking_date);
+
+-- 🏷️ Правила категоризации пользователя (встроенные правила живут в коде)
+CREATE TABLE IF NOT EXISTS category_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(64) NOT NULL,
+    mcc_codes TEXT[],
+    counterparty VARCHAR(255),
+    description_regex TEXT,
+    min_amount NUMERIC(18,2),
+    max_amount NUMERIC(18,2),
+    direction VARCHAR(8),                 -- debit | credit
+    priority INT NOT NULL DEFAULT 100,    -- меньше — раньше
+    learned BOOLEAN NOT NULL DEFAULT false, -- создано из ручной правки категории
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_rules_user ON category_rules(user_id);
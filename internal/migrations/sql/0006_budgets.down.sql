@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS budget_alerts;
+DROP TABLE IF EXISTS budgets;
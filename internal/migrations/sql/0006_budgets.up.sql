@@ -0,0 +1,25 @@
+-- 🎯 Бюджеты по категории или продавцу
+CREATE TABLE IF NOT EXISTS budgets (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    period VARCHAR(8) NOT NULL CHECK (period IN ('week', 'month')),
+    category VARCHAR(64),
+    merchant VARCHAR(255),
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(8) NOT NULL DEFAULT 'RUB',
+    rollover BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CHECK (category IS NOT NULL OR merchant IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_user ON budgets(user_id);
+
+-- Отправленные уведомления о порогах (50/80/100%), чтобы не слать повторно в том же периоде
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    budget_id INT NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    threshold INT NOT NULL,
+    sent_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (budget_id, period_start, threshold)
+);
@@ -0,0 +1,9 @@
+-- 🔁 Решения пользователя по найденным регулярным платежам.
+-- Сами серии вычисляются по операциям, здесь только подтверждение/скрытие по ключу серии.
+CREATE TABLE IF NOT EXISTS recurring_decisions (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    series_id VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('confirmed', 'dismissed')),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, series_id)
+);
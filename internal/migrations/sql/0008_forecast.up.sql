@@ -0,0 +1,7 @@
+-- 📉 Порог остатка для предупреждений прогноза: одна запись на клиента, сумма в валюте currency
+CREATE TABLE IF NOT EXISTS forecast_thresholds (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC(18,2) NOT NULL CHECK (amount >= 0),
+    currency VARCHAR(8) NOT NULL DEFAULT 'RUB',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
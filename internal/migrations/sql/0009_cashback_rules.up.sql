@@ -0,0 +1,13 @@
+-- 💳 Кешбэк по продуктам банков, заданный клиентом: банк, продукт (пусто — любой), категория (пусто — любая)
+CREATE TABLE IF NOT EXISTS cashback_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    bank_id INT NOT NULL REFERENCES banks(id) ON DELETE CASCADE,
+    product_id VARCHAR(64),
+    category VARCHAR(64),
+    percent NUMERIC(5,2) NOT NULL CHECK (percent > 0 AND percent <= 100),
+    max_cashback NUMERIC(18,2),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cashback_rules_user ON cashback_rules(user_id);
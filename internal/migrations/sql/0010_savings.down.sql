@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sweep_transfers;
+DROP TABLE IF EXISTS sweep_rules;
+DROP TABLE IF EXISTS savings_goals;
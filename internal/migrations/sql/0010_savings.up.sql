@@ -0,0 +1,59 @@
+-- 🐷 Цели накоплений: копятся на сберегательном счёте или на вкладе (договор в банке)
+CREATE TABLE IF NOT EXISTS savings_goals (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    target_amount NUMERIC(18,2) NOT NULL CHECK (target_amount > 0),
+    currency VARCHAR(8) NOT NULL DEFAULT 'RUB',
+    target_date DATE,
+    account_id INT REFERENCES accounts(id) ON DELETE SET NULL,
+    bank_id INT REFERENCES banks(id),
+    agreement_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW(),
+    CHECK (account_id IS NOT NULL OR (bank_id IS NOT NULL AND agreement_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_savings_goals_user ON savings_goals(user_id);
+
+-- Правила автопополнения цели
+--   roundup        — округление покупок до round_to
+--   salary_percent — percent % от каждого поступления зарплаты
+--   excess         — всё, что на счёте сверх keep_amount
+CREATE TABLE IF NOT EXISTS sweep_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    goal_id INT NOT NULL REFERENCES savings_goals(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('roundup', 'salary_percent', 'excess')),
+    source_account_id INT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    round_to NUMERIC(18,2),
+    percent NUMERIC(5,2),
+    keep_amount NUMERIC(18,2),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    last_run_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sweep_rules_user ON sweep_rules(user_id);
+
+-- Переводы по правилам: предложение пользователю или исполненный платёж.
+-- source_key не даёт создать перевод дважды за одно и то же событие (операцию, день).
+CREATE TABLE IF NOT EXISTS sweep_transfers (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rule_id INT NOT NULL REFERENCES sweep_rules(id) ON DELETE CASCADE,
+    goal_id INT NOT NULL REFERENCES savings_goals(id) ON DELETE CASCADE,
+    source_account_id INT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(8) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'proposed'
+        CHECK (status IN ('proposed', 'approved', 'executed', 'rejected', 'failed')),
+    reason TEXT NOT NULL,
+    source_key VARCHAR(128) NOT NULL,
+    payment_id VARCHAR(64),
+    error TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (rule_id, source_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sweep_transfers_user_status ON sweep_transfers(user_id, status);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_products_bank_type;
+ALTER TABLE products DROP COLUMN IF EXISTS synced_at;
+ALTER TABLE products DROP COLUMN IF EXISTS is_active;
+ALTER TABLE products DROP COLUMN IF EXISTS capitalization;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
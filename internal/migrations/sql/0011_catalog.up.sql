@@ -0,0 +1,6 @@
+-- 🗂️ Каталог продуктов банков: синхронизируется из витрины продуктов каждого банка
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency VARCHAR(8);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS capitalization BOOLEAN NOT NULL DEFAULT false; -- ежемесячная капитализация процентов
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT true;      -- false — банк убрал продукт из витрины
+ALTER TABLE products ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_products_bank_type ON products(bank_id, product_type);
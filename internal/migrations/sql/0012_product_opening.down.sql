@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_product_agreements_status;
+ALTER TABLE product_agreements DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE product_agreements DROP COLUMN IF EXISTS source_account_id;
@@ -0,0 +1,4 @@
+-- Открытие продуктов через MoneyPilot: счёт списания и отслеживание статуса открытия
+ALTER TABLE product_agreements ADD COLUMN IF NOT EXISTS source_account_id VARCHAR(64);
+ALTER TABLE product_agreements ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS idx_product_agreements_status ON product_agreements(status);
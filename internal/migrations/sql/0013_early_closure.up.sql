@@ -0,0 +1,2 @@
+-- Ставка, по которой пересчитываются проценты при досрочном закрытии вклада (обычно «до востребования»)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS early_closure_rate NUMERIC(5,2);
@@ -0,0 +1,9 @@
+-- 📄 Ежемесячные PDF-отчёты, сформированные планировщиком: один на клиента за месяц
+CREATE TABLE IF NOT EXISTS monthly_reports (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month CHAR(7) NOT NULL, -- YYYY-MM
+    pdf BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, month)
+);
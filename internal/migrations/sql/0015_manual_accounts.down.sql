@@ -0,0 +1,3 @@
+DELETE FROM banks WHERE code = 'manual';
+ALTER TABLE accounts DROP COLUMN IF EXISTS institution;
+ALTER TABLE accounts DROP COLUMN IF EXISTS source;
@@ -0,0 +1,7 @@
+-- Ручные счета: банки без API, операции загружаются из файлов выписок
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS source VARCHAR(16) NOT NULL DEFAULT 'api';
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS institution VARCHAR(128);
+
+-- Служебный банк для ручных счетов: accounts.bank_id обязателен для сводок по банкам
+INSERT INTO banks (code, name, api_base_url) VALUES ('manual', 'Manual accounts', '')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,5 @@
+-- незавершённые переводы нельзя вернуть в approved: платёж мог уйти в банк
+UPDATE sweep_transfers SET status = 'failed' WHERE status IN ('executing', 'unconfirmed');
+ALTER TABLE sweep_transfers DROP CONSTRAINT IF EXISTS sweep_transfers_status_check;
+ALTER TABLE sweep_transfers ADD CONSTRAINT sweep_transfers_status_check
+    CHECK (status IN ('proposed', 'approved', 'executed', 'rejected', 'failed'));
@@ -0,0 +1,5 @@
+-- executing — перевод забран на исполнение, платёж отправляется в банк;
+-- unconfirmed — банк мог провести платёж, но ответ не получен или не сохранён: повторять нельзя.
+ALTER TABLE sweep_transfers DROP CONSTRAINT IF EXISTS sweep_transfers_status_check;
+ALTER TABLE sweep_transfers ADD CONSTRAINT sweep_transfers_status_check
+    CHECK (status IN ('proposed', 'approved', 'executing', 'executed', 'unconfirmed', 'rejected', 'failed'));
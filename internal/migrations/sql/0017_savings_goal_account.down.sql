@@ -0,0 +1,3 @@
+ALTER TABLE savings_goals DROP CONSTRAINT IF EXISTS savings_goals_account_id_fkey;
+ALTER TABLE savings_goals ADD CONSTRAINT savings_goals_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+-- SET NULL нарушал CHECK цели на счёт: удаление счёта падало. Цель без счёта бессмысленна —
+-- удаляется вместе с ним, как правила и переводы с этого счёта.
+ALTER TABLE savings_goals DROP CONSTRAINT IF EXISTS savings_goals_account_id_fkey;
+ALTER TABLE savings_goals ADD CONSTRAINT savings_goals_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE;
@@ -0,0 +1,6 @@
+-- одинаковые product_id разных банков не помещаются в старый ключ: остаётся последний сохранённый
+DELETE FROM products p USING products q WHERE p.product_id = q.product_id AND p.id < q.id;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_bank_product_key;
+ALTER TABLE products ADD CONSTRAINT products_product_id_key UNIQUE (product_id);
+ALTER TABLE product_agreements ADD CONSTRAINT product_agreements_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(product_id);
@@ -0,0 +1,5 @@
+-- Песочницы банков отдают одинаковые product_id: продукт уникален только в пределах банка.
+-- Договор ищет продукт в банке своего пользователя, поэтому FK по одному product_id снимается.
+ALTER TABLE product_agreements DROP CONSTRAINT IF EXISTS product_agreements_product_id_fkey;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_product_id_key;
+ALTER TABLE products ADD CONSTRAINT products_bank_product_key UNIQUE (bank_id, product_id);
@@ -0,0 +1,2 @@
+ALTER TABLE cashback_rules DROP CONSTRAINT IF EXISTS cashback_rules_cap_currency;
+ALTER TABLE cashback_rules DROP COLUMN IF EXISTS max_cashback_currency;
@@ -0,0 +1,8 @@
+-- Лимит кешбэка хранится со своей валютой: счета одного банка бывают в разных валютах.
+-- Старые лимиты считаем заданными в базовой валюте клиента.
+ALTER TABLE cashback_rules ADD COLUMN IF NOT EXISTS max_cashback_currency VARCHAR(8);
+UPDATE cashback_rules cr SET max_cashback_currency = u.base_currency
+FROM users u
+WHERE u.id = cr.user_id AND cr.max_cashback IS NOT NULL AND cr.max_cashback_currency IS NULL;
+ALTER TABLE cashback_rules ADD CONSTRAINT cashback_rules_cap_currency
+    CHECK (max_cashback IS NULL OR max_cashback_currency IS NOT NULL);
@@ -0,0 +1,33 @@
+// Package money — общие для сервисов операции с суммами: округление, дни, пересчёт валют
+package money
+
+import (
+	"math"
+	"time"
+)
+
+// Round округляет сумму до копеек
+func Round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// TruncateDay — начало дня t в UTC
+func TruncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Converter пересчитывает сумму по курсу на дату; его реализует fx.Service
+type Converter interface {
+	Convert(amount float64, from, to string, on time.Time) (float64, error)
+}
+
+// Convert пересчитывает amount из from в to; нулевая сумма, неизвестная или совпадающая валюта
+// возвращаются как есть, без обращения к курсам
+func Convert(c Converter, amount float64, from, to string, on time.Time) (float64, error) {
+	if amount == 0 || from == "" || to == "" || from == to {
+		return amount, nil
+	}
+	return c.Convert(amount, from, to, on)
+}
@@ -0,0 +1,178 @@
+package payments
+
+import (
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrNoConsent — нет действующего платёжного согласия, покрывающего перевод
+var ErrNoConsent = errors.New("no payment consent for this transfer")
+
+// ErrNotExecuted — банк точно не провёл платёж: запрос не отправлялся или банк ответил 4xx.
+// При прочих ошибках Execute платёж мог пройти, повторять его нельзя.
+var ErrNotExecuted = errors.New("payment was not executed")
+
+// Transfer — перевод между счетами клиента; номера счетов — identification из банка
+type Transfer struct {
+	BankCode        string
+	BankID          int
+	DebtorAccount   string
+	CreditorAccount string
+	Amount          float64
+	Currency        string
+	Reference       string
+	IdempotencyKey  string // банк не проведёт второй платёж с тем же ключом
+}
+
+type Service struct {
+	Repo        storage.Store
+	Payments    storage.PaymentRepository
+	TokenSvc    *bankapi.TokenService
+	BankClients map[string]*bankapi.BankClient
+	HTTPClient  *http.Client
+	Audit       *audit.Service
+}
+
+func NewService(repo storage.Store, payments storage.PaymentRepository, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient, httpClient *http.Client, auditSvc *audit.Service) *Service {
+	return &Service{
+		Repo:        repo,
+		Payments:    payments,
+		TokenSvc:    ts,
+		BankClients: clients,
+		HTTPClient:  httpClient,
+		Audit:       auditSvc,
+	}
+}
+
+// FindConsent ищет согласие банка перевода, чьи счета и лимит суммы покрывают перевод
+func (s *Service) FindConsent(userID int, t Transfer) (*storage.PaymentConsent, error) {
+	consents, err := s.Payments.GetActivePaymentConsents(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range consents {
+		if c.BankID == nil || *c.BankID != t.BankID {
+			continue
+		}
+		if c.DebtorAccount != nil && *c.DebtorAccount != "" && *c.DebtorAccount != t.DebtorAccount {
+			continue
+		}
+		if c.CreditorAccount != nil && *c.CreditorAccount != "" && *c.CreditorAccount != t.CreditorAccount {
+			continue
+		}
+		if c.Amount != nil && *c.Amount < t.Amount {
+			continue
+		}
+		return &consents[i], nil
+	}
+	return nil, ErrNoConsent
+}
+
+// Execute инициирует платёж в банке по согласию, сохраняет его и пишет в аудит.
+// Если банк принял платёж, но сохранить его не удалось, возвращается и платёж, и ошибка.
+func (s *Service) Execute(ctx context.Context, userID int, t Transfer) (*storage.Payment, error) {
+	consent, err := s.FindConsent(userID, t)
+	if err != nil {
+		return nil, err
+	}
+	bankClient := s.BankClients[t.BankCode]
+	if bankClient == nil {
+		return nil, fmt.Errorf("%w: unknown bank code %s", ErrNotExecuted, t.BankCode)
+	}
+	user, err := s.Repo.GetUserByUserIDAndBank(userID, t.BankCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotExecuted, err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("%w: user not found for bank %s", ErrNotExecuted, t.BankCode)
+	}
+	tokenObj, err := s.TokenSvc.GetValidToken(bankClient)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotExecuted, err)
+	}
+
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"initiation": map[string]interface{}{
+				"instructedAmount": map[string]string{
+					"amount":   strconv.FormatFloat(t.Amount, 'f', 2, 64),
+					"currency": t.Currency,
+				},
+				"debtorAccount":   map[string]string{"schemeName": "RU.CBR.PAN", "identification": t.DebtorAccount},
+				"creditorAccount": map[string]string{"schemeName": "RU.CBR.PAN", "identification": t.CreditorAccount},
+				"comment":         t.Reference,
+			},
+		},
+	}
+	body, _ := json.Marshal(payload)
+	url := fmt.Sprintf("%s/payments?client_id=%s", bankClient.BaseURL, user.ClientID)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(body)))
+	req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-payment-consent-id", consent.ConsentID)
+	req.Header.Set("x-requesting-bank", bankClient.ClientID)
+	if t.IdempotencyKey != "" {
+		req.Header.Set("x-idempotency-key", t.IdempotencyKey)
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if errors.Is(err, bankapi.ErrCircuitOpen) {
+		return nil, fmt.Errorf("%w: %v", ErrNotExecuted, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var errResp map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return nil, fmt.Errorf("%w: bank returned %d: %v", ErrNotExecuted, resp.StatusCode, errResp)
+		}
+		return nil, fmt.Errorf("bank returned %d: %v", resp.StatusCode, errResp)
+	}
+
+	var result struct {
+		Data struct {
+			PaymentID string `json:"paymentId"`
+			Status    string `json:"status"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("unexpected payment response: %w", err)
+	}
+	if result.Data.Status == "" {
+		result.Data.Status = "pending"
+	}
+
+	p := &storage.Payment{
+		PaymentID:       result.Data.PaymentID,
+		UserID:          &user.ID,
+		DebtorAccount:   &t.DebtorAccount,
+		CreditorAccount: &t.CreditorAccount,
+		Amount:          &t.Amount,
+		Currency:        &t.Currency,
+		Status:          result.Data.Status,
+	}
+	if err := s.Payments.InsertPayment(p); err != nil {
+		return p, err
+	}
+
+	s.Audit.RecordAction(ctx, audit.ActionTransfer, t.BankCode, consent.ConsentID, map[string]interface{}{
+		"payment_id": p.PaymentID,
+		"amount":     t.Amount,
+		"currency":   t.Currency,
+		"reference":  t.Reference,
+	})
+	return p, nil
+}
@@ -2,15 +2,18 @@ package poller
 
 import (
 	"MoneyPilot/internal/storage"
+	"context"
 )
 
 // --- AccountConsents adapter ---
 type AccountConsentRepoAdapter struct {
-	Repo *storage.Repository
+	Repo storage.Store
 }
 
-func (a *AccountConsentRepoAdapter) GetPendingConsents() ([]ConsentRecord, error) {
-	items, err := a.Repo.GetPendingAccountConsents()
+func (a *AccountConsentRepoAdapter) ConsentType() string { return "account" }
+
+func (a *AccountConsentRepoAdapter) GetPendingConsents(ctx context.Context) ([]ConsentRecord, error) {
+	items, err := a.Repo.WithContext(ctx).GetPendingAccountConsents()
 	if err != nil {
 		return nil, err
 	}
@@ -21,61 +24,55 @@ func (a *AccountConsentRepoAdapter) GetPendingConsents() ([]ConsentRecord, error
 			BankCode:       derefString(c.BankCode),
 			UserID:         c.UserID,
 			Status:         c.Status,
-			ConsentType:    "account",
+			ConsentType:    a.ConsentType(),
 			RequestingBank: derefString(c.RequestingBank),
+			CreatedAt:      c.CreatedAt,
 		})
 	}
 	return res, nil
 }
 
-func (a *AccountConsentRepoAdapter) UpdateConsentStatus(consentID string, status string) error {
-	return a.Repo.UpdateAccountConsentStatusByConsentID(consentID, status)
+func (a *AccountConsentRepoAdapter) UpdateConsentStatus(ctx context.Context, consentID string, status string) error {
+	return a.Repo.WithContext(ctx).UpdateAccountConsentStatusByConsentID(consentID, status)
 }
 
-func (a *AccountConsentRepoAdapter) UpdateConsentID(oldID, newID, status string) error {
-	return a.Repo.UpdateAccountConsentIDAndStatus(oldID, newID, status)
+func (a *AccountConsentRepoAdapter) UpdateConsentID(ctx context.Context, oldID, newID, status string) error {
+	return a.Repo.WithContext(ctx).UpdateAccountConsentIDAndStatus(oldID, newID, status)
 }
 
 // --- ProductConsents adapter ---
 type ProductConsentRepoAdapter struct {
-	Repo *storage.Repository
+	Repo storage.Store
 }
 
-func (p *ProductConsentRepoAdapter) GetPendingConsents() ([]ConsentRecord, error) {
-	rows, err := p.Repo.DB.Query(`
-		SELECT ac.id, ac.consent_id, ac.user_id, b.code AS bank_code, ac.requesting_bank, ac.status
-		FROM product_agreement_consents ac
-		LEFT JOIN banks b ON b.id = ac.bank_id
-		WHERE ac.status = 'pending'
-	`)
+func (p *ProductConsentRepoAdapter) ConsentType() string { return "product-agreement" }
+
+func (p *ProductConsentRepoAdapter) GetPendingConsents(ctx context.Context) ([]ConsentRecord, error) {
+	items, err := p.Repo.WithContext(ctx).GetPendingProductConsents()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var results []ConsentRecord
-	for rows.Next() {
-		var c ConsentRecord
-		var bankCode, reqBank string
-		if err := rows.Scan(&c.UserID, &c.ConsentID, &bankCode, &reqBank, &c.Status); err != nil {
-			return nil, err
-		}
-		c.BankCode = bankCode
-		c.RequestingBank = reqBank
-		c.ConsentType = "product-agreement"
-		results = append(results, c)
+	res := make([]ConsentRecord, 0, len(items))
+	for _, c := range items {
+		res = append(res, ConsentRecord{
+			ConsentID:      c.ConsentID,
+			BankCode:       derefString(c.BankCode),
+			UserID:         c.UserID,
+			Status:         c.Status,
+			ConsentType:    p.ConsentType(),
+			RequestingBank: derefString(c.RequestingBank),
+			CreatedAt:      c.CreatedAt,
+		})
 	}
-	return results, nil
+	return res, nil
 }
 
-func (p *ProductConsentRepoAdapter) UpdateConsentStatus(consentID string, status string) error {
-	_, err := p.Repo.DB.Exec(`UPDATE product_agreement_consents SET status=$1 WHERE consent_id=$2`, status, consentID)
-	return err
+func (p *ProductConsentRepoAdapter) UpdateConsentStatus(ctx context.Context, consentID string, status string) error {
+	return p.Repo.WithContext(ctx).UpdateProductConsentStatusByConsentID(consentID, status)
 }
 
-func (p *ProductConsentRepoAdapter) UpdateConsentID(oldID, newID, status string) error {
-	_, err := p.Repo.DB.Exec(`UPDATE product_agreement_consents SET consent_id=$1, status=$2 WHERE consent_id=$3`, newID, status, oldID)
-	return err
+func (p *ProductConsentRepoAdapter) UpdateConsentID(ctx context.Context, oldID, newID, status string) error {
+	return p.Repo.WithContext(ctx).UpdateProductConsentIDAndStatus(oldID, newID, status)
 }
 
 // helper
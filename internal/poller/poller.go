@@ -1,16 +1,24 @@
 package poller
 
 import (
+	"MoneyPilot/internal/audit"
 	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/metrics"
 	"MoneyPilot/internal/websockets"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ConsentRecord struct {
@@ -20,13 +28,15 @@ type ConsentRecord struct {
 	Status         string
 	ConsentType    string // "account" | "product"
 	RequestingBank string
+	CreatedAt      time.Time
 }
 
 // Интерфейс, который должен реализовать любой репозиторий с согласиями (на счета, продукты и т.д.)
 type ConsentRepo interface {
-	GetPendingConsents() ([]ConsentRecord, error)
-	UpdateConsentStatus(consentID string, status string) error
-	UpdateConsentID(oldID, newID, status string) error
+	ConsentType() string
+	GetPendingConsents(ctx context.Context) ([]ConsentRecord, error)
+	UpdateConsentStatus(ctx context.Context, consentID string, status string) error
+	UpdateConsentID(ctx context.Context, oldID, newID, status string) error
 }
 
 // Poller — универсальный менеджер согласий
@@ -39,6 +49,9 @@ type Poller struct {
 	subscribers map[string]chan string // consentID → канал, который ждёт подтверждения
 	mu          sync.Mutex
 	WSHub       *websockets.WebSocketHub
+	Log         *slog.Logger
+
+	lastCycle atomic.Int64 // unix nano окончания последнего цикла (heartbeat для /readyz)
 }
 
 // Создаёт новый Poller
@@ -46,23 +59,26 @@ func NewPoller(
 	repos []ConsentRepo,
 	tokenSvc *bankapi.TokenService,
 	banks map[string]*bankapi.BankClient,
+	httpClient *http.Client,
 	hub *websockets.WebSocketHub, // новый аргумент
-
+	logger *slog.Logger,
 ) *Poller {
 	return &Poller{
 		Repos:       repos,
 		TokenSvc:    tokenSvc,
 		BankClients: banks,
-		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		HTTPClient:  httpClient,
 		subscribers: make(map[string]chan string),
 		WSHub:       hub,
+		Log:         logging.For(logger, "poller"),
 	}
 }
 
 // 🔁 Запуск фонового процесса опроса
 func (p *Poller) Start(interval time.Duration, stopCh <-chan struct{}) {
 	ticker := time.NewTicker(interval)
-	log.Printf("[poller] started, interval=%s", interval)
+	p.lastCycle.Store(time.Now().UnixNano())
+	p.Log.Info("started", "interval", interval)
 
 	go func() {
 		defer ticker.Stop()
@@ -71,61 +87,97 @@ func (p *Poller) Start(interval time.Duration, stopCh <-chan struct{}) {
 			case <-ticker.C:
 				p.pollAll()
 			case <-stopCh:
-				log.Println("[poller] stopping")
+				p.Log.Info("stopping")
 				return
 			}
 		}
 	}()
 }
 
-// Проверка всех согласий из всех репозиториев
+// Проверка всех согласий из всех репозиториев; цикл ждёт все проверки, чтобы его длительность попала в метрики.
+// Проверки согласий одного типа — один трейс с запросами к банкам и БД; пустые циклы не трейсятся.
 func (p *Poller) pollAll() {
+	start := time.Now()
+	var (
+		wg    sync.WaitGroup
+		spans []trace.Span
+	)
 	for _, repo := range p.Repos {
-		consents, err := repo.GetPendingConsents()
+		consents, err := repo.GetPendingConsents(context.Background())
 		if err != nil {
-			log.Printf("[poller] failed to load consents: %v", err)
+			p.Log.Error("failed to load consents", "type", repo.ConsentType(), logging.Err(err))
+			continue
+		}
+		created := make([]time.Time, 0, len(consents))
+		for _, c := range consents {
+			created = append(created, c.CreatedAt)
+		}
+		metrics.ObserveConsents(repo.ConsentType(), created)
+		if len(consents) == 0 {
 			continue
 		}
+
+		ctx, span := otel.Tracer("MoneyPilot/internal/poller").Start(context.Background(), "poller "+repo.ConsentType()+" consents")
+		spans = append(spans, span)
 		for _, c := range consents {
-			go p.checkConsentStatus(repo, c)
+			wg.Add(1)
+			go func(c ConsentRecord) {
+				defer wg.Done()
+				p.checkConsentStatus(ctx, repo, c)
+			}(c)
 		}
 	}
+	wg.Wait()
+	for _, span := range spans {
+		span.End()
+	}
+	metrics.PollerCycle.Observe(time.Since(start).Seconds())
+	p.lastCycle.Store(time.Now().UnixNano())
+}
+
+// LastCycle — когда закончился последний цикл опроса; нулевое время — поллер не запущен
+func (p *Poller) LastCycle() time.Time {
+	if ns := p.lastCycle.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
 }
 
 // Проверка конкретного согласия через API банка
-func (p *Poller) checkConsentStatus(repo ConsentRepo, c ConsentRecord) {
+func (p *Poller) checkConsentStatus(ctx context.Context, repo ConsentRepo, c ConsentRecord) {
 	if c.BankCode == "" {
 		return
 	}
+	log := p.Log.With("bank", c.BankCode, "consent_id", c.ConsentID, "type", c.ConsentType)
 	client := p.BankClients[c.BankCode]
 	if client == nil {
-		log.Printf("[poller] unknown bank code %s", c.BankCode)
+		log.Warn("unknown bank code")
 		return
 	}
 
 	token, err := p.TokenSvc.GetValidToken(client)
 	if err != nil {
-		log.Printf("[poller] failed to get token for %s: %v", c.BankCode, err)
+		log.Error("failed to get token", logging.Err(err))
 		return
 	}
 
 	// endpoint зависит от типа согласия
 	url := fmt.Sprintf("%s/%s-consents/%s", strings.TrimRight(client.BaseURL, "/"), c.ConsentType, c.ConsentID)
 
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(audit.WithUserID(ctx, c.UserID), "GET", url, nil)
 	req.Header.Set("Authorization", "Bearer "+token.Token)
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := p.HTTPClient.Do(req)
 	if err != nil {
-		log.Printf("[poller] request failed: %v", err)
+		log.Error("request failed", logging.Err(err))
 		return
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Printf("[poller] non-200 for %s: %d %s", c.ConsentID, resp.StatusCode, string(body))
+		log.Warn("unexpected status", "status", resp.StatusCode, logging.Body(body))
 		return
 	}
 
@@ -136,18 +188,18 @@ func (p *Poller) checkConsentStatus(repo ConsentRepo, c ConsentRecord) {
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(body, &wrapper); err != nil {
-		log.Printf("[poller] unmarshal failed: %v (body=%s)", err, string(body))
+		log.Error("unmarshal failed", logging.Err(err), logging.Body(body))
 		return
 	}
 	parsed := wrapper.Data
-	log.Printf("[poller] parsed consentId=%s, status=%s", parsed.ConsentID, parsed.Status)
+	log.Debug("consent status", "status", parsed.Status, "bank_consent_id", parsed.ConsentID)
 	if strings.EqualFold(parsed.Status, "approved") ||
 		strings.EqualFold(parsed.Status, "authorized") ||
 		strings.EqualFold(parsed.Status, "authorised") {
 
-		log.Printf("[poller] consent %s approved ✅", c.ConsentID)
-		repo.UpdateConsentStatus(c.ConsentID, "approved")
-		repo.UpdateConsentID(c.ConsentID, parsed.ConsentID, "approved")
+		log.Info("consent approved")
+		repo.UpdateConsentStatus(ctx, c.ConsentID, "approved")
+		repo.UpdateConsentID(ctx, c.ConsentID, parsed.ConsentID, "approved")
 		p.notifySubscribers(c.ConsentID)
 	}
 }
@@ -180,6 +232,6 @@ func (p *Poller) notifySubscribers(consentID string) {
 	if p.WSHub != nil {
 		msg := fmt.Sprintf(`{"consent_id":"%s","status":"approved"}`, consentID)
 		p.WSHub.Broadcast(msg)
-		log.Printf("[poller→ws] sent WS update: %s", msg)
+		p.Log.Debug("sent WS update", "consent_id", consentID)
 	}
 }
@@ -0,0 +1,204 @@
+package productagreements
+
+import (
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// defaultEarlyClosureRate — ставка «до востребования», если в каталоге нет условий досрочного закрытия, %
+const defaultEarlyClosureRate = 0.01
+
+// ErrQuoteMismatch — закрытие без quote_id или по устаревшему расчёту
+var ErrQuoteMismatch = errors.New("closure quote is missing or outdated")
+
+// ClosureQuote — что клиент получит, закрыв договор сегодня, и что потеряет по сравнению с окончанием срока
+type ClosureQuote struct {
+	QuoteID          string    `json:"quote_id"` // передаётся в DELETE как подтверждение расчёта
+	AgreementID      string    `json:"agreement_id"`
+	ProductID        string    `json:"product_id"`
+	ProductName      string    `json:"product_name"`
+	ProductType      string    `json:"product_type"`
+	Bank             string    `json:"bank"`
+	AsOf             string    `json:"as_of"`
+	StartDate        string    `json:"start_date,omitempty"`
+	EndDate          *string   `json:"end_date,omitempty"`
+	Principal        float64   `json:"principal"`
+	InterestRate     float64   `json:"interest_rate"`
+	Capitalization   bool      `json:"capitalization"`
+	Early            bool      `json:"early"`
+	EarlyClosureRate *float64  `json:"early_closure_rate,omitempty"`
+	DaysHeld         int       `json:"days_held"`
+	AccruedInterest  float64   `json:"accrued_interest"` // по ставке договора на сегодня
+	PayoutInterest   float64   `json:"payout_interest"`  // проценты, которые банк выплатит при закрытии сегодня
+	Penalty          float64   `json:"penalty"`          // проценты, теряемые из-за досрочного закрытия
+	Payout           float64   `json:"payout"`
+	PayoutAccount    *string   `json:"payout_account,omitempty"`
+	HoldToMaturity   *Maturity `json:"hold_to_maturity,omitempty"`
+}
+
+// Maturity — выплата, если дождаться окончания срока
+type Maturity struct {
+	Date     string  `json:"date"`
+	DaysLeft int     `json:"days_left"`
+	Interest float64 `json:"interest"`
+	Payout   float64 `json:"payout"`
+	Gain     float64 `json:"gain"` // на сколько выплата больше, чем при закрытии сегодня
+}
+
+// ClosureQuote считает проценты на дату now по деталям договора из банка и условиям продукта из каталога.
+// Без даты начала (карты, текущие счета) процентов и штрафа нет: клиент получает остаток.
+func (s *Service) ClosureQuote(ctx context.Context, userID int, bankCode, agreementID string, now time.Time) (*ClosureQuote, error) {
+	details, err := s.GetProductDetails(ctx, userID, bankCode, agreementID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &ClosureQuote{
+		AgreementID: details.AgreementID,
+		ProductID:   details.ProductID,
+		ProductName: details.ProductName,
+		ProductType: details.ProductType,
+		Bank:        bankCode,
+		AsOf:        now.Format("2006-01-02"),
+		Principal:   details.Amount,
+	}
+	if q.AgreementID == "" {
+		q.AgreementID = agreementID
+	}
+	q.PayoutAccount = details.AccountNumber
+	if a := s.agreement(userID, bankCode, agreementID); a != nil && a.SourceAccountID != nil {
+		q.PayoutAccount = a.SourceAccountID
+	}
+
+	start, err := parseDate(details.StartDate)
+	if err != nil {
+		if details.StartDate != "" {
+			s.Log.WarnContext(ctx, "invalid agreement start_date", "agreement_id", agreementID, "start_date", details.StartDate)
+		}
+		q.Payout = money.Round(q.Principal)
+		q.QuoteID = quoteID(q, userID)
+		return q, nil
+	}
+	q.StartDate = start.Format("2006-01-02")
+
+	earlyRate := defaultEarlyClosureRate
+	bank, err := s.Repo.GetBankByCode(bankCode)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.Repo.GetProductByProductID(bank.ID, details.ProductID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if product != nil {
+		q.Capitalization = product.Capitalization
+		if product.EarlyClosureRate != nil {
+			earlyRate = *product.EarlyClosureRate
+		}
+		if details.InterestRate == nil && product.InterestRate != nil {
+			q.InterestRate = *product.InterestRate
+		}
+	}
+	if details.InterestRate != nil {
+		q.InterestRate = *details.InterestRate
+	}
+
+	q.DaysHeld = daysBetween(start, now)
+	var end time.Time
+	if details.EndDate != nil && *details.EndDate != "" {
+		if end, err = parseDate(*details.EndDate); err != nil {
+			return nil, fmt.Errorf("agreement %s: invalid end_date %q", agreementID, *details.EndDate)
+		}
+		e := end.Format("2006-01-02")
+		q.EndDate = &e
+		// после окончания срока проценты не начисляются
+		if termDays := daysBetween(start, end); q.DaysHeld > termDays {
+			q.DaysHeld = termDays
+		}
+	}
+
+	q.AccruedInterest = money.Round(interest(q.Principal, q.InterestRate, q.Capitalization, q.DaysHeld))
+	q.PayoutInterest = q.AccruedInterest
+	q.Early = !end.IsZero() && now.Before(end)
+	if q.Early {
+		q.EarlyClosureRate = &earlyRate
+		q.PayoutInterest = money.Round(math.Min(interest(q.Principal, earlyRate, false, q.DaysHeld), q.AccruedInterest))
+		q.Penalty = money.Round(q.AccruedInterest - q.PayoutInterest)
+
+		termDays := daysBetween(start, end)
+		m := &Maturity{
+			Date:     end.Format("2006-01-02"),
+			DaysLeft: termDays - q.DaysHeld,
+			Interest: money.Round(interest(q.Principal, q.InterestRate, q.Capitalization, termDays)),
+		}
+		m.Payout = money.Round(q.Principal + m.Interest)
+		q.HoldToMaturity = m
+	}
+	q.Payout = money.Round(q.Principal + q.PayoutInterest)
+	if q.HoldToMaturity != nil {
+		q.HoldToMaturity.Gain = money.Round(q.HoldToMaturity.Payout - q.Payout)
+	}
+
+	q.QuoteID = quoteID(q, userID)
+	return q, nil
+}
+
+// quoteID не меняется в течение дня, пока не изменились выплата и штраф
+func quoteID(q *ClosureQuote, userID int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%.2f|%.2f", q.AgreementID, userID, q.AsOf, q.Payout, q.Penalty)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// agreement — договор, открытый через MoneyPilot; для остальных nil
+func (s *Service) agreement(userID int, bankCode, agreementID string) *storage.ProductAgreement {
+	user, err := s.Repo.GetUserByUserIDAndBank(userID, bankCode)
+	if err != nil || user == nil {
+		return nil
+	}
+	agreements, err := s.Repo.GetProductAgreementsByUserID(user.ID)
+	if err != nil {
+		return nil
+	}
+	for i := range agreements {
+		if agreements[i].AgreementID == agreementID {
+			return &agreements[i]
+		}
+	}
+	return nil
+}
+
+// interest — проценты на principal за days дней по годовой ставке rate; при капитализации — ежемесячно сложные
+func interest(principal, rate float64, capitalization bool, days int) float64 {
+	if principal <= 0 || rate <= 0 || days <= 0 {
+		return 0
+	}
+	r := rate / 100
+	if capitalization {
+		return principal * (math.Pow(1+r/12, float64(days)/(365.0/12)) - 1)
+	}
+	return principal * r * float64(days) / 365
+}
+
+func daysBetween(from, to time.Time) int {
+	d := int(to.Sub(from).Hours() / 24)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// parseDate — банки отдают даты как YYYY-MM-DD или RFC 3339
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,289 @@
+package productagreements
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// closureService отдаёт детали договоров из agreements через тестовый банк;
+// клиент team-1 с согласием на продукты vbank заведён в repo, его id возвращается вторым
+func closureService(t *testing.T, repo *memory.Store, agreements map[string]ProductDetails) (*Service, int) {
+	t.Helper()
+	vbank := repo.AddBank(storage.Bank{Code: "vbank"})
+	u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &vbank.ID})
+	if err := repo.SaveProductAgreementConsent("team-1", "vbank", "req-1", "pac-1", "team",
+		true, false, true, nil, 0, "approved", time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/bank-token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"token"}`))
+	})
+	mux.HandleFunc("/product-agreements/", func(w http.ResponseWriter, r *http.Request) {
+		d, ok := agreements[strings.TrimPrefix(r.URL.Path, "/product-agreements/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": d})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	// Redis недоступен: токен каждый раз берётся у тестового банка
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+	t.Cleanup(func() { rdb.Close() })
+	logger := slog.New(slog.NewTextHandler(testWriter{t}, nil))
+	banks := map[string]*bankapi.BankClient{"vbank": {Name: "vbank", BaseURL: srv.URL}}
+	return NewService(repo, bankapi.NewTokenService(rdb, logger), banks, srv.Client(), nil, nil, logger), u.ID
+}
+
+type testWriter struct{ t *testing.T }
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func TestClosureQuote(t *testing.T) {
+	agreements := map[string]ProductDetails{
+		"early": {
+			ProductID: "dep-10", ProductType: "deposit", Amount: 100000, InterestRate: float(10),
+			StartDate: "2024-01-01", EndDate: str("2025-01-01"),
+		},
+		"capitalized": {
+			ProductID: "dep-cap", ProductType: "deposit", Amount: 100000, InterestRate: float(12),
+			StartDate: "2024-01-01T00:00:00Z",
+		},
+		"matured": {
+			ProductID: "dep-10", ProductType: "deposit", Amount: 100000, InterestRate: float(10),
+			StartDate: "2024-01-01", EndDate: str("2024-03-01"),
+		},
+		"catalog-rate": {
+			ProductID: "dep-8", ProductType: "deposit", Amount: 50000,
+			StartDate: "2024-01-01", EndDate: str("2025-01-01"),
+		},
+		"card": {
+			ProductID: "card-1", ProductType: "card", Amount: 1234.567, AccountNumber: str("40817810000000000001"),
+		},
+		"bad-start": {
+			ProductID: "dep-10", ProductType: "deposit", Amount: 1000, InterestRate: float(10), StartDate: "01.01.2024",
+		},
+		"bad-end": {
+			ProductID: "dep-10", ProductType: "deposit", Amount: 1000, InterestRate: float(10),
+			StartDate: "2024-01-01", EndDate: str("someday"),
+		},
+	}
+	repo := memory.New()
+	s, userID := closureService(t, repo, agreements)
+	vbank, err := repo.GetBankByCode("vbank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []storage.Product{
+		{BankID: &vbank.ID, ProductID: "dep-10", EarlyClosureRate: float(0.01)},
+		{BankID: &vbank.ID, ProductID: "dep-cap", Capitalization: true},
+		{BankID: &vbank.ID, ProductID: "dep-8", InterestRate: float(8)},
+	} {
+		p := p
+		if err := repo.UpsertProduct(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		agreement string
+		want      ClosureQuote
+		err       bool
+	}{
+		{
+			// 182 дня из 366: проценты по ставке 0.01% вместо 10%
+			agreement: "early",
+			want: ClosureQuote{
+				InterestRate: 10, Early: true, EarlyClosureRate: float(0.01), DaysHeld: 182,
+				AccruedInterest: 4986.30, PayoutInterest: 4.99, Penalty: 4981.31, Payout: 100004.99,
+				HoldToMaturity: &Maturity{Date: "2025-01-01", DaysLeft: 184, Interest: 10027.40, Payout: 110027.40, Gain: 10022.41},
+			},
+		},
+		{
+			agreement: "capitalized",
+			want: ClosureQuote{
+				InterestRate: 12, Capitalization: true, DaysHeld: 182,
+				AccruedInterest: 6134.65, PayoutInterest: 6134.65, Payout: 106134.65,
+			},
+		},
+		{
+			// после окончания срока проценты не растут и штрафа нет
+			agreement: "matured",
+			want: ClosureQuote{
+				InterestRate: 10, DaysHeld: 60,
+				AccruedInterest: 1643.84, PayoutInterest: 1643.84, Payout: 101643.84,
+			},
+		},
+		{
+			// ставка из каталога, ставка досрочного закрытия по умолчанию
+			agreement: "catalog-rate",
+			want: ClosureQuote{
+				InterestRate: 8, Early: true, EarlyClosureRate: float(defaultEarlyClosureRate), DaysHeld: 182,
+				AccruedInterest: 1994.52, PayoutInterest: 2.49, Penalty: 1992.03, Payout: 50002.49,
+				HoldToMaturity: &Maturity{Date: "2025-01-01", DaysLeft: 184, Interest: 4010.96, Payout: 54010.96, Gain: 4008.47},
+			},
+		},
+		{
+			agreement: "card",
+			want:      ClosureQuote{Payout: 1234.57},
+		},
+		{
+			agreement: "bad-start",
+			want:      ClosureQuote{Payout: 1000},
+		},
+		{
+			agreement: "bad-end",
+			err:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.agreement, func(t *testing.T) {
+			q, err := s.ClosureQuote(context.Background(), userID, "vbank", tt.agreement, now)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("want error, got %+v", q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.AgreementID != tt.agreement || q.AsOf != "2024-07-01" || q.Bank != "vbank" {
+				t.Errorf("quote header = %s %s %s", q.AgreementID, q.AsOf, q.Bank)
+			}
+			if q.QuoteID != quoteID(q, userID) {
+				t.Errorf("quote_id = %q, want %q", q.QuoteID, quoteID(q, userID))
+			}
+			w := tt.want
+			if q.InterestRate != w.InterestRate || q.Capitalization != w.Capitalization || q.Early != w.Early || q.DaysHeld != w.DaysHeld {
+				t.Errorf("terms = rate %v cap %v early %v days %d, want rate %v cap %v early %v days %d",
+					q.InterestRate, q.Capitalization, q.Early, q.DaysHeld, w.InterestRate, w.Capitalization, w.Early, w.DaysHeld)
+			}
+			if !sameFloat(q.EarlyClosureRate, w.EarlyClosureRate) {
+				t.Errorf("early_closure_rate = %v, want %v", q.EarlyClosureRate, w.EarlyClosureRate)
+			}
+			if q.AccruedInterest != w.AccruedInterest || q.PayoutInterest != w.PayoutInterest || q.Penalty != w.Penalty || q.Payout != w.Payout {
+				t.Errorf("amounts = accrued %v payout interest %v penalty %v payout %v, want %v %v %v %v",
+					q.AccruedInterest, q.PayoutInterest, q.Penalty, q.Payout, w.AccruedInterest, w.PayoutInterest, w.Penalty, w.Payout)
+			}
+			switch {
+			case q.HoldToMaturity == nil && w.HoldToMaturity == nil:
+			case q.HoldToMaturity == nil || w.HoldToMaturity == nil || *q.HoldToMaturity != *w.HoldToMaturity:
+				t.Errorf("hold_to_maturity = %+v, want %+v", q.HoldToMaturity, w.HoldToMaturity)
+			}
+		})
+	}
+}
+
+func TestClosureQuotePayoutAccount(t *testing.T) {
+	repo := memory.New()
+	s, userID := closureService(t, repo, map[string]ProductDetails{
+		"card":    {ProductID: "card-1", Amount: 10, AccountNumber: str("40817810000000000001")},
+		"deposit": {ProductID: "dep-1", Amount: 10, AccountNumber: str("42301810000000000001")},
+	})
+	// вклад открыт через MoneyPilot: выплата уходит на счёт, с которого он фондировался
+	if err := repo.SaveProductAgreement(&storage.ProductAgreement{
+		AgreementID: "deposit", UserID: userID, ProductID: "dep-1", Status: "active", SourceAccountID: str("acc-1"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{"card": "40817810000000000001", "deposit": "acc-1"}
+	for agreement, want := range tests {
+		q, err := s.ClosureQuote(context.Background(), userID, "vbank", agreement, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.PayoutAccount == nil || *q.PayoutAccount != want || q.StartDate != "" {
+			t.Errorf("%s: payout account = %v, start date = %q, want %s", agreement, q.PayoutAccount, q.StartDate, want)
+		}
+	}
+}
+
+func TestClosureQuoteWithoutConsent(t *testing.T) {
+	repo := memory.New()
+	vbank := repo.AddBank(storage.Bank{Code: "vbank"})
+	u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &vbank.ID})
+	s := NewService(repo, nil, nil, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := s.ClosureQuote(context.Background(), u.ID, "vbank", "agr-1", time.Now()); err == nil {
+		t.Error("want error without a product consent")
+	}
+}
+
+func TestQuoteID(t *testing.T) {
+	base := ClosureQuote{AgreementID: "agr-1", AsOf: "2024-07-01", Payout: 100004.99, Penalty: 4981.31}
+	id := quoteID(&base, 7)
+	if len(id) != 16 {
+		t.Fatalf("quote_id %q: want 16 hex characters", id)
+	}
+
+	same := base
+	same.DaysHeld, same.AccruedInterest = 1, 1 // на подтверждение влияют только выплата и штраф
+	if got := quoteID(&same, 7); got != id {
+		t.Errorf("quote_id changed without a change in payout or penalty: %q != %q", got, id)
+	}
+
+	changes := map[string]func(q *ClosureQuote) (userID int){
+		"user":      func(q *ClosureQuote) int { return 8 },
+		"agreement": func(q *ClosureQuote) int { q.AgreementID = "agr-2"; return 7 },
+		"day":       func(q *ClosureQuote) int { q.AsOf = "2024-07-02"; return 7 },
+		"payout":    func(q *ClosureQuote) int { q.Payout += 0.01; return 7 },
+		"penalty":   func(q *ClosureQuote) int { q.Penalty -= 0.01; return 7 },
+	}
+	for name, change := range changes {
+		q := base
+		if got := quoteID(&q, change(&q)); got == id {
+			t.Errorf("%s: quote_id did not change", name)
+		}
+	}
+}
+
+func TestInterest(t *testing.T) {
+	tests := []struct {
+		principal, rate float64
+		capitalization  bool
+		days            int
+		want            float64
+	}{
+		{100000, 10, false, 365, 10000},
+		{100000, 10, false, 73, 2000},
+		{100000, 12, true, 365, 12682.50}, // 12 капитализаций: (1.01)^12 − 1
+		{100000, 10, false, 0, 0},
+		{100000, 0, true, 365, 0},
+		{0, 10, false, 365, 0},
+		{-100, 10, false, 365, 0},
+	}
+	for _, tt := range tests {
+		got := money.Round(interest(tt.principal, tt.rate, tt.capitalization, tt.days))
+		if got != tt.want {
+			t.Errorf("interest(%v, %v, %v, %d) = %v, want %v", tt.principal, tt.rate, tt.capitalization, tt.days, got, tt.want)
+		}
+	}
+}
+
+func sameFloat(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
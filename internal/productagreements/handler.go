@@ -1,7 +1,10 @@
 package productagreements
 
 import (
+	"MoneyPilot/internal/audit"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,7 +25,7 @@ func (h *Handler) ListProducts(c *gin.Context) {
 		return
 	}
 
-	products, err := h.Service.GetProducts(userID, bankCode)
+	products, err := h.Service.GetProducts(c.Request.Context(), userID, bankCode)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
@@ -31,12 +34,58 @@ func (h *Handler) ListProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": products})
 }
 
+// OpenProduct — POST /api/products, банк в X-Bank-Code
+// {"product_id":"prod-vbank-deposit-001","amount":100000,"term_months":12,"source_account_id":"acc-1"}
+// 201 — продукт открыт, 202 — банк ещё открывает его, статус придёт по WebSocket (product_agreement).
+func (h *Handler) OpenProduct(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	bankCode := c.GetHeader("X-Bank-Code")
+	if bankCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Bank-Code header required"})
+		return
+	}
+	var req struct {
+		ProductID       string  `json:"product_id" binding:"required"`
+		Amount          float64 `json:"amount"`
+		TermMonths      *int    `json:"term_months"`
+		SourceAccountID string  `json:"source_account_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	agreement, err := h.Service.OpenProduct(audit.WithUserID(c.Request.Context(), userID), userID, bankCode, OpenRequest{
+		ProductID:       req.ProductID,
+		Amount:          req.Amount,
+		TermMonths:      req.TermMonths,
+		SourceAccountID: req.SourceAccountID,
+	})
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	if pending(agreement.Status) {
+		c.JSON(http.StatusAccepted, gin.H{"data": agreement})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": agreement})
+}
+
 func (h *Handler) GetProductDetails(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	bankCode := c.GetHeader("X-Bank-Code")
 	agreementID := c.Param("agreement_id")
 
-	product, err := h.Service.GetProductDetails(userID, bankCode, agreementID)
+	product, err := h.Service.GetProductDetails(c.Request.Context(), userID, bankCode, agreementID)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
@@ -45,6 +94,26 @@ func (h *Handler) GetProductDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": product})
 }
 
+// ClosureQuote — GET /api/products/:agreement_id/closure-quote, банк в X-Bank-Code
+// Начисленные проценты, потери при досрочном закрытии и сравнение с закрытием в конце срока.
+func (h *Handler) ClosureQuote(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	bankCode := c.GetHeader("X-Bank-Code")
+	if bankCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Bank-Code header required"})
+		return
+	}
+
+	quote, err := h.Service.ClosureQuote(c.Request.Context(), userID, bankCode, c.Param("agreement_id"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quote})
+}
+
+// DeleteProduct — DELETE /api/products/:agreement_id {"quote_id":"..."}
+// quote_id берётся из closure-quote за сегодня; остальные поля уходят в банк как есть.
 func (h *Handler) DeleteProduct(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	bankCode := c.GetHeader("X-Bank-Code")
@@ -52,8 +121,15 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 
 	var payload map[string]interface{}
 	c.ShouldBindJSON(&payload)
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
 
-	err := h.Service.DeleteProduct(userID, bankCode, agreementID, payload)
+	err := h.Service.DeleteProduct(c.Request.Context(), userID, bankCode, agreementID, payload)
+	if errors.Is(err, ErrQuoteMismatch) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "details": "get a fresh closure-quote and pass its quote_id"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,284 @@
+package productagreements
+
+import (
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidRequest — продукт не найден в каталоге или сумма/срок/счёт не подходят
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrNotAllowed — действующее согласие не разрешает открыть такой продукт
+	ErrNotAllowed = errors.New("not allowed by product consent")
+)
+
+// OpenRequest — заявка на открытие продукта из каталога
+type OpenRequest struct {
+	ProductID       string
+	Amount          float64
+	TermMonths      *int   // пусто — срок продукта из каталога
+	SourceAccountID string // счёт клиента в том же банке, с которого фондируется продукт
+}
+
+// StatusUpdate — WebSocket-уведомление о смене статуса открытия
+type StatusUpdate struct {
+	Type      string                   `json:"type"` // product_agreement
+	Agreement storage.ProductAgreement `json:"agreement"`
+}
+
+// pending — банк ещё обрабатывает открытие
+func pending(status string) bool {
+	return status == "pending" || status == "processing"
+}
+
+// OpenProduct проверяет заявку по согласию и каталогу и открывает договор в банке.
+// Если банк не открыл продукт сразу, статус отслеживает TrackPending.
+func (s *Service) OpenProduct(ctx context.Context, userID int, bankCode string, req OpenRequest) (*storage.ProductAgreement, error) {
+	bankClient := s.BankClients[bankCode]
+	if bankClient == nil {
+		return nil, fmt.Errorf("%w: unknown bank code %s", ErrInvalidRequest, bankCode)
+	}
+
+	user, err := s.Repo.GetUserByUserIDAndBank(userID, bankCode)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found for bank " + bankCode)
+	}
+
+	consent, err := s.Repo.GetActiveProductConsentByUserAndBank(userID, bankCode)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && consent == nil) {
+		return nil, fmt.Errorf("%w: no active consent for bank %s", ErrNotAllowed, bankCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.validateOpen(user, consent, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenObj, err := s.TokenSvc.GetValidToken(bankClient)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"product_id": req.ProductID,
+		"amount":     req.Amount,
+	}
+	if req.TermMonths != nil {
+		payload["term_months"] = *req.TermMonths
+	}
+	if req.SourceAccountID != "" {
+		payload["source_account_id"] = req.SourceAccountID
+	}
+	body, _ := json.Marshal(payload)
+	url := fmt.Sprintf("%s/product-agreements?client_id=%s", bankClient.BaseURL, user.ClientID)
+	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(body)))
+	httpReq.Header.Set("Authorization", "Bearer "+tokenObj.Token)
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-product-agreement-consent-id", consent.ConsentID)
+	httpReq.Header.Set("x-requesting-bank", bankClient.ClientID)
+
+	resp, err := s.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		var errResp map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		return nil, fmt.Errorf("bank returned %d: %v", resp.StatusCode, errResp)
+	}
+
+	var result struct {
+		Data ProductDetails `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("unexpected product agreement response: %w", err)
+	}
+	if result.Data.AgreementID == "" {
+		return nil, errors.New("bank did not return agreement_id")
+	}
+	status := strings.ToLower(result.Data.Status)
+	if status == "" {
+		status = "pending"
+	}
+
+	a := &storage.ProductAgreement{
+		AgreementID: result.Data.AgreementID,
+		UserID:      user.ID,
+		ProductID:   product.ProductID,
+		Amount:      &req.Amount,
+		TermMonths:  req.TermMonths,
+		Status:      status,
+		BankCode:    bankCode,
+	}
+	if req.SourceAccountID != "" {
+		a.SourceAccountID = &req.SourceAccountID
+	}
+	if err := s.Repo.SaveProductAgreement(a); err != nil {
+		return nil, err
+	}
+
+	s.Audit.RecordAction(ctx, audit.ActionProductOpen, bankCode, consent.ConsentID, map[string]interface{}{
+		"agreement_id": a.AgreementID,
+		"product_id":   a.ProductID,
+		"amount":       req.Amount,
+		"status":       status,
+	})
+	s.notify(a)
+	return a, nil
+}
+
+// validateOpen сверяет заявку с согласием (тип продукта, лимит суммы) и с каталогом банка
+func (s *Service) validateOpen(user *storage.User, consent *storage.ProductAgreementConsent, req *OpenRequest) (*storage.Product, error) {
+	if !consent.OpenProductAgreements {
+		return nil, fmt.Errorf("%w: consent does not allow opening products", ErrNotAllowed)
+	}
+	if !strings.EqualFold(consent.Status, "approved") {
+		return nil, fmt.Errorf("%w: consent is %s", ErrNotAllowed, consent.Status)
+	}
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("%w: amount must not be negative", ErrInvalidRequest)
+	}
+
+	if user.BankID == nil {
+		return nil, fmt.Errorf("%w: product %q is not offered by this bank", ErrInvalidRequest, req.ProductID)
+	}
+	product, err := s.Repo.GetProductByProductID(*user.BankID, req.ProductID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: product %q is not in the catalog", ErrInvalidRequest, req.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !product.IsActive {
+		return nil, fmt.Errorf("%w: product %q is not offered by this bank", ErrInvalidRequest, req.ProductID)
+	}
+
+	productType := ""
+	if product.ProductType != nil {
+		productType = *product.ProductType
+	}
+	if len(consent.AllowedProductTypes) > 0 && !containsFold(consent.AllowedProductTypes, productType) {
+		return nil, fmt.Errorf("%w: product type %q is not in allowed types %v", ErrNotAllowed, productType, consent.AllowedProductTypes)
+	}
+	if consent.MaxAmount > 0 && req.Amount > consent.MaxAmount {
+		return nil, fmt.Errorf("%w: amount exceeds consent limit %.2f", ErrNotAllowed, consent.MaxAmount)
+	}
+	if product.MinAmount != nil && req.Amount < *product.MinAmount {
+		return nil, fmt.Errorf("%w: minimum amount is %.2f", ErrInvalidRequest, *product.MinAmount)
+	}
+	if product.MaxAmount != nil && *product.MaxAmount > 0 && req.Amount > *product.MaxAmount {
+		return nil, fmt.Errorf("%w: maximum amount is %.2f", ErrInvalidRequest, *product.MaxAmount)
+	}
+	if req.TermMonths == nil {
+		req.TermMonths = product.TermMonths
+	} else if *req.TermMonths <= 0 {
+		return nil, fmt.Errorf("%w: term_months must be positive", ErrInvalidRequest)
+	}
+
+	if req.SourceAccountID != "" {
+		accs, err := s.Repo.GetAccountsByClientUser(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, acc := range accs {
+			if acc.ExternalID != nil && *acc.ExternalID == req.SourceAccountID && acc.BankID == *user.BankID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: source account %s not found in this bank", ErrInvalidRequest, req.SourceAccountID)
+		}
+	}
+	return product, nil
+}
+
+// Start запускает отслеживание открываемых договоров: сразу и затем раз в interval
+func (s *Service) Start(interval time.Duration, stopCh <-chan struct{}) {
+	s.Log.Info("opening tracker started", "interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.TrackPending(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				s.TrackPending(context.Background())
+			case <-stopCh:
+				s.Log.Info("stopping")
+				return
+			}
+		}
+	}()
+}
+
+// TrackPending запрашивает в банке статус договоров в pending/processing и сообщает клиенту об изменениях
+func (s *Service) TrackPending(ctx context.Context) {
+	agreements, err := s.Repo.GetPendingProductAgreements()
+	if err != nil {
+		s.Log.ErrorContext(ctx, "failed to load pending agreements", logging.Err(err))
+		return
+	}
+	for _, a := range agreements {
+		if a.BankCode == "" {
+			continue
+		}
+		details, err := s.GetProductDetails(audit.WithUserID(ctx, a.UserID), a.UserID, a.BankCode, a.AgreementID)
+		if err != nil {
+			s.Log.ErrorContext(ctx, "failed to load agreement", "bank", a.BankCode, "agreement_id", a.AgreementID, logging.Err(err))
+			continue
+		}
+		status := strings.ToLower(details.Status)
+		if status == "" || status == a.Status {
+			continue
+		}
+		a.Status = status
+		if err := s.Repo.SaveProductAgreement(&a); err != nil {
+			s.Log.ErrorContext(ctx, "failed to save agreement", "agreement_id", a.AgreementID, logging.Err(err))
+			continue
+		}
+		s.Log.InfoContext(ctx, "agreement status changed", "agreement_id", a.AgreementID, "status", status)
+		s.notify(&a)
+	}
+}
+
+func (s *Service) notify(a *storage.ProductAgreement) {
+	if s.Hub == nil {
+		return
+	}
+	ids, err := s.Repo.GetClientUserIDs(a.UserID)
+	if err != nil {
+		s.Log.Error("notify failed", "user_id", a.UserID, logging.Err(err))
+		return
+	}
+	msg, _ := json.Marshal(StatusUpdate{Type: "product_agreement", Agreement: *a})
+	s.Hub.SendToUsers(ids, string(msg))
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package productagreements
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func float(v float64) *float64 { return &v }
+func integer(v int) *int       { return &v }
+func str(v string) *string     { return &v }
+
+func TestValidateOpen(t *testing.T) {
+	repo := memory.New()
+	vbank, abank := repo.AddBank(storage.Bank{Code: "vbank"}), repo.AddBank(storage.Bank{Code: "abank"})
+	u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &vbank.ID})
+	other := repo.AddUser(storage.User{ClientID: "team-2", BankID: &vbank.ID})
+	for _, p := range []storage.Product{
+		{BankID: &vbank.ID, ProductID: "dep-1", ProductType: str("deposit"), MinAmount: float(10000), MaxAmount: float(1e6), TermMonths: integer(12)},
+		{BankID: &vbank.ID, ProductID: "card-1", ProductType: str("card")},
+		{BankID: &vbank.ID, ProductID: "dep-gone", ProductType: str("deposit")},
+		// тот же product_id в другом банке: открыть его из vbank нельзя
+		{BankID: &abank.ID, ProductID: "dep-2", ProductType: str("deposit")},
+	} {
+		p := p
+		if err := repo.UpsertProduct(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.DeactivateMissingProducts(vbank.ID, []string{"dep-1", "card-1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []storage.Account{
+		{UserID: u.ID, BankID: vbank.ID, ExternalID: str("acc-own"), AccountNumber: "1"},
+		{UserID: u.ID, BankID: abank.ID, ExternalID: str("acc-abank"), AccountNumber: "2"},
+		{UserID: other.ID, BankID: vbank.ID, ExternalID: str("acc-other"), AccountNumber: "3"},
+	} {
+		a := a
+		if err := repo.UpsertAccount(&a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &Service{Repo: repo}
+
+	consent := storage.ProductAgreementConsent{
+		OpenProductAgreements: true,
+		Status:                "approved",
+		AllowedProductTypes:   []string{"Deposit"},
+		MaxAmount:             500000,
+	}
+	tests := []struct {
+		name    string
+		consent func(c *storage.ProductAgreementConsent)
+		req     OpenRequest
+		err     error
+		term    *int
+	}{
+		{name: "valid", req: OpenRequest{ProductID: "dep-1", Amount: 100000, SourceAccountID: "acc-own"}, term: integer(12)},
+		{name: "own term", req: OpenRequest{ProductID: "dep-1", Amount: 100000, TermMonths: integer(6)}, term: integer(6)},
+		{name: "open not allowed", consent: func(c *storage.ProductAgreementConsent) { c.OpenProductAgreements = false }, req: OpenRequest{ProductID: "dep-1", Amount: 100000}, err: ErrNotAllowed},
+		{name: "consent pending", consent: func(c *storage.ProductAgreementConsent) { c.Status = "pending" }, req: OpenRequest{ProductID: "dep-1", Amount: 100000}, err: ErrNotAllowed},
+		{name: "type not allowed", req: OpenRequest{ProductID: "card-1"}, err: ErrNotAllowed},
+		{name: "any type allowed", consent: func(c *storage.ProductAgreementConsent) { c.AllowedProductTypes = nil }, req: OpenRequest{ProductID: "card-1"}},
+		{name: "above consent limit", req: OpenRequest{ProductID: "dep-1", Amount: 600000}, err: ErrNotAllowed},
+		{name: "no consent limit", consent: func(c *storage.ProductAgreementConsent) { c.MaxAmount = 0 }, req: OpenRequest{ProductID: "dep-1", Amount: 600000}, term: integer(12)},
+		{name: "negative amount", req: OpenRequest{ProductID: "dep-1", Amount: -1}, err: ErrInvalidRequest},
+		{name: "below catalog minimum", req: OpenRequest{ProductID: "dep-1", Amount: 5000}, err: ErrInvalidRequest},
+		{name: "above catalog maximum", consent: func(c *storage.ProductAgreementConsent) { c.MaxAmount = 0 }, req: OpenRequest{ProductID: "dep-1", Amount: 2e6}, err: ErrInvalidRequest},
+		{name: "not in catalog", req: OpenRequest{ProductID: "dep-9", Amount: 100000}, err: ErrInvalidRequest},
+		{name: "other bank's product", req: OpenRequest{ProductID: "dep-2", Amount: 100000}, err: ErrInvalidRequest},
+		{name: "inactive product", req: OpenRequest{ProductID: "dep-gone", Amount: 100000}, err: ErrInvalidRequest},
+		{name: "non-positive term", req: OpenRequest{ProductID: "dep-1", Amount: 100000, TermMonths: integer(0)}, err: ErrInvalidRequest},
+		{name: "funding account in another bank", req: OpenRequest{ProductID: "dep-1", Amount: 100000, SourceAccountID: "acc-abank"}, err: ErrInvalidRequest},
+		{name: "funding account of another client", req: OpenRequest{ProductID: "dep-1", Amount: 100000, SourceAccountID: "acc-other"}, err: ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := consent
+			if tt.consent != nil {
+				tt.consent(&c)
+			}
+			req := tt.req
+			p, err := s.validateOpen(&u, &c, &req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if p.ProductID != req.ProductID || *p.BankID != vbank.ID {
+				t.Errorf("product = %+v", p)
+			}
+			if (req.TermMonths == nil) != (tt.term == nil) || (tt.term != nil && *req.TermMonths != *tt.term) {
+				t.Errorf("term = %v, want %v", req.TermMonths, tt.term)
+			}
+		})
+	}
+}
+
+// consentErrStore — хранилище, у которого не читается согласие
+type consentErrStore struct {
+	*memory.Store
+	err error
+}
+
+func (s consentErrStore) GetActiveProductConsentByUserAndBank(userID int, bankCode string) (*storage.ProductAgreementConsent, error) {
+	return nil, s.err
+}
+
+func TestOpenProductConsentLookup(t *testing.T) {
+	boom := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error // ошибка чтения согласия; nil — согласия просто нет
+		want error
+	}{
+		{name: "no consent", want: ErrNotAllowed},
+		{name: "database error", err: boom, want: boom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.New()
+			bank := repo.AddBank(storage.Bank{Code: "vbank"})
+			u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &bank.ID})
+			var store storage.Store = repo
+			if tt.err != nil {
+				store = consentErrStore{Store: repo, err: tt.err}
+			}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			s := NewService(store, nil, map[string]*bankapi.BankClient{"vbank": {Name: "vbank"}}, nil, nil, nil, logger)
+
+			_, err := s.OpenProduct(context.Background(), u.ID, "vbank", OpenRequest{ProductID: "dep-1", Amount: 100})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.err != nil && errors.Is(err, ErrNotAllowed) {
+				t.Errorf("database error reported as ErrNotAllowed: %v", err)
+			}
+		})
+	}
+}
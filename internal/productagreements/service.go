@@ -1,34 +1,45 @@
 package productagreements
 
 import (
+	"MoneyPilot/internal/audit"
 	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/logging"
 	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/websockets"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type Service struct {
-	Repo        *storage.Repository
+	Repo        storage.Store
 	TokenSvc    *bankapi.TokenService
 	BankClients map[string]*bankapi.BankClient
 	HTTPClient  *http.Client
+	Audit       *audit.Service
+	Hub         *websockets.WebSocketHub
+	Log         *slog.Logger
 }
 
-func NewService(repo *storage.Repository, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient) *Service {
+func NewService(repo storage.Store, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient, httpClient *http.Client, auditSvc *audit.Service, hub *websockets.WebSocketHub, logger *slog.Logger) *Service {
 	return &Service{
 		Repo:        repo,
 		TokenSvc:    ts,
 		BankClients: clients,
-		HTTPClient:  &http.Client{},
+		HTTPClient:  httpClient,
+		Audit:       auditSvc,
+		Hub:         hub,
+		Log:         logging.For(logger, "productagreements"),
 	}
 }
 
 // Получение списка продуктов
-func (s *Service) GetProducts(userID int, bankCode string) ([]Product, error) {
+func (s *Service) GetProducts(ctx context.Context, userID int, bankCode string) ([]Product, error) {
 	bankClient := s.BankClients[bankCode]
 	if bankClient == nil {
 		return nil, fmt.Errorf("unknown bank code %s", bankCode)
@@ -43,7 +54,6 @@ func (s *Service) GetProducts(userID int, bankCode string) ([]Product, error) {
 	}
 
 	consent, err := s.Repo.GetActiveProductConsentByUserAndBank(userID, bankCode)
-	log.Println(consent)
 	if err != nil || consent == nil {
 		return nil, errors.New("no active consent for bank " + bankCode)
 	}
@@ -54,11 +64,11 @@ func (s *Service) GetProducts(userID int, bankCode string) ([]Product, error) {
 	}
 
 	url := fmt.Sprintf("%s/product-agreements?client_id=%s", bankClient.BaseURL, user.ClientID)
-	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("x-product-agreement-consent-id", consent.ConsentID)
-	req.Header.Set("x-requesting-bank", "team081")
+	req.Header.Set("x-requesting-bank", bankClient.ClientID)
 
 	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
@@ -81,7 +91,7 @@ func (s *Service) GetProducts(userID int, bankCode string) ([]Product, error) {
 }
 
 // Получение деталей продукта
-func (s *Service) GetProductDetails(userID int, bankCode, agreementID string) (*ProductDetails, error) {
+func (s *Service) GetProductDetails(ctx context.Context, userID int, bankCode, agreementID string) (*ProductDetails, error) {
 	bankClient := s.BankClients[bankCode]
 	if bankClient == nil {
 		return nil, fmt.Errorf("unknown bank code %s", bankCode)
@@ -106,11 +116,11 @@ func (s *Service) GetProductDetails(userID int, bankCode, agreementID string) (*
 	}
 
 	url := fmt.Sprintf("%s/product-agreements/%s?client_id=%s", bankClient.BaseURL, agreementID, user.ClientID)
-	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("x-product-agreement-consent-id", consent.ConsentID)
-	req.Header.Set("x-requesting-bank", "team081")
+	req.Header.Set("x-requesting-bank", bankClient.ClientID)
 
 	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
@@ -132,13 +142,27 @@ func (s *Service) GetProductDetails(userID int, bankCode, agreementID string) (*
 	return &result.Data, nil
 }
 
-// Удаление продукта (если разрешено согласием)
-func (s *Service) DeleteProduct(userID int, bankCode, agreementID string, payload map[string]interface{}) error {
+// Удаление продукта (если разрешено согласием).
+// payload должен содержать quote_id из актуального GET closure-quote: клиент подтверждает, что видел расчёт.
+func (s *Service) DeleteProduct(ctx context.Context, userID int, bankCode, agreementID string, payload map[string]interface{}) error {
 	bankClient := s.BankClients[bankCode]
 	if bankClient == nil {
 		return fmt.Errorf("unknown bank code %s", bankCode)
 	}
 
+	quoteID, _ := payload["quote_id"].(string)
+	if quoteID == "" {
+		return ErrQuoteMismatch
+	}
+	quote, err := s.ClosureQuote(ctx, userID, bankCode, agreementID, time.Now())
+	if err != nil {
+		return err
+	}
+	if quote.QuoteID != quoteID {
+		return ErrQuoteMismatch
+	}
+	delete(payload, "quote_id")
+
 	user, err := s.Repo.GetUserByUserIDAndBank(userID, bankCode)
 	if err != nil {
 		return err
@@ -159,12 +183,12 @@ func (s *Service) DeleteProduct(userID int, bankCode, agreementID string, payloa
 
 	url := fmt.Sprintf("%s/product-agreements/%s?client_id=%s", bankClient.BaseURL, agreementID, user.ClientID)
 	body, _ := json.Marshal(payload)
-	req, _ := http.NewRequest(http.MethodDelete, url, strings.NewReader(string(body)))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, url, strings.NewReader(string(body)))
 	req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-product-agreement-consent-id", consent.ConsentID)
-	req.Header.Set("x-requesting-bank", "team081")
+	req.Header.Set("x-requesting-bank", bankClient.ClientID)
 
 	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
@@ -178,5 +202,11 @@ func (s *Service) DeleteProduct(userID int, bankCode, agreementID string, payloa
 		return fmt.Errorf("bank returned %d: %v", resp.StatusCode, errResp)
 	}
 
+	s.Audit.RecordAction(ctx, audit.ActionProductClose, bankCode, consent.ConsentID, map[string]interface{}{
+		"agreement_id": agreementID,
+		"quote_id":     quote.QuoteID,
+		"payout":       quote.Payout,
+		"penalty":      quote.Penalty,
+	})
 	return nil
 }
@@ -1,32 +1,40 @@
 package productconsents
 
 import (
+	"MoneyPilot/internal/audit"
 	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/logging"
 	"MoneyPilot/internal/storage"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 )
 
 type Service struct {
-	Repo        *storage.Repository
+	Repo        storage.Store
 	TokenSvc    *bankapi.TokenService
 	BankClients map[string]*bankapi.BankClient
 	HTTPClient  *http.Client
+	Audit       *audit.Service
+	ConsentTTL  time.Duration // срок действия согласия на продукты
+	Log         *slog.Logger
 }
 
-func NewService(repo *storage.Repository, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient) *Service {
+func NewService(repo storage.Store, ts *bankapi.TokenService, clients map[string]*bankapi.BankClient, httpClient *http.Client, auditSvc *audit.Service, logger *slog.Logger) *Service {
 	return &Service{
 		Repo:        repo,
 		TokenSvc:    ts,
 		BankClients: clients,
-		HTTPClient:  &http.Client{Timeout: 15 * time.Second},
+		HTTPClient:  httpClient,
+		Audit:       auditSvc,
+		ConsentTTL:  90 * 24 * time.Hour,
+		Log:         logging.For(logger, "productconsents"),
 	}
 }
 
@@ -43,14 +51,14 @@ func (s *Service) CreateConsent(ctx context.Context, userID int, bankCode string
 	// 1️⃣ Проверка существующего согласия
 	existing, err := s.Repo.GetActiveProductConsentByUserAndBank(userID, bankCode)
 	if err != nil {
-		log.Println(err.Error())
+		s.Log.WarnContext(ctx, "failed to load active consent", "bank", bankCode, logging.Err(err))
 	}
 
+	// если старое согласие не покрывает новые разрешения → заменяем его новым
+	var replaced *storage.ProductAgreementConsent
 	if existing != nil {
-		// если старое согласие не покрывает новые разрешения → отзываем
 		if (req.Open && !existing.OpenProductAgreements) || (req.Close && !existing.CloseProductAgreements) || (req.Read && !existing.ReadProductAgreements) {
-			_ = s.revokeConsent(bankClient, existing.ConsentID, user.ClientID)
-			_ = s.Repo.DeleteProductConsent(existing.ConsentID)
+			replaced = existing
 		} else {
 			return &CreateProductConsentResponse{
 				Status:     existing.Status,
@@ -72,14 +80,14 @@ func (s *Service) CreateConsent(ctx context.Context, userID int, bankCode string
 	// 3️⃣ Создаём запрос к банку
 	target := fmt.Sprintf(strings.TrimRight(bankClient.BaseURL, "/")+"/product-agreement-consents/request?client_id=%s", user.ClientID)
 	payload := map[string]interface{}{
-		"requesting_bank":          "team081",
+		"requesting_bank":          bankClient.ClientID,
 		"client_id":                user.ClientID,
 		"read_product_agreements":  req.Read,
 		"open_product_agreements":  req.Open,
 		"close_product_agreements": req.Close,
 		"allowed_product_types":    req.Types,
 		"max_amount":               req.Amount,
-		"valid_until":              time.Now().Add(90 * 24 * time.Hour).Format("2006-01-02T15:04:05"),
+		"valid_until":              time.Now().Add(s.ConsentTTL).Format("2006-01-02T15:04:05"),
 		"reason":                   "Финансовый агрегатор для управления продуктами",
 	}
 	body, _ := json.Marshal(payload)
@@ -109,25 +117,49 @@ func (s *Service) CreateConsent(ctx context.Context, userID int, bankCode string
 		return nil, fmt.Errorf("failed to decode bank response: %w", err)
 	}
 
-	// 4️⃣ Сохраняем в БД
-	// expires := time.Now().Add(90 * 24 * time.Hour)
-	if err := s.Repo.SaveProductAgreementConsent(
-		user.ClientID,
-		bankCode,
-		bankResp.RequestID,
-		bankResp.ConsentID,
-		"team081",
-		req.Read,
-		req.Open,
-		req.Close,
-		req.Types,
-		req.Amount,
-		bankResp.Status,
-		bankResp.ValidUntil,
-	); err != nil {
+	// 4️⃣ Атомарно удаляем старое согласие и сохраняем новое
+	err = s.Repo.WithTx(ctx, func(tx storage.Store) error {
+		if replaced != nil {
+			if err := tx.DeleteProductConsent(replaced.ConsentID); err != nil {
+				return err
+			}
+		}
+		return tx.SaveProductAgreementConsent(
+			user.ClientID,
+			bankCode,
+			bankResp.RequestID,
+			bankResp.ConsentID,
+			bankClient.ClientID,
+			req.Read,
+			req.Open,
+			req.Close,
+			req.Types,
+			req.Amount,
+			bankResp.Status,
+			bankResp.ValidUntil,
+		)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	// 5️⃣ Старое согласие отзываем в банке только после того, как новое сохранено
+	if replaced != nil {
+		if err := s.revokeConsent(ctx, bankCode, replaced.ConsentID, user.ClientID); err != nil {
+			s.Log.ErrorContext(ctx, "failed to revoke replaced consent", "bank", bankCode, "consent_id", replaced.ConsentID, logging.Err(err))
+		}
+	}
+
+	s.Audit.RecordAction(ctx, audit.ActionConsentCreate, bankCode, bankResp.ConsentID, map[string]interface{}{
+		"consent_type":  "product-agreement",
+		"request_id":    bankResp.RequestID,
+		"read":          req.Read,
+		"open":          req.Open,
+		"close":         req.Close,
+		"allowed_types": req.Types,
+		"max_amount":    req.Amount,
+	})
+
 	return &CreateProductConsentResponse{
 		Status:     bankResp.Status,
 		ConsentID:  bankResp.ConsentID,
@@ -137,18 +169,27 @@ func (s *Service) CreateConsent(ctx context.Context, userID int, bankCode string
 	}, nil
 }
 
-func (s *Service) revokeConsent(bankClient *bankapi.BankClient, consentID string, clientId string) error {
+func (s *Service) revokeConsent(ctx context.Context, bankCode, consentID string, clientId string) error {
+	bankClient := s.BankClients[bankCode]
+	if bankClient == nil {
+		return errors.New("unknown bank code")
+	}
 	tokenObj, err := s.TokenSvc.GetValidToken(bankClient)
 	if err != nil {
 		return err
 	}
-	url := fmt.Sprintf(strings.TrimRight(bankClient.BaseURL, "/") + "/product-agreement-consents/" + consentID + "?client_id=%s")
-	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	url := fmt.Sprintf(strings.TrimRight(bankClient.BaseURL, "/")+"/product-agreement-consents/"+consentID+"?client_id=%s", clientId)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenObj.Token)
 	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+
+	s.Audit.RecordAction(ctx, audit.ActionConsentRevoke, bankCode, consentID, map[string]interface{}{
+		"consent_type": "product-agreement",
+		"status_code":  resp.StatusCode,
+	})
 	return nil
 }
@@ -0,0 +1,133 @@
+package recommendations
+
+import (
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/storage"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// PaymentSource — POST /api/recommendations/payment-source {"amount":3500,"currency":"RUB","category":"Groceries"}
+// Счета и карты во всех банках по убыванию выгоды: кешбэк минус комиссия за конвертацию и проценты по кредиту.
+func (h *Handler) PaymentSource(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Amount   float64 `json:"amount" binding:"required"`
+		Currency string  `json:"currency"`
+		Category string  `json:"category"`
+		MCC      string  `json:"mcc"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	ctx := audit.WithUserID(c.Request.Context(), userID)
+	res, err := h.Service.PaymentSource(ctx, userID, Request{Amount: body.Amount, Currency: body.Currency, Category: body.Category, MCC: body.MCC})
+	if errors.Is(err, ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, fx.ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "missing exchange rate", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rank payment sources", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ListCashbackRules — GET /api/recommendations/cashback-rules
+func (h *Handler) ListCashbackRules(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	rules, err := h.Service.ListRules(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load cashback rules", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(rules), "rules": rules})
+}
+
+// CreateCashbackRule — POST /api/recommendations/cashback-rules {"bank":"vbank","product_id":"prod-1","category":"Groceries","percent":5}
+func (h *Handler) CreateCashbackRule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Bank        string   `json:"bank" binding:"required"`
+		ProductID   *string  `json:"product_id"`
+		Category    *string  `json:"category"`
+		Percent     float64  `json:"percent" binding:"required"`
+		MaxCashback *float64 `json:"max_cashback"`
+		MaxCurrency *string  `json:"max_cashback_currency"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	rule, err := h.Service.CreateRule(userID, storage.CashbackRule{
+		BankCode:    body.Bank,
+		ProductID:   body.ProductID,
+		Category:    body.Category,
+		Percent:     body.Percent,
+		MaxCashback: body.MaxCashback,
+		MaxCurrency: body.MaxCurrency,
+	})
+	if errors.Is(err, ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create cashback rule", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// DeleteCashbackRule — DELETE /api/recommendations/cashback-rules/:rule_id
+func (h *Handler) DeleteCashbackRule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule_id"})
+		return
+	}
+	err = h.Service.DeleteRule(userID, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete cashback rule", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
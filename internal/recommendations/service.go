@@ -0,0 +1,347 @@
+package recommendations
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/categories"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/productagreements"
+	"MoneyPilot/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// conversionFee — комиссия за покупку не в валюте счёта, %
+	conversionFee = 1.5
+	// defaultCreditRate — годовая ставка по кредитному продукту, если её нет в каталоге, %
+	defaultCreditRate = 30.0
+)
+
+// creditTypes — типы продуктов, дающие кредитный лимит на привязанный счёт
+var creditTypes = map[string]bool{"credit_card": true, "overdraft": true, "credit_line": true}
+
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrNotFound       = errors.New("cashback rule not found")
+)
+
+type Request struct {
+	Amount   float64
+	Currency string // пусто — базовая валюта
+	Category string
+	MCC      string // если категория не задана, она определяется по MCC стандартными правилами
+}
+
+// Option — счёт как источник оплаты; суммы в валюте счёта, Benefit — в валюте запроса
+type Option struct {
+	Rank          int      `json:"rank"`
+	ID            int      `json:"id"`
+	AccountID     string   `json:"account_id"`
+	BankCode      string   `json:"bank"`
+	AccountType   string   `json:"account_type"`
+	Nickname      *string  `json:"nickname,omitempty"`
+	Currency      string   `json:"currency"`
+	Available     float64  `json:"available"`
+	CreditLimit   float64  `json:"credit_limit"`
+	CreditRate    *float64 `json:"credit_rate,omitempty"`
+	ProductID     string   `json:"product_id,omitempty"`
+	Amount        float64  `json:"amount"`
+	CurrencyMatch bool     `json:"currency_match"`
+	UsesCredit    float64  `json:"uses_credit"`
+	Cashback      float64  `json:"cashback"`
+	FXCost        float64  `json:"fx_cost"`
+	CreditCost    float64  `json:"credit_cost"` // проценты за месяц пользования кредитом
+	Benefit       float64  `json:"benefit"`     // кешбэк минус расходы
+	Eligible      bool     `json:"eligible"`
+	Reasons       []string `json:"reasons"`
+}
+
+type Result struct {
+	Amount   float64  `json:"amount"`
+	Currency string   `json:"currency"`
+	Category string   `json:"category,omitempty"`
+	Best     *Option  `json:"best,omitempty"`
+	Options  []Option `json:"options"`
+}
+
+type Service struct {
+	Repo     storage.Store
+	Rules    storage.CashbackRepository
+	Products *productagreements.Service
+	FX       *fx.Service
+	Banks    map[string]*bankapi.BankClient
+	Log      *slog.Logger
+}
+
+func NewService(repo storage.Store, rules storage.CashbackRepository, products *productagreements.Service, fxSvc *fx.Service, banks map[string]*bankapi.BankClient, logger *slog.Logger) *Service {
+	return &Service{Repo: repo, Rules: rules, Products: products, FX: fxSvc, Banks: banks, Log: logging.For(logger, "recommendations")}
+}
+
+// PaymentSource ранжирует счета клиента во всех банках для покупки
+func (s *Service) PaymentSource(ctx context.Context, userID int, req Request) (*Result, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidRequest)
+	}
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		var err error
+		if currency, err = s.FX.BaseCurrency(userID); err != nil {
+			return nil, err
+		}
+	}
+	if !fx.ValidCurrency(currency) {
+		return nil, fmt.Errorf("%w: invalid currency %q", ErrInvalidRequest, currency)
+	}
+
+	if req.Category == "" && req.MCC != "" {
+		mcc := req.MCC
+		req.Category, _ = (&categories.Matcher{}).Categorize(&storage.Transaction{MCC: &mcc, Amount: -req.Amount})
+	}
+
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.Rules.ListCashbackRules(userID)
+	if err != nil {
+		return nil, err
+	}
+	codes := map[int]string{}
+	for code := range s.Banks {
+		if b, err := s.Repo.GetBankByCode(code); err == nil {
+			codes[b.ID] = code
+		}
+	}
+	linked := s.linkedProducts(ctx, userID)
+
+	now := time.Now()
+	res := &Result{Amount: req.Amount, Currency: currency, Category: req.Category, Options: []Option{}}
+	for _, acc := range accs {
+		if !acc.Spendable() || strings.EqualFold(acc.Status, "closed") || strings.EqualFold(acc.Status, "disabled") {
+			continue
+		}
+		opt, err := s.evaluate(acc, codes[acc.BankID], linked[acc.AccountNumber], rules, req, currency, now)
+		if err != nil {
+			return nil, err
+		}
+		res.Options = append(res.Options, opt)
+	}
+
+	sort.SliceStable(res.Options, func(i, j int) bool {
+		a, b := res.Options[i], res.Options[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Benefit != b.Benefit {
+			return a.Benefit > b.Benefit
+		}
+		return a.Available-a.Amount > b.Available-b.Amount
+	})
+	for i := range res.Options {
+		res.Options[i].Rank = i + 1
+	}
+	if len(res.Options) > 0 && res.Options[0].Eligible {
+		best := res.Options[0]
+		res.Best = &best
+	}
+	return res, nil
+}
+
+func (s *Service) evaluate(acc storage.Account, bankCode string, product *productagreements.Product, rules []storage.CashbackRule,
+	req Request, currency string, now time.Time) (Option, error) {
+	opt := Option{
+		ID:            acc.ID,
+		BankCode:      bankCode,
+		AccountType:   acc.AccountType,
+		Nickname:      acc.Nickname,
+		Currency:      acc.Currency,
+		Available:     acc.Balance,
+		CurrencyMatch: acc.Currency == "" || acc.Currency == currency,
+		Reasons:       []string{},
+	}
+	if acc.ExternalID != nil {
+		opt.AccountID = *acc.ExternalID
+	}
+
+	amount, err := money.Convert(s.FX, req.Amount, currency, acc.Currency, now)
+	if err != nil {
+		return opt, err
+	}
+	opt.Amount = money.Round(amount)
+	if !opt.CurrencyMatch {
+		opt.FXCost = money.Round(req.Amount * conversionFee / 100)
+		opt.Reasons = append(opt.Reasons, fmt.Sprintf("paid in %s from a %s account, %.1f%% conversion fee", currency, acc.Currency, conversionFee))
+	}
+
+	productID := ""
+	if product != nil {
+		productID = product.ProductID
+		opt.ProductID = productID
+		if creditTypes[strings.ToLower(product.ProductType)] {
+			opt.CreditLimit = product.Amount
+			rate := defaultCreditRate
+			if p, err := s.Repo.GetProductByProductID(acc.BankID, productID); err == nil && p.InterestRate != nil {
+				rate = *p.InterestRate
+			}
+			opt.CreditRate = &rate
+		}
+	}
+
+	own := math.Max(acc.Balance, 0)
+	opt.Eligible = acc.Balance+opt.CreditLimit >= opt.Amount
+	if !opt.Eligible {
+		opt.Reasons = append(opt.Reasons, fmt.Sprintf("not enough funds: %.2f %s available", acc.Balance+opt.CreditLimit, acc.Currency))
+	}
+	if opt.Amount > own && opt.CreditLimit > 0 {
+		opt.UsesCredit = money.Round(math.Min(opt.Amount-own, opt.CreditLimit))
+		monthly := opt.UsesCredit * *opt.CreditRate / 100 / 12
+		if opt.CreditCost, err = money.Convert(s.FX, monthly, acc.Currency, currency, now); err != nil {
+			return opt, err
+		}
+		opt.CreditCost = money.Round(opt.CreditCost)
+		opt.Reasons = append(opt.Reasons, fmt.Sprintf("uses %.2f %s of credit at %.2f%% a year", opt.UsesCredit, acc.Currency, *opt.CreditRate))
+	}
+
+	if rule := bestRule(rules, bankCode, productID, req.Category); rule != nil {
+		cashback := opt.Amount * rule.Percent / 100
+		if rule.MaxCashback != nil {
+			capCurrency := acc.Currency
+			if rule.MaxCurrency != nil {
+				capCurrency = *rule.MaxCurrency
+			}
+			limit, err := money.Convert(s.FX, *rule.MaxCashback, capCurrency, acc.Currency, now)
+			if err != nil {
+				return opt, err
+			}
+			cashback = math.Min(cashback, limit)
+		}
+		if opt.Cashback, err = money.Convert(s.FX, cashback, acc.Currency, currency, now); err != nil {
+			return opt, err
+		}
+		opt.Cashback = money.Round(opt.Cashback)
+		opt.Reasons = append(opt.Reasons, fmt.Sprintf("%.2f%% cashback (rule %d)", rule.Percent, rule.ID))
+	}
+
+	opt.Benefit = money.Round(opt.Cashback - opt.FXCost - opt.CreditCost)
+	return opt, nil
+}
+
+// bestRule — правило с наибольшим процентом среди подходящих по банку, продукту и категории
+func bestRule(rules []storage.CashbackRule, bankCode, productID, category string) *storage.CashbackRule {
+	var best *storage.CashbackRule
+	for i, r := range rules {
+		if r.BankCode != bankCode {
+			continue
+		}
+		if r.ProductID != nil && *r.ProductID != productID {
+			continue
+		}
+		if r.Category != nil && !strings.EqualFold(*r.Category, category) {
+			continue
+		}
+		if best == nil || r.Percent > best.Percent {
+			best = &rules[i]
+		}
+	}
+	return best
+}
+
+// linkedProducts — договоры клиента с привязанным счётом по номеру счёта.
+// Банк без согласия на договоры или с ошибкой просто пропускается.
+func (s *Service) linkedProducts(ctx context.Context, userID int) map[string]*productagreements.Product {
+	res := map[string]*productagreements.Product{}
+	for code := range s.Banks {
+		products, err := s.Products.GetProducts(ctx, userID, code)
+		if err != nil {
+			s.Log.DebugContext(ctx, "product agreements unavailable", "bank", code, logging.Err(err))
+			continue
+		}
+		for i, p := range products {
+			if p.AccountNumber == nil || *p.AccountNumber == "" {
+				continue
+			}
+			// кредитный договор важнее любого другого по тому же счёту
+			if prev := res[*p.AccountNumber]; prev != nil && creditTypes[strings.ToLower(prev.ProductType)] {
+				continue
+			}
+			res[*p.AccountNumber] = &products[i]
+		}
+	}
+	return res
+}
+
+func (s *Service) ListRules(userID int) ([]storage.CashbackRule, error) {
+	rules, err := s.Rules.ListCashbackRules(userID)
+	if rules == nil {
+		rules = []storage.CashbackRule{}
+	}
+	return rules, err
+}
+
+// CreateRule сохраняет правило кешбэка; банк задаётся кодом
+func (s *Service) CreateRule(userID int, r storage.CashbackRule) (*storage.CashbackRule, error) {
+	if r.Percent <= 0 || r.Percent > 100 {
+		return nil, fmt.Errorf("%w: percent must be in (0, 100]", ErrInvalidRequest)
+	}
+	if r.MaxCashback != nil && *r.MaxCashback <= 0 {
+		return nil, fmt.Errorf("%w: max_cashback must be positive", ErrInvalidRequest)
+	}
+	if r.MaxCashback == nil {
+		r.MaxCurrency = nil
+	} else {
+		// лимит без валюты — в базовой валюте клиента
+		currency := ""
+		if c := trimmed(r.MaxCurrency); c != nil {
+			currency = strings.ToUpper(*c)
+		}
+		if currency == "" {
+			var err error
+			if currency, err = s.FX.BaseCurrency(userID); err != nil {
+				return nil, err
+			}
+		}
+		if !fx.ValidCurrency(currency) {
+			return nil, fmt.Errorf("%w: invalid max_cashback_currency %q", ErrInvalidRequest, currency)
+		}
+		r.MaxCurrency = &currency
+	}
+	if _, ok := s.Banks[r.BankCode]; !ok {
+		return nil, fmt.Errorf("%w: unknown bank %q", ErrInvalidRequest, r.BankCode)
+	}
+	bank, err := s.Repo.GetBankByCode(r.BankCode)
+	if err != nil {
+		return nil, err
+	}
+	r.UserID, r.BankID = userID, bank.ID
+	r.ProductID = trimmed(r.ProductID)
+	r.Category = trimmed(r.Category)
+	if err := s.Rules.InsertCashbackRule(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Service) DeleteRule(userID, id int) error {
+	err := s.Rules.DeleteCashbackRule(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func trimmed(v *string) *string {
+	if v == nil || strings.TrimSpace(*v) == "" {
+		return nil
+	}
+	t := strings.TrimSpace(*v)
+	return &t
+}
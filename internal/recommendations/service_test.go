@@ -0,0 +1,197 @@
+package recommendations
+
+import (
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/productagreements"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func str(v string) *string     { return &v }
+func float(v float64) *float64 { return &v }
+
+func newTestService(t *testing.T, handler http.Handler) (*Service, *memory.Store, storage.User) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	repo := memory.New()
+	vbank := repo.AddBank(storage.Bank{Code: "vbank"})
+	abank := repo.AddBank(storage.Bank{Code: "abank"})
+	u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &vbank.ID})
+	repo.AddUser(storage.User{ClientID: "team-1", BankID: &abank.ID})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	fxSvc := fx.NewService(repo, repo, nil, logger)
+	if err := fxSvc.AddRates([]storage.FXRate{{RateDate: time.Now().AddDate(0, 0, -1), Base: "USD", Quote: "RUB", Rate: 90}}); err != nil {
+		t.Fatal(err)
+	}
+	// Redis недоступен: токен каждый раз берётся у тестового банка
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+	t.Cleanup(func() { rdb.Close() })
+	banks := map[string]*bankapi.BankClient{
+		"vbank": {Name: "vbank", BaseURL: srv.URL},
+		"abank": {Name: "abank", BaseURL: srv.URL},
+	}
+	products := productagreements.NewService(repo, bankapi.NewTokenService(rdb, logger), banks, srv.Client(), nil, nil, logger)
+	return NewService(repo, repo, products, fxSvc, banks, logger), repo, u
+}
+
+func TestPaymentSource(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/bank-token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"token"}`))
+	})
+	mux.HandleFunc("/product-agreements", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[
+			{"agreement_id":"agr-1","product_id":"cc-1","product_type":"credit_card","amount":50000,"account_number":"a-credit"},
+			{"agreement_id":"agr-2","product_id":"gold","product_type":"debit_card","amount":0,"account_number":"v-gold"}
+		]}`))
+	})
+	s, repo, u := newTestService(t, mux)
+	vbank, _ := repo.GetBankByCode("vbank")
+	abank, _ := repo.GetBankByCode("abank")
+	// договоры видны только в vbank: у abank нет согласия
+	if err := repo.SaveProductAgreementConsent("team-1", "vbank", "req-1", "pc-1", "team", true, false, false, nil, 0, "approved", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpsertProduct(&storage.Product{BankID: &vbank.ID, ProductID: "cc-1", InterestRate: float(24)}); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := []storage.Account{
+		{BankID: vbank.ID, AccountNumber: "a-credit", Currency: "RUB", Balance: 200},
+		{BankID: vbank.ID, AccountNumber: "v-gold", Currency: "RUB", Balance: 5000},
+		{BankID: abank.ID, AccountNumber: "a-usd", Currency: "USD", Balance: 1000},
+		{BankID: vbank.ID, AccountNumber: "v-poor", Currency: "RUB", Balance: 100},
+		{BankID: abank.ID, AccountNumber: "a-closed", Currency: "RUB", Balance: 9000, Status: "closed"},
+		{BankID: abank.ID, AccountNumber: "a-flat", Currency: "RUB", Balance: 1e7, AccountType: storage.AccountTypeAsset},
+		{BankID: vbank.ID, AccountNumber: "v-plain", Currency: "RUB", Balance: 3000},
+		{BankID: vbank.ID, AccountNumber: "v-rich", Currency: "RUB", Balance: 4000},
+	}
+	for i := range accounts {
+		accounts[i].UserID = u.ID
+		if err := repo.UpsertAccount(&accounts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range []storage.CashbackRule{
+		// 5% от 1000 упирается в лимит 30 ₽
+		{BankCode: "vbank", ProductID: str("gold"), Category: str("groceries"), Percent: 5, MaxCashback: float(30)},
+		// 10% от 11.11 $ = 100 ₽, лимит 90 ₽ задан в рублях, а не в долларах счёта
+		{BankCode: "abank", Percent: 10, MaxCashback: float(90), MaxCurrency: str("RUB")},
+		{BankCode: "abank", Category: str("travel"), Percent: 20},
+	} {
+		if _, err := s.CreateRule(u.ID, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := s.PaymentSource(context.Background(), u.ID, Request{Amount: 1000, MCC: "5411"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Category != "groceries" || res.Currency != "RUB" {
+		t.Errorf("category = %q, currency = %q", res.Category, res.Currency)
+	}
+
+	want := []struct {
+		account  string
+		eligible bool
+		benefit  float64
+	}{
+		{"a-usd", true, 75},  // кешбэк 90 − 1.5% за конвертацию
+		{"v-gold", true, 30}, // кешбэк по продукту
+		{"v-rich", true, 0},  // при равной выгоде — где больше останется
+		{"v-plain", true, 0},
+		{"a-credit", true, -16}, // 800 ₽ кредита под 24% из каталога
+		{"v-poor", false, 0},
+	}
+	number := map[int]string{}
+	for _, a := range accounts {
+		number[a.ID] = a.AccountNumber
+	}
+	if len(res.Options) != len(want) {
+		t.Fatalf("options = %d, want %d", len(res.Options), len(want))
+	}
+	for i, w := range want {
+		o := res.Options[i]
+		if number[o.ID] != w.account || o.Rank != i+1 || o.Eligible != w.eligible || o.Benefit != w.benefit {
+			t.Errorf("rank %d = %s eligible=%v benefit=%v, want %s eligible=%v benefit=%v",
+				i+1, number[o.ID], o.Eligible, o.Benefit, w.account, w.eligible, w.benefit)
+		}
+	}
+	if res.Best == nil || number[res.Best.ID] != "a-usd" {
+		t.Errorf("best = %+v, want a-usd", res.Best)
+	}
+
+	usd := res.Options[0]
+	if usd.Amount != 11.11 || usd.Cashback != 90 || usd.FXCost != 15 || usd.CurrencyMatch {
+		t.Errorf("a-usd = %+v", usd)
+	}
+	credit := res.Options[4]
+	if credit.CreditLimit != 50000 || credit.UsesCredit != 800 || credit.CreditRate == nil || *credit.CreditRate != 24 || credit.CreditCost != 16 {
+		t.Errorf("a-credit = %+v", credit)
+	}
+}
+
+func TestPaymentSourceNoEligible(t *testing.T) {
+	s, repo, u := newTestService(t, http.NotFoundHandler())
+	vbank, _ := repo.GetBankByCode("vbank")
+	if err := repo.UpsertAccount(&storage.Account{UserID: u.ID, BankID: vbank.ID, AccountNumber: "v-1", Currency: "RUB", Balance: 10}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.PaymentSource(context.Background(), u.ID, Request{Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Best != nil || len(res.Options) != 1 || res.Options[0].Eligible {
+		t.Errorf("result = %+v, want one ineligible option and no best", res)
+	}
+	if _, err := s.PaymentSource(context.Background(), u.ID, Request{Amount: 0}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("zero amount: err = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestCreateRule(t *testing.T) {
+	s, _, u := newTestService(t, http.NotFoundHandler())
+	tests := []struct {
+		name     string
+		rule     storage.CashbackRule
+		currency *string
+		err      error
+	}{
+		{name: "cap in base currency", rule: storage.CashbackRule{BankCode: "vbank", Percent: 5, MaxCashback: float(300)}, currency: str("RUB")},
+		{name: "cap currency", rule: storage.CashbackRule{BankCode: "vbank", Percent: 5, MaxCashback: float(3), MaxCurrency: str(" usd ")}, currency: str("USD")},
+		{name: "currency without cap", rule: storage.CashbackRule{BankCode: "vbank", Percent: 5, MaxCurrency: str("USD")}},
+		{name: "invalid cap currency", rule: storage.CashbackRule{BankCode: "vbank", Percent: 5, MaxCashback: float(3), MaxCurrency: str("dollars")}, err: ErrInvalidRequest},
+		{name: "non-positive cap", rule: storage.CashbackRule{BankCode: "vbank", Percent: 5, MaxCashback: float(0)}, err: ErrInvalidRequest},
+		{name: "percent above 100", rule: storage.CashbackRule{BankCode: "vbank", Percent: 101}, err: ErrInvalidRequest},
+		{name: "unknown bank", rule: storage.CashbackRule{BankCode: "zbank", Percent: 5}, err: ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := s.CreateRule(u.ID, tt.rule)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if (r.MaxCurrency == nil) != (tt.currency == nil) || (r.MaxCurrency != nil && *r.MaxCurrency != *tt.currency) {
+				t.Errorf("max currency = %v, want %v", r.MaxCurrency, tt.currency)
+			}
+		})
+	}
+}
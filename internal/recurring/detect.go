@@ -0,0 +1,290 @@
+package recurring
+
+import (
+	"MoneyPilot/internal/storage"
+	"crypto/sha1"
+	"encoding/hex"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Периодичность серии
+const (
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+)
+
+type cadence struct {
+	name     string
+	days     float64 // номинальный интервал
+	min, max float64 // допустимый интервал между платежами, дней
+	grace    int     // через сколько дней после ожидаемой даты платёж считается пропущенным
+	minCount int
+}
+
+var cadences = []cadence{
+	{Weekly, 7, 5, 9, 3, 3},
+	{Monthly, 30.4, 26, 35, 7, 3},
+	{Yearly, 365, 350, 380, 14, 2},
+}
+
+const (
+	// amountTolerance — насколько суммы одной серии могут отличаться от первой
+	amountTolerance = 0.25
+	// regularShare — доля интервалов, которые должны попасть в окно периодичности
+	regularShare = 0.7
+	maxMissed    = 3
+)
+
+// Series — найденный регулярный платёж или поступление
+type Series struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Counterparty   string    `json:"counterparty"`
+	Category       string    `json:"category,omitempty"`
+	Direction      string    `json:"direction"` // debit | credit
+	Cadence        string    `json:"cadence"`
+	Currency       string    `json:"currency"`
+	Occurrences    int       `json:"occurrences"`
+	AccountID      int       `json:"account_id"`
+	LastDate       time.Time `json:"last_date"`
+	LastAmount     float64   `json:"last_amount"` // со знаком, как в операциях
+	NextDate       time.Time `json:"next_expected_date"`
+	NextAmount     float64   `json:"next_expected_amount"`
+	PriceIncreased bool      `json:"price_increased"`
+	PreviousAmount *float64  `json:"previous_amount,omitempty"`
+	Missed         bool      `json:"missed"`
+	Status         string    `json:"status"` // detected | confirmed | dismissed
+	TransactionIDs []int     `json:"transaction_ids"`
+
+	first storage.Transaction
+}
+
+var (
+	nonLetters = regexp.MustCompile(`[^\p{L}\s]+`)
+	spaces     = regexp.MustCompile(`\s+`)
+)
+
+// Normalize приводит контрагента к ключу группировки: без регистра, цифр и знаков
+// ("NETFLIX.COM 12345" и "Netflix.com*987" → "netflix com")
+func Normalize(name string) string {
+	name = nonLetters.ReplaceAllString(strings.ToLower(name), " ")
+	return strings.TrimSpace(spaces.ReplaceAllString(name, " "))
+}
+
+// Detect ищет регулярные серии в операциях на момент now
+func Detect(txs []storage.Transaction, now time.Time) []Series {
+	groups := map[string][]storage.Transaction{}
+	names := map[string]string{}
+	for _, t := range txs {
+		raw := ""
+		switch {
+		case t.Counterparty != nil && *t.Counterparty != "":
+			raw = *t.Counterparty
+		case t.Description != nil:
+			raw = *t.Description
+		}
+		name := Normalize(raw)
+		if name == "" || t.Amount == 0 {
+			continue
+		}
+		key := strconv.Itoa(t.AccountID) + "|" + name + "|" + direction(t.Amount) + "|" + currency(t)
+		groups[key] = append(groups[key], t)
+		if _, ok := names[key]; !ok {
+			names[key] = raw
+		}
+	}
+
+	var res []Series
+	for key, group := range groups {
+		// ID строится из счёта, контрагента, направления, валюты и периодичности,
+		// чтобы подтверждение серии переживало новые операции и смену цены.
+		// Параллельные серии с теми же атрибутами отличаются первой операцией;
+		// старейшая получает ID без суффикса.
+		byID := map[string][]Series{}
+		for _, cluster := range mergeIncreases(clusterByAmount(group), now) {
+			s, ok := detectSeries(cluster, now)
+			if !ok {
+				continue
+			}
+			s.Counterparty = names[key]
+			s.Name = Normalize(names[key])
+			id := key + "|" + s.Cadence
+			byID[id] = append(byID[id], s)
+		}
+		for id, series := range byID {
+			sort.Slice(series, func(i, j int) bool { return series[i].first.BookingDate.Before(series[j].first.BookingDate) })
+			for i, s := range series {
+				if i == 0 {
+					s.ID = hashID(id)
+				} else {
+					s.ID = hashID(id + "|" + strconv.Itoa(s.first.ID))
+				}
+				res = append(res, s)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].NextDate.Equal(res[j].NextDate) {
+			return res[i].NextDate.Before(res[j].NextDate)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// clusterByAmount делит группу на кластеры близких по модулю сумм
+func clusterByAmount(txs []storage.Transaction) [][]storage.Transaction {
+	sorted := append([]storage.Transaction(nil), txs...)
+	sort.Slice(sorted, func(i, j int) bool { return math.Abs(sorted[i].Amount) < math.Abs(sorted[j].Amount) })
+
+	var clusters [][]storage.Transaction
+	var current []storage.Transaction
+	for _, t := range sorted {
+		if len(current) > 0 && math.Abs(t.Amount) > math.Abs(current[0].Amount)*(1+amountTolerance) {
+			clusters = append(clusters, current)
+			current = nil
+		}
+		current = append(current, t)
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+	return clusters
+}
+
+// mergeIncreases склеивает кластер со следующим по сумме, если тот начинается после
+// его последней операции и вместе они образуют серию: так повышение цены больше
+// допуска продолжает ту же серию, а не открывает новую. Параллельные кластеры
+// и разовые покупки у того же контрагента остаются отдельными.
+func mergeIncreases(clusters [][]storage.Transaction, now time.Time) [][]storage.Transaction {
+	for _, c := range clusters {
+		sort.Slice(c, func(i, j int) bool { return c[i].BookingDate.Before(c[j].BookingDate) })
+	}
+	var res [][]storage.Transaction
+	for _, c := range clusters {
+		if n := len(res); n > 0 {
+			prev := res[n-1]
+			if c[0].BookingDate.After(prev[len(prev)-1].BookingDate) {
+				merged := append(append([]storage.Transaction(nil), prev...), c...)
+				if _, ok := detectSeries(merged, now); ok {
+					res[n-1] = merged
+					continue
+				}
+			}
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+func detectSeries(cluster []storage.Transaction, now time.Time) (Series, bool) {
+	sort.Slice(cluster, func(i, j int) bool { return cluster[i].BookingDate.Before(cluster[j].BookingDate) })
+
+	for _, c := range cadences {
+		if len(cluster) < c.minCount {
+			continue
+		}
+		regular := 0
+		for i := 1; i < len(cluster); i++ {
+			days := cluster[i].BookingDate.Sub(cluster[i-1].BookingDate).Hours() / 24
+			if days >= c.min && days <= c.max {
+				regular++
+			}
+		}
+		if float64(regular) < regularShare*float64(len(cluster)-1) {
+			continue
+		}
+
+		last := cluster[len(cluster)-1]
+		s := Series{
+			Direction:   direction(last.Amount),
+			Cadence:     c.name,
+			Currency:    currency(last),
+			Occurrences: len(cluster),
+			AccountID:   last.AccountID,
+			LastDate:    last.BookingDate,
+			LastAmount:  last.Amount,
+			NextDate:    next(last.BookingDate, c),
+			NextAmount:  last.Amount,
+			Status:      "detected",
+			first:       cluster[0],
+		}
+		if last.Category != nil {
+			s.Category = *last.Category
+		}
+		for _, t := range cluster {
+			s.TransactionIDs = append(s.TransactionIDs, t.ID)
+		}
+		// повышение цены отмечается у серии, пока не придёт следующий платёж по новой цене
+		prev := cluster[len(cluster)-2].Amount
+		if math.Abs(last.Amount) > math.Abs(prev)*1.01 {
+			s.PriceIncreased = true
+			s.PreviousAmount = &prev
+		}
+		// пропущенные периоды сдвигают ожидаемую дату вперёд, но серия помечается;
+		// после maxMissed пропусков считаем, что платёж прекращён
+		missed := 0
+		for now.After(s.NextDate.AddDate(0, 0, c.grace)) {
+			if missed++; missed > maxMissed {
+				return Series{}, false
+			}
+			s.Missed = true
+			s.NextDate = next(s.NextDate, c)
+		}
+		return s, true
+	}
+	return Series{}, false
+}
+
+func next(t time.Time, c cadence) time.Time {
+	switch c.name {
+	case Weekly:
+		return t.AddDate(0, 0, 7)
+	case Monthly:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(1, 0, 0)
+}
+
+func direction(amount float64) string {
+	if amount < 0 {
+		return "debit"
+	}
+	return "credit"
+}
+
+func currency(t storage.Transaction) string {
+	if t.Currency != nil {
+		return *t.Currency
+	}
+	return ""
+}
+
+func hashID(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Upcoming — ожидаемые даты серии до to включительно, начиная с NextDate
+func (s Series) Upcoming(to time.Time) []time.Time {
+	var c cadence
+	for _, cd := range cadences {
+		if cd.name == s.Cadence {
+			c = cd
+		}
+	}
+	if c.name == "" {
+		return nil
+	}
+	var res []time.Time
+	for d := s.NextDate; !d.After(to); d = next(d, c) {
+		res = append(res, d)
+	}
+	return res
+}
@@ -0,0 +1,186 @@
+package recurring
+
+import (
+	"MoneyPilot/internal/storage"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+func str(v string) *string { return &v }
+
+// payments — операции контрагенту по датам "2006-01-02" с одной суммой
+func payments(firstID, accountID int, counterparty string, amount float64, dates ...string) []storage.Transaction {
+	var res []storage.Transaction
+	for i, d := range dates {
+		booked, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, storage.Transaction{
+			ID: firstID + i, AccountID: accountID, Amount: amount, BookingDate: booked,
+			Currency: str("RUB"), Counterparty: str(counterparty),
+		})
+	}
+	return res
+}
+
+func concat(groups ...[]storage.Transaction) []storage.Transaction {
+	var res []storage.Transaction
+	for _, g := range groups {
+		res = append(res, g...)
+	}
+	return res
+}
+
+func reversed(txs []storage.Transaction) []storage.Transaction {
+	res := make([]storage.Transaction, len(txs))
+	for i, t := range txs {
+		res[len(txs)-1-i] = t
+	}
+	return res
+}
+
+func TestNormalize(t *testing.T) {
+	for raw, want := range map[string]string{
+		"NETFLIX.COM 12345": "netflix com",
+		"Netflix.com*987":   "netflix com",
+		"  Яндекс   Плюс ":  "яндекс плюс",
+		"12345":             "",
+	} {
+		if got := Normalize(raw); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		txs  []storage.Transaction
+		want []Series // сравниваются только заданные поля
+	}{
+		{
+			name: "monthly",
+			txs:  payments(1, 1, "NETFLIX.COM 1", -499, "2024-03-05", "2024-04-05", "2024-05-05"),
+			want: []Series{{Cadence: Monthly, Direction: "debit", Occurrences: 3, NextAmount: -499}},
+		},
+		{
+			name: "too few payments",
+			txs:  payments(1, 1, "Netflix", -499, "2024-04-05", "2024-05-05"),
+		},
+		{
+			name: "weekly with a missed payment",
+			txs:  payments(1, 1, "Gym", -700, "2024-05-06", "2024-05-13", "2024-05-20", "2024-05-27"),
+			want: []Series{{Cadence: Weekly, Occurrences: 4, Missed: true}},
+		},
+		{
+			name: "stopped",
+			txs:  payments(1, 1, "Gym", -700, "2024-03-04", "2024-03-11", "2024-03-18", "2024-03-25"),
+		},
+		{
+			name: "price increase above tolerance stays in the series",
+			txs: concat(
+				payments(1, 1, "Netflix", -499, "2024-02-05", "2024-03-05", "2024-04-05"),
+				payments(10, 1, "Netflix", -699, "2024-05-05"),
+			),
+			want: []Series{{Cadence: Monthly, Occurrences: 4, NextAmount: -699, PriceIncreased: true}},
+		},
+		{
+			name: "one-off purchase is not merged",
+			txs: concat(
+				payments(1, 1, "Yandex", -299, "2024-03-05", "2024-04-05", "2024-05-05"),
+				payments(10, 1, "Yandex", -5000, "2024-05-20"),
+			),
+			want: []Series{{Cadence: Monthly, Occurrences: 3, NextAmount: -299}},
+		},
+		{
+			name: "parallel subscriptions",
+			txs: concat(
+				payments(1, 1, "Yandex", -299, "2024-03-05", "2024-04-05", "2024-05-05"),
+				payments(10, 1, "Yandex", -1500, "2024-03-20", "2024-04-20", "2024-05-20"),
+			),
+			want: []Series{{Cadence: Monthly, NextAmount: -299}, {Cadence: Monthly, NextAmount: -1500}},
+		},
+		{
+			name: "salary",
+			txs:  payments(1, 1, "ООО Ромашка", 100000, "2024-03-01", "2024-04-01", "2024-05-01", "2024-06-01"),
+			want: []Series{{Cadence: Monthly, Direction: "credit", Occurrences: 4}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.txs, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("series = %+v, want %d", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Cadence != w.Cadence || g.Missed != w.Missed || g.PriceIncreased != w.PriceIncreased ||
+					(w.Direction != "" && g.Direction != w.Direction) ||
+					(w.Occurrences != 0 && g.Occurrences != w.Occurrences) ||
+					(w.NextAmount != 0 && g.NextAmount != w.NextAmount) {
+					t.Errorf("series %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectPriceIncrease(t *testing.T) {
+	txs := concat(
+		payments(1, 1, "Netflix", -499, "2024-02-05", "2024-03-05", "2024-04-05"),
+		payments(10, 1, "Netflix", -699, "2024-05-05"),
+	)
+	got := Detect(txs, now)
+	if len(got) != 1 || got[0].PreviousAmount == nil || *got[0].PreviousAmount != -499 {
+		t.Fatalf("series = %+v, want previous amount -499", got)
+	}
+	if got[0].ID != Detect(txs[:3], time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))[0].ID {
+		t.Error("price increase changed the series ID")
+	}
+}
+
+func TestDetectStableIDs(t *testing.T) {
+	netflix := payments(1, 1, "Netflix", -499, "2024-02-05", "2024-03-05", "2024-04-05", "2024-05-05")
+	yandex := concat(
+		payments(10, 1, "Yandex", -299, "2024-02-05", "2024-03-05", "2024-04-05", "2024-05-05"),
+		payments(20, 1, "Yandex", -1500, "2024-03-20", "2024-04-20", "2024-05-20"),
+	)
+	ids := func(txs []storage.Transaction, at time.Time) map[float64]string {
+		res := map[float64]string{}
+		for _, s := range Detect(txs, at) {
+			res[s.NextAmount] = s.ID
+		}
+		return res
+	}
+
+	base := ids(concat(netflix, yandex), now)
+	if len(base) != 3 {
+		t.Fatalf("series = %v, want 3", base)
+	}
+	if base[-299] == base[-1500] {
+		t.Error("parallel series share an ID")
+	}
+
+	// порядок операций и новый платёж не меняют ID
+	for name, got := range map[string]map[float64]string{
+		"reversed":    ids(reversed(concat(yandex, netflix)), now),
+		"new payment": ids(concat(netflix, yandex, payments(30, 1, "Yandex", -299, "2024-06-05")), now),
+		"earlier":     ids(concat(netflix[:3], yandex[:3], yandex[4:6]), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)),
+	} {
+		for amount, id := range got {
+			if id != base[amount] {
+				t.Errorf("%s: series %v ID = %s, want %s", name, amount, id, base[amount])
+			}
+		}
+	}
+
+	// тот же контрагент на другом счёте — другая серия
+	other := payments(40, 2, "Netflix", -499, "2024-02-05", "2024-03-05", "2024-04-05", "2024-05-05")
+	got := Detect(concat(netflix, other), now)
+	if len(got) != 2 || got[0].ID == got[1].ID {
+		t.Errorf("series = %+v, want two with distinct IDs", got)
+	}
+}
@@ -0,0 +1,69 @@
+package recurring
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListRecurring — GET /api/recurring?include_dismissed=true
+// Подписки и регулярные поступления по всем банкам, с ожидаемой датой и суммой.
+func (h *Handler) ListRecurring(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var (
+		series []Series
+		err    error
+	)
+	if c.Query("include_dismissed") == "true" {
+		series, err = h.Service.List(userID, time.Now())
+	} else {
+		series, err = h.Service.Active(userID, time.Now())
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to detect recurring payments", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(series), "recurring": series})
+}
+
+// UpdateRecurring — PUT /api/recurring/:series_id {"status":"confirmed|dismissed|detected"}
+func (h *Handler) UpdateRecurring(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+
+	series, err := h.Service.Decide(userID, c.Param("series_id"), req.Status)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to update recurring payment", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
@@ -0,0 +1,104 @@
+package recurring
+
+import (
+	"MoneyPilot/internal/storage"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// window — за сколько дней назад смотреть операции
+const window = 400
+
+var ErrNotFound = errors.New("recurring series not found")
+
+type Service struct {
+	Repo      storage.Store
+	Decisions storage.RecurringRepository
+}
+
+func NewService(repo storage.Store, decisions storage.RecurringRepository) *Service {
+	return &Service{Repo: repo, Decisions: decisions}
+}
+
+// List находит серии клиента и проставляет решения пользователя
+func (s *Service) List(userID int, now time.Time) ([]Series, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accs) == 0 {
+		return []Series{}, nil
+	}
+	ids := make([]int, len(accs))
+	for i, a := range accs {
+		ids[i] = a.ID
+	}
+	txs, err := s.Repo.GetTransactionsByAccountIDs(ids, now.AddDate(0, 0, -window), now)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, err := s.Decisions.ListRecurringDecisions(userID)
+	if err != nil {
+		return nil, err
+	}
+	status := map[string]string{}
+	for _, d := range decisions {
+		status[d.SeriesID] = d.Status
+	}
+
+	series := Detect(txs, now)
+	for i := range series {
+		if st, ok := status[series[i].ID]; ok {
+			series[i].Status = st
+		}
+	}
+	return series, nil
+}
+
+// Active — серии без скрытых пользователем: на них опираются прогнозы
+func (s *Service) Active(userID int, now time.Time) ([]Series, error) {
+	all, err := s.List(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	active := all[:0]
+	for _, sr := range all {
+		if sr.Status != "dismissed" {
+			active = append(active, sr)
+		}
+	}
+	return active, nil
+}
+
+// Decide подтверждает (confirmed), скрывает (dismissed) или сбрасывает (detected) серию
+func (s *Service) Decide(userID int, seriesID, status string) (*Series, error) {
+	series, err := s.List(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var found *Series
+	for i := range series {
+		if series[i].ID == seriesID {
+			found = &series[i]
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+
+	switch status {
+	case "confirmed", "dismissed":
+		err = s.Decisions.SetRecurringDecision(userID, seriesID, status)
+	case "detected":
+		err = s.Decisions.DeleteRecurringDecision(userID, seriesID)
+	default:
+		return nil, fmt.Errorf("status must be confirmed, dismissed or detected")
+	}
+	if err != nil {
+		return nil, err
+	}
+	found.Status = status
+	return found, nil
+}
@@ -0,0 +1,64 @@
+package reports
+
+import (
+	"MoneyPilot/internal/fx"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetMonthly — GET /api/reports/monthly/:month?format=pdf|json&refresh=true
+// Прошлые месяцы отдаются из сохранённых отчётов; refresh=true пересобирает по текущим данным.
+func (h *Handler) GetMonthly(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	month, err := ParseMonth(c.Param("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+
+	if c.Query("format") == "json" {
+		r, err := h.Service.Build(userID, month, now)
+		if err != nil {
+			h.error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, r)
+		return
+	}
+
+	pdf, err := h.Service.MonthlyPDF(userID, month, c.Query("refresh") == "true", now)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="report_%s.pdf"`, month.Format("2006-01")))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+func (h *Handler) error(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidMonth):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, fx.ErrNoRate):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report", "details": err.Error()})
+	}
+}
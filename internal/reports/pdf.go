@@ -0,0 +1,163 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Минимальный генератор PDF 1.4: страницы A4, стандартные шрифты Helvetica в WinAnsiEncoding,
+// текст, линии и закрашенные прямоугольники. Шрифты не встраиваются, поэтому кириллица
+// транслитерируется (см. winAnsi).
+
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	margin     = 50.0
+)
+
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64 // текущая строка сверху вниз
+}
+
+func newPDF() *pdfDoc {
+	d := &pdfDoc{}
+	d.addPage()
+	return d
+}
+
+func (d *pdfDoc) addPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - margin
+}
+
+// need переносит вывод на новую страницу, если до нижнего поля меньше h
+func (d *pdfDoc) need(h float64) {
+	if d.y-h < margin {
+		d.addPage()
+	}
+}
+
+// text выводит строку; x — левый край, y — базовая линия от низа страницы
+func (d *pdfDoc) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(winAnsi(s)))
+}
+
+// textRight выводит строку, выровненную по правому краю right
+func (d *pdfDoc) textRight(right, y, size float64, bold bool, s string) {
+	d.text(right-textWidth(winAnsi(s), size), y, size, bold, s)
+}
+
+// rect закрашивает прямоугольник цветом rgb (компоненты 0..1)
+func (d *pdfDoc) rect(x, y, w, h float64, rgb [3]float64) {
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f 0 g\n", rgb[0], rgb[1], rgb[2], x, y, w, h)
+}
+
+func (d *pdfDoc) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page, "0.7 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", x1, y1, x2, y2)
+}
+
+// bytes собирает файл: каталог, дерево страниц, два шрифта, страницы и их содержимое, таблица xref
+func (d *pdfDoc) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.Len(), p.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", " ", "\n", " ").Replace(s)
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// winAnsi приводит строку к однобайтовой WinAnsi: Latin-1 как есть, кириллица транслитерируется,
+// прочие символы заменяются на '?'
+func winAnsi(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			b.WriteByte(byte(r))
+		case r == '—' || r == '–':
+			b.WriteByte(0x96)
+		case r == '…':
+			b.WriteByte(0x85)
+		case r == '€':
+			b.WriteByte(0x80)
+		case r == '₽':
+			b.WriteString("RUB")
+		default:
+			lower := []rune(strings.ToLower(string(r)))[0]
+			t, ok := translit[lower]
+			if !ok {
+				b.WriteByte('?')
+				continue
+			}
+			if lower != r && t != "" {
+				t = strings.ToUpper(t[:1]) + t[1:]
+			}
+			b.WriteString(t)
+		}
+	}
+	return b.String()
+}
+
+// textWidth — ширина строки Helvetica по упрощённой таблице метрик (на 1000 единиц шрифта)
+func textWidth(s string, size float64) float64 {
+	w := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == ' ', c == '.', c == ',', c == ':', c == 'i', c == 'l', c == 'j', c == 'I', c == '!':
+			w += 278
+		case c == '-' || c == '(' || c == ')' || c == 'r' || c == 't' || c == 'f':
+			w += 333
+		case c == '%':
+			w += 889
+		case c == 'm' || c == 'M' || c == 'W' || c == 'w':
+			w += 833
+		case c >= 'A' && c <= 'Z':
+			w += 667
+		default:
+			w += 556
+		}
+	}
+	return float64(w) * size / 1000
+}
@@ -0,0 +1,88 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain", "plain"},
+		{"(a)", `\(a\)`},
+		{`C:\tmp`, `C:\\tmp`},
+		{`\(`, `\\\(`},
+		{"two\r\nlines", "two  lines"},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWinAnsi(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Coffee 5814", "Coffee 5814"},
+		{"Рестораны", "Restorany"},
+		{"Щука и ёж", "Shchuka i ezh"},
+		{"ЁЖ", "EZh"},
+		{"Объём", "Obem"}, // твёрдый и мягкий знаки опускаются
+		{"café", "caf\xe9"},
+		{"1 000 ₽", "1 000 RUB"},
+		{"10 €", "10 \x80"},
+		{"янв — фев…", "yanv \x96 fev\x85"},
+		{"日本", "??"},
+	}
+	for _, tt := range tests {
+		if got := winAnsi(tt.in); got != tt.want {
+			t.Errorf("winAnsi(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPDFBytes(t *testing.T) {
+	d := newPDF()
+	d.text(margin, d.y, 12, true, "Отчёт (март)")
+	d.addPage()
+	d.text(margin, d.y, 10, false, "page 2")
+	out := d.bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("bad header or trailer:\n%s", out)
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Error("page count is not 2")
+	}
+	if !bytes.Contains(out, []byte(`(Otchet \(mart\)) Tj`)) {
+		t.Errorf("text is not transliterated and escaped:\n%s", out)
+	}
+
+	// xref должен указывать на начало каждого объекта
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 4+2*len(d.pages) {
+		t.Fatalf("got %d xref entries, want %d", len(entries), 4+2*len(d.pages))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[off:], []byte(want)) {
+			t.Errorf("xref entry %d points to %q", i+1, out[off:off+10])
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := textWidth("ii", 10); got != 5.56 {
+		t.Errorf("textWidth(ii) = %v, want 5.56", got)
+	}
+	if textWidth("1 000,00", 10) >= textWidth("1 000 000,00", 10) {
+		t.Error("longer amount is not wider")
+	}
+}
@@ -0,0 +1,184 @@
+package reports
+
+import (
+	"MoneyPilot/internal/money"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	colorBar   = [3]float64{0.26, 0.52, 0.96}
+	colorOver  = [3]float64{0.90, 0.30, 0.24}
+	colorTrack = [3]float64{0.92, 0.92, 0.92}
+)
+
+const right = pageWidth - margin
+
+// Render рисует отчёт в PDF
+func Render(r *Report) []byte {
+	d := newPDF()
+
+	d.text(margin, d.y, 20, true, "MoneyPilot monthly report")
+	d.y -= 22
+	d.text(margin, d.y, 11, false, fmt.Sprintf("%s · amounts in %s · generated %s",
+		monthTitle(r.Month), r.Currency, r.GeneratedAt.Format("2006-01-02 15:04")))
+	d.y -= 30
+
+	section(d, "Net worth")
+	row(d, false, []string{"Start of month", amount(r.NetWorthStart)})
+	row(d, false, []string{"End of month", amount(r.NetWorthEnd)})
+	change := signed(r.NetWorthChange)
+	if r.ChangePercent != nil {
+		change += fmt.Sprintf(" (%s%%)", signed(*r.ChangePercent))
+	}
+	row(d, true, []string{"Change", change})
+	d.y -= 12
+
+	section(d, "Balances by bank")
+	if len(r.Banks) == 0 {
+		empty(d, "No balance history for this month.")
+	} else {
+		table(d, []string{"Bank", "Start", "End", "Change"})
+		for _, b := range r.Banks {
+			table(d, []string{b.Bank, amount(b.Start), amount(b.End), signed(b.Change)})
+		}
+	}
+	d.y -= 12
+
+	section(d, "Cash flow")
+	row(d, false, []string{"Income", amount(r.Income)})
+	row(d, false, []string{"Spending", amount(r.Spending)})
+	row(d, true, []string{"Net", signed(money.Round(r.Income - r.Spending))})
+	d.y -= 12
+
+	section(d, "Top spending categories")
+	if len(r.TopCategories) == 0 {
+		empty(d, "No spending this month.")
+	}
+	for _, c := range r.TopCategories {
+		d.need(28)
+		d.text(margin, d.y, 10, false, c.Category)
+		d.textRight(right, d.y, 10, false, fmt.Sprintf("%s  (%s%%)", amount(c.Amount), number(c.Share)))
+		bar(d, c.Share, colorBar)
+	}
+	d.y -= 12
+
+	section(d, "Recurring charges")
+	if len(r.Recurring) == 0 {
+		empty(d, "No recurring charges this month.")
+	} else {
+		table(d, []string{"Name", "Cadence", "Charges", "Amount"})
+		for _, c := range r.Recurring {
+			table(d, []string{c.Name, c.Cadence, strconv.Itoa(c.Charges), amount(c.Amount)})
+		}
+	}
+	d.y -= 12
+
+	section(d, "Budgets")
+	if len(r.Budgets) == 0 {
+		empty(d, "No budgets.")
+	}
+	for _, b := range r.Budgets {
+		d.need(28)
+		status := "within budget"
+		color := colorBar
+		if b.Over {
+			status, color = "over budget", colorOver
+		}
+		d.text(margin, d.y, 10, b.Over, fmt.Sprintf("%s (%s)", b.Name, b.Period))
+		d.textRight(right, d.y, 10, b.Over, fmt.Sprintf("%s of %s %s · %s%% · %s",
+			amount(b.Spent), amount(b.Limit), b.Currency, number(b.PercentUsed), status))
+		bar(d, b.PercentUsed, color)
+	}
+
+	return d.bytes()
+}
+
+func section(d *pdfDoc, title string) {
+	d.need(40)
+	d.text(margin, d.y, 13, true, title)
+	d.y -= 6
+	d.line(margin, d.y, right, d.y)
+	d.y -= 16
+}
+
+// row — подпись слева, значение справа
+func row(d *pdfDoc, bold bool, cols []string) {
+	d.need(16)
+	d.text(margin, d.y, 10, bold, cols[0])
+	d.textRight(right, d.y, 10, bold, cols[1])
+	d.y -= 16
+}
+
+// table — первая колонка слева, остальные выровнены по правому краю равных колонок
+func table(d *pdfDoc, cols []string) {
+	d.need(16)
+	d.text(margin, d.y, 10, false, truncate(cols[0], 40))
+	step := (right - margin - 200) / float64(len(cols)-1)
+	for i, c := range cols[1:] {
+		d.textRight(margin+200+step*float64(i+1), d.y, 10, false, c)
+	}
+	d.y -= 16
+}
+
+// bar — полоса процента под строкой; больше 100% обрезается
+func bar(d *pdfDoc, percent float64, color [3]float64) {
+	d.y -= 8
+	width := right - margin
+	d.rect(margin, d.y, width, 4, colorTrack)
+	d.rect(margin, d.y, width*math.Min(math.Max(percent, 0), 100)/100, 4, color)
+	d.y -= 16
+}
+
+func empty(d *pdfDoc, text string) {
+	d.need(16)
+	d.text(margin, d.y, 10, false, text)
+	d.y -= 16
+}
+
+func monthTitle(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return t.Format("January 2006")
+}
+
+// amount — 1234567.8 → "1 234 567.80"
+func amount(v float64) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', 2, 64)
+	intPart, frac := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	if v < 0 {
+		return "-" + b.String() + frac
+	}
+	return b.String() + frac
+}
+
+func signed(v float64) string {
+	if v > 0 {
+		return "+" + amount(v)
+	}
+	return amount(v)
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
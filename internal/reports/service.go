@@ -0,0 +1,386 @@
+package reports
+
+import (
+	"MoneyPilot/internal/balances"
+	"MoneyPilot/internal/budgets"
+	"MoneyPilot/internal/categories"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/recurring"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/websockets"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// topCategories — сколько категорий расходов показывать в отчёте
+const topCategories = 5
+
+var ErrInvalidMonth = errors.New("invalid month")
+
+type Service struct {
+	Repo      storage.Store
+	Reports   storage.ReportRepository
+	Balances  *balances.Service
+	Budgets   *budgets.Service
+	Recurring *recurring.Service
+	FX        *fx.Service
+	Hub       *websockets.WebSocketHub
+	Log       *slog.Logger
+}
+
+func NewService(repo storage.Store, reports storage.ReportRepository, balanceSvc *balances.Service, budgetSvc *budgets.Service,
+	recurringSvc *recurring.Service, fxSvc *fx.Service, hub *websockets.WebSocketHub, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:      repo,
+		Reports:   reports,
+		Balances:  balanceSvc,
+		Budgets:   budgetSvc,
+		Recurring: recurringSvc,
+		FX:        fxSvc,
+		Hub:       hub,
+		Log:       logging.For(logger, "reports"),
+	}
+}
+
+type BankBalance struct {
+	Bank   string  `json:"bank"`
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Change float64 `json:"change"`
+}
+
+type CategoryTotal struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+	Share    float64 `json:"share"` // доля в расходах месяца, %
+}
+
+type RecurringCharge struct {
+	Name    string  `json:"name"`
+	Cadence string  `json:"cadence"`
+	Charges int     `json:"charges"`
+	Amount  float64 `json:"amount"` // списано за месяц
+}
+
+type BudgetResult struct {
+	Name        string  `json:"name"`
+	Period      string  `json:"period"`
+	Currency    string  `json:"currency"`
+	Limit       float64 `json:"limit"`
+	Spent       float64 `json:"spent"`
+	PercentUsed float64 `json:"percent_used"`
+	Over        bool    `json:"over"`
+}
+
+// Report — итоги месяца; суммы в базовой валюте клиента, бюджеты — в своей валюте
+type Report struct {
+	Month          string            `json:"month"`
+	Currency       string            `json:"currency"`
+	From           time.Time         `json:"from"`
+	To             time.Time         `json:"to"`
+	NetWorthStart  float64           `json:"net_worth_start"`
+	NetWorthEnd    float64           `json:"net_worth_end"`
+	NetWorthChange float64           `json:"net_worth_change"`
+	ChangePercent  *float64          `json:"net_worth_change_percent,omitempty"`
+	Banks          []BankBalance     `json:"banks"`
+	Income         float64           `json:"income"`
+	Spending       float64           `json:"spending"`
+	TopCategories  []CategoryTotal   `json:"top_categories"`
+	Recurring      []RecurringCharge `json:"recurring"`
+	Budgets        []BudgetResult    `json:"budgets"`
+	GeneratedAt    time.Time         `json:"generated_at"`
+}
+
+// ParseMonth разбирает YYYY-MM в начало месяца (UTC)
+func ParseMonth(v string) (time.Time, error) {
+	t, err := time.Parse("2006-01", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: expected YYYY-MM", ErrInvalidMonth)
+	}
+	return t, nil
+}
+
+// Build собирает отчёт за месяц month; текущий месяц считается по now
+func (s *Service) Build(userID int, month, now time.Time) (*Report, error) {
+	from := month
+	to := from.AddDate(0, 1, 0)
+	if !from.Before(now) {
+		return nil, fmt.Errorf("%w: month has not started yet", ErrInvalidMonth)
+	}
+	if to.After(now) {
+		to = now
+	}
+	currency, err := s.FX.BaseCurrency(userID)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		Month:         month.Format("2006-01"),
+		Currency:      currency,
+		From:          from,
+		To:            to,
+		Banks:         []BankBalance{},
+		TopCategories: []CategoryTotal{},
+		Recurring:     []RecurringCharge{},
+		Budgets:       []BudgetResult{},
+		GeneratedAt:   now,
+	}
+
+	if err := s.netWorth(r, userID); err != nil {
+		return nil, err
+	}
+	txs, err := s.transactions(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cashFlow(r, txs); err != nil {
+		return nil, err
+	}
+	if err := s.recurring(r, userID, txs); err != nil {
+		return nil, err
+	}
+	if err := s.budgets(r, userID); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// netWorth — первая точка дневного ряда с предыдущего дня — остаток на начало месяца, последняя — на конец
+func (s *Service) netWorth(r *Report, userID int) error {
+	nw, err := s.Balances.NetWorth(userID, r.From.AddDate(0, 0, -1), r.To, balances.Day, r.Currency)
+	if err != nil {
+		return err
+	}
+	if n := len(nw.Total); n > 0 {
+		r.NetWorthStart, r.NetWorthEnd = nw.Total[0].Available, nw.Total[n-1].Available
+	}
+	r.NetWorthChange = money.Round(r.NetWorthEnd - r.NetWorthStart)
+	if r.NetWorthStart != 0 {
+		pct := money.Round(r.NetWorthChange / math.Abs(r.NetWorthStart) * 100)
+		r.ChangePercent = &pct
+	}
+	for bank, points := range nw.Banks {
+		if len(points) == 0 {
+			continue
+		}
+		b := BankBalance{Bank: bank, Start: points[0].Available, End: points[len(points)-1].Available}
+		b.Change = money.Round(b.End - b.Start)
+		r.Banks = append(r.Banks, b)
+	}
+	sort.Slice(r.Banks, func(i, j int) bool { return r.Banks[i].End > r.Banks[j].End })
+	return nil
+}
+
+// cashFlow — доходы, расходы и топ категорий; переводы между своими счетами не учитываются
+func (s *Service) cashFlow(r *Report, txs []storage.Transaction) error {
+	byCategory := map[string]float64{}
+	for _, t := range txs {
+		category := categories.Other
+		if t.Category != nil && *t.Category != "" {
+			category = *t.Category
+		}
+		if category == categories.Transfers {
+			continue
+		}
+		amount, err := s.convert(t, r.Currency)
+		if err != nil {
+			return err
+		}
+		if amount > 0 {
+			r.Income += amount
+			continue
+		}
+		r.Spending -= amount
+		byCategory[category] -= amount
+	}
+	r.Income, r.Spending = money.Round(r.Income), money.Round(r.Spending)
+
+	for category, amount := range byCategory {
+		c := CategoryTotal{Category: category, Amount: money.Round(amount)}
+		if r.Spending > 0 {
+			c.Share = money.Round(amount / r.Spending * 100)
+		}
+		r.TopCategories = append(r.TopCategories, c)
+	}
+	sort.Slice(r.TopCategories, func(i, j int) bool { return r.TopCategories[i].Amount > r.TopCategories[j].Amount })
+	if len(r.TopCategories) > topCategories {
+		r.TopCategories = r.TopCategories[:topCategories]
+	}
+	return nil
+}
+
+// recurring — регулярные списания, прошедшие в этом месяце
+func (s *Service) recurring(r *Report, userID int, txs []storage.Transaction) error {
+	series, err := s.Recurring.Active(userID, r.To)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]storage.Transaction, len(txs))
+	for _, t := range txs {
+		byID[t.ID] = t
+	}
+	for _, sr := range series {
+		if sr.Direction != "debit" {
+			continue
+		}
+		c := RecurringCharge{Name: sr.Name, Cadence: sr.Cadence}
+		for _, id := range sr.TransactionIDs {
+			t, ok := byID[id]
+			if !ok {
+				continue
+			}
+			amount, err := s.convert(t, r.Currency)
+			if err != nil {
+				return err
+			}
+			c.Charges++
+			c.Amount -= amount
+		}
+		if c.Charges > 0 {
+			c.Amount = money.Round(c.Amount)
+			r.Recurring = append(r.Recurring, c)
+		}
+	}
+	sort.Slice(r.Recurring, func(i, j int) bool { return r.Recurring[i].Amount > r.Recurring[j].Amount })
+	return nil
+}
+
+// budgets — исполнение бюджетов в периоде, которым заканчивается месяц
+func (s *Service) budgets(r *Report, userID int) error {
+	progress, err := s.Budgets.ListAt(userID, r.To.Add(-time.Nanosecond))
+	if err != nil {
+		return err
+	}
+	for _, p := range progress {
+		r.Budgets = append(r.Budgets, BudgetResult{
+			Name:        p.Name,
+			Period:      p.Period,
+			Currency:    p.Currency,
+			Limit:       p.Limit,
+			Spent:       p.Spent,
+			PercentUsed: p.PercentUsed,
+			Over:        p.Spent > p.Limit,
+		})
+	}
+	return nil
+}
+
+func (s *Service) transactions(userID int, from, to time.Time) ([]storage.Transaction, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accs) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(accs))
+	for i, a := range accs {
+		ids[i] = a.ID
+	}
+	return s.Repo.GetTransactionsByAccountIDs(ids, from, to)
+}
+
+func (s *Service) convert(t storage.Transaction, currency string) (float64, error) {
+	if t.Currency == nil {
+		return t.Amount, nil
+	}
+	return money.Convert(s.FX, t.Amount, *t.Currency, currency, t.BookingDate)
+}
+
+// MonthlyPDF — PDF за месяц. Прошлый месяц берётся из сохранённых планировщиком, если refresh не задан.
+func (s *Service) MonthlyPDF(userID int, month time.Time, refresh bool, now time.Time) ([]byte, error) {
+	key := month.Format("2006-01")
+	past := !month.AddDate(0, 1, 0).After(now)
+	if past && !refresh {
+		stored, err := s.Reports.GetMonthlyReport(userID, key)
+		if err == nil {
+			return stored.PDF, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+	r, err := s.Build(userID, month, now)
+	if err != nil {
+		return nil, err
+	}
+	return Render(r), nil
+}
+
+// Start формирует отчёты за прошедший месяц всем клиентам с согласием: сразу и затем раз в interval.
+// Уже сформированные отчёты не пересчитываются; о новом отчёте клиент узнаёт по WebSocket (report_ready).
+func (s *Service) Start(interval time.Duration, stopCh <-chan struct{}) {
+	s.Log.Info("monthly job started", "interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.GenerateAll(time.Now())
+		for {
+			select {
+			case <-ticker.C:
+				s.GenerateAll(time.Now())
+			case <-stopCh:
+				s.Log.Info("stopping")
+				return
+			}
+		}
+	}()
+}
+
+// GenerateAll формирует отчёт за месяц, предшествующий now
+func (s *Service) GenerateAll(now time.Time) {
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	month := current.AddDate(0, -1, 0)
+	key := month.Format("2006-01")
+
+	userIDs, err := s.Repo.GetUserIDsWithValidAccountConsents()
+	if err != nil {
+		s.Log.Error("failed to load users", logging.Err(err))
+		return
+	}
+	for _, userID := range userIDs {
+		if _, err := s.Reports.GetMonthlyReport(userID, key); err == nil {
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			s.Log.Error("failed to load report", "user_id", userID, "month", key, logging.Err(err))
+			continue
+		}
+		r, err := s.Build(userID, month, now)
+		if err != nil {
+			s.Log.Error("failed to build report", "user_id", userID, "month", key, logging.Err(err))
+			continue
+		}
+		if err := s.Reports.SaveMonthlyReport(&storage.MonthlyReport{UserID: userID, Month: key, PDF: Render(r)}); err != nil {
+			s.Log.Error("failed to save report", "user_id", userID, "month", key, logging.Err(err))
+			continue
+		}
+		s.Log.Info("report ready", "user_id", userID, "month", key)
+		s.notify(userID, key)
+	}
+}
+
+func (s *Service) notify(userID int, month string) {
+	if s.Hub == nil {
+		return
+	}
+	ids, err := s.Repo.GetClientUserIDs(userID)
+	if err != nil {
+		s.Log.Error("notify failed", "user_id", userID, logging.Err(err))
+		return
+	}
+	msg, _ := json.Marshal(map[string]string{
+		"type":  "report_ready",
+		"month": month,
+		"url":   "/api/reports/monthly/" + month,
+	})
+	s.Hub.SendToUsers(ids, string(msg))
+}
@@ -0,0 +1,303 @@
+package savings
+
+import (
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/storage"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListGoals — GET /api/savings/goals: цели с накопленным по живым остаткам
+func (h *Handler) ListGoals(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	goals, err := h.Service.ListGoals(audit.WithUserID(c.Request.Context(), userID), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load savings goals", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(goals), "goals": goals})
+}
+
+// GetGoal — GET /api/savings/goals/:goal_id
+func (h *Handler) GetGoal(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "goal_id")
+	if !ok {
+		return
+	}
+	goal, err := h.Service.GetGoal(audit.WithUserID(c.Request.Context(), userID), userID, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "savings goal not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load savings goal", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, goal)
+}
+
+// CreateGoal — POST /api/savings/goals
+// {"name":"Отпуск","target_amount":150000,"target_date":"2026-06-01","account_id":12}
+// или вклад: {"name":"Подушка","target_amount":300000,"bank":"vbank","agreement_id":"agr-1"}
+func (h *Handler) CreateGoal(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req struct {
+		Name         string  `json:"name" binding:"required"`
+		TargetAmount float64 `json:"target_amount" binding:"required"`
+		Currency     string  `json:"currency"`
+		TargetDate   string  `json:"target_date"`
+		AccountID    *int    `json:"account_id"`
+		Bank         string  `json:"bank"`
+		AgreementID  *string `json:"agreement_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	g := storage.SavingsGoal{
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+		Currency:     req.Currency,
+		AccountID:    req.AccountID,
+		AgreementID:  req.AgreementID,
+	}
+	if req.TargetDate != "" {
+		d, err := time.Parse("2006-01-02", req.TargetDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_date must be YYYY-MM-DD"})
+			return
+		}
+		g.TargetDate = &d
+	}
+
+	goal, err := h.Service.CreateGoal(userID, g, req.Bank)
+	if errors.Is(err, ErrInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create savings goal", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, goal)
+}
+
+// DeleteGoal — DELETE /api/savings/goals/:goal_id вместе с правилами и переводами
+func (h *Handler) DeleteGoal(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "goal_id")
+	if !ok {
+		return
+	}
+	err := h.Service.DeleteGoal(userID, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "savings goal not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete savings goal", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
+
+// ListRules — GET /api/savings/rules
+func (h *Handler) ListRules(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	rules, err := h.Service.ListRules(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load sweep rules", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(rules), "rules": rules})
+}
+
+// CreateRule — POST /api/savings/goals/:goal_id/rules
+// {"type":"roundup","source_account_id":3,"round_to":100}
+// {"type":"salary_percent","source_account_id":3,"percent":10}
+// {"type":"excess","source_account_id":3,"keep_amount":50000}
+func (h *Handler) CreateRule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	goalID, ok := paramID(c, "goal_id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Type            string   `json:"type" binding:"required"`
+		SourceAccountID int      `json:"source_account_id" binding:"required"`
+		RoundTo         *float64 `json:"round_to"`
+		Percent         *float64 `json:"percent"`
+		KeepAmount      *float64 `json:"keep_amount"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body", "details": err.Error()})
+		return
+	}
+	rule, err := h.Service.CreateRule(userID, storage.SweepRule{
+		GoalID:          goalID,
+		Type:            req.Type,
+		SourceAccountID: req.SourceAccountID,
+		RoundTo:         req.RoundTo,
+		Percent:         req.Percent,
+		KeepAmount:      req.KeepAmount,
+	})
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "savings goal not found"})
+		return
+	}
+	if errors.Is(err, ErrInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create sweep rule", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// DeleteRule — DELETE /api/savings/rules/:rule_id
+func (h *Handler) DeleteRule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "rule_id")
+	if !ok {
+		return
+	}
+	err := h.Service.DeleteRule(userID, id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sweep rule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete sweep rule", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
+
+// ListTransfers — GET /api/savings/transfers?status=proposed
+func (h *Handler) ListTransfers(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	transfers, err := h.Service.ListTransfers(userID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load transfers", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(transfers), "transfers": transfers})
+}
+
+// ApproveTransfer — POST /api/savings/transfers/:transfer_id/approve
+// Исполняется сразу при наличии платёжного согласия, иначе остаётся approved до его появления.
+func (h *Handler) ApproveTransfer(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "transfer_id")
+	if !ok {
+		return
+	}
+	t, err := h.Service.Approve(audit.WithUserID(c.Request.Context(), userID), userID, id)
+	h.transferResult(c, t, err)
+}
+
+// RejectTransfer — POST /api/savings/transfers/:transfer_id/reject
+func (h *Handler) RejectTransfer(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := paramID(c, "transfer_id")
+	if !ok {
+		return
+	}
+	t, err := h.Service.Reject(userID, id)
+	h.transferResult(c, t, err)
+}
+
+// Run — POST /api/savings/run: проверить правила клиента сейчас, не дожидаясь планировщика
+func (h *Handler) Run(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	n, err := h.Service.Run(audit.WithUserID(c.Request.Context(), userID), userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to run sweep rules", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"created": n})
+}
+
+func (h *Handler) transferResult(c *gin.Context, t *storage.SweepTransfer, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+		return
+	}
+	if errors.Is(err, ErrInvalid) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update transfer", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+func paramID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,245 @@
+package savings
+
+import (
+	"MoneyPilot/internal/accounts"
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/payments"
+	"MoneyPilot/internal/productagreements"
+	"MoneyPilot/internal/recurring"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/websockets"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrInvalid  = errors.New("invalid request")
+)
+
+type Service struct {
+	Repo      storage.Store
+	Savings   storage.SavingsRepository
+	Accounts  *accounts.Service
+	Products  *productagreements.Service
+	Recurring *recurring.Service
+	Payments  *payments.Service
+	FX        *fx.Service
+	Hub       *websockets.WebSocketHub
+	Banks     map[string]*bankapi.BankClient
+	Log       *slog.Logger
+}
+
+func NewService(repo storage.Store, savings storage.SavingsRepository, accountSvc *accounts.Service, products *productagreements.Service,
+	recurringSvc *recurring.Service, paymentSvc *payments.Service, fxSvc *fx.Service, hub *websockets.WebSocketHub, banks map[string]*bankapi.BankClient,
+	logger *slog.Logger) *Service {
+	return &Service{
+		Repo:      repo,
+		Savings:   savings,
+		Accounts:  accountSvc,
+		Products:  products,
+		Recurring: recurringSvc,
+		Payments:  paymentSvc,
+		FX:        fxSvc,
+		Hub:       hub,
+		Banks:     banks,
+		Log:       logging.For(logger, "savings"),
+	}
+}
+
+// Progress — накоплено по цели по живому остатку счёта или вклада
+type Progress struct {
+	storage.SavingsGoal
+	Bank          string   `json:"bank,omitempty"`
+	Saved         float64  `json:"saved"`
+	Remaining     float64  `json:"remaining"`
+	Percent       float64  `json:"percent"`
+	MonthlyNeeded *float64 `json:"monthly_needed,omitempty"` // сколько откладывать в месяц, чтобы успеть к дате
+	Live          bool     `json:"live"`                     // false — остаток из последнего снимка
+	Error         string   `json:"error,omitempty"`
+}
+
+func (s *Service) ListGoals(ctx context.Context, userID int) ([]Progress, error) {
+	goals, err := s.Savings.ListSavingsGoals(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Progress, 0, len(goals))
+	for _, g := range goals {
+		res = append(res, s.progress(ctx, userID, g, time.Now()))
+	}
+	return res, nil
+}
+
+func (s *Service) GetGoal(ctx context.Context, userID, id int) (*Progress, error) {
+	g, err := s.Savings.GetSavingsGoal(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := s.progress(ctx, userID, *g, time.Now())
+	return &p, nil
+}
+
+// CreateGoal сохраняет цель; счёт должен принадлежать клиенту, вклад задаётся кодом банка и договором
+func (s *Service) CreateGoal(userID int, g storage.SavingsGoal, bankCode string) (*storage.SavingsGoal, error) {
+	g.UserID = userID
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if g.TargetAmount <= 0 {
+		return nil, fmt.Errorf("%w: target_amount must be positive", ErrInvalid)
+	}
+	if g.TargetDate != nil && !g.TargetDate.After(time.Now()) {
+		return nil, fmt.Errorf("%w: target_date must be in the future", ErrInvalid)
+	}
+
+	switch {
+	case g.AccountID != nil:
+		acc, err := s.clientAccount(userID, *g.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if g.Currency == "" {
+			g.Currency = acc.Currency
+		}
+		g.BankID, g.AgreementID = nil, nil
+	case g.AgreementID != nil && *g.AgreementID != "":
+		if _, ok := s.Banks[bankCode]; !ok {
+			return nil, fmt.Errorf("%w: bank is required for a deposit agreement", ErrInvalid)
+		}
+		bank, err := s.Repo.GetBankByCode(bankCode)
+		if err != nil {
+			return nil, err
+		}
+		g.BankID = &bank.ID
+	default:
+		return nil, fmt.Errorf("%w: account_id or agreement_id is required", ErrInvalid)
+	}
+
+	if g.Currency == "" {
+		cur, err := s.FX.BaseCurrency(userID)
+		if err != nil {
+			return nil, err
+		}
+		g.Currency = cur
+	}
+	g.Currency = strings.ToUpper(g.Currency)
+	if !fx.ValidCurrency(g.Currency) {
+		return nil, fmt.Errorf("%w: invalid currency", ErrInvalid)
+	}
+	if err := s.Savings.InsertSavingsGoal(&g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (s *Service) DeleteGoal(userID, id int) error {
+	err := s.Savings.DeleteSavingsGoal(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// progress запрашивает остаток в банке; при ошибке берёт сохранённый остаток счёта
+func (s *Service) progress(ctx context.Context, userID int, g storage.SavingsGoal, now time.Time) Progress {
+	p := Progress{SavingsGoal: g}
+	balance, currency, err := s.goalBalance(ctx, userID, g, &p)
+	if err != nil {
+		p.Error = err.Error()
+	}
+	if currency != "" && currency != g.Currency && balance != 0 {
+		if balance, err = s.FX.Convert(balance, currency, g.Currency, now); err != nil {
+			p.Error = err.Error()
+			balance = 0
+		}
+	}
+
+	p.Saved = money.Round(balance)
+	p.Remaining = money.Round(math.Max(g.TargetAmount-balance, 0))
+	p.Percent = money.Round(math.Min(balance/g.TargetAmount*100, 100))
+	if g.TargetDate != nil && p.Remaining > 0 {
+		months := g.TargetDate.Sub(now).Hours() / 24 / 30.4
+		if months < 1 {
+			months = 1
+		}
+		monthly := money.Round(p.Remaining / months)
+		p.MonthlyNeeded = &monthly
+	}
+	return p
+}
+
+func (s *Service) goalBalance(ctx context.Context, userID int, g storage.SavingsGoal, p *Progress) (float64, string, error) {
+	if g.AccountID != nil {
+		acc, err := s.Repo.GetAccountByID(*g.AccountID)
+		if err != nil {
+			return 0, "", err
+		}
+		p.Bank = s.bankCode(acc.BankID)
+		if acc.ExternalID != nil {
+			bal, err := s.Accounts.FetchBalances(ctx, userID, p.Bank, *acc.ExternalID)
+			if err == nil && bal.Available != nil {
+				p.Live = true
+				cur := bal.Currency
+				if cur == "" {
+					cur = acc.Currency
+				}
+				return *bal.Available, cur, nil
+			}
+			if err != nil {
+				s.Log.WarnContext(ctx, "live balance unavailable", "goal_id", g.ID, logging.Err(err))
+			}
+		}
+		return acc.Balance, acc.Currency, nil
+	}
+
+	if g.BankID == nil || g.AgreementID == nil {
+		return 0, "", nil
+	}
+	p.Bank = s.bankCode(*g.BankID)
+	details, err := s.Products.GetProductDetails(ctx, userID, p.Bank, *g.AgreementID)
+	if err != nil {
+		return 0, "", err
+	}
+	p.Live = true
+	if details.AccountBalance != nil {
+		return *details.AccountBalance, g.Currency, nil
+	}
+	return details.Amount, g.Currency, nil
+}
+
+// clientAccount — сохранённый счёт, если он принадлежит клиенту
+func (s *Service) clientAccount(userID, accountID int) (*storage.Account, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range accs {
+		if accs[i].ID == accountID {
+			return &accs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: account %d not found", ErrInvalid, accountID)
+}
+
+func (s *Service) bankCode(bankID int) string {
+	for code := range s.Banks {
+		if b, err := s.Repo.GetBankByCode(code); err == nil && b.ID == bankID {
+			return code
+		}
+	}
+	return ""
+}
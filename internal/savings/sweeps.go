@@ -0,0 +1,517 @@
+package savings
+
+import (
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/forecast"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/payments"
+	"MoneyPilot/internal/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Типы правил автопополнения
+const (
+	RoundUp       = "roundup"
+	SalaryPercent = "salary_percent"
+	Excess        = "excess"
+)
+
+// Статусы переводов
+const (
+	Proposed    = "proposed"
+	Approved    = "approved"  // одобрен, ждёт платёжного согласия
+	Executing   = "executing" // платёж отправляется в банк
+	Executed    = "executed"
+	Unconfirmed = "unconfirmed" // банк мог провести платёж: не повторяется, нужна сверка
+	Rejected    = "rejected"
+	Failed      = "failed" // банк точно не провёл платёж, можно одобрить снова
+)
+
+// staleClaim — через сколько перевод в executing считается прерванным
+const staleClaim = time.Hour
+
+// minSweep — меньшие суммы не переводятся
+const minSweep = 1.0
+
+// Proposal — уведомление о новом переводе, который нужно одобрить
+type Proposal struct {
+	Type     string                `json:"type"` // sweep_proposal
+	Transfer storage.SweepTransfer `json:"transfer"`
+	Goal     string                `json:"goal"`
+}
+
+// Start запускает проверку правил: сразу и затем раз в interval
+func (s *Service) Start(interval time.Duration, stopCh <-chan struct{}) {
+	s.Log.Info("sweep job started", "interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.RunAll(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				s.RunAll(context.Background())
+			case <-stopCh:
+				s.Log.Info("stopping")
+				return
+			}
+		}
+	}()
+}
+
+func (s *Service) RunAll(ctx context.Context) {
+	if n, err := s.Savings.MarkStaleSweepTransfers(time.Now().Add(-staleClaim)); err != nil {
+		s.Log.ErrorContext(ctx, "failed to release stale transfers", logging.Err(err))
+	} else if n > 0 {
+		s.Log.WarnContext(ctx, "interrupted transfers need reconciliation", "count", n)
+	}
+
+	userIDs, err := s.Repo.GetUserIDsWithValidAccountConsents()
+	if err != nil {
+		s.Log.ErrorContext(ctx, "failed to load users", logging.Err(err))
+		return
+	}
+	for _, userID := range userIDs {
+		n, err := s.Run(audit.WithUserID(ctx, userID), userID, time.Now())
+		if err != nil {
+			s.Log.ErrorContext(ctx, "sweep failed", "user_id", userID, logging.Err(err))
+			continue
+		}
+		if n > 0 {
+			s.Log.InfoContext(ctx, "transfers created", "user_id", userID, "count", n)
+		}
+	}
+}
+
+// Run проверяет правила клиента на событиях с прошлого запуска и создаёт переводы.
+// Переводы исполняются сразу, если есть платёжное согласие, иначе ждут одобрения.
+// Одобренные ранее переводы повторяются — согласие могло появиться.
+func (s *Service) Run(ctx context.Context, userID int, now time.Time) (int, error) {
+	rules, err := s.Savings.ListSweepRules(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// пока предыдущий излишек не переведён, новый не предлагаем — остаток ещё не уменьшился
+	waiting := map[int]bool{}
+	for _, status := range []string{Proposed, Approved, Executing, Unconfirmed} {
+		pending, err := s.Savings.ListSweepTransfers(userID, status)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range pending {
+			waiting[t.RuleID] = true
+		}
+	}
+
+	var incomeTx map[int]bool
+	created := 0
+	for _, r := range rules {
+		if !r.Enabled || (r.Type == Excess && waiting[r.ID]) {
+			continue
+		}
+		if r.Type == SalaryPercent && incomeTx == nil {
+			if incomeTx, err = s.incomeTransactions(userID, now); err != nil {
+				return created, err
+			}
+		}
+		transfers, err := s.evaluate(r, incomeTx, now)
+		if err != nil {
+			s.Log.ErrorContext(ctx, "failed to evaluate rule", "rule_id", r.ID, logging.Err(err))
+			continue
+		}
+		for i := range transfers {
+			t := &transfers[i]
+			fresh, err := s.Savings.InsertSweepTransfer(t)
+			if err != nil {
+				s.Log.ErrorContext(ctx, "failed to insert transfer", "rule_id", r.ID, logging.Err(err))
+				continue
+			}
+			if !fresh {
+				continue
+			}
+			created++
+			s.settle(ctx, userID, t)
+		}
+		if err := s.Savings.SetSweepRuleRun(r.ID, now); err != nil {
+			s.Log.ErrorContext(ctx, "failed to mark rule run", "rule_id", r.ID, logging.Err(err))
+		}
+	}
+
+	approved, err := s.Savings.ListSweepTransfers(userID, Approved)
+	if err != nil {
+		return created, err
+	}
+	for i := range approved {
+		if err := s.execute(ctx, userID, &approved[i], Approved); err != nil && !errors.Is(err, payments.ErrNoConsent) {
+			s.Log.ErrorContext(ctx, "transfer failed", "transfer_id", approved[i].ID, logging.Err(err))
+		}
+	}
+	return created, nil
+}
+
+// evaluate считает переводы по операциям, сохранённым с прошлого запуска. Выборка идёт
+// по времени сохранения, а не проводки: синхронизация приносит операции с опозданием.
+func (s *Service) evaluate(r storage.SweepRule, incomeTx map[int]bool, now time.Time) ([]storage.SweepTransfer, error) {
+	acc, err := s.Repo.GetAccountByID(r.SourceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	since := r.CreatedAt
+	if r.LastRunAt != nil {
+		since = *r.LastRunAt
+	}
+	transfer := func(amount float64, reason, key string) storage.SweepTransfer {
+		return storage.SweepTransfer{
+			UserID:          r.UserID,
+			RuleID:          r.ID,
+			GoalID:          r.GoalID,
+			SourceAccountID: r.SourceAccountID,
+			Amount:          money.Round(amount),
+			Currency:        acc.Currency,
+			Status:          Proposed,
+			Reason:          reason,
+			SourceKey:       key,
+		}
+	}
+
+	var res []storage.SweepTransfer
+	switch r.Type {
+	case Excess:
+		if r.KeepAmount == nil {
+			return nil, nil
+		}
+		if excess := acc.Balance - *r.KeepAmount; excess >= minSweep {
+			reason := fmt.Sprintf("balance %.2f %s above %.2f", acc.Balance, acc.Currency, *r.KeepAmount)
+			res = append(res, transfer(excess, reason, "excess:"+now.UTC().Format("2006-01-02")))
+		}
+		return res, nil
+	}
+
+	txs, err := s.Repo.GetTransactionsAddedSince(acc.ID, since, now)
+	if err != nil {
+		return nil, err
+	}
+	switch r.Type {
+	case RoundUp:
+		if r.RoundTo == nil || *r.RoundTo <= 0 {
+			return nil, nil
+		}
+		sum, count, last := 0.0, 0, 0
+		for _, t := range txs {
+			if t.Amount >= 0 {
+				continue
+			}
+			spent := -t.Amount
+			if up := math.Ceil(spent / *r.RoundTo) * *r.RoundTo; up > spent {
+				sum += up - spent
+				count++
+			}
+			if t.ID > last {
+				last = t.ID
+			}
+		}
+		if sum >= minSweep {
+			reason := fmt.Sprintf("round-up of %d purchases to %.0f", count, *r.RoundTo)
+			res = append(res, transfer(sum, reason, fmt.Sprintf("roundup:%d", last)))
+		}
+	case SalaryPercent:
+		if r.Percent == nil {
+			return nil, nil
+		}
+		for _, t := range txs {
+			if t.Amount <= 0 || !incomeTx[t.ID] {
+				continue
+			}
+			amount := t.Amount * *r.Percent / 100
+			if amount < minSweep {
+				continue
+			}
+			reason := fmt.Sprintf("%.2f%% of income %.2f %s on %s", *r.Percent, t.Amount, acc.Currency, t.BookingDate.Format("2006-01-02"))
+			res = append(res, transfer(amount, reason, fmt.Sprintf("salary:%d", t.ID)))
+		}
+	}
+	return res, nil
+}
+
+// incomeTransactions — операции регулярных поступлений клиента (зарплата и т.п.)
+func (s *Service) incomeTransactions(userID int, now time.Time) (map[int]bool, error) {
+	series, err := s.Recurring.Active(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	res := map[int]bool{}
+	for _, sr := range series {
+		if sr.Direction != "credit" {
+			continue
+		}
+		for _, id := range sr.TransactionIDs {
+			res[id] = true
+		}
+	}
+	return res, nil
+}
+
+// settle исполняет новый перевод по согласию или отправляет его на одобрение
+func (s *Service) settle(ctx context.Context, userID int, t *storage.SweepTransfer) {
+	err := s.execute(ctx, userID, t, Proposed)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, payments.ErrNoConsent) {
+		s.Log.ErrorContext(ctx, "transfer failed", "transfer_id", t.ID, logging.Err(err))
+		return
+	}
+	s.notify(userID, t)
+}
+
+// execute переводит деньги в цель. Перевод сначала атомарно забирается из статуса from
+// в executing, поэтому параллельные запуски не отправят платёж дважды; если его уже забрали,
+// execute ничего не делает. ErrNoConsent возвращает перевод в from. Если банк точно
+// не провёл платёж — failed, иначе unconfirmed: повторять такой перевод нельзя.
+func (s *Service) execute(ctx context.Context, userID int, t *storage.SweepTransfer, from string) error {
+	claimed, err := s.Savings.SetSweepTransferStatus(t, Executing, from)
+	if err != nil || !claimed {
+		return err
+	}
+	transfer, err := s.transfer(ctx, userID, t)
+	if err != nil {
+		return s.fail(t, Failed, err)
+	}
+	// ключ не меняется между попытками: банк не проведёт повтор того же перевода
+	transfer.IdempotencyKey = fmt.Sprintf("moneypilot-sweep-%d", t.ID)
+	p, err := s.Payments.Execute(ctx, userID, *transfer)
+	switch {
+	case p != nil:
+		if err != nil {
+			s.Log.ErrorContext(ctx, "payment executed but not saved", "transfer_id", t.ID, "payment_id", p.PaymentID, logging.Err(err))
+		}
+		t.Status = Executed
+		t.PaymentID = &p.PaymentID
+		t.Error = nil
+		return s.Savings.UpdateSweepTransfer(t)
+	case errors.Is(err, payments.ErrNoConsent):
+		if _, uerr := s.Savings.SetSweepTransferStatus(t, from, Executing); uerr != nil {
+			return uerr
+		}
+		return err
+	case errors.Is(err, payments.ErrNotExecuted):
+		return s.fail(t, Failed, err)
+	default:
+		return s.fail(t, Unconfirmed, err)
+	}
+}
+
+func (s *Service) fail(t *storage.SweepTransfer, status string, cause error) error {
+	msg := cause.Error()
+	t.Status = status
+	t.Error = &msg
+	if err := s.Savings.UpdateSweepTransfer(t); err != nil {
+		return err
+	}
+	return cause
+}
+
+// transfer — платёж со счёта правила на счёт цели (сберегательный счёт или счёт вклада)
+func (s *Service) transfer(ctx context.Context, userID int, t *storage.SweepTransfer) (*payments.Transfer, error) {
+	src, err := s.Repo.GetAccountByID(t.SourceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.Savings.GetSavingsGoal(userID, t.GoalID)
+	if err != nil {
+		return nil, err
+	}
+
+	creditor := ""
+	switch {
+	case g.AccountID != nil:
+		dst, err := s.Repo.GetAccountByID(*g.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		creditor = dst.AccountNumber
+	case g.BankID != nil && g.AgreementID != nil:
+		details, err := s.Products.GetProductDetails(ctx, userID, s.bankCode(*g.BankID), *g.AgreementID)
+		if err != nil {
+			return nil, err
+		}
+		if details.AccountNumber != nil {
+			creditor = *details.AccountNumber
+		}
+	}
+	if creditor == "" {
+		return nil, errors.New("goal has no account to transfer to")
+	}
+
+	return &payments.Transfer{
+		BankCode:        s.bankCode(src.BankID),
+		BankID:          src.BankID,
+		DebtorAccount:   src.AccountNumber,
+		CreditorAccount: creditor,
+		Amount:          t.Amount,
+		Currency:        t.Currency,
+		Reference:       fmt.Sprintf("MoneyPilot: %s (%s)", g.Name, t.Reason),
+	}, nil
+}
+
+func (s *Service) notify(userID int, t *storage.SweepTransfer) {
+	if s.Hub == nil {
+		return
+	}
+	ids, err := s.Repo.GetClientUserIDs(userID)
+	if err != nil {
+		s.Log.Error("notify failed", "user_id", userID, logging.Err(err))
+		return
+	}
+	goal := ""
+	if g, err := s.Savings.GetSavingsGoal(userID, t.GoalID); err == nil {
+		goal = g.Name
+	}
+	msg, _ := json.Marshal(Proposal{Type: "sweep_proposal", Transfer: *t, Goal: goal})
+	s.Hub.SendToUsers(ids, string(msg))
+}
+
+// Approve одобряет предложенный перевод и сразу пытается его исполнить.
+// Повторно одобрить можно только failed — перевод, который банк точно не провёл.
+func (s *Service) Approve(ctx context.Context, userID, id int) (*storage.SweepTransfer, error) {
+	t, err := s.transition(userID, id, Approved, Proposed, Failed)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.execute(ctx, userID, t, Approved); err != nil && !errors.Is(err, payments.ErrNoConsent) {
+		s.Log.ErrorContext(ctx, "transfer failed", "transfer_id", t.ID, logging.Err(err))
+	}
+	return t, nil
+}
+
+// Reject отклоняет перевод; unconfirmed отклоняется после сверки с банком
+func (s *Service) Reject(userID, id int) (*storage.SweepTransfer, error) {
+	return s.transition(userID, id, Rejected, Proposed, Approved, Failed, Unconfirmed)
+}
+
+// transition переводит перевод клиента из одного из статусов from в status.
+// Статус меняется условно: параллельно перевод мог забрать на исполнение фоновый запуск.
+func (s *Service) transition(userID, id int, status string, from ...string) (*storage.SweepTransfer, error) {
+	t, err := s.pending(userID, id, from...)
+	if err != nil {
+		return nil, err
+	}
+	t.Error = nil
+	ok, err := s.Savings.SetSweepTransferStatus(t, status, from...)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: transfer status has changed", ErrInvalid)
+	}
+	return t, nil
+}
+
+// pending — перевод клиента в одном из статусов allowed
+func (s *Service) pending(userID, id int, allowed ...string) (*storage.SweepTransfer, error) {
+	t, err := s.Savings.GetSweepTransfer(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range allowed {
+		if t.Status == st {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: transfer is %s", ErrInvalid, t.Status)
+}
+
+func (s *Service) ListTransfers(userID int, status string) ([]storage.SweepTransfer, error) {
+	transfers, err := s.Savings.ListSweepTransfers(userID, status)
+	if transfers == nil {
+		transfers = []storage.SweepTransfer{}
+	}
+	return transfers, err
+}
+
+func (s *Service) ListRules(userID int) ([]storage.SweepRule, error) {
+	rules, err := s.Savings.ListSweepRules(userID)
+	if rules == nil {
+		rules = []storage.SweepRule{}
+	}
+	return rules, err
+}
+
+// CreateRule добавляет правило к цели; источник — счёт клиента
+func (s *Service) CreateRule(userID int, r storage.SweepRule) (*storage.SweepRule, error) {
+	if _, err := s.Savings.GetSavingsGoal(userID, r.GoalID); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := s.clientAccount(userID, r.SourceAccountID); err != nil {
+		return nil, err
+	}
+	switch r.Type {
+	case RoundUp:
+		if r.RoundTo == nil || *r.RoundTo <= 0 {
+			return nil, fmt.Errorf("%w: round_to must be positive", ErrInvalid)
+		}
+	case SalaryPercent:
+		if r.Percent == nil || *r.Percent <= 0 || *r.Percent > 100 {
+			return nil, fmt.Errorf("%w: percent must be in (0, 100]", ErrInvalid)
+		}
+	case Excess:
+		if r.KeepAmount == nil || *r.KeepAmount < 0 {
+			return nil, fmt.Errorf("%w: keep_amount must not be negative", ErrInvalid)
+		}
+	default:
+		return nil, fmt.Errorf("%w: type must be roundup, salary_percent or excess", ErrInvalid)
+	}
+	r.UserID = userID
+	r.Enabled = true
+	if err := s.Savings.InsertSweepRule(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Service) DeleteRule(userID, id int) error {
+	err := s.Savings.DeleteSweepRule(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ScheduledFlows отдаёт прогнозу ещё не исполненные переводы: списание с источника
+// и зачисление на счёт цели в ближайший день
+func (s *Service) ScheduledFlows(userID int, from, to time.Time) ([]forecast.Flow, error) {
+	transfers, err := s.Savings.ListSweepTransfers(userID, "")
+	if err != nil {
+		return nil, err
+	}
+	var flows []forecast.Flow
+	for _, t := range transfers {
+		if t.Status != Proposed && t.Status != Approved && t.Status != Executing {
+			continue
+		}
+		desc := "savings: " + t.Reason
+		flows = append(flows, forecast.Flow{AccountID: t.SourceAccountID, Date: from, Amount: -t.Amount, Source: "scheduled", Description: desc})
+		g, err := s.Savings.GetSavingsGoal(userID, t.GoalID)
+		if err != nil || g.AccountID == nil {
+			continue
+		}
+		if dst, err := s.Repo.GetAccountByID(*g.AccountID); err == nil && dst.Currency == t.Currency {
+			flows = append(flows, forecast.Flow{AccountID: dst.ID, Date: from, Amount: t.Amount, Source: "scheduled", Description: desc})
+		}
+	}
+	return flows, nil
+}
@@ -0,0 +1,159 @@
+package savings
+
+import (
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestEvaluate(t *testing.T) {
+	booked := time.Date(2024, 6, 28, 0, 0, 0, 0, time.UTC)
+
+	type want struct {
+		amount float64
+		key    string // %d — ID операции с индексом tx
+		tx     int
+		reason string
+	}
+	tests := []struct {
+		name     string
+		rule     storage.SweepRule
+		firstRun bool // правило ещё не запускалось: операции берутся с его создания
+		balance  float64
+		old      []float64 // сохранены до прошлого запуска
+		txs      []float64 // сохранены после
+		income   []int     // индексы txs, признанных доходом
+		want     []want
+	}{
+		{
+			name: "roundup sums change of purchases",
+			rule: storage.SweepRule{Type: RoundUp, RoundTo: float(100)},
+			old:  []float64{-1},
+			txs:  []float64{-150.5, -200, -99.99, 500, -30},
+			want: []want{{119.51, "roundup:%d", 4, "round-up of 3 purchases to 100"}},
+		},
+		{
+			name:     "roundup first run starts at rule creation",
+			rule:     storage.SweepRule{Type: RoundUp, RoundTo: float(10)},
+			firstRun: true,
+			old:      []float64{-1},
+			txs:      []float64{-95},
+			want:     []want{{5, "roundup:%d", 0, "round-up of 1 purchases to 10"}},
+		},
+		{
+			name: "roundup below minimum",
+			rule: storage.SweepRule{Type: RoundUp, RoundTo: float(100)},
+			txs:  []float64{-99.5, 1000},
+		},
+		{
+			name: "roundup without step",
+			rule: storage.SweepRule{Type: RoundUp},
+			txs:  []float64{-150.5},
+		},
+		{
+			name:   "salary percent of each income",
+			rule:   storage.SweepRule{Type: SalaryPercent, Percent: float(10)},
+			old:    []float64{90000},
+			txs:    []float64{50000, 3000, -100, 5, 1234.56},
+			income: []int{0, 2, 3, 4},
+			want: []want{
+				{5000, "salary:%d", 0, "10.00% of income 50000.00 RUB on 2024-06-28"},
+				{123.46, "salary:%d", 4, "10.00% of income 1234.56 RUB on 2024-06-28"},
+			},
+		},
+		{
+			name:   "salary percent without percent",
+			rule:   storage.SweepRule{Type: SalaryPercent},
+			txs:    []float64{50000},
+			income: []int{0},
+		},
+		{
+			name:    "excess above kept balance",
+			rule:    storage.SweepRule{Type: Excess, KeepAmount: float(10000)},
+			balance: 12345.678,
+			want:    []want{{2345.68, "excess:%s", -1, "balance 12345.68 RUB above 10000.00"}},
+		},
+		{
+			name:    "excess below minimum",
+			rule:    storage.SweepRule{Type: Excess, KeepAmount: float(10000)},
+			balance: 10000.5,
+		},
+		{
+			name:    "excess below kept balance",
+			rule:    storage.SweepRule{Type: Excess, KeepAmount: float(10000)},
+			balance: 500,
+		},
+		{
+			name:    "excess without kept amount",
+			rule:    storage.SweepRule{Type: Excess},
+			balance: 12345,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.New()
+			u := repo.AddUser(storage.User{ClientID: "team-1"})
+			acc := &storage.Account{UserID: u.ID, AccountNumber: "40817", Currency: "RUB", Balance: tt.balance}
+			if err := repo.UpsertAccount(acc); err != nil {
+				t.Fatal(err)
+			}
+			insert := func(amount float64) storage.Transaction {
+				tx := storage.Transaction{AccountID: acc.ID, Amount: amount, BookingDate: booked}
+				if err := repo.InsertTransaction(&tx); err != nil {
+					t.Fatal(err)
+				}
+				return tx
+			}
+			for _, amount := range tt.old {
+				insert(amount)
+			}
+			time.Sleep(time.Millisecond)
+			mark := time.Now()
+			time.Sleep(time.Millisecond)
+			var txs []storage.Transaction
+			for _, amount := range tt.txs {
+				txs = append(txs, insert(amount))
+			}
+			income := map[int]bool{}
+			for _, i := range tt.income {
+				income[txs[i].ID] = true
+			}
+
+			rule := tt.rule
+			rule.ID, rule.UserID, rule.GoalID, rule.SourceAccountID = 3, u.ID, 5, acc.ID
+			rule.CreatedAt = mark.Add(-time.Hour)
+			if tt.firstRun {
+				rule.CreatedAt = mark
+			} else {
+				rule.LastRunAt = &mark
+			}
+			now := time.Now().Add(time.Hour)
+
+			s := &Service{Repo: repo}
+			got, err := s.evaluate(rule, income, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transfers, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				key := fmt.Sprintf(w.key, now.UTC().Format("2006-01-02"))
+				if w.tx >= 0 {
+					key = fmt.Sprintf(w.key, txs[w.tx].ID)
+				}
+				g := got[i]
+				if g.Amount != w.amount || g.SourceKey != key || g.Reason != w.reason {
+					t.Errorf("transfer %d = %v %q %q, want %v %q %q", i, g.Amount, g.SourceKey, g.Reason, w.amount, key, w.reason)
+				}
+				if g.UserID != u.ID || g.RuleID != 3 || g.GoalID != 5 || g.SourceAccountID != acc.ID || g.Currency != "RUB" || g.Status != Proposed {
+					t.Errorf("transfer %d = %+v", i, g)
+				}
+			}
+		})
+	}
+}
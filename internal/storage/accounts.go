@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const accountColumns = `id, user_id, bank_id, external_id, account_number, account_type, nickname, currency, balance, status, source, institution, created_at`
+
+func scanAccount(row interface{ Scan(...interface{}) error }, a *Account) error {
+	return row.Scan(&a.ID, &a.UserID, &a.BankID, &a.ExternalID, &a.AccountNumber, &a.AccountType, &a.Nickname, &a.Currency, &a.Balance, &a.Status, &a.Source, &a.Institution, &a.CreatedAt)
+}
+
+func (r *Repository) GetAccountByID(id int) (*Account, error) {
+	var a Account
+	if err := scanAccount(r.db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE id=$1`, id), &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *Repository) GetAccountsByUserID(userID int) ([]Account, error) {
+	rows, err := r.db.Query(`
+		SELECT `+accountColumns+`
+		FROM accounts WHERE user_id=$1 ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	for rows.Next() {
+		var a Account
+		if err := scanAccount(rows, &a); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// UpsertAccount сохраняет счёт и заполняет ID/CreatedAt.
+// Счета из API банка ищутся по (bank_id, external_id), остальные — по account_number.
+func (r *Repository) UpsertAccount(a *Account) error {
+	conflict := "(account_number)"
+	if a.ExternalID != nil {
+		conflict = "(bank_id, external_id)"
+	}
+	if a.Source == "" {
+		a.Source = SourceAPI
+	}
+	return r.db.QueryRow(`
+		INSERT INTO accounts (user_id, bank_id, external_id, account_number, account_type, nickname, currency, balance, status, source, institution)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		ON CONFLICT `+conflict+` DO UPDATE SET
+			account_number=EXCLUDED.account_number, account_type=EXCLUDED.account_type, nickname=EXCLUDED.nickname,
+			currency=EXCLUDED.currency, balance=EXCLUDED.balance, status=EXCLUDED.status, institution=EXCLUDED.institution
+		RETURNING id, created_at
+	`, a.UserID, a.BankID, a.ExternalID, a.AccountNumber, a.AccountType, a.Nickname, a.Currency, a.Balance, a.Status, a.Source, a.Institution).
+		Scan(&a.ID, &a.CreatedAt)
+}
+
+// GetAccountByExternalID ищет счёт по коду банка и идентификатору счёта в API банка
+func (r *Repository) GetAccountByExternalID(bankCode, externalID string) (*Account, error) {
+	var a Account
+	err := scanAccount(r.db.QueryRow(`
+		SELECT `+accountColumns+`
+		FROM accounts
+		WHERE bank_id = (SELECT id FROM banks WHERE code=$1) AND external_id=$2
+	`, bankCode, externalID), &a)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetAccountsByClientUser возвращает счета клиента во всех банках:
+// у одного клиента по записи users на каждый банк с общим client_id.
+func (r *Repository) GetAccountsByClientUser(userID int) ([]Account, error) {
+	rows, err := r.db.Query(`
+		SELECT `+accountColumns+`
+		FROM accounts
+		WHERE user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	for rows.Next() {
+		var a Account
+		if err := scanAccount(rows, &a); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+const transactionColumns = `id, account_id, external_id, amount, currency, description, mcc, counterparty, category, category_source, booking_date, created_at`
+
+func scanTransaction(row interface{ Scan(...interface{}) error }, t *Transaction) error {
+	return row.Scan(&t.ID, &t.AccountID, &t.ExternalID, &t.Amount, &t.Currency, &t.Description, &t.MCC, &t.Counterparty, &t.Category, &t.CategorySource, &t.BookingDate, &t.CreatedAt)
+}
+
+func (r *Repository) GetTransactionsByAccountID(accountID int, from, to time.Time) ([]Transaction, error) {
+	return r.GetTransactionsByAccountIDs([]int{accountID}, from, to)
+}
+
+// GetTransactionsAddedSince — операции счёта, сохранённые за [from, to): в отличие от выборки
+// по дате проводки, сюда попадают и старые операции, которые синхронизация подтянула позже
+func (r *Repository) GetTransactionsAddedSince(accountID int, from, to time.Time) ([]Transaction, error) {
+	rows, err := r.db.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE account_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY id
+	`, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+// GetTransactionsByAccountIDs — операции нескольких счетов за [from, to) по дате проводки
+func (r *Repository) GetTransactionsByAccountIDs(accountIDs []int, from, to time.Time) ([]Transaction, error) {
+	ids := make([]int64, len(accountIDs))
+	for i, id := range accountIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := r.db.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE account_id = ANY($1) AND booking_date >= $2 AND booking_date < $3
+		ORDER BY booking_date, id
+	`, pq.Array(ids), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func (r *Repository) GetTransactionByID(id int) (*Transaction, error) {
+	var t Transaction
+	if err := scanTransaction(r.db.QueryRow(`SELECT `+transactionColumns+` FROM transactions WHERE id=$1`, id), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *Repository) InsertTransaction(t *Transaction) error {
+	return r.db.QueryRow(`
+		INSERT INTO transactions (account_id, external_id, amount, currency, description, mcc, counterparty, category, category_source, booking_date)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		RETURNING id, created_at
+	`, t.AccountID, t.ExternalID, t.Amount, t.Currency, t.Description, t.MCC, t.Counterparty, t.Category, t.CategorySource, t.BookingDate).
+		Scan(&t.ID, &t.CreatedAt)
+}
+
+// InsertTransactionIfNew добавляет операцию банка, если её (account_id, external_id) ещё нет.
+// Возвращает false для уже сохранённой операции.
+func (r *Repository) InsertTransactionIfNew(t *Transaction) (bool, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO transactions (account_id, external_id, amount, currency, description, mcc, counterparty, category, category_source, booking_date)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		ON CONFLICT (account_id, external_id) DO NOTHING
+		RETURNING id, created_at
+	`, t.AccountID, t.ExternalID, t.Amount, t.Currency, t.Description, t.MCC, t.Counterparty, t.Category, t.CategorySource, t.BookingDate).
+		Scan(&t.ID, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FirstBookingDate — дата первой сохранённой операции счёта (nil, если операций нет)
+func (r *Repository) FirstBookingDate(accountID int) (*time.Time, error) {
+	var first sql.NullTime
+	if err := r.db.QueryRow(`SELECT MIN(booking_date) FROM transactions WHERE account_id=$1`, accountID).Scan(&first); err != nil {
+		return nil, err
+	}
+	if !first.Valid {
+		return nil, nil
+	}
+	return &first.Time, nil
+}
+
+// LastBookingDate — дата последней сохранённой операции счёта (nil, если операций нет)
+func (r *Repository) LastBookingDate(accountID int) (*time.Time, error) {
+	var last sql.NullTime
+	if err := r.db.QueryRow(`SELECT MAX(booking_date) FROM transactions WHERE account_id=$1`, accountID).Scan(&last); err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+func (r *Repository) UpdateTransactionCategory(id int, category, source string) error {
+	_, err := r.db.Exec(`UPDATE transactions SET category=$2, category_source=$3 WHERE id=$1`, id, category, source)
+	return err
+}
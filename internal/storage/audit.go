@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InsertAuditEntry добавляет запись в журнал аудита.
+// Таблица audit_log защищена триггером от UPDATE/DELETE.
+func (r *Repository) InsertAuditEntry(e *AuditEntry) error {
+	var details interface{}
+	if len(e.Details) > 0 {
+		details = []byte(e.Details)
+	}
+	return r.db.QueryRow(`
+		INSERT INTO audit_log (kind, user_id, action, bank_code, method, endpoint, consent_id, status_code, latency_ms, request_id, details)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		RETURNING id, created_at
+	`, e.Kind, e.UserID, e.Action, e.BankCode, e.Method, e.Endpoint, e.ConsentID, e.StatusCode, e.LatencyMs, e.RequestID, details).
+		Scan(&e.ID, &e.CreatedAt)
+}
+
+// ListAuditEntries возвращает записи журнала по фильтру, новые первыми
+func (r *Repository) ListAuditEntries(f AuditFilter) ([]AuditEntry, error) {
+	where, args := auditWhere(f)
+	query := `SELECT id, kind, user_id, action, bank_code, method, endpoint, consent_id, status_code, latency_ms, request_id, details, created_at FROM audit_log` + where
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit, f.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.Kind, &e.UserID, &e.Action, &e.BankCode, &e.Method, &e.Endpoint,
+			&e.ConsentID, &e.StatusCode, &e.LatencyMs, &e.RequestID, &details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Details = details
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// CountAuditEntries — число записей журнала по фильтру без учёта Limit/Offset
+func (r *Repository) CountAuditEntries(f AuditFilter) (int, error) {
+	where, args := auditWhere(f)
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&n)
+	return n, err
+}
+
+// auditWhere строит условие WHERE по фильтру (пустую строку, если условий нет)
+func auditWhere(f AuditFilter) (string, []interface{}) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.UserID != nil {
+		add("user_id=$%d", *f.UserID)
+	}
+	if f.Kind != "" {
+		add("kind=$%d", f.Kind)
+	}
+	if f.Action != "" {
+		add("action=$%d", f.Action)
+	}
+	if f.BankCode != "" {
+		add("bank_code=$%d", f.BankCode)
+	}
+	if f.From != nil {
+		add("created_at>=$%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at<$%d", *f.To)
+	}
+	if len(where) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) InsertBalanceSnapshot(s *BalanceSnapshot) error {
+	if s.TakenAt.IsZero() {
+		s.TakenAt = time.Now()
+	}
+	return r.db.QueryRow(`
+		INSERT INTO balance_snapshots (account_id, available, booked, currency, taken_at)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING id
+	`, s.AccountID, s.Available, s.Booked, s.Currency, s.TakenAt).Scan(&s.ID)
+}
+
+// GetBalanceSnapshots возвращает срезы по счетам за [from, to) и, для каждого счёта,
+// последний срез до from — чтобы ряд начинался с известного значения.
+func (r *Repository) GetBalanceSnapshots(accountIDs []int, from, to time.Time) ([]BalanceSnapshot, error) {
+	ids := make([]int64, len(accountIDs))
+	for i, id := range accountIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := r.db.Query(`
+		SELECT * FROM (
+			SELECT DISTINCT ON (account_id) id, account_id, available, booked, currency, taken_at
+			FROM balance_snapshots
+			WHERE account_id = ANY($1) AND taken_at < $2
+			ORDER BY account_id, taken_at DESC
+		) carry
+		UNION ALL
+		SELECT id, account_id, available, booked, currency, taken_at
+		FROM balance_snapshots
+		WHERE account_id = ANY($1) AND taken_at >= $2 AND taken_at < $3
+		ORDER BY taken_at
+	`, pq.Array(ids), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snaps []BalanceSnapshot
+	for rows.Next() {
+		var s BalanceSnapshot
+		if err := rows.Scan(&s.ID, &s.AccountID, &s.Available, &s.Booked, &s.Currency, &s.TakenAt); err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, s)
+	}
+	return snaps, rows.Err()
+}
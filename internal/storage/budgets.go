@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+const budgetColumns = `id, user_id, name, period, category, merchant, amount, currency, rollover, created_at`
+
+func scanBudget(row interface{ Scan(...interface{}) error }, b *Budget) error {
+	return row.Scan(&b.ID, &b.UserID, &b.Name, &b.Period, &b.Category, &b.Merchant, &b.Amount, &b.Currency, &b.Rollover, &b.CreatedAt)
+}
+
+// ListBudgets — бюджеты клиента (всех его записей users)
+func (r *Repository) ListBudgets(userID int) ([]Budget, error) {
+	rows, err := r.db.Query(`
+		SELECT `+budgetColumns+`
+		FROM budgets
+		WHERE user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []Budget
+	for rows.Next() {
+		var b Budget
+		if err := scanBudget(rows, &b); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+func (r *Repository) GetBudget(userID, id int) (*Budget, error) {
+	var b Budget
+	err := scanBudget(r.db.QueryRow(`
+		SELECT `+budgetColumns+`
+		FROM budgets
+		WHERE id=$2 AND user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+	`, userID, id), &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *Repository) InsertBudget(b *Budget) error {
+	return r.db.QueryRow(`
+		INSERT INTO budgets (user_id, name, period, category, merchant, amount, currency, rollover)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		RETURNING id, created_at
+	`, b.UserID, b.Name, b.Period, b.Category, b.Merchant, b.Amount, b.Currency, b.Rollover).Scan(&b.ID, &b.CreatedAt)
+}
+
+func (r *Repository) UpdateBudget(b *Budget) error {
+	res, err := r.db.Exec(`
+		UPDATE budgets SET name=$2, period=$3, category=$4, merchant=$5, amount=$6, currency=$7, rollover=$8
+		WHERE id=$1
+	`, b.ID, b.Name, b.Period, b.Category, b.Merchant, b.Amount, b.Currency, b.Rollover)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *Repository) DeleteBudget(userID, id int) error {
+	res, err := r.db.Exec(`
+		DELETE FROM budgets
+		WHERE id=$2 AND user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+	`, userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *Repository) MarkBudgetAlert(budgetID int, periodStart time.Time, threshold int) (bool, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO budget_alerts (budget_id, period_start, threshold)
+		VALUES ($1, $2::date, $3)
+		ON CONFLICT DO NOTHING
+	`, budgetID, periodStart, threshold)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import "database/sql"
+
+// ListCashbackRules — правила клиента (всех его записей users) с кодом банка
+func (r *Repository) ListCashbackRules(userID int) ([]CashbackRule, error) {
+	rows, err := r.db.Query(`
+		SELECT cr.id, cr.user_id, cr.bank_id, b.code, cr.product_id, cr.category, cr.percent, cr.max_cashback, cr.max_cashback_currency, cr.created_at
+		FROM cashback_rules cr
+		JOIN banks b ON b.id = cr.bank_id
+		WHERE cr.user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+		ORDER BY cr.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []CashbackRule
+	for rows.Next() {
+		var cr CashbackRule
+		if err := rows.Scan(&cr.ID, &cr.UserID, &cr.BankID, &cr.BankCode, &cr.ProductID, &cr.Category,
+			&cr.Percent, &cr.MaxCashback, &cr.MaxCurrency, &cr.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, cr)
+	}
+	return rules, rows.Err()
+}
+
+func (r *Repository) InsertCashbackRule(cr *CashbackRule) error {
+	return r.db.QueryRow(`
+		INSERT INTO cashback_rules (user_id, bank_id, product_id, category, percent, max_cashback, max_cashback_currency)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id, created_at
+	`, cr.UserID, cr.BankID, cr.ProductID, cr.Category, cr.Percent, cr.MaxCashback, cr.MaxCurrency).Scan(&cr.ID, &cr.CreatedAt)
+}
+
+// DeleteCashbackRule удаляет правило, если оно принадлежит клиенту userID
+func (r *Repository) DeleteCashbackRule(userID, id int) error {
+	res, err := r.db.Exec(`
+		DELETE FROM cashback_rules
+		WHERE id=$2 AND user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+	`, userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
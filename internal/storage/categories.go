@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) ListCategoryRules(userID int) ([]CategoryRule, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, category, mcc_codes, counterparty, description_regex, min_amount, max_amount, direction, priority, learned, created_at
+		FROM category_rules
+		WHERE user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+		ORDER BY priority, id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []CategoryRule
+	for rows.Next() {
+		var cr CategoryRule
+		if err := rows.Scan(&cr.ID, &cr.UserID, &cr.Category, pq.Array(&cr.MCCCodes), &cr.Counterparty, &cr.DescriptionRegex,
+			&cr.MinAmount, &cr.MaxAmount, &cr.Direction, &cr.Priority, &cr.Learned, &cr.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, cr)
+	}
+	return rules, rows.Err()
+}
+
+func (r *Repository) InsertCategoryRule(cr *CategoryRule) error {
+	return r.db.QueryRow(`
+		INSERT INTO category_rules (user_id, category, mcc_codes, counterparty, description_regex, min_amount, max_amount, direction, priority, learned)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		RETURNING id, created_at
+	`, cr.UserID, cr.Category, pq.Array(cr.MCCCodes), cr.Counterparty, cr.DescriptionRegex, cr.MinAmount, cr.MaxAmount, cr.Direction, cr.Priority, cr.Learned).
+		Scan(&cr.ID, &cr.CreatedAt)
+}
+
+func (r *Repository) UpdateCategoryRuleCategory(id int, category string) error {
+	_, err := r.db.Exec(`UPDATE category_rules SET category=$2 WHERE id=$1`, id, category)
+	return err
+}
+
+// DeleteCategoryRule удаляет правило, если оно принадлежит клиенту userID
+func (r *Repository) DeleteCategoryRule(userID, id int) error {
+	res, err := r.db.Exec(`
+		DELETE FROM category_rules
+		WHERE id=$2 AND user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+	`, userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
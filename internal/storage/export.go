@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// StreamTransactions передаёт в fn операции счетов за [from, to) по одной, сгруппированными по счёту.
+// Строки читаются курсором — весь диапазон в память не загружается. Ошибка fn прерывает чтение.
+func (r *Repository) StreamTransactions(accountIDs []int, from, to time.Time, fn func(Transaction) error) error {
+	ids := make([]int64, len(accountIDs))
+	for i, id := range accountIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := r.db.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE account_id = ANY($1) AND booking_date >= $2 AND booking_date < $3
+		ORDER BY account_id, booking_date, id
+	`, pq.Array(ids), from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
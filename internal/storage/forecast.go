@@ -0,0 +1,38 @@
+package storage
+
+// GetForecastThreshold — порог клиента (заданный любой из его записей users)
+func (r *Repository) GetForecastThreshold(userID int) (*ForecastThreshold, error) {
+	var t ForecastThreshold
+	err := r.db.QueryRow(`
+		SELECT user_id, amount, currency, updated_at
+		FROM forecast_thresholds
+		WHERE user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`, userID).Scan(&t.UserID, &t.Amount, &t.Currency, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SetForecastThreshold заменяет порог клиента
+func (r *Repository) SetForecastThreshold(t *ForecastThreshold) error {
+	return r.inTx(func(q *Repository) error {
+		if err := q.DeleteForecastThreshold(t.UserID); err != nil {
+			return err
+		}
+		return q.db.QueryRow(`
+			INSERT INTO forecast_thresholds (user_id, amount, currency) VALUES ($1,$2,$3)
+			RETURNING updated_at
+		`, t.UserID, t.Amount, t.Currency).Scan(&t.UpdatedAt)
+	})
+}
+
+func (r *Repository) DeleteForecastThreshold(userID int) error {
+	_, err := r.db.Exec(`
+		DELETE FROM forecast_thresholds
+		WHERE user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+	`, userID)
+	return err
+}
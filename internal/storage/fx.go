@@ -0,0 +1,58 @@
+package storage
+
+import "time"
+
+// UpsertFXRates сохраняет курсы; повторная загрузка за тот же день перезаписывает курс
+func (r *Repository) UpsertFXRates(rates []FXRate) error {
+	return r.inTx(func(q *Repository) error {
+		for _, fr := range rates {
+			if _, err := q.db.Exec(`
+				INSERT INTO fx_rates (rate_date, base, quote, rate, source)
+				VALUES ($1,$2,$3,$4,$5)
+				ON CONFLICT (base, quote, rate_date) DO UPDATE SET rate=EXCLUDED.rate, source=EXCLUDED.source, created_at=NOW()
+			`, fr.RateDate, fr.Base, fr.Quote, fr.Rate, fr.Source); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) GetFXRate(base, quote string, on time.Time) (*FXRate, error) {
+	var fr FXRate
+	err := r.db.QueryRow(`
+		SELECT rate_date, base, quote, rate, source, created_at
+		FROM fx_rates
+		WHERE base=$1 AND quote=$2 AND rate_date <= $3::date
+		ORDER BY rate_date DESC
+		LIMIT 1
+	`, base, quote, on).Scan(&fr.RateDate, &fr.Base, &fr.Quote, &fr.Rate, &fr.Source, &fr.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &fr, nil
+}
+
+// ListFXRates — актуальные на дату on курсы по всем парам
+func (r *Repository) ListFXRates(on time.Time) ([]FXRate, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT ON (base, quote) rate_date, base, quote, rate, source, created_at
+		FROM fx_rates
+		WHERE rate_date <= $1::date
+		ORDER BY base, quote, rate_date DESC
+	`, on)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []FXRate
+	for rows.Next() {
+		var fr FXRate
+		if err := rows.Scan(&fr.RateDate, &fr.Base, &fr.Quote, &fr.Rate, &fr.Source, &fr.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, fr)
+	}
+	return rates, rows.Err()
+}
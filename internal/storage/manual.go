@@ -0,0 +1,33 @@
+package storage
+
+import "database/sql"
+
+// DeleteAccount удаляет счёт вместе с операциями и историей балансов
+func (r *Repository) DeleteAccount(id int) error {
+	res, err := r.db.Exec(`DELETE FROM accounts WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddAccountBalance сдвигает остаток счёта на delta одним UPDATE и возвращает новый остаток
+func (r *Repository) AddAccountBalance(id int, delta float64) (float64, error) {
+	var balance float64
+	err := r.db.QueryRow(`UPDATE accounts SET balance = balance + $2 WHERE id=$1 RETURNING balance`, id, delta).Scan(&balance)
+	return balance, err
+}
+
+func (r *Repository) DeleteTransaction(id int) error {
+	res, err := r.db.Exec(`DELETE FROM transactions WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
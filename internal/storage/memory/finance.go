@@ -0,0 +1,455 @@
+package memory
+
+import (
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// --- BalanceRepository ---
+
+func (s *Store) InsertBalanceSnapshot(b *storage.BalanceSnapshot) error {
+	if err := s.fail("InsertBalanceSnapshot"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b.TakenAt.IsZero() {
+		b.TakenAt = time.Now()
+	}
+	b.ID = s.state.nextID()
+	s.state.snapshots = append(s.state.snapshots, *b)
+	return nil
+}
+
+func (s *Store) GetBalanceSnapshots(accountIDs []int, from, to time.Time) ([]storage.BalanceSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := map[int]bool{}
+	for _, id := range accountIDs {
+		ids[id] = true
+	}
+	carry := map[int]storage.BalanceSnapshot{}
+	var res []storage.BalanceSnapshot
+	for _, b := range s.state.snapshots {
+		switch {
+		case !ids[b.AccountID]:
+		case b.TakenAt.Before(from):
+			if prev, ok := carry[b.AccountID]; !ok || b.TakenAt.After(prev.TakenAt) {
+				carry[b.AccountID] = b
+			}
+		case b.TakenAt.Before(to):
+			res = append(res, b)
+		}
+	}
+	for _, b := range carry {
+		res = append(res, b)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].TakenAt.Before(res[j].TakenAt) })
+	return res, nil
+}
+
+// --- FXRateRepository ---
+
+func (s *Store) UpsertFXRates(rates []storage.FXRate) error {
+	if err := s.fail("UpsertFXRates"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fr := range rates {
+		fr.RateDate = money.TruncateDay(fr.RateDate)
+		fr.CreatedAt = time.Now()
+		replaced := false
+		for i, existing := range s.state.fxRates {
+			if existing.Base == fr.Base && existing.Quote == fr.Quote && existing.RateDate.Equal(fr.RateDate) {
+				s.state.fxRates[i], replaced = fr, true
+			}
+		}
+		if !replaced {
+			s.state.fxRates = append(s.state.fxRates, fr)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetFXRate(base, quote string, on time.Time) (*storage.FXRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day := money.TruncateDay(on)
+	var res *storage.FXRate
+	for i, fr := range s.state.fxRates {
+		if fr.Base == base && fr.Quote == quote && !fr.RateDate.After(day) && (res == nil || fr.RateDate.After(res.RateDate)) {
+			res = &s.state.fxRates[i]
+		}
+	}
+	if res == nil {
+		return nil, sql.ErrNoRows
+	}
+	cp := *res
+	return &cp, nil
+}
+
+func (s *Store) ListFXRates(on time.Time) ([]storage.FXRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day := money.TruncateDay(on)
+	latest := map[[2]string]storage.FXRate{}
+	for _, fr := range s.state.fxRates {
+		key := [2]string{fr.Base, fr.Quote}
+		if prev, ok := latest[key]; !fr.RateDate.After(day) && (!ok || fr.RateDate.After(prev.RateDate)) {
+			latest[key] = fr
+		}
+	}
+	var res []storage.FXRate
+	for _, fr := range latest {
+		res = append(res, fr)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Base != res[j].Base {
+			return res[i].Base < res[j].Base
+		}
+		return res[i].Quote < res[j].Quote
+	})
+	return res, nil
+}
+
+// --- CategoryRepository ---
+
+func (s *Store) ListCategoryRules(userID int) ([]storage.CategoryRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.CategoryRule
+	for _, cr := range s.state.categoryRules {
+		if ids[cr.UserID] {
+			res = append(res, cr)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Priority != res[j].Priority {
+			return res[i].Priority < res[j].Priority
+		}
+		return res[i].ID > res[j].ID
+	})
+	return res, nil
+}
+
+func (s *Store) InsertCategoryRule(cr *storage.CategoryRule) error {
+	if err := s.fail("InsertCategoryRule"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cr.ID = int(s.state.nextID())
+	cr.CreatedAt = time.Now()
+	s.state.categoryRules = append(s.state.categoryRules, *cr)
+	return nil
+}
+
+func (s *Store) UpdateCategoryRuleCategory(id int, category string) error {
+	if err := s.fail("UpdateCategoryRuleCategory"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.categoryRules {
+		if s.state.categoryRules[i].ID == id {
+			s.state.categoryRules[i].Category = category
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteCategoryRule(userID, id int) error {
+	if err := s.fail("DeleteCategoryRule"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for i, cr := range s.state.categoryRules {
+		if cr.ID == id && ids[cr.UserID] {
+			s.state.categoryRules = append(s.state.categoryRules[:i], s.state.categoryRules[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// --- BudgetRepository ---
+
+func (s *Store) ListBudgets(userID int) ([]storage.Budget, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.Budget
+	for _, b := range s.state.budgets {
+		if ids[b.UserID] {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) GetBudget(userID, id int) (*storage.Budget, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for _, b := range s.state.budgets {
+		if b.ID == id && ids[b.UserID] {
+			return &b, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) InsertBudget(b *storage.Budget) error {
+	if err := s.fail("InsertBudget"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b.ID = int(s.state.nextID())
+	b.CreatedAt = time.Now()
+	s.state.budgets = append(s.state.budgets, *b)
+	return nil
+}
+
+func (s *Store) UpdateBudget(b *storage.Budget) error {
+	if err := s.fail("UpdateBudget"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.state.budgets {
+		if existing.ID == b.ID {
+			b.UserID, b.CreatedAt = existing.UserID, existing.CreatedAt
+			s.state.budgets[i] = *b
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *Store) DeleteBudget(userID, id int) error {
+	if err := s.fail("DeleteBudget"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for i, b := range s.state.budgets {
+		if b.ID == id && ids[b.UserID] {
+			s.state.budgets = append(s.state.budgets[:i], s.state.budgets[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *Store) MarkBudgetAlert(budgetID int, periodStart time.Time, threshold int) (bool, error) {
+	if err := s.fail("MarkBudgetAlert"); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := budgetAlert{budgetID: budgetID, periodStart: money.TruncateDay(periodStart), threshold: threshold}
+	if s.state.budgetAlerts[key] {
+		return false, nil
+	}
+	s.state.budgetAlerts[key] = true
+	return true, nil
+}
+
+// --- RecurringRepository ---
+
+func (s *Store) ListRecurringDecisions(userID int) ([]storage.RecurringDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.RecurringDecision
+	for _, d := range s.state.decisions {
+		if ids[d.UserID] {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) SetRecurringDecision(userID int, seriesID, status string) error {
+	if err := s.fail("SetRecurringDecision"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.deleteDecision(userID, seriesID)
+	s.state.decisions = append(s.state.decisions, storage.RecurringDecision{
+		UserID: userID, SeriesID: seriesID, Status: status, UpdatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (s *Store) DeleteRecurringDecision(userID int, seriesID string) error {
+	if err := s.fail("DeleteRecurringDecision"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.deleteDecision(userID, seriesID)
+	return nil
+}
+
+func (s *state) deleteDecision(userID int, seriesID string) {
+	ids := s.userIDsSharingClient(userID)
+	kept := s.decisions[:0]
+	for _, d := range s.decisions {
+		if !(d.SeriesID == seriesID && ids[d.UserID]) {
+			kept = append(kept, d)
+		}
+	}
+	s.decisions = kept
+}
+
+// --- ForecastRepository ---
+
+func (s *Store) GetForecastThreshold(userID int) (*storage.ForecastThreshold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res *storage.ForecastThreshold
+	for i, t := range s.state.thresholds {
+		if ids[t.UserID] && (res == nil || !t.UpdatedAt.Before(res.UpdatedAt)) {
+			res = &s.state.thresholds[i]
+		}
+	}
+	if res == nil {
+		return nil, sql.ErrNoRows
+	}
+	cp := *res
+	return &cp, nil
+}
+
+func (s *Store) SetForecastThreshold(t *storage.ForecastThreshold) error {
+	if err := s.fail("SetForecastThreshold"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.deleteThresholds(t.UserID)
+	t.UpdatedAt = time.Now()
+	s.state.thresholds = append(s.state.thresholds, *t)
+	return nil
+}
+
+func (s *Store) DeleteForecastThreshold(userID int) error {
+	if err := s.fail("DeleteForecastThreshold"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.deleteThresholds(userID)
+	return nil
+}
+
+func (s *state) deleteThresholds(userID int) {
+	ids := s.userIDsSharingClient(userID)
+	kept := s.thresholds[:0]
+	for _, t := range s.thresholds {
+		if !ids[t.UserID] {
+			kept = append(kept, t)
+		}
+	}
+	s.thresholds = kept
+}
+
+// --- CashbackRepository ---
+
+func (s *Store) ListCashbackRules(userID int) ([]storage.CashbackRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.CashbackRule
+	for _, cr := range s.state.cashbackRules {
+		if !ids[cr.UserID] {
+			continue
+		}
+		for _, b := range s.state.banks {
+			if b.ID == cr.BankID {
+				cr.BankCode = b.Code
+			}
+		}
+		res = append(res, cr)
+	}
+	return res, nil
+}
+
+func (s *Store) InsertCashbackRule(cr *storage.CashbackRule) error {
+	if err := s.fail("InsertCashbackRule"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cr.ID = int(s.state.nextID())
+	cr.CreatedAt = time.Now()
+	s.state.cashbackRules = append(s.state.cashbackRules, *cr)
+	return nil
+}
+
+func (s *Store) DeleteCashbackRule(userID, id int) error {
+	if err := s.fail("DeleteCashbackRule"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for i, cr := range s.state.cashbackRules {
+		if cr.ID == id && ids[cr.UserID] {
+			s.state.cashbackRules = append(s.state.cashbackRules[:i], s.state.cashbackRules[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// --- PaymentRepository ---
+
+// AddPaymentConsent добавляет платёжное согласие и возвращает его с назначенным ID
+func (s *Store) AddPaymentConsent(c storage.PaymentConsent) storage.PaymentConsent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.ID = int(s.state.nextID())
+	c.CreatedAt = time.Now()
+	s.state.paymentConsents = append(s.state.paymentConsents, c)
+	return c
+}
+
+func (s *Store) GetActivePaymentConsents(userID int) ([]storage.PaymentConsent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	now := time.Now()
+	var res []storage.PaymentConsent
+	for i := len(s.state.paymentConsents) - 1; i >= 0; i-- {
+		c := s.state.paymentConsents[i]
+		active := c.Status == "active" || c.Status == "authorized" || c.Status == "Authorised"
+		if c.UserID != nil && ids[*c.UserID] && active && (c.ValidUntil == nil || c.ValidUntil.After(now)) {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) InsertPayment(p *storage.Payment) error {
+	if err := s.fail("InsertPayment"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.ID = int(s.state.nextID())
+	p.CreatedAt = time.Now()
+	s.state.payments = append(s.state.payments, *p)
+	return nil
+}
@@ -0,0 +1,397 @@
+package memory
+
+import (
+	"MoneyPilot/internal/storage"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// --- SavingsRepository ---
+
+func (s *Store) ListSavingsGoals(userID int) ([]storage.SavingsGoal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.SavingsGoal
+	for _, g := range s.state.goals {
+		if ids[g.UserID] {
+			res = append(res, g)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) GetSavingsGoal(userID, id int) (*storage.SavingsGoal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for _, g := range s.state.goals {
+		if g.ID == id && ids[g.UserID] {
+			return &g, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) InsertSavingsGoal(g *storage.SavingsGoal) error {
+	if err := s.fail("InsertSavingsGoal"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g.ID = int(s.state.nextID())
+	g.CreatedAt = time.Now()
+	s.state.goals = append(s.state.goals, *g)
+	return nil
+}
+
+func (s *Store) DeleteSavingsGoal(userID, id int) error {
+	if err := s.fail("DeleteSavingsGoal"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for _, g := range s.state.goals {
+		if g.ID == id && ids[g.UserID] {
+			s.state.deleteGoals(func(g storage.SavingsGoal) bool { return g.ID == id })
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *Store) ListSweepRules(userID int) ([]storage.SweepRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.SweepRule
+	for _, r := range s.state.sweepRules {
+		if ids[r.UserID] {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) InsertSweepRule(r *storage.SweepRule) error {
+	if err := s.fail("InsertSweepRule"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.ID = int(s.state.nextID())
+	r.CreatedAt = time.Now()
+	s.state.sweepRules = append(s.state.sweepRules, *r)
+	return nil
+}
+
+func (s *Store) DeleteSweepRule(userID, id int) error {
+	if err := s.fail("DeleteSweepRule"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for _, r := range s.state.sweepRules {
+		if r.ID == id && ids[r.UserID] {
+			s.state.deleteSweepRules(func(r storage.SweepRule) bool { return r.ID == id })
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *Store) SetSweepRuleRun(id int, at time.Time) error {
+	if err := s.fail("SetSweepRuleRun"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.sweepRules {
+		if s.state.sweepRules[i].ID == id {
+			s.state.sweepRules[i].LastRunAt = &at
+		}
+	}
+	return nil
+}
+
+func (s *Store) InsertSweepTransfer(t *storage.SweepTransfer) (bool, error) {
+	if err := s.fail("InsertSweepTransfer"); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.state.transfers {
+		if existing.RuleID == t.RuleID && existing.SourceKey == t.SourceKey {
+			return false, nil
+		}
+	}
+	t.ID = int(s.state.nextID())
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	s.state.transfers = append(s.state.transfers, *t)
+	return true, nil
+}
+
+func (s *Store) ListSweepTransfers(userID int, status string) ([]storage.SweepTransfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.SweepTransfer
+	for i := len(s.state.transfers) - 1; i >= 0; i-- {
+		t := s.state.transfers[i]
+		if ids[t.UserID] && (status == "" || t.Status == status) {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) GetSweepTransfer(userID, id int) (*storage.SweepTransfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for _, t := range s.state.transfers {
+		if t.ID == id && ids[t.UserID] {
+			return &t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) UpdateSweepTransfer(t *storage.SweepTransfer) error {
+	if err := s.fail("UpdateSweepTransfer"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.transfers {
+		cur := &s.state.transfers[i]
+		if cur.ID == t.ID {
+			cur.Status, cur.PaymentID, cur.Error, cur.UpdatedAt = t.Status, t.PaymentID, t.Error, time.Now()
+			t.UpdatedAt = cur.UpdatedAt
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *Store) SetSweepTransferStatus(t *storage.SweepTransfer, status string, from ...string) (bool, error) {
+	if err := s.fail("SetSweepTransferStatus"); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.transfers {
+		cur := &s.state.transfers[i]
+		if cur.ID != t.ID {
+			continue
+		}
+		for _, f := range from {
+			if cur.Status == f {
+				cur.Status, cur.Error, cur.UpdatedAt = status, t.Error, time.Now()
+				*t = *cur
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+func (s *Store) MarkStaleSweepTransfers(before time.Time) (int, error) {
+	if err := s.fail("MarkStaleSweepTransfers"); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	msg := "execution was interrupted"
+	for i := range s.state.transfers {
+		cur := &s.state.transfers[i]
+		if cur.Status == "executing" && cur.UpdatedAt.Before(before) {
+			e := msg
+			cur.Status, cur.Error, cur.UpdatedAt = "unconfirmed", &e, time.Now()
+			n++
+		}
+	}
+	return n, nil
+}
+
+// --- ExportRepository ---
+
+func (s *Store) StreamTransactions(accountIDs []int, from, to time.Time, fn func(storage.Transaction) error) error {
+	s.mu.Lock()
+	ids := map[int]bool{}
+	for _, id := range accountIDs {
+		ids[id] = true
+	}
+	var txs []storage.Transaction
+	for _, t := range s.state.transactions {
+		if ids[t.AccountID] && !t.BookingDate.Before(from) && t.BookingDate.Before(to) {
+			txs = append(txs, t)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(txs, func(i, j int) bool {
+		if txs[i].AccountID != txs[j].AccountID {
+			return txs[i].AccountID < txs[j].AccountID
+		}
+		return txs[i].BookingDate.Before(txs[j].BookingDate)
+	})
+	for _, t := range txs {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- ReportRepository ---
+
+func (s *Store) GetMonthlyReport(userID int, month string) (*storage.MonthlyReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	for i := len(s.state.reports) - 1; i >= 0; i-- {
+		m := s.state.reports[i]
+		if m.Month == month && ids[m.UserID] {
+			return &m, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) SaveMonthlyReport(m *storage.MonthlyReport) error {
+	if err := s.fail("SaveMonthlyReport"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(m.UserID)
+	kept := s.state.reports[:0]
+	for _, r := range s.state.reports {
+		if !(r.Month == m.Month && ids[r.UserID]) {
+			kept = append(kept, r)
+		}
+	}
+	m.ID = int(s.state.nextID())
+	m.CreatedAt = time.Now()
+	s.state.reports = append(kept, *m)
+	return nil
+}
+
+// --- ManualAccountRepository ---
+
+// DeleteAccount удаляет счёт и, как ON DELETE CASCADE в схеме, его операции,
+// историю балансов, цели на нём и правила автопополнения с него
+func (s *Store) DeleteAccount(id int) error {
+	if err := s.fail("DeleteAccount"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	accounts := s.state.accounts[:0]
+	for _, a := range s.state.accounts {
+		if a.ID == id {
+			found = true
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+	if !found {
+		return sql.ErrNoRows
+	}
+	s.state.accounts = accounts
+
+	txs := s.state.transactions[:0]
+	for _, t := range s.state.transactions {
+		if t.AccountID != id {
+			txs = append(txs, t)
+		}
+	}
+	s.state.transactions = txs
+
+	snaps := s.state.snapshots[:0]
+	for _, b := range s.state.snapshots {
+		if b.AccountID != id {
+			snaps = append(snaps, b)
+		}
+	}
+	s.state.snapshots = snaps
+
+	s.state.deleteGoals(func(g storage.SavingsGoal) bool { return g.AccountID != nil && *g.AccountID == id })
+	s.state.deleteSweepRules(func(r storage.SweepRule) bool { return r.SourceAccountID == id })
+	return nil
+}
+
+func (s *Store) DeleteTransaction(id int) error {
+	if err := s.fail("DeleteTransaction"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, t := range s.state.transactions {
+		if t.ID == id {
+			s.state.transactions = append(s.state.transactions[:i], s.state.transactions[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *Store) AddAccountBalance(id int, delta float64) (float64, error) {
+	if err := s.fail("AddAccountBalance"); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.accounts {
+		if s.state.accounts[i].ID == id {
+			s.state.accounts[i].Balance += delta
+			return s.state.accounts[i].Balance, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+// deleteGoals удаляет цели вместе с их правилами и переводами
+func (s *state) deleteGoals(match func(storage.SavingsGoal) bool) {
+	removed := map[int]bool{}
+	goals := s.goals[:0]
+	for _, g := range s.goals {
+		if match(g) {
+			removed[g.ID] = true
+			continue
+		}
+		goals = append(goals, g)
+	}
+	s.goals = goals
+	s.deleteSweepRules(func(r storage.SweepRule) bool { return removed[r.GoalID] })
+}
+
+// deleteSweepRules удаляет правила вместе с их переводами
+func (s *state) deleteSweepRules(match func(storage.SweepRule) bool) {
+	removed := map[int]bool{}
+	rules := s.sweepRules[:0]
+	for _, r := range s.sweepRules {
+		if match(r) {
+			removed[r.ID] = true
+			continue
+		}
+		rules = append(rules, r)
+	}
+	s.sweepRules = rules
+
+	transfers := s.transfers[:0]
+	for _, t := range s.transfers {
+		if !removed[t.RuleID] {
+			transfers = append(transfers, t)
+		}
+	}
+	s.transfers = transfers
+}
@@ -0,0 +1,946 @@
+package memory
+
+import (
+	"MoneyPilot/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store — реализация storage.Store в памяти для тестов.
+// Повторяет семантику Postgres-репозитория: sql.ErrNoRows, фильтры по сроку действия и т.п.
+type Store struct {
+	// Fail, если задана, вызывается в начале каждого изменяющего метода с его именем;
+	// её ошибка возвращается вместо записи — так проверяется откат WithTx
+	Fail func(method string) error
+
+	mu    sync.Mutex
+	state *state
+}
+
+var _ storage.Store = (*Store)(nil)
+
+type state struct {
+	seq             int64
+	banks           []storage.Bank
+	users           []storage.User
+	accountConsents []storage.AccountConsent
+	productConsents []storage.ProductAgreementConsent
+	accounts        []storage.Account
+	transactions    []storage.Transaction
+	products        []storage.Product
+	agreements      []storage.ProductAgreement
+	audit           []storage.AuditEntry
+
+	snapshots       []storage.BalanceSnapshot
+	fxRates         []storage.FXRate
+	categoryRules   []storage.CategoryRule
+	budgets         []storage.Budget
+	budgetAlerts    map[budgetAlert]bool
+	decisions       []storage.RecurringDecision
+	thresholds      []storage.ForecastThreshold
+	cashbackRules   []storage.CashbackRule
+	paymentConsents []storage.PaymentConsent
+	payments        []storage.Payment
+	goals           []storage.SavingsGoal
+	sweepRules      []storage.SweepRule
+	transfers       []storage.SweepTransfer
+	reports         []storage.MonthlyReport
+}
+
+type budgetAlert struct {
+	budgetID    int
+	periodStart time.Time
+	threshold   int
+}
+
+func New() *Store {
+	return &Store{state: &state{budgetAlerts: map[budgetAlert]bool{}}}
+}
+
+func (s *state) clone() *state {
+	c := *s
+	c.banks = append([]storage.Bank(nil), s.banks...)
+	c.users = append([]storage.User(nil), s.users...)
+	c.accountConsents = append([]storage.AccountConsent(nil), s.accountConsents...)
+	c.productConsents = append([]storage.ProductAgreementConsent(nil), s.productConsents...)
+	c.accounts = append([]storage.Account(nil), s.accounts...)
+	c.transactions = append([]storage.Transaction(nil), s.transactions...)
+	c.products = append([]storage.Product(nil), s.products...)
+	c.agreements = append([]storage.ProductAgreement(nil), s.agreements...)
+	c.audit = append([]storage.AuditEntry(nil), s.audit...)
+	c.snapshots = append([]storage.BalanceSnapshot(nil), s.snapshots...)
+	c.fxRates = append([]storage.FXRate(nil), s.fxRates...)
+	c.categoryRules = append([]storage.CategoryRule(nil), s.categoryRules...)
+	c.budgets = append([]storage.Budget(nil), s.budgets...)
+	c.budgetAlerts = make(map[budgetAlert]bool, len(s.budgetAlerts))
+	for k, v := range s.budgetAlerts {
+		c.budgetAlerts[k] = v
+	}
+	c.decisions = append([]storage.RecurringDecision(nil), s.decisions...)
+	c.thresholds = append([]storage.ForecastThreshold(nil), s.thresholds...)
+	c.cashbackRules = append([]storage.CashbackRule(nil), s.cashbackRules...)
+	c.paymentConsents = append([]storage.PaymentConsent(nil), s.paymentConsents...)
+	c.payments = append([]storage.Payment(nil), s.payments...)
+	c.goals = append([]storage.SavingsGoal(nil), s.goals...)
+	c.sweepRules = append([]storage.SweepRule(nil), s.sweepRules...)
+	c.transfers = append([]storage.SweepTransfer(nil), s.transfers...)
+	c.reports = append([]storage.MonthlyReport(nil), s.reports...)
+	return &c
+}
+
+func (s *state) nextID() int64 {
+	s.seq++
+	return s.seq
+}
+
+// WithTx выполняет fn на копии данных и подменяет состояние, только если fn завершилась без ошибки
+func (s *Store) WithTx(ctx context.Context, fn func(tx storage.Store) error) error {
+	s.mu.Lock()
+	tx := &Store{Fail: s.Fail, state: s.state.clone()}
+	s.mu.Unlock()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.state = tx.state
+	s.mu.Unlock()
+	return nil
+}
+
+// WithContext — в памяти запросы не трассируются
+func (s *Store) WithContext(ctx context.Context) storage.Store {
+	return s
+}
+
+// fail возвращает ошибку, заданную в Fail для метода
+func (s *Store) fail(method string) error {
+	if s.Fail == nil {
+		return nil
+	}
+	return s.Fail(method)
+}
+
+// --- Наполнение для тестов ---
+
+// AddBank добавляет банк и возвращает его с назначенным ID
+func (s *Store) AddBank(b storage.Bank) storage.Bank {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b.ID = int(s.state.nextID())
+	b.CreatedAt = time.Now()
+	s.state.banks = append(s.state.banks, b)
+	return b
+}
+
+// AddUser добавляет пользователя и возвращает его с назначенным ID
+func (s *Store) AddUser(u storage.User) storage.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u.ID = int(s.state.nextID())
+	if u.Role == "" {
+		u.Role = "user"
+	}
+	if u.BaseCurrency == "" {
+		u.BaseCurrency = "RUB"
+	}
+	u.CreatedAt = time.Now()
+	s.state.users = append(s.state.users, u)
+	return u
+}
+
+// --- UserRepository ---
+
+func (s *Store) GetBankByCode(code string) (*storage.Bank, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b := s.state.bankByCode(code); b != nil {
+		cp := *b
+		return &cp, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByID(id int) (*storage.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u := s.state.userByID(id); u != nil {
+		cp := *u
+		return &cp, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByClientIDAndBank(clientID, bankCode string) (*storage.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bankCode == "" {
+		for _, u := range s.state.users {
+			if u.ClientID == clientID {
+				return &u, nil
+			}
+		}
+		return nil, sql.ErrNoRows
+	}
+	b := s.state.bankByCode(bankCode)
+	if b == nil {
+		return nil, sql.ErrNoRows
+	}
+	for _, u := range s.state.users {
+		if u.ClientID == clientID && (u.BankID == nil || *u.BankID == b.ID) {
+			return &u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByUserIDAndBank(userID int, bankCode string) (*storage.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.state.bankByCode(bankCode)
+	u := s.state.userByID(userID)
+	if b == nil || u == nil {
+		return nil, sql.ErrNoRows
+	}
+	for _, cand := range s.state.users {
+		if cand.ClientID == u.ClientID && cand.BankID != nil && *cand.BankID == b.ID {
+			return &cand, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) SetUserBaseCurrency(userID int, currency string) error {
+	if err := s.fail("SetUserBaseCurrency"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	if len(ids) == 0 {
+		return sql.ErrNoRows
+	}
+	for i := range s.state.users {
+		if ids[s.state.users[i].ID] {
+			s.state.users[i].BaseCurrency = currency
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetClientUserIDs(userID int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	for id := range s.state.userIDsSharingClient(userID) {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// --- ConsentRepository ---
+
+func (s *Store) SaveAccountConsentByClientIdAndBank(clientID, bankCode, consentID, requestingBank string, permissions []string, status string, expires time.Time) error {
+	if err := s.fail("SaveAccountConsentByClientIdAndBank"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var user *storage.User
+	for i := range s.state.users {
+		if s.state.users[i].ClientID == clientID {
+			user = &s.state.users[i]
+			break
+		}
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	b := s.state.bankByCode(bankCode)
+	if b == nil {
+		return errors.New("bank not found")
+	}
+	for _, c := range s.state.accountConsents {
+		if c.ConsentID == consentID {
+			return errors.New("duplicate consent_id")
+		}
+	}
+	code := b.Code
+	s.state.accountConsents = append(s.state.accountConsents, storage.AccountConsent{
+		ID:             int(s.state.nextID()),
+		ConsentID:      consentID,
+		UserID:         user.ID,
+		BankID:         b.ID,
+		BankCode:       &code,
+		RequestingBank: &requestingBank,
+		Permissions:    append([]string(nil), permissions...),
+		Status:         status,
+		ExpiresAt:      &expires,
+		CreatedAt:      time.Now(),
+	})
+	return nil
+}
+
+func (s *Store) GetValidAccountConsentsByEmailAndBank(email, bank string) ([]storage.AccountConsent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var user *storage.User
+	for i := range s.state.users {
+		if e := s.state.users[i].Email; e != nil && *e == email {
+			user = &s.state.users[i]
+			break
+		}
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	b := s.state.bankByCode(bank)
+	if b == nil {
+		return nil, errors.New("bank not found")
+	}
+	return s.state.validAccountConsents(func(c storage.AccountConsent) bool {
+		return c.UserID == user.ID && c.BankID == b.ID
+	}), nil
+}
+
+func (s *Store) GetValidAccountConsentsByUserIDAndBank(userID int, bankCode string) ([]storage.AccountConsent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	return s.state.validAccountConsents(func(c storage.AccountConsent) bool {
+		return ids[c.UserID] && c.BankCode != nil && *c.BankCode == bankCode
+	}), nil
+}
+
+func (s *Store) GetValidAccountConsentsByUserID(userID int) ([]storage.AccountConsent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	return s.state.validAccountConsents(func(c storage.AccountConsent) bool {
+		return ids[c.UserID]
+	}), nil
+}
+
+func (s *Store) GetPendingAccountConsents() ([]storage.AccountConsent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []storage.AccountConsent
+	for _, c := range s.state.accountConsents {
+		if c.Status == "pending" {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) GetUserIDsWithValidAccountConsents() ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byClient := map[string]int{}
+	for _, c := range s.state.validAccountConsents(func(c storage.AccountConsent) bool { return c.Status == "approved" }) {
+		u := s.state.userByID(c.UserID)
+		if u == nil {
+			continue
+		}
+		if id, ok := byClient[u.ClientID]; !ok || u.ID < id {
+			byClient[u.ClientID] = u.ID
+		}
+	}
+	var ids []int
+	for _, id := range byClient {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (s *Store) UpdateAccountConsentStatusByConsentID(consentID string, status string) error {
+	if err := s.fail("UpdateAccountConsentStatusByConsentID"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.accountConsents {
+		if s.state.accountConsents[i].ConsentID == consentID {
+			s.state.accountConsents[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpdateAccountConsentIDAndStatus(oldConsentID, newConsentID, status string) error {
+	if err := s.fail("UpdateAccountConsentIDAndStatus"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.accountConsents {
+		if s.state.accountConsents[i].ConsentID == oldConsentID {
+			s.state.accountConsents[i].ConsentID = newConsentID
+			s.state.accountConsents[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (s *Store) SaveProductAgreementConsent(clientID, bankCode, requestID, consentID, requestingBank string,
+	read, open, close bool, allowedTypes []string, maxAmount float64, status string, expiresAt time.Time) error {
+	if err := s.fail("SaveProductAgreementConsent"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.state.bankByCode(bankCode)
+	if b == nil {
+		return sql.ErrNoRows
+	}
+	var user *storage.User
+	for i := range s.state.users {
+		u := s.state.users[i]
+		if u.ClientID == clientID && u.BankID != nil && *u.BankID == b.ID {
+			user = &s.state.users[i]
+			break
+		}
+	}
+	if user == nil {
+		return sql.ErrNoRows
+	}
+	for _, c := range s.state.productConsents {
+		if c.ConsentID == consentID || c.RequestID == requestID {
+			return errors.New("duplicate product consent")
+		}
+	}
+	code := b.Code
+	s.state.productConsents = append(s.state.productConsents, storage.ProductAgreementConsent{
+		ID:                     int(s.state.nextID()),
+		RequestID:              requestID,
+		ConsentID:              consentID,
+		UserID:                 user.ID,
+		BankID:                 b.ID,
+		RequestingBank:         &requestingBank,
+		ReadProductAgreements:  read,
+		OpenProductAgreements:  open,
+		CloseProductAgreements: close,
+		AllowedProductTypes:    append([]string(nil), allowedTypes...),
+		MaxAmount:              maxAmount,
+		Status:                 status,
+		ExpiresAt:              &expiresAt,
+		CreatedAt:              time.Now(),
+		BankCode:               &code,
+	})
+	return nil
+}
+
+func (s *Store) GetActiveProductConsentByUserAndBank(userID int, bankCode string) (*storage.ProductAgreementConsent, error) {
+	user, err := s.GetUserByUserIDAndBank(userID, bankCode)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.state.productConsents {
+		if c.UserID == user.ID && c.BankCode != nil && *c.BankCode == bankCode && (c.Status == "approved" || c.Status == "pending") {
+			return &c, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) GetPendingProductConsents() ([]storage.ProductAgreementConsent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []storage.ProductAgreementConsent
+	for _, c := range s.state.productConsents {
+		if c.Status == "pending" {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) UpdateProductConsentStatusByConsentID(consentID string, status string) error {
+	if err := s.fail("UpdateProductConsentStatusByConsentID"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.productConsents {
+		if s.state.productConsents[i].ConsentID == consentID {
+			s.state.productConsents[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpdateProductConsentIDAndStatus(oldConsentID, newConsentID, status string) error {
+	if err := s.fail("UpdateProductConsentIDAndStatus"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.productConsents {
+		if s.state.productConsents[i].ConsentID == oldConsentID {
+			s.state.productConsents[i].ConsentID = newConsentID
+			s.state.productConsents[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteProductConsent(consentID string) error {
+	if err := s.fail("DeleteProductConsent"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.state.productConsents[:0]
+	for _, c := range s.state.productConsents {
+		if c.ConsentID != consentID {
+			kept = append(kept, c)
+		}
+	}
+	s.state.productConsents = kept
+	return nil
+}
+
+// --- AccountRepository ---
+
+func (s *Store) GetAccountByID(id int) (*storage.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.state.accounts {
+		if a.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) GetAccountsByUserID(userID int) ([]storage.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []storage.Account
+	for _, a := range s.state.accounts {
+		if a.UserID == userID {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) GetAccountByExternalID(bankCode, externalID string) (*storage.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.state.bankByCode(bankCode)
+	if b == nil {
+		return nil, sql.ErrNoRows
+	}
+	for _, a := range s.state.accounts {
+		if a.BankID == b.ID && a.ExternalID != nil && *a.ExternalID == externalID {
+			return &a, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) GetAccountsByClientUser(userID int) ([]storage.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.state.userIDsSharingClient(userID)
+	var res []storage.Account
+	for _, a := range s.state.accounts {
+		if ids[a.UserID] {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) UpsertAccount(a *storage.Account) error {
+	if err := s.fail("UpsertAccount"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.Source == "" {
+		a.Source = storage.SourceAPI
+	}
+	for i, existing := range s.state.accounts {
+		same := existing.AccountNumber == a.AccountNumber
+		if a.ExternalID != nil {
+			same = existing.BankID == a.BankID && existing.ExternalID != nil && *existing.ExternalID == *a.ExternalID
+		}
+		if same {
+			a.ID, a.UserID, a.BankID, a.Source, a.CreatedAt = existing.ID, existing.UserID, existing.BankID, existing.Source, existing.CreatedAt
+			s.state.accounts[i] = *a
+			return nil
+		}
+	}
+	a.ID = int(s.state.nextID())
+	a.CreatedAt = time.Now()
+	s.state.accounts = append(s.state.accounts, *a)
+	return nil
+}
+
+// --- TransactionRepository ---
+
+func (s *Store) GetTransactionByID(id int) (*storage.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.state.transactions {
+		if t.ID == id {
+			return &t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) GetTransactionsByAccountID(accountID int, from, to time.Time) ([]storage.Transaction, error) {
+	return s.GetTransactionsByAccountIDs([]int{accountID}, from, to)
+}
+
+func (s *Store) GetTransactionsByAccountIDs(accountIDs []int, from, to time.Time) ([]storage.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := map[int]bool{}
+	for _, id := range accountIDs {
+		ids[id] = true
+	}
+	var res []storage.Transaction
+	for _, t := range s.state.transactions {
+		if ids[t.AccountID] && !t.BookingDate.Before(from) && t.BookingDate.Before(to) {
+			res = append(res, t)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].BookingDate.Before(res[j].BookingDate) })
+	return res, nil
+}
+
+// GetTransactionsAddedSince — операции счёта, сохранённые в [from, to), в порядке добавления
+func (s *Store) GetTransactionsAddedSince(accountID int, from, to time.Time) ([]storage.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []storage.Transaction
+	for _, t := range s.state.transactions {
+		if t.AccountID == accountID && !t.CreatedAt.Before(from) && t.CreatedAt.Before(to) {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) InsertTransaction(t *storage.Transaction) error {
+	if err := s.fail("InsertTransaction"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.insertTransaction(t)
+	return nil
+}
+
+func (s *Store) InsertTransactionIfNew(t *storage.Transaction) (bool, error) {
+	if err := s.fail("InsertTransactionIfNew"); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.ExternalID != nil {
+		for _, existing := range s.state.transactions {
+			if existing.AccountID == t.AccountID && existing.ExternalID != nil && *existing.ExternalID == *t.ExternalID {
+				return false, nil
+			}
+		}
+	}
+	s.state.insertTransaction(t)
+	return true, nil
+}
+
+func (s *Store) FirstBookingDate(accountID int) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first *time.Time
+	for _, t := range s.state.transactions {
+		if t.AccountID == accountID && (first == nil || t.BookingDate.Before(*first)) {
+			d := t.BookingDate
+			first = &d
+		}
+	}
+	return first, nil
+}
+
+func (s *Store) LastBookingDate(accountID int) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var last *time.Time
+	for _, t := range s.state.transactions {
+		if t.AccountID == accountID && (last == nil || t.BookingDate.After(*last)) {
+			d := t.BookingDate
+			last = &d
+		}
+	}
+	return last, nil
+}
+
+func (s *Store) UpdateTransactionCategory(id int, category, source string) error {
+	if err := s.fail("UpdateTransactionCategory"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.state.transactions {
+		if s.state.transactions[i].ID == id {
+			s.state.transactions[i].Category = &category
+			s.state.transactions[i].CategorySource = &source
+		}
+	}
+	return nil
+}
+
+// --- ProductRepository ---
+
+func (s *Store) GetProductByProductID(bankID int, productID string) (*storage.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.state.products {
+		if p.BankID != nil && *p.BankID == bankID && p.ProductID == productID {
+			return &p, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) ListProducts(bankID *int) ([]storage.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []storage.Product
+	for _, p := range s.state.products {
+		if bankID == nil || (p.BankID != nil && *p.BankID == *bankID) {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) UpsertProduct(p *storage.Product) error {
+	if err := s.fail("UpsertProduct"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.state.products {
+		if existing.ProductID == p.ProductID && sameInt(existing.BankID, p.BankID) {
+			p.ID, p.CreatedAt, p.IsActive = existing.ID, existing.CreatedAt, true
+			s.state.products[i] = *p
+			return nil
+		}
+	}
+	p.ID = int(s.state.nextID())
+	p.CreatedAt, p.IsActive = time.Now(), true
+	s.state.products = append(s.state.products, *p)
+	return nil
+}
+
+func (s *Store) DeactivateMissingProducts(bankID int, keep []string) (int, error) {
+	if err := s.fail("DeactivateMissingProducts"); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := map[string]bool{}
+	for _, id := range keep {
+		kept[id] = true
+	}
+	n := 0
+	for i, p := range s.state.products {
+		if p.BankID != nil && *p.BankID == bankID && p.IsActive && !kept[p.ProductID] {
+			s.state.products[i].IsActive = false
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) GetProductAgreementsByUserID(userID int) ([]storage.ProductAgreement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []storage.ProductAgreement
+	for _, a := range s.state.agreements {
+		if a.UserID == userID {
+			a.BankCode = s.state.userBankCode(a.UserID)
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) GetPendingProductAgreements() ([]storage.ProductAgreement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []storage.ProductAgreement
+	for _, a := range s.state.agreements {
+		if a.Status != "pending" && a.Status != "processing" {
+			continue
+		}
+		a.BankCode = s.state.userBankCode(a.UserID)
+		res = append(res, a)
+	}
+	return res, nil
+}
+
+func (s *Store) SaveProductAgreement(a *storage.ProductAgreement) error {
+	if err := s.fail("SaveProductAgreement"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	a.UpdatedAt = &now
+	for i, existing := range s.state.agreements {
+		if existing.AgreementID == a.AgreementID {
+			a.ID, a.CreatedAt = existing.ID, existing.CreatedAt
+			if a.SourceAccountID == nil {
+				a.SourceAccountID = existing.SourceAccountID
+			}
+			s.state.agreements[i] = *a
+			return nil
+		}
+	}
+	a.ID = int(s.state.nextID())
+	a.CreatedAt = now
+	s.state.agreements = append(s.state.agreements, *a)
+	return nil
+}
+
+// --- AuditRepository ---
+
+func (s *Store) InsertAuditEntry(e *storage.AuditEntry) error {
+	if err := s.fail("InsertAuditEntry"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.ID = s.state.nextID()
+	e.CreatedAt = time.Now()
+	s.state.audit = append(s.state.audit, *e)
+	return nil
+}
+
+func (s *Store) ListAuditEntries(f storage.AuditFilter) ([]storage.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := s.state.auditEntries(f)
+	if f.Offset >= len(res) {
+		return nil, nil
+	}
+	res = res[f.Offset:]
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (s *Store) CountAuditEntries(f storage.AuditFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.state.auditEntries(f)), nil
+}
+
+// auditEntries — записи журнала по фильтру, новые первыми
+func (s *state) auditEntries(f storage.AuditFilter) []storage.AuditEntry {
+	var res []storage.AuditEntry
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		e := s.audit[i]
+		switch {
+		case f.UserID != nil && (e.UserID == nil || *e.UserID != *f.UserID),
+			f.Kind != "" && e.Kind != f.Kind,
+			f.Action != "" && e.Action != f.Action,
+			f.BankCode != "" && (e.BankCode == nil || *e.BankCode != f.BankCode),
+			f.From != nil && e.CreatedAt.Before(*f.From),
+			f.To != nil && !e.CreatedAt.Before(*f.To):
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// --- helpers (вызываются под s.mu) ---
+
+func (s *state) bankByCode(code string) *storage.Bank {
+	for i := range s.banks {
+		if s.banks[i].Code == code {
+			return &s.banks[i]
+		}
+	}
+	return nil
+}
+
+func (s *state) userByID(id int) *storage.User {
+	for i := range s.users {
+		if s.users[i].ID == id {
+			return &s.users[i]
+		}
+	}
+	return nil
+}
+
+// userBankCode — код банка записи users ("" для записи без банка)
+func (s *state) userBankCode(userID int) string {
+	u := s.userByID(userID)
+	if u == nil || u.BankID == nil {
+		return ""
+	}
+	for _, b := range s.banks {
+		if b.ID == *u.BankID {
+			return b.Code
+		}
+	}
+	return ""
+}
+
+// userIDsSharingClient — все записи users с тем же client_id, что и у userID
+func (s *state) userIDsSharingClient(userID int) map[int]bool {
+	ids := map[int]bool{}
+	u := s.userByID(userID)
+	if u == nil {
+		return ids
+	}
+	for _, cand := range s.users {
+		if cand.ClientID == u.ClientID {
+			ids[cand.ID] = true
+		}
+	}
+	return ids
+}
+
+func (s *state) insertTransaction(t *storage.Transaction) {
+	t.ID = int(s.nextID())
+	t.CreatedAt = time.Now()
+	s.transactions = append(s.transactions, *t)
+}
+
+func sameInt(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func (s *state) validAccountConsents(match func(storage.AccountConsent) bool) []storage.AccountConsent {
+	now := time.Now()
+	var res []storage.AccountConsent
+	for _, c := range s.accountConsents {
+		if (c.ExpiresAt == nil || c.ExpiresAt.After(now)) && match(c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"MoneyPilot/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTx(t *testing.T) {
+	boom := errors.New("boom")
+	s := New()
+	u := s.AddUser(storage.User{ClientID: "c1"})
+	acc := &storage.Account{UserID: u.ID, AccountNumber: "40817", Currency: "RUB", Balance: 100}
+	if err := s.UpsertAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.WithTx(context.Background(), func(tx storage.Store) error {
+		if _, err := tx.AddAccountBalance(acc.ID, 50); err != nil {
+			return err
+		}
+		if err := tx.InsertTransaction(&storage.Transaction{AccountID: acc.ID, Amount: 50}); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	got, _ := s.GetAccountByID(acc.ID)
+	if got.Balance != 100 {
+		t.Errorf("balance after rollback = %v, want 100", got.Balance)
+	}
+	if txs, _ := s.GetTransactionsAddedSince(acc.ID, time.Time{}, time.Now().Add(time.Hour)); len(txs) != 0 {
+		t.Errorf("transactions after rollback = %d, want 0", len(txs))
+	}
+
+	err = s.WithTx(context.Background(), func(tx storage.Store) error {
+		_, err := tx.AddAccountBalance(acc.ID, 50)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetAccountByID(acc.ID); got.Balance != 150 {
+		t.Errorf("balance after commit = %v, want 150", got.Balance)
+	}
+}
+
+func TestFail(t *testing.T) {
+	boom := errors.New("boom")
+	s := New()
+	u := s.AddUser(storage.User{ClientID: "c1"})
+	s.Fail = func(method string) error {
+		if method == "InsertBalanceSnapshot" {
+			return boom
+		}
+		return nil
+	}
+	err := s.WithTx(context.Background(), func(tx storage.Store) error {
+		if err := tx.InsertBudget(&storage.Budget{UserID: u.ID, Name: "food"}); err != nil {
+			return err
+		}
+		return tx.InsertBalanceSnapshot(&storage.BalanceSnapshot{AccountID: 1})
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if b, _ := s.ListBudgets(u.ID); len(b) != 0 {
+		t.Errorf("budgets after rollback = %d, want 0", len(b))
+	}
+}
+
+func TestProductKey(t *testing.T) {
+	s := New()
+	a, b := s.AddBank(storage.Bank{Code: "abank"}), s.AddBank(storage.Bank{Code: "bbank"})
+	for _, bankID := range []int{a.ID, b.ID, a.ID} {
+		id := bankID
+		if err := s.UpsertProduct(&storage.Product{BankID: &id, ProductID: "dep-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if all, _ := s.ListProducts(nil); len(all) != 2 {
+		t.Fatalf("products = %d, want 2", len(all))
+	}
+	p, err := s.GetProductByProductID(b.ID, "dep-1")
+	if err != nil || *p.BankID != b.ID {
+		t.Fatalf("GetProductByProductID = %+v, %v", p, err)
+	}
+	if _, err := s.GetProductByProductID(b.ID+100, "dep-1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("unknown bank: err = %v, want sql.ErrNoRows", err)
+	}
+}
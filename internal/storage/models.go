@@ -1,6 +1,9 @@
 package storage
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	ID           int       `db:"id" json:"id"`
@@ -9,6 +12,8 @@ type User struct {
 	Email        *string   `db:"email" json:"email"`
 	PasswordHash string    `db:"password_hash" json:"-"`
 	Segment      *string   `db:"segment" json:"segment"`
+	Role         string    `db:"role" json:"role"`
+	BaseCurrency string    `db:"base_currency" json:"base_currency"`
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 }
 
@@ -21,28 +26,57 @@ type Bank struct {
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
+// Источник счёта: API банка или ручной (выписки загружаются файлами)
+const (
+	SourceAPI    = "api"
+	SourceManual = "manual"
+
+	// ManualBankCode — служебный банк, к которому привязаны ручные счета
+	ManualBankCode = "manual"
+)
+
+// Типы ручных счетов; счета из выписок — обычные checking
+const (
+	AccountTypeCash      = "cash"
+	AccountTypeAsset     = "asset"     // недвижимость, брокерский счёт и т.п.: только оценка стоимости
+	AccountTypeLiability = "liability" // долг в стороннем банке: остаток хранится со знаком минус
+)
+
 type Account struct {
 	ID            int       `db:"id" json:"id"`
 	UserID        int       `db:"user_id" json:"user_id"`
 	BankID        int       `db:"bank_id" json:"bank_id"`
+	ExternalID    *string   `db:"external_id" json:"external_id"`
 	AccountNumber string    `db:"account_number" json:"account_number"`
 	AccountType   string    `db:"account_type" json:"account_type"`
 	Nickname      *string   `db:"nickname" json:"nickname"`
 	Currency      string    `db:"currency" json:"currency"`
 	Balance       float64   `db:"balance" json:"balance"`
 	Status        string    `db:"status" json:"status"`
+	Source        string    `db:"source" json:"source"`
+	Institution   *string   `db:"institution" json:"institution,omitempty"` // банк ручного счёта
 	CreatedAt     time.Time `db:"created_at" json:"created_at"`
 }
 
+// Spendable — с этого счёта можно платить: не актив и не обязательство.
+// Прогноз остатков и выбор источника оплаты учитывают только такие счета.
+func (a Account) Spendable() bool {
+	return a.AccountType != AccountTypeAsset && a.AccountType != AccountTypeLiability
+}
+
 type Transaction struct {
-	ID          int       `db:"id" json:"id"`
-	AccountID   int       `db:"account_id" json:"account_id"`
-	Amount      float64   `db:"amount" json:"amount"`
-	Currency    *string   `db:"currency" json:"currency"`
-	Description *string   `db:"description" json:"description"`
-	Category    *string   `db:"category" json:"category"`
-	BookingDate time.Time `db:"booking_date" json:"booking_date"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	ID             int       `db:"id" json:"id"`
+	AccountID      int       `db:"account_id" json:"account_id"`
+	ExternalID     *string   `db:"external_id" json:"external_id"`
+	Amount         float64   `db:"amount" json:"amount"` // расход — отрицательный
+	Currency       *string   `db:"currency" json:"currency"`
+	Description    *string   `db:"description" json:"description"`
+	MCC            *string   `db:"mcc" json:"mcc"`
+	Counterparty   *string   `db:"counterparty" json:"counterparty"`
+	Category       *string   `db:"category" json:"category"`
+	CategorySource *string   `db:"category_source" json:"category_source"` // default | rule | manual
+	BookingDate    time.Time `db:"booking_date" json:"booking_date"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
 }
 
 type AccountConsent struct {
@@ -86,17 +120,22 @@ type Payment struct {
 }
 
 type Product struct {
-	ID           int       `db:"id" json:"id"`
-	ProductID    string    `db:"product_id" json:"product_id"`
-	BankID       *int      `db:"bank_id" json:"bank_id"`
-	ProductType  *string   `db:"product_type" json:"product_type"`
-	Name         *string   `db:"name" json:"name"`
-	Description  *string   `db:"description" json:"description"`
-	InterestRate *float64  `db:"interest_rate" json:"interest_rate"`
-	MinAmount    *float64  `db:"min_amount" json:"min_amount"`
-	MaxAmount    *float64  `db:"max_amount" json:"max_amount"`
-	TermMonths   *int      `db:"term_months" json:"term_months"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	ID               int        `db:"id" json:"id"`
+	ProductID        string     `db:"product_id" json:"product_id"`
+	BankID           *int       `db:"bank_id" json:"bank_id"`
+	ProductType      *string    `db:"product_type" json:"product_type"`
+	Name             *string    `db:"name" json:"name"`
+	Description      *string    `db:"description" json:"description"`
+	InterestRate     *float64   `db:"interest_rate" json:"interest_rate"`
+	MinAmount        *float64   `db:"min_amount" json:"min_amount"`
+	MaxAmount        *float64   `db:"max_amount" json:"max_amount"`
+	TermMonths       *int       `db:"term_months" json:"term_months"`
+	Currency         *string    `db:"currency" json:"currency"`
+	Capitalization   bool       `db:"capitalization" json:"capitalization"`         // ежемесячная капитализация процентов
+	EarlyClosureRate *float64   `db:"early_closure_rate" json:"early_closure_rate"` // ставка при досрочном закрытии, %
+	IsActive         bool       `db:"is_active" json:"is_active"`
+	SyncedAt         *time.Time `db:"synced_at" json:"synced_at,omitempty"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
 }
 
 type ProductAgreement struct {
@@ -108,6 +147,10 @@ type ProductAgreement struct {
 	TermMonths  *int      `db:"term_months" json:"term_months"`
 	Status      string    `db:"status" json:"status"`
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+
+	SourceAccountID *string    `db:"source_account_id" json:"source_account_id,omitempty"` // счёт, с которого фондируется продукт
+	UpdatedAt       *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	BankCode        string     `db:"-" json:"bank,omitempty"`
 }
 
 type ProductAgreementConsent struct {
@@ -127,3 +170,169 @@ type ProductAgreementConsent struct {
 	CreatedAt              time.Time
 	BankCode               *string
 }
+
+// AuditEntry — запись журнала аудита (только добавление, без изменений)
+type AuditEntry struct {
+	ID         int64           `db:"id" json:"id"`
+	Kind       string          `db:"kind" json:"kind"`
+	UserID     *int            `db:"user_id" json:"user_id"`
+	Action     string          `db:"action" json:"action"`
+	BankCode   *string         `db:"bank_code" json:"bank_code,omitempty"`
+	Method     *string         `db:"method" json:"method,omitempty"`
+	Endpoint   *string         `db:"endpoint" json:"endpoint,omitempty"`
+	ConsentID  *string         `db:"consent_id" json:"consent_id,omitempty"`
+	StatusCode *int            `db:"status_code" json:"status_code,omitempty"`
+	LatencyMs  *int64          `db:"latency_ms" json:"latency_ms,omitempty"`
+	RequestID  *string         `db:"request_id" json:"request_id,omitempty"`
+	Details    json.RawMessage `db:"details" json:"details,omitempty"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
+
+// AuditFilter — параметры выборки журнала аудита
+type AuditFilter struct {
+	UserID   *int
+	Kind     string
+	Action   string
+	BankCode string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+// BalanceSnapshot — срез баланса счёта на момент времени
+type BalanceSnapshot struct {
+	ID        int64     `db:"id" json:"id"`
+	AccountID int       `db:"account_id" json:"account_id"`
+	Available *float64  `db:"available" json:"available"`
+	Booked    *float64  `db:"booked" json:"booked"`
+	Currency  *string   `db:"currency" json:"currency"`
+	TakenAt   time.Time `db:"taken_at" json:"taken_at"`
+}
+
+// FXRate — курс на дату: 1 Base = Rate Quote
+type FXRate struct {
+	RateDate  time.Time `db:"rate_date" json:"date"`
+	Base      string    `db:"base" json:"base"`
+	Quote     string    `db:"quote" json:"quote"`
+	Rate      float64   `db:"rate" json:"rate"`
+	Source    string    `db:"source" json:"source"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// CategoryRule — пользовательское правило категоризации.
+// Пустые условия не проверяются; суммы сравниваются по модулю.
+type CategoryRule struct {
+	ID               int       `db:"id" json:"id"`
+	UserID           int       `db:"user_id" json:"user_id"`
+	Category         string    `db:"category" json:"category"`
+	MCCCodes         []string  `db:"mcc_codes" json:"mcc_codes,omitempty"`
+	Counterparty     *string   `db:"counterparty" json:"counterparty,omitempty"`
+	DescriptionRegex *string   `db:"description_regex" json:"description_regex,omitempty"`
+	MinAmount        *float64  `db:"min_amount" json:"min_amount,omitempty"`
+	MaxAmount        *float64  `db:"max_amount" json:"max_amount,omitempty"`
+	Direction        *string   `db:"direction" json:"direction,omitempty"` // debit | credit
+	Priority         int       `db:"priority" json:"priority"`
+	Learned          bool      `db:"learned" json:"learned"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
+
+// Budget — лимит расходов на неделю или месяц по категории или продавцу
+type Budget struct {
+	ID        int       `db:"id" json:"id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Name      string    `db:"name" json:"name"`
+	Period    string    `db:"period" json:"period"` // week | month
+	Category  *string   `db:"category" json:"category,omitempty"`
+	Merchant  *string   `db:"merchant" json:"merchant,omitempty"`
+	Amount    float64   `db:"amount" json:"amount"`
+	Currency  string    `db:"currency" json:"currency"`
+	Rollover  bool      `db:"rollover" json:"rollover"` // остаток прошлого периода переносится
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// RecurringDecision — подтверждение или скрытие найденной регулярной серии
+type RecurringDecision struct {
+	UserID    int       `db:"user_id" json:"user_id"`
+	SeriesID  string    `db:"series_id" json:"series_id"`
+	Status    string    `db:"status" json:"status"` // confirmed | dismissed
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ForecastThreshold — остаток, ниже которого прогноз предупреждает заранее
+type ForecastThreshold struct {
+	UserID    int       `db:"user_id" json:"-"`
+	Amount    float64   `db:"amount" json:"amount"`
+	Currency  string    `db:"currency" json:"currency"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// CashbackRule — процент кешбэка по продукту банка; BankCode заполняется из banks
+type CashbackRule struct {
+	ID          int       `db:"id" json:"id"`
+	UserID      int       `db:"user_id" json:"user_id"`
+	BankID      int       `db:"bank_id" json:"bank_id"`
+	BankCode    string    `json:"bank"`
+	ProductID   *string   `db:"product_id" json:"product_id,omitempty"`
+	Category    *string   `db:"category" json:"category,omitempty"`
+	Percent     float64   `db:"percent" json:"percent"`
+	MaxCashback *float64  `db:"max_cashback" json:"max_cashback,omitempty"`                   // с одной покупки
+	MaxCurrency *string   `db:"max_cashback_currency" json:"max_cashback_currency,omitempty"` // валюта лимита
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// SavingsGoal — цель накоплений на счёте (AccountID) или вкладе (BankID + AgreementID)
+type SavingsGoal struct {
+	ID           int        `db:"id" json:"id"`
+	UserID       int        `db:"user_id" json:"user_id"`
+	Name         string     `db:"name" json:"name"`
+	TargetAmount float64    `db:"target_amount" json:"target_amount"`
+	Currency     string     `db:"currency" json:"currency"`
+	TargetDate   *time.Time `db:"target_date" json:"target_date,omitempty"`
+	AccountID    *int       `db:"account_id" json:"account_id,omitempty"`
+	BankID       *int       `db:"bank_id" json:"bank_id,omitempty"`
+	AgreementID  *string    `db:"agreement_id" json:"agreement_id,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// SweepRule — правило автопополнения цели со счёта SourceAccountID
+type SweepRule struct {
+	ID              int        `db:"id" json:"id"`
+	UserID          int        `db:"user_id" json:"user_id"`
+	GoalID          int        `db:"goal_id" json:"goal_id"`
+	Type            string     `db:"type" json:"type"` // roundup | salary_percent | excess
+	SourceAccountID int        `db:"source_account_id" json:"source_account_id"`
+	RoundTo         *float64   `db:"round_to" json:"round_to,omitempty"`
+	Percent         *float64   `db:"percent" json:"percent,omitempty"`
+	KeepAmount      *float64   `db:"keep_amount" json:"keep_amount,omitempty"`
+	Enabled         bool       `db:"enabled" json:"enabled"`
+	LastRunAt       *time.Time `db:"last_run_at" json:"last_run_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+// SweepTransfer — перевод в цель по правилу: предложение или исполненный платёж
+type SweepTransfer struct {
+	ID              int       `db:"id" json:"id"`
+	UserID          int       `db:"user_id" json:"user_id"`
+	RuleID          int       `db:"rule_id" json:"rule_id"`
+	GoalID          int       `db:"goal_id" json:"goal_id"`
+	SourceAccountID int       `db:"source_account_id" json:"source_account_id"`
+	Amount          float64   `db:"amount" json:"amount"`
+	Currency        string    `db:"currency" json:"currency"`
+	Status          string    `db:"status" json:"status"` // proposed | approved | executed | rejected | failed
+	Reason          string    `db:"reason" json:"reason"`
+	SourceKey       string    `db:"source_key" json:"-"`
+	PaymentID       *string   `db:"payment_id" json:"payment_id,omitempty"`
+	Error           *string   `db:"error" json:"error,omitempty"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// MonthlyReport — PDF-отчёт клиента за месяц, сформированный планировщиком
+type MonthlyReport struct {
+	ID        int       `db:"id" json:"id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Month     string    `db:"month" json:"month"` // YYYY-MM
+	PDF       []byte    `db:"pdf" json:"-"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package storage
+
+// GetActivePaymentConsents — согласия со статусом active/authorized, срок которых не истёк
+func (r *Repository) GetActivePaymentConsents(userID int) ([]PaymentConsent, error) {
+	rows, err := r.db.Query(`
+		SELECT id, consent_id, user_id, bank_id, consent_type, amount, debtor_account, creditor_account, valid_until, status, created_at
+		FROM payment_consents
+		WHERE user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+		  AND status IN ('active', 'authorized', 'Authorised')
+		  AND (valid_until IS NULL OR valid_until > NOW())
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consents []PaymentConsent
+	for rows.Next() {
+		var c PaymentConsent
+		if err := rows.Scan(&c.ID, &c.ConsentID, &c.UserID, &c.BankID, &c.ConsentType, &c.Amount,
+			&c.DebtorAccount, &c.CreditorAccount, &c.ValidUntil, &c.Status, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		consents = append(consents, c)
+	}
+	return consents, rows.Err()
+}
+
+func (r *Repository) InsertPayment(p *Payment) error {
+	return r.db.QueryRow(`
+		INSERT INTO payments (payment_id, user_id, debtor_account, creditor_account, amount, currency, status)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id, created_at
+	`, p.PaymentID, p.UserID, p.DebtorAccount, p.CreditorAccount, p.Amount, p.Currency, p.Status).Scan(&p.ID, &p.CreatedAt)
+}
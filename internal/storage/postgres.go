@@ -1,20 +1,41 @@
 package storage
 
 import (
+	"MoneyPilot/internal/logging"
+	"context"
 	"database/sql"
-	"log"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewPostgres(dsn string) *sql.DB {
-	db, err := sql.Open("postgres", dsn)
+// NewPostgres открывает пул с трейсингом запросов: спан на каждый Exec/Query, выполненный
+// с контекстом трейса (Store.WithContext, WithTx). Запросы без родительского спана не трейсятся:
+// иначе каждый из них становится отдельным корневым трейсом.
+func NewPostgres(dsn string, logger *slog.Logger) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
 	if err != nil {
-		log.Fatalf("[Postgres] connection error: %v", err)
+		return nil, fmt.Errorf("postgres connection: %w", err)
 	}
 	if err := db.Ping(); err != nil {
-		log.Fatalf("[Postgres] ping failed: %v", err)
+		db.Close()
+		return nil, fmt.Errorf("postgres ping: %w", err)
 	}
-	log.Println("✅ Connected to Postgres")
-	return db
+	logging.For(logger, "storage").Info("connected to postgres")
+	return db, nil
 }
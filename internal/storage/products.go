@@ -0,0 +1,122 @@
+package storage
+
+import "github.com/lib/pq"
+
+// GetProductByProductID — продукт каталога банка; product_id уникален только внутри банка
+func (r *Repository) GetProductByProductID(bankID int, productID string) (*Product, error) {
+	var p Product
+	err := r.db.QueryRow(`
+		SELECT id, product_id, bank_id, product_type, name, description, interest_rate, min_amount, max_amount, term_months,
+		       currency, capitalization, early_closure_rate, is_active, synced_at, created_at
+		FROM products WHERE bank_id=$1 AND product_id=$2
+	`, bankID, productID).Scan(&p.ID, &p.ProductID, &p.BankID, &p.ProductType, &p.Name, &p.Description,
+		&p.InterestRate, &p.MinAmount, &p.MaxAmount, &p.TermMonths, &p.Currency, &p.Capitalization, &p.EarlyClosureRate, &p.IsActive, &p.SyncedAt, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListProducts возвращает каталог продуктов; bankID == nil — по всем банкам
+func (r *Repository) ListProducts(bankID *int) ([]Product, error) {
+	rows, err := r.db.Query(`
+		SELECT id, product_id, bank_id, product_type, name, description, interest_rate, min_amount, max_amount, term_months,
+		       currency, capitalization, early_closure_rate, is_active, synced_at, created_at
+		FROM products WHERE ($1::int IS NULL OR bank_id=$1) ORDER BY id
+	`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.ProductID, &p.BankID, &p.ProductType, &p.Name, &p.Description,
+			&p.InterestRate, &p.MinAmount, &p.MaxAmount, &p.TermMonths, &p.Currency, &p.Capitalization, &p.EarlyClosureRate, &p.IsActive, &p.SyncedAt, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// UpsertProduct сохраняет продукт по (bank_id, product_id); продукт снова считается активным
+func (r *Repository) UpsertProduct(p *Product) error {
+	p.IsActive = true
+	return r.db.QueryRow(`
+		INSERT INTO products (product_id, bank_id, product_type, name, description, interest_rate, min_amount, max_amount, term_months,
+		                      currency, capitalization, early_closure_rate, is_active, synced_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,true,$13)
+		ON CONFLICT (bank_id, product_id) DO UPDATE SET
+			product_type=EXCLUDED.product_type, name=EXCLUDED.name,
+			description=EXCLUDED.description, interest_rate=EXCLUDED.interest_rate, min_amount=EXCLUDED.min_amount,
+			max_amount=EXCLUDED.max_amount, term_months=EXCLUDED.term_months, currency=EXCLUDED.currency,
+			capitalization=EXCLUDED.capitalization, early_closure_rate=EXCLUDED.early_closure_rate, is_active=true, synced_at=EXCLUDED.synced_at
+		RETURNING id, created_at
+	`, p.ProductID, p.BankID, p.ProductType, p.Name, p.Description, p.InterestRate, p.MinAmount, p.MaxAmount, p.TermMonths,
+		p.Currency, p.Capitalization, p.EarlyClosureRate, p.SyncedAt).
+		Scan(&p.ID, &p.CreatedAt)
+}
+
+// DeactivateMissingProducts помечает неактивными продукты банка, которых нет в keep.
+// Строки не удаляются: на них ссылаются договоры.
+func (r *Repository) DeactivateMissingProducts(bankID int, keep []string) (int, error) {
+	res, err := r.db.Exec(`
+		UPDATE products SET is_active=false
+		WHERE bank_id=$1 AND is_active AND NOT (product_id = ANY($2))
+	`, bankID, pq.Array(keep))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (r *Repository) GetProductAgreementsByUserID(userID int) ([]ProductAgreement, error) {
+	return r.queryProductAgreements(`WHERE pa.user_id=$1`, userID)
+}
+
+// GetPendingProductAgreements — договоры, открытие которых банк ещё не завершил
+func (r *Repository) GetPendingProductAgreements() ([]ProductAgreement, error) {
+	return r.queryProductAgreements(`WHERE pa.status IN ('pending','processing')`)
+}
+
+func (r *Repository) queryProductAgreements(where string, args ...interface{}) ([]ProductAgreement, error) {
+	rows, err := r.db.Query(`
+		SELECT pa.id, pa.agreement_id, pa.user_id, pa.product_id, pa.amount, pa.term_months, pa.status, pa.created_at,
+		       pa.source_account_id, pa.updated_at, COALESCE(b.code, '')
+		FROM product_agreements pa
+		LEFT JOIN users u ON u.id = pa.user_id
+		LEFT JOIN banks b ON b.id = u.bank_id
+		`+where+` ORDER BY pa.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agreements []ProductAgreement
+	for rows.Next() {
+		var a ProductAgreement
+		if err := rows.Scan(&a.ID, &a.AgreementID, &a.UserID, &a.ProductID, &a.Amount, &a.TermMonths, &a.Status, &a.CreatedAt,
+			&a.SourceAccountID, &a.UpdatedAt, &a.BankCode); err != nil {
+			return nil, err
+		}
+		agreements = append(agreements, a)
+	}
+	return agreements, rows.Err()
+}
+
+// SaveProductAgreement сохраняет договор по agreement_id
+func (r *Repository) SaveProductAgreement(a *ProductAgreement) error {
+	return r.db.QueryRow(`
+		INSERT INTO product_agreements (agreement_id, user_id, product_id, amount, term_months, status, source_account_id, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,NOW())
+		ON CONFLICT (agreement_id) DO UPDATE SET
+			amount=EXCLUDED.amount, term_months=EXCLUDED.term_months, status=EXCLUDED.status,
+			source_account_id=COALESCE(EXCLUDED.source_account_id, product_agreements.source_account_id), updated_at=NOW()
+		RETURNING id, created_at, updated_at
+	`, a.AgreementID, a.UserID, a.ProductID, a.Amount, a.TermMonths, a.Status, a.SourceAccountID).
+		Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+}
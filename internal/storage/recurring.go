@@ -0,0 +1,46 @@
+package storage
+
+// ListRecurringDecisions — решения клиента (всех его записей users)
+func (r *Repository) ListRecurringDecisions(userID int) ([]RecurringDecision, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id, series_id, status, updated_at
+		FROM recurring_decisions
+		WHERE user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+		ORDER BY updated_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []RecurringDecision
+	for rows.Next() {
+		var d RecurringDecision
+		if err := rows.Scan(&d.UserID, &d.SeriesID, &d.Status, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
+
+// SetRecurringDecision сохраняет решение; прежние решения других записей клиента по той же серии заменяются
+func (r *Repository) SetRecurringDecision(userID int, seriesID, status string) error {
+	return r.inTx(func(q *Repository) error {
+		if err := q.DeleteRecurringDecision(userID, seriesID); err != nil {
+			return err
+		}
+		_, err := q.db.Exec(`
+			INSERT INTO recurring_decisions (user_id, series_id, status) VALUES ($1,$2,$3)
+		`, userID, seriesID, status)
+		return err
+	})
+}
+
+func (r *Repository) DeleteRecurringDecision(userID int, seriesID string) error {
+	_, err := r.db.Exec(`
+		DELETE FROM recurring_decisions
+		WHERE series_id=$2 AND user_id IN (SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))
+	`, userID, seriesID)
+	return err
+}
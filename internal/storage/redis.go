@@ -1,17 +1,20 @@
 package storage
 
 import (
+	"MoneyPilot/internal/logging"
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 
 	"github.com/redis/go-redis/v9"
 )
 
-func NewRedis(addr string) *redis.Client {
+func NewRedis(addr string, logger *slog.Logger) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{Addr: addr})
 	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		log.Fatalf("[Redis] connection failed: %v", err)
+		rdb.Close()
+		return nil, fmt.Errorf("redis connection: %w", err)
 	}
-	log.Println("✅ Connected to Redis")
-	return rdb
+	logging.For(logger, "storage").Info("connected to redis", "addr", addr)
+	return rdb, nil
 }
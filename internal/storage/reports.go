@@ -0,0 +1,28 @@
+package storage
+
+// GetMonthlyReport — отчёт клиента за месяц YYYY-MM
+func (r *Repository) GetMonthlyReport(userID int, month string) (*MonthlyReport, error) {
+	var m MonthlyReport
+	err := r.db.QueryRow(`
+		SELECT id, user_id, month, pdf, created_at FROM monthly_reports
+		WHERE month=$2 AND user_id IN `+clientUsers+`
+		ORDER BY created_at DESC LIMIT 1
+	`, userID, month).Scan(&m.ID, &m.UserID, &m.Month, &m.PDF, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SaveMonthlyReport заменяет отчёт клиента за месяц
+func (r *Repository) SaveMonthlyReport(m *MonthlyReport) error {
+	return r.inTx(func(q *Repository) error {
+		if _, err := q.db.Exec(`DELETE FROM monthly_reports WHERE month=$2 AND user_id IN `+clientUsers, m.UserID, m.Month); err != nil {
+			return err
+		}
+		return q.db.QueryRow(`
+			INSERT INTO monthly_reports (user_id, month, pdf) VALUES ($1,$2,$3)
+			RETURNING id, created_at
+		`, m.UserID, m.Month, m.PDF).Scan(&m.ID, &m.CreatedAt)
+	})
+}
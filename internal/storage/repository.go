@@ -1,25 +1,99 @@
 package storage
 
 import (
+	"MoneyPilot/internal/logging"
+	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/lib/pq"
 )
 
+// dbtx — общее между *sql.DB и *sql.Tx, чтобы одни и те же методы работали в транзакции и без неё
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ctxConn — то же с контекстом; его реализуют *sql.DB и *sql.Tx
+type ctxConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ctxDB передаёт в драйвер контекст запроса: SQL-спаны становятся дочерними спанами API или задачи
+type ctxDB struct {
+	ctx  context.Context
+	conn ctxConn
+}
+
+func (d ctxDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.conn.ExecContext(d.ctx, query, args...)
+}
+
+func (d ctxDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.conn.QueryContext(d.ctx, query, args...)
+}
+
+func (d ctxDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.conn.QueryRowContext(d.ctx, query, args...)
+}
+
+// Repository — реализация Store поверх Postgres
 type Repository struct {
-	DB *sql.DB
+	conn *sql.DB
+	db   dbtx
+	tx   *sql.Tx
+	log  *slog.Logger
+}
+
+var _ Store = (*Repository)(nil)
+
+func NewRepository(db *sql.DB, logger *slog.Logger) *Repository {
+	return &Repository{conn: db, db: db, log: logging.For(logger, "storage")}
+}
+
+// WithContext — тот же репозиторий, чьи запросы выполняются с ctx
+func (r *Repository) WithContext(ctx context.Context) Store {
+	var conn ctxConn = r.conn
+	if r.tx != nil {
+		conn = r.tx
+	}
+	return &Repository{conn: r.conn, db: ctxDB{ctx: ctx, conn: conn}, tx: r.tx, log: r.log}
+}
+
+// WithTx выполняет fn в транзакции; запросы внутри выполняются с ctx.
+// Вложенный вызов переиспользует текущую транзакцию.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Repository{conn: r.conn, db: ctxDB{ctx: ctx, conn: tx}, tx: tx, log: r.log}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func NewRepository(db *sql.DB) *Repository {
-	return &Repository{DB: db}
+// inTx — WithTx для внутренних многошаговых методов репозитория
+func (r *Repository) inTx(fn func(q *Repository) error) error {
+	return r.WithTx(context.Background(), func(tx Store) error {
+		return fn(tx.(*Repository))
+	})
 }
 
 // Deprecated: SaveConsent kept for compatibility but project uses account_consents table now.
 func (r *Repository) SaveConsent(userID, bankID int, consent_id string, expires time.Time, requesting_bank string) error {
-	_, err := r.DB.Exec(
+	_, err := r.db.Exec(
 		`INSERT INTO account_consents (consent_id, user_id, bank_id, requesting_bank, permissions, status, expires_at)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
 		consent_id, userID, bankID, requesting_bank, nil, "approved", expires,
@@ -29,7 +103,7 @@ func (r *Repository) SaveConsent(userID, bankID int, consent_id string, expires
 
 func (r *Repository) GetBankByCode(code string) (*Bank, error) {
 	var b Bank
-	err := r.DB.QueryRow(`SELECT id, code, name, api_base_url, jwks_url, created_at FROM banks WHERE code=$1`, code).
+	err := r.db.QueryRow(`SELECT id, code, name, api_base_url, jwks_url, created_at FROM banks WHERE code=$1`, code).
 		Scan(&b.ID, &b.Code, &b.Name, &b.APIBase, &b.JWKSURL, &b.CreatedAt)
 	if err != nil {
 		return nil, err
@@ -39,8 +113,8 @@ func (r *Repository) GetBankByCode(code string) (*Bank, error) {
 
 func (r *Repository) GetUserByID(id int) (*User, error) {
 	var b User
-	err := r.DB.QueryRow(`SELECT id, client_id, bank_id, email, password_hash, segment, created_at FROM users WHERE id=$1`, id).
-		Scan(&b.ID, &b.ClientID, &b.BankID, &b.Email, &b.PasswordHash, &b.Segment, &b.CreatedAt)
+	err := r.db.QueryRow(`SELECT id, client_id, bank_id, email, password_hash, segment, role, base_currency, created_at FROM users WHERE id=$1`, id).
+		Scan(&b.ID, &b.ClientID, &b.BankID, &b.Email, &b.PasswordHash, &b.Segment, &b.Role, &b.BaseCurrency, &b.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -53,8 +127,8 @@ func (r *Repository) GetUserByID(id int) (*User, error) {
 func (r *Repository) GetUserByClientIDAndBank(clientID, bankCode string) (*User, error) {
 	var u User
 	if bankCode == "" {
-		err := r.DB.QueryRow(`SELECT id, client_id, bank_id, email, password_hash, segment, created_at FROM users WHERE client_id=$1`, clientID).
-			Scan(&u.ID, &u.ClientID, &u.BankID, &u.Email, &u.PasswordHash, &u.Segment, &u.CreatedAt)
+		err := r.db.QueryRow(`SELECT id, client_id, bank_id, email, password_hash, segment, role, base_currency, created_at FROM users WHERE client_id=$1`, clientID).
+			Scan(&u.ID, &u.ClientID, &u.BankID, &u.Email, &u.PasswordHash, &u.Segment, &u.Role, &u.BaseCurrency, &u.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -63,31 +137,62 @@ func (r *Repository) GetUserByClientIDAndBank(clientID, bankCode string) (*User,
 
 	// resolve bank id
 	var bankID int
-	if err := r.DB.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
 		return nil, err
 	}
 
 	// allow user records that are global (bank_id IS NULL) or tied to the specific bank
-	err := r.DB.QueryRow(`SELECT id, client_id, bank_id, email, password_hash, segment, created_at FROM users WHERE client_id=$1 AND (bank_id IS NULL OR bank_id=$2)`, clientID, bankID).
-		Scan(&u.ID, &u.ClientID, &u.BankID, &u.Email, &u.PasswordHash, &u.Segment, &u.CreatedAt)
+	err := r.db.QueryRow(`SELECT id, client_id, bank_id, email, password_hash, segment, role, base_currency, created_at FROM users WHERE client_id=$1 AND (bank_id IS NULL OR bank_id=$2)`, clientID, bankID).
+		Scan(&u.ID, &u.ClientID, &u.BankID, &u.Email, &u.PasswordHash, &u.Segment, &u.Role, &u.BaseCurrency, &u.CreatedAt)
 	if err != nil {
-		log.Println(err.Error())
+		r.log.Debug("user lookup failed", "bank", bankCode, logging.Err(err))
 		return nil, err
 	}
 	return &u, nil
 }
 
-// SaveAccountConsentByEmailAndBank inserts an account_consents row by resolving user and bank
-// from human-friendly values (email and bank code). This avoids requiring caller to know DB ids.
-func (r *Repository) SaveAccountConsentByClientIdAndBank(client_id, bankCode, consentID, requestingBank string, permissions []string, status string, expires time.Time) error {
-	tx, err := r.DB.Begin()
+// SetUserBaseCurrency sets the base currency for every user row of the same client
+func (r *Repository) SetUserBaseCurrency(userID int, currency string) error {
+	res, err := r.db.Exec(`UPDATE users SET base_currency=$2 WHERE client_id = (SELECT client_id FROM users WHERE id=$1)`, userID, currency)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetClientUserIDs returns ids of all user rows sharing the client_id of userID
+func (r *Repository) GetClientUserIDs(userID int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1) ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SaveAccountConsentByEmailAndBank inserts an account_consents row by resolving user and bank
+// from human-friendly values (email and bank code). This avoids requiring caller to know DB ids.
+func (r *Repository) SaveAccountConsentByClientIdAndBank(client_id, bankCode, consentID, requestingBank string, permissions []string, status string, expires time.Time) error {
+	return r.inTx(func(q *Repository) error {
+		return q.saveAccountConsent(client_id, bankCode, consentID, requestingBank, permissions, status, expires)
+	})
+}
 
+func (r *Repository) saveAccountConsent(client_id, bankCode, consentID, requestingBank string, permissions []string, status string, expires time.Time) error {
 	var userID int
-	if err := tx.QueryRow(`SELECT id FROM users WHERE client_id=$1`, client_id).Scan(&userID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM users WHERE client_id=$1`, client_id).Scan(&userID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return errors.New("user not found")
 		}
@@ -95,32 +200,28 @@ func (r *Repository) SaveAccountConsentByClientIdAndBank(client_id, bankCode, co
 	}
 
 	var bankID int
-	if err := tx.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return errors.New("bank not found")
 		}
 		return err
 	}
 
-	_, err = tx.Exec(`INSERT INTO account_consents (consent_id, user_id, bank_id, requesting_bank, permissions, status, expires_at)
+	_, err := r.db.Exec(`INSERT INTO account_consents (consent_id, user_id, bank_id, requesting_bank, permissions, status, expires_at)
 		VALUES ($1,$2,$3,$4,$5,$6,$7)`, consentID, userID, bankID, requestingBank, pq.Array(permissions), status, expires)
-	if err != nil {
-		return err
-	}
-
-	return tx.Commit()
+	return err
 }
 
 func (r *Repository) GetValidAccountConsentsByEmail(email string) ([]AccountConsent, error) {
 	var userID int
-	if err := r.DB.QueryRow(`SELECT id FROM users WHERE email=$1`, email).Scan(&userID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM users WHERE email=$1`, email).Scan(&userID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("user not found")
 		}
 		return nil, err
 	}
 
-	rows, err := r.DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT ac.id, ac.consent_id, ac.user_id, ac.bank_id, b.code AS bank_code, ac.requesting_bank, ac.permissions, ac.status, ac.expires_at, ac.created_at
 		FROM account_consents ac
 		LEFT JOIN banks b ON b.id = ac.bank_id
@@ -144,7 +245,7 @@ func (r *Repository) GetValidAccountConsentsByEmail(email string) ([]AccountCons
 
 func (r *Repository) GetValidAccountConsentsByEmailAndBank(email, bank string) ([]AccountConsent, error) {
 	var userID int
-	if err := r.DB.QueryRow(`SELECT id FROM users WHERE email=$1`, email).Scan(&userID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM users WHERE email=$1`, email).Scan(&userID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("user not found")
 		}
@@ -152,17 +253,17 @@ func (r *Repository) GetValidAccountConsentsByEmailAndBank(email, bank string) (
 	}
 
 	var BankID int
-	if err := r.DB.QueryRow(`SELECT id FROM banks WHERE code=$1`, bank).Scan(&BankID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM banks WHERE code=$1`, bank).Scan(&BankID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("bank not found")
 		}
 		return nil, err
 	}
-	rows, err := r.DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT ac.id, ac.consent_id, ac.user_id, ac.bank_id, b.code AS bank_code, ac.requesting_bank, ac.permissions, ac.status, ac.expires_at, ac.created_at
 		FROM account_consents ac
 		LEFT JOIN banks b ON b.id = ac.bank_id
-		WHERE ac.user_id=$1 AND b.code=$2 AND (ac.expires_at IS NULL OR ac.expires_at > NOW())
+		WHERE ac.user_id=$1 AND ac.bank_id=$2 AND (ac.expires_at IS NULL OR ac.expires_at > NOW())
 	`, userID, BankID)
 	if err != nil {
 		return nil, err
@@ -182,7 +283,7 @@ func (r *Repository) GetValidAccountConsentsByEmailAndBank(email, bank string) (
 
 func (r *Repository) GetValidAccountConsentsByUserIDAndBank(userID int, bankcode string) ([]AccountConsent, error) {
 	user, _ := r.GetUserByID(userID)
-	rows, err := r.DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT 
 			ac.id,
 			ac.consent_id,
@@ -229,7 +330,7 @@ func (r *Repository) GetValidAccountConsentsByUserIDAndBank(userID int, bankcode
 
 func (r *Repository) GetValidAccountConsentsByUserID(userID int) ([]AccountConsent, error) {
 	user, _ := r.GetUserByID(userID)
-	rows, err := r.DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT 
 			ac.id,
 			ac.consent_id,
@@ -278,7 +379,7 @@ func (r *Repository) GetValidAccountConsentsByUserID(userID int) ([]AccountConse
 // It includes the bank code (joined from banks table) so callers can route requests to the correct bank.
 func (r *Repository) GetPendingAccountConsents() ([]AccountConsent, error) {
 
-	rows, err := r.DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT ac.id, ac.consent_id, ac.user_id, ac.bank_id, b.code AS bank_code, ac.requesting_bank, ac.permissions, ac.status, ac.expires_at, ac.created_at
 		FROM account_consents ac
 		LEFT JOIN banks b ON b.id = ac.bank_id
@@ -300,70 +401,88 @@ func (r *Repository) GetPendingAccountConsents() ([]AccountConsent, error) {
 	return consents, nil
 }
 
+// GetUserIDsWithValidAccountConsents returns one user id per client that has at least one approved,
+// non-expired account consent. Used by background jobs that walk all connected clients.
+func (r *Repository) GetUserIDsWithValidAccountConsents() ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT MIN(u.id)
+		FROM account_consents ac
+		JOIN users u ON u.id = ac.user_id
+		WHERE ac.status = 'approved' AND (ac.expires_at IS NULL OR ac.expires_at > NOW())
+		GROUP BY u.client_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // UpdateAccountConsentStatusByConsentID updates the status of an account_consent row identified by consent_id.
 func (r *Repository) UpdateAccountConsentStatusByConsentID(consentID string, status string) error {
-	_, err := r.DB.Exec(`UPDATE account_consents SET status=$1 WHERE consent_id=$2`, status, consentID)
+	_, err := r.db.Exec(`UPDATE account_consents SET status=$1 WHERE consent_id=$2`, status, consentID)
 	return err
 }
 
 // UpdateAccountConsentIDAndStatus replaces the consent_id for a row and updates its status.
 // This is used when a bank first returned a temporary request id and later provides a final consent id.
 func (r *Repository) UpdateAccountConsentIDAndStatus(oldConsentID, newConsentID, status string) error {
-	_, err := r.DB.Exec(`UPDATE account_consents SET consent_id=$1, status=$2 WHERE consent_id=$3`, newConsentID, status, oldConsentID)
+	_, err := r.db.Exec(`UPDATE account_consents SET consent_id=$1, status=$2 WHERE consent_id=$3`, newConsentID, status, oldConsentID)
 	return err
 }
 
 func (r *Repository) SaveProductAgreementConsent(clientID, bankCode, requestID, consentID, requestingBank string,
 	read, open, close bool, allowedTypes []string, maxAmount float64, status string, expiresAt time.Time) error {
+	return r.inTx(func(q *Repository) error {
+		return q.saveProductAgreementConsent(clientID, bankCode, requestID, consentID, requestingBank,
+			read, open, close, allowedTypes, maxAmount, status, expiresAt)
+	})
+}
 
-	tx, err := r.DB.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
+func (r *Repository) saveProductAgreementConsent(clientID, bankCode, requestID, consentID, requestingBank string,
+	read, open, close bool, allowedTypes []string, maxAmount float64, status string, expiresAt time.Time) error {
 	var userID, bankID int
 
-	if err := tx.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
 		return err
 	}
-	if err := tx.QueryRow(`SELECT id FROM users WHERE client_id=$1 AND bank_id=$2`, clientID, bankID).Scan(&userID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM users WHERE client_id=$1 AND bank_id=$2`, clientID, bankID).Scan(&userID); err != nil {
 		return err
 	}
-	_, err = tx.Exec(`
+	_, err := r.db.Exec(`
 		INSERT INTO product_agreement_consents (
 			request_id, consent_id, user_id, bank_id, requesting_bank,
 			read_product_agreements, open_product_agreements, close_product_agreements,
 			allowed_product_types, max_amount, status, expires_at
 		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
 	`, requestID, consentID, userID, bankID, requestingBank, read, open, close, pq.Array(allowedTypes), maxAmount, status, expiresAt)
-
-	if err != nil {
-		return err
-	}
-
-	return tx.Commit()
+	return err
 }
 
 func (r *Repository) GetUserByUserIDAndBank(userID int, bankCode string) (*User, error) {
-	tx, err := r.DB.Begin()
-	if err != nil {
-		return nil, err
-	}
 	var bankID int
 
-	if err := tx.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
+	if err := r.db.QueryRow(`SELECT id FROM banks WHERE code=$1`, bankCode).Scan(&bankID); err != nil {
 		return nil, err
 	}
 
 	var clientId string
-	if err := tx.QueryRow(`SELECT client_id FROM users WHERE id=$1`, userID).Scan(&clientId); err != nil {
+	if err := r.db.QueryRow(`SELECT client_id FROM users WHERE id=$1`, userID).Scan(&clientId); err != nil {
 		return nil, err
 	}
 	var u User
 
-	err = r.DB.QueryRow(`SELECT id, client_id,bank_id, email, password_hash,segment,created_at  FROM users WHERE client_id=$1 AND bank_id=$2`, clientId, bankID).
-		Scan(&u.ID, &u.ClientID, &u.BankID, &u.Email, &u.PasswordHash, &u.Segment, &u.CreatedAt)
+	err := r.db.QueryRow(`SELECT id, client_id,bank_id, email, password_hash, segment, role, base_currency, created_at FROM users WHERE client_id=$1 AND bank_id=$2`, clientId, bankID).
+		Scan(&u.ID, &u.ClientID, &u.BankID, &u.Email, &u.PasswordHash, &u.Segment, &u.Role, &u.BaseCurrency, &u.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -373,8 +492,10 @@ func (r *Repository) GetUserByUserIDAndBank(userID int, bankCode string) (*User,
 
 func (r *Repository) GetActiveProductConsentByUserAndBank(userID int, bankCode string) (*ProductAgreementConsent, error) {
 	user, err := r.GetUserByUserIDAndBank(userID, bankCode)
-	// log.Println(user)
-	rows, err := r.DB.Query(`
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`
 		SELECT ac.id, ac.request_id , ac.consent_id, ac.user_id, ac.bank_id, b.code, ac.requesting_bank,
 			   ac.read_product_agreements, ac.open_product_agreements, ac.close_product_agreements,
 			   ac.allowed_product_types, ac.max_amount, ac.status, ac.expires_at, ac.created_at
@@ -400,6 +521,48 @@ func (r *Repository) GetActiveProductConsentByUserAndBank(userID int, bankCode s
 }
 
 func (r *Repository) DeleteProductConsent(consentID string) error {
-	_, err := r.DB.Exec(`DELETE FROM product_agreement_consents WHERE consent_id=$1`, consentID)
+	_, err := r.db.Exec(`DELETE FROM product_agreement_consents WHERE consent_id=$1`, consentID)
+	return err
+}
+
+// GetPendingProductConsents returns product agreement consents still waiting for approval at the bank.
+func (r *Repository) GetPendingProductConsents() ([]ProductAgreementConsent, error) {
+	rows, err := r.db.Query(`
+		SELECT ac.id, ac.request_id, ac.consent_id, ac.user_id, ac.bank_id, b.code, ac.requesting_bank,
+			   ac.read_product_agreements, ac.open_product_agreements, ac.close_product_agreements,
+			   ac.allowed_product_types, ac.max_amount, ac.status, ac.expires_at, ac.created_at
+		FROM product_agreement_consents ac
+		LEFT JOIN banks b ON b.id = ac.bank_id
+		WHERE ac.status = 'pending'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consents []ProductAgreementConsent
+	for rows.Next() {
+		var c ProductAgreementConsent
+		var maxAmount sql.NullFloat64
+		if err := rows.Scan(&c.ID, &c.RequestID, &c.ConsentID, &c.UserID, &c.BankID, &c.BankCode,
+			&c.RequestingBank, &c.ReadProductAgreements, &c.OpenProductAgreements, &c.CloseProductAgreements,
+			pq.Array(&c.AllowedProductTypes), &maxAmount, &c.Status, &c.ExpiresAt, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.MaxAmount = maxAmount.Float64
+		consents = append(consents, c)
+	}
+	return consents, rows.Err()
+}
+
+// UpdateProductConsentStatusByConsentID updates the status of a product_agreement_consents row.
+func (r *Repository) UpdateProductConsentStatusByConsentID(consentID string, status string) error {
+	_, err := r.db.Exec(`UPDATE product_agreement_consents SET status=$1 WHERE consent_id=$2`, status, consentID)
+	return err
+}
+
+// UpdateProductConsentIDAndStatus replaces a temporary request id with the final consent id.
+func (r *Repository) UpdateProductConsentIDAndStatus(oldConsentID, newConsentID, status string) error {
+	_, err := r.db.Exec(`UPDATE product_agreement_consents SET consent_id=$1, status=$2 WHERE consent_id=$3`, newConsentID, status, oldConsentID)
 	return err
 }
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	goalColumns     = `id, user_id, name, target_amount, currency, target_date, account_id, bank_id, agreement_id, created_at`
+	ruleColumns     = `id, user_id, goal_id, type, source_account_id, round_to, percent, keep_amount, enabled, last_run_at, created_at`
+	transferColumns = `id, user_id, rule_id, goal_id, source_account_id, amount, currency, status, reason, source_key, payment_id, error, created_at, updated_at`
+
+	// clientUsers — все записи users клиента, которому принадлежит $1
+	clientUsers = `(SELECT id FROM users WHERE client_id = (SELECT client_id FROM users WHERE id=$1))`
+)
+
+func scanGoal(row interface{ Scan(...interface{}) error }, g *SavingsGoal) error {
+	return row.Scan(&g.ID, &g.UserID, &g.Name, &g.TargetAmount, &g.Currency, &g.TargetDate, &g.AccountID, &g.BankID, &g.AgreementID, &g.CreatedAt)
+}
+
+func scanRule(row interface{ Scan(...interface{}) error }, r *SweepRule) error {
+	return row.Scan(&r.ID, &r.UserID, &r.GoalID, &r.Type, &r.SourceAccountID, &r.RoundTo, &r.Percent, &r.KeepAmount, &r.Enabled, &r.LastRunAt, &r.CreatedAt)
+}
+
+func scanTransfer(row interface{ Scan(...interface{}) error }, t *SweepTransfer) error {
+	return row.Scan(&t.ID, &t.UserID, &t.RuleID, &t.GoalID, &t.SourceAccountID, &t.Amount, &t.Currency, &t.Status, &t.Reason,
+		&t.SourceKey, &t.PaymentID, &t.Error, &t.CreatedAt, &t.UpdatedAt)
+}
+
+func (r *Repository) ListSavingsGoals(userID int) ([]SavingsGoal, error) {
+	rows, err := r.db.Query(`SELECT `+goalColumns+` FROM savings_goals WHERE user_id IN `+clientUsers+` ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []SavingsGoal
+	for rows.Next() {
+		var g SavingsGoal
+		if err := scanGoal(rows, &g); err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+func (r *Repository) GetSavingsGoal(userID, id int) (*SavingsGoal, error) {
+	var g SavingsGoal
+	err := scanGoal(r.db.QueryRow(`SELECT `+goalColumns+` FROM savings_goals WHERE id=$2 AND user_id IN `+clientUsers, userID, id), &g)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *Repository) InsertSavingsGoal(g *SavingsGoal) error {
+	return r.db.QueryRow(`
+		INSERT INTO savings_goals (user_id, name, target_amount, currency, target_date, account_id, bank_id, agreement_id)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		RETURNING id, created_at
+	`, g.UserID, g.Name, g.TargetAmount, g.Currency, g.TargetDate, g.AccountID, g.BankID, g.AgreementID).Scan(&g.ID, &g.CreatedAt)
+}
+
+func (r *Repository) DeleteSavingsGoal(userID, id int) error {
+	return r.deleteOwned(`DELETE FROM savings_goals WHERE id=$2 AND user_id IN `+clientUsers, userID, id)
+}
+
+func (r *Repository) ListSweepRules(userID int) ([]SweepRule, error) {
+	rows, err := r.db.Query(`SELECT `+ruleColumns+` FROM sweep_rules WHERE user_id IN `+clientUsers+` ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []SweepRule
+	for rows.Next() {
+		var sr SweepRule
+		if err := scanRule(rows, &sr); err != nil {
+			return nil, err
+		}
+		rules = append(rules, sr)
+	}
+	return rules, rows.Err()
+}
+
+func (r *Repository) InsertSweepRule(sr *SweepRule) error {
+	return r.db.QueryRow(`
+		INSERT INTO sweep_rules (user_id, goal_id, type, source_account_id, round_to, percent, keep_amount, enabled)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		RETURNING id, created_at
+	`, sr.UserID, sr.GoalID, sr.Type, sr.SourceAccountID, sr.RoundTo, sr.Percent, sr.KeepAmount, sr.Enabled).Scan(&sr.ID, &sr.CreatedAt)
+}
+
+func (r *Repository) DeleteSweepRule(userID, id int) error {
+	return r.deleteOwned(`DELETE FROM sweep_rules WHERE id=$2 AND user_id IN `+clientUsers, userID, id)
+}
+
+func (r *Repository) SetSweepRuleRun(id int, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE sweep_rules SET last_run_at=$2 WHERE id=$1`, id, at)
+	return err
+}
+
+func (r *Repository) InsertSweepTransfer(t *SweepTransfer) (bool, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO sweep_transfers (user_id, rule_id, goal_id, source_account_id, amount, currency, status, reason, source_key)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		ON CONFLICT (rule_id, source_key) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`, t.UserID, t.RuleID, t.GoalID, t.SourceAccountID, t.Amount, t.Currency, t.Status, t.Reason, t.SourceKey).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *Repository) ListSweepTransfers(userID int, status string) ([]SweepTransfer, error) {
+	rows, err := r.db.Query(`
+		SELECT `+transferColumns+`
+		FROM sweep_transfers
+		WHERE user_id IN `+clientUsers+` AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC
+	`, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []SweepTransfer
+	for rows.Next() {
+		var t SweepTransfer
+		if err := scanTransfer(rows, &t); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+func (r *Repository) GetSweepTransfer(userID, id int) (*SweepTransfer, error) {
+	var t SweepTransfer
+	err := scanTransfer(r.db.QueryRow(`SELECT `+transferColumns+` FROM sweep_transfers WHERE id=$2 AND user_id IN `+clientUsers, userID, id), &t)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// UpdateSweepTransfer сохраняет статус, платёж и ошибку перевода
+func (r *Repository) UpdateSweepTransfer(t *SweepTransfer) error {
+	return r.db.QueryRow(`
+		UPDATE sweep_transfers SET status=$2, payment_id=$3, error=$4, updated_at=NOW()
+		WHERE id=$1
+		RETURNING updated_at
+	`, t.ID, t.Status, t.PaymentID, t.Error).Scan(&t.UpdatedAt)
+}
+
+// SetSweepTransferStatus меняет статус и ошибку, только если перевод сейчас в одном из from.
+// false — статус уже сменил кто-то другой: так перевод забирается на исполнение ровно один раз.
+func (r *Repository) SetSweepTransferStatus(t *SweepTransfer, status string, from ...string) (bool, error) {
+	err := scanTransfer(r.db.QueryRow(`
+		UPDATE sweep_transfers SET status=$2, error=$3, updated_at=NOW()
+		WHERE id=$1 AND status = ANY($4)
+		RETURNING `+transferColumns, t.ID, status, t.Error, pq.Array(from)), t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MarkStaleSweepTransfers переводит в unconfirmed переводы, застрявшие в executing
+// (процесс упал во время платежа): был ли платёж, нужно сверить с банком
+func (r *Repository) MarkStaleSweepTransfers(before time.Time) (int, error) {
+	res, err := r.db.Exec(`
+		UPDATE sweep_transfers SET status='unconfirmed', error='execution was interrupted', updated_at=NOW()
+		WHERE status='executing' AND updated_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// deleteOwned выполняет DELETE с параметрами (userID, id); sql.ErrNoRows — нечего удалять
+func (r *Repository) deleteOwned(query string, userID, id int) error {
+	res, err := r.db.Exec(query, userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// UserRepository — пользователи и справочник банков
+type UserRepository interface {
+	GetBankByCode(code string) (*Bank, error)
+	GetUserByID(id int) (*User, error)
+	GetUserByClientIDAndBank(clientID, bankCode string) (*User, error)
+	GetUserByUserIDAndBank(userID int, bankCode string) (*User, error)
+	SetUserBaseCurrency(userID int, currency string) error
+	// GetClientUserIDs — все записи users того же клиента (по одной на банк)
+	GetClientUserIDs(userID int) ([]int, error)
+}
+
+// ConsentRepository — согласия на доступ к счетам и к продуктам
+type ConsentRepository interface {
+	SaveAccountConsentByClientIdAndBank(clientID, bankCode, consentID, requestingBank string, permissions []string, status string, expires time.Time) error
+	GetValidAccountConsentsByEmailAndBank(email, bank string) ([]AccountConsent, error)
+	GetValidAccountConsentsByUserIDAndBank(userID int, bankCode string) ([]AccountConsent, error)
+	GetValidAccountConsentsByUserID(userID int) ([]AccountConsent, error)
+	GetPendingAccountConsents() ([]AccountConsent, error)
+	GetUserIDsWithValidAccountConsents() ([]int, error)
+	UpdateAccountConsentStatusByConsentID(consentID string, status string) error
+	UpdateAccountConsentIDAndStatus(oldConsentID, newConsentID, status string) error
+
+	SaveProductAgreementConsent(clientID, bankCode, requestID, consentID, requestingBank string,
+		read, open, close bool, allowedTypes []string, maxAmount float64, status string, expiresAt time.Time) error
+	GetActiveProductConsentByUserAndBank(userID int, bankCode string) (*ProductAgreementConsent, error)
+	GetPendingProductConsents() ([]ProductAgreementConsent, error)
+	UpdateProductConsentStatusByConsentID(consentID string, status string) error
+	UpdateProductConsentIDAndStatus(oldConsentID, newConsentID, status string) error
+	DeleteProductConsent(consentID string) error
+}
+
+// AccountRepository — сохранённые счета пользователя
+type AccountRepository interface {
+	GetAccountByID(id int) (*Account, error)
+	GetAccountsByUserID(userID int) ([]Account, error)
+	GetAccountByExternalID(bankCode, externalID string) (*Account, error)
+	GetAccountsByClientUser(userID int) ([]Account, error)
+	UpsertAccount(a *Account) error
+}
+
+// TransactionRepository — сохранённые операции по счетам
+type TransactionRepository interface {
+	GetTransactionByID(id int) (*Transaction, error)
+	GetTransactionsByAccountID(accountID int, from, to time.Time) ([]Transaction, error)
+	GetTransactionsByAccountIDs(accountIDs []int, from, to time.Time) ([]Transaction, error)
+	GetTransactionsAddedSince(accountID int, from, to time.Time) ([]Transaction, error)
+	InsertTransaction(t *Transaction) error
+	InsertTransactionIfNew(t *Transaction) (bool, error)
+	FirstBookingDate(accountID int) (*time.Time, error)
+	LastBookingDate(accountID int) (*time.Time, error)
+	UpdateTransactionCategory(id int, category, source string) error
+}
+
+// ProductRepository — каталог продуктов банков и договоры пользователя
+type ProductRepository interface {
+	GetProductByProductID(bankID int, productID string) (*Product, error)
+	ListProducts(bankID *int) ([]Product, error)
+	UpsertProduct(p *Product) error
+	// DeactivateMissingProducts — продукты банка, пропавшие из витрины, становятся неактивными
+	DeactivateMissingProducts(bankID int, keep []string) (int, error)
+	GetProductAgreementsByUserID(userID int) ([]ProductAgreement, error)
+	// GetPendingProductAgreements — договоры в статусе pending/processing с кодом банка
+	GetPendingProductAgreements() ([]ProductAgreement, error)
+	SaveProductAgreement(a *ProductAgreement) error
+}
+
+// AuditRepository — журнал аудита
+type AuditRepository interface {
+	InsertAuditEntry(e *AuditEntry) error
+	ListAuditEntries(f AuditFilter) ([]AuditEntry, error)
+	// CountAuditEntries — сколько всего записей подходит под фильтр (Limit и Offset не учитываются)
+	CountAuditEntries(f AuditFilter) (int, error)
+}
+
+// BalanceRepository — история балансов счетов
+type BalanceRepository interface {
+	InsertBalanceSnapshot(s *BalanceSnapshot) error
+	GetBalanceSnapshots(accountIDs []int, from, to time.Time) ([]BalanceSnapshot, error)
+}
+
+// FXRateRepository — курсы валют по дням
+type FXRateRepository interface {
+	UpsertFXRates(rates []FXRate) error
+	// GetFXRate возвращает последний курс base→quote на дату on или раньше
+	GetFXRate(base, quote string, on time.Time) (*FXRate, error)
+	ListFXRates(on time.Time) ([]FXRate, error)
+}
+
+// CategoryRepository — правила категоризации клиента
+type CategoryRepository interface {
+	// ListCategoryRules возвращает правила клиента (всех его записей users) по приоритету
+	ListCategoryRules(userID int) ([]CategoryRule, error)
+	InsertCategoryRule(r *CategoryRule) error
+	UpdateCategoryRuleCategory(id int, category string) error
+	DeleteCategoryRule(userID, id int) error
+}
+
+// BudgetRepository — бюджеты клиента и отметки об отправленных уведомлениях
+type BudgetRepository interface {
+	ListBudgets(userID int) ([]Budget, error)
+	GetBudget(userID, id int) (*Budget, error)
+	InsertBudget(b *Budget) error
+	UpdateBudget(b *Budget) error
+	DeleteBudget(userID, id int) error
+	// MarkBudgetAlert отмечает порог как отправленный; false — уже был отправлен в этом периоде
+	MarkBudgetAlert(budgetID int, periodStart time.Time, threshold int) (bool, error)
+}
+
+// RecurringRepository — решения клиента по регулярным платежам
+type RecurringRepository interface {
+	ListRecurringDecisions(userID int) ([]RecurringDecision, error)
+	SetRecurringDecision(userID int, seriesID, status string) error
+	DeleteRecurringDecision(userID int, seriesID string) error
+}
+
+// ForecastRepository — настройки прогноза остатков клиента
+type ForecastRepository interface {
+	// GetForecastThreshold возвращает sql.ErrNoRows, если порог не задан
+	GetForecastThreshold(userID int) (*ForecastThreshold, error)
+	SetForecastThreshold(t *ForecastThreshold) error
+	DeleteForecastThreshold(userID int) error
+}
+
+// CashbackRepository — правила кешбэка клиента по продуктам банков
+type CashbackRepository interface {
+	ListCashbackRules(userID int) ([]CashbackRule, error)
+	InsertCashbackRule(r *CashbackRule) error
+	DeleteCashbackRule(userID, id int) error
+}
+
+// PaymentRepository — платёжные согласия и исполненные платежи
+type PaymentRepository interface {
+	// GetActivePaymentConsents — действующие согласия клиента (всех его записей users)
+	GetActivePaymentConsents(userID int) ([]PaymentConsent, error)
+	InsertPayment(p *Payment) error
+}
+
+// SavingsRepository — цели накоплений, правила автопополнения и переводы по ним
+type SavingsRepository interface {
+	ListSavingsGoals(userID int) ([]SavingsGoal, error)
+	GetSavingsGoal(userID, id int) (*SavingsGoal, error)
+	InsertSavingsGoal(g *SavingsGoal) error
+	DeleteSavingsGoal(userID, id int) error
+
+	ListSweepRules(userID int) ([]SweepRule, error)
+	InsertSweepRule(r *SweepRule) error
+	DeleteSweepRule(userID, id int) error
+	SetSweepRuleRun(id int, at time.Time) error
+
+	// InsertSweepTransfer возвращает false, если перевод с тем же (rule_id, source_key) уже есть
+	InsertSweepTransfer(t *SweepTransfer) (bool, error)
+	// ListSweepTransfers — переводы клиента; пустой status — все
+	ListSweepTransfers(userID int, status string) ([]SweepTransfer, error)
+	GetSweepTransfer(userID, id int) (*SweepTransfer, error)
+	UpdateSweepTransfer(t *SweepTransfer) error
+	// SetSweepTransferStatus возвращает false, если перевод уже не в одном из статусов from
+	SetSweepTransferStatus(t *SweepTransfer, status string, from ...string) (bool, error)
+	MarkStaleSweepTransfers(before time.Time) (int, error)
+}
+
+// ExportRepository — потоковое чтение операций для выгрузки выписок
+type ExportRepository interface {
+	StreamTransactions(accountIDs []int, from, to time.Time, fn func(Transaction) error) error
+}
+
+// ReportRepository — сохранённые ежемесячные отчёты клиента
+type ReportRepository interface {
+	GetMonthlyReport(userID int, month string) (*MonthlyReport, error)
+	SaveMonthlyReport(m *MonthlyReport) error
+}
+
+// ManualAccountRepository — удаление ручных счетов и их операций, сдвиг остатка
+type ManualAccountRepository interface {
+	DeleteAccount(id int) error
+	DeleteTransaction(id int) error
+	AddAccountBalance(id int, delta float64) (float64, error)
+}
+
+// Store объединяет все репозитории и умеет выполнять их в одной транзакции
+type Store interface {
+	UserRepository
+	ConsentRepository
+	AccountRepository
+	TransactionRepository
+	ProductRepository
+	AuditRepository
+	BalanceRepository
+	FXRateRepository
+	CategoryRepository
+	BudgetRepository
+	RecurringRepository
+	ForecastRepository
+	CashbackRepository
+	PaymentRepository
+	SavingsRepository
+	ExportRepository
+	ReportRepository
+	ManualAccountRepository
+
+	// WithTx выполняет fn атомарно: если fn вернула ошибку, все изменения откатываются.
+	// Внутри fn нужно работать только через переданный tx.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+	// WithContext привязывает запросы к ctx: их спаны попадают в трейс запроса API или задачи
+	WithContext(ctx context.Context) Store
+}
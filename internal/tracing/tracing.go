@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Экспортёры трейсов (OTEL_TRACES_EXPORTER)
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"   // OTLP/HTTP, адрес из OTEL_EXPORTER_OTLP_ENDPOINT
+	ExporterStdout = "stdout" // для локальной отладки
+)
+
+// Setup настраивает глобальный TracerProvider и W3C-пропагацию (traceparent, baggage).
+// Пропагация включена и без экспортёра: входящий traceparent уходит дальше в банки.
+// Sampler настраивается стандартными OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG.
+// Возвращает функцию, которая дописывает накопленные спаны при остановке.
+func Setup(ctx context.Context, exporter, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exp, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q (expected otlp, stdout or none)", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	slog.Info("tracing enabled", "component", "tracing", "exporter", exporter, "service", service)
+	return tp.Shutdown, nil
+}
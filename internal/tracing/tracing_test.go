@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	if _, err := Setup(context.Background(), "jaeger", "moneypilot-api"); err == nil {
+		t.Error("unknown exporter: want error")
+	}
+
+	for _, exporter := range []string{"", ExporterNone, ExporterStdout} {
+		shutdown, err := Setup(context.Background(), exporter, "moneypilot-api")
+		if err != nil {
+			t.Fatalf("%q: %v", exporter, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("%q: shutdown: %v", exporter, err)
+		}
+	}
+
+	// пропагация включена и без экспортёра: входящий traceparent уходит дальше
+	if _, err := Setup(context.Background(), ExporterNone, "moneypilot-api"); err != nil {
+		t.Fatal(err)
+	}
+	in := http.Header{"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(in))
+	if sc := trace.SpanContextFromContext(ctx); sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("extracted trace id = %s", sc.TraceID())
+	}
+	out := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(out))
+	if got := out.Get("Traceparent"); got != in.Get("Traceparent") {
+		t.Errorf("injected traceparent = %q, want %q", got, in.Get("Traceparent"))
+	}
+}
@@ -0,0 +1,152 @@
+package transactions
+
+import (
+	"MoneyPilot/internal/fx"
+	"MoneyPilot/internal/money"
+	"MoneyPilot/internal/storage"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Syncer *Syncer
+	FX     *fx.Service
+}
+
+func NewHandler(s *Syncer, fxSvc *fx.Service) *Handler {
+	return &Handler{Syncer: s, FX: fxSvc}
+}
+
+// Totals — поступления и расходы за период в одной валюте
+type Totals struct {
+	Currency string  `json:"currency"`
+	Income   float64 `json:"income"`
+	Expenses float64 `json:"expenses"` // положительное число
+	Net      float64 `json:"net"`
+}
+
+// ListTransactions — GET /api/transactions?from=&to=&category=&currency=
+// Сохранённые (синхронизированные) операции клиента по всем банкам с категориями.
+// Итоги пересчитываются в currency (по умолчанию — базовая валюта клиента) по курсу на дату операции.
+func (h *Handler) ListTransactions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, -1, 0)
+	for param, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		if v := c.Query(param); v != "" {
+			t, err := parseTime(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param, "details": err.Error()})
+				return
+			}
+			*dst = t
+		}
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency != "" && !fx.ValidCurrency(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid currency"})
+		return
+	}
+
+	accs, err := h.Syncer.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load accounts", "details": err.Error()})
+		return
+	}
+	ids := make([]int, len(accs))
+	for i, a := range accs {
+		ids[i] = a.ID
+	}
+	txs, err := h.Syncer.Repo.GetTransactionsByAccountIDs(ids, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load transactions", "details": err.Error()})
+		return
+	}
+
+	if category := c.Query("category"); category != "" {
+		filtered := txs[:0]
+		for _, t := range txs {
+			if t.Category != nil && *t.Category == category {
+				filtered = append(filtered, t)
+			}
+		}
+		txs = filtered
+	}
+
+	if currency == "" {
+		if currency, err = h.FX.BaseCurrency(userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load base currency", "details": err.Error()})
+			return
+		}
+	}
+	totals, err := h.totals(accs, txs, currency)
+	if errors.Is(err, fx.ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to convert totals", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(txs), "totals": totals, "transactions": txs})
+}
+
+// totals суммирует операции в currency; операция без валюты — в валюте своего счёта
+func (h *Handler) totals(accs []storage.Account, txs []storage.Transaction, currency string) (Totals, error) {
+	accCurrency := make(map[int]string, len(accs))
+	for _, a := range accs {
+		accCurrency[a.ID] = a.Currency
+	}
+	res := Totals{Currency: currency}
+	for _, t := range txs {
+		from := accCurrency[t.AccountID]
+		if t.Currency != nil && *t.Currency != "" {
+			from = *t.Currency
+		}
+		amount, err := money.Convert(h.FX, t.Amount, from, currency, t.BookingDate)
+		if err != nil {
+			return res, err
+		}
+		if amount >= 0 {
+			res.Income += amount
+		} else {
+			res.Expenses -= amount
+		}
+	}
+	res.Income = money.Round(res.Income)
+	res.Expenses = money.Round(res.Expenses)
+	res.Net = money.Round(res.Income - res.Expenses)
+	return res, nil
+}
+
+// Sync — POST /api/transactions/sync: синхронизировать операции клиента сейчас
+func (h *Handler) Sync(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	n, err := h.Syncer.SyncUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to sync transactions", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"new": n})
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
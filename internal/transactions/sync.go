@@ -0,0 +1,297 @@
+package transactions
+
+import (
+	"MoneyPilot/internal/accounts"
+	"MoneyPilot/internal/audit"
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/categories"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pageSize = 100
+	maxPages = 50
+	// firstSync — глубина первой загрузки операций счёта
+	firstSync = 90 * 24 * time.Hour
+	// overlap — повторно запрашиваем хвост, чтобы не потерять поздно проведённые операции
+	overlap = 3 * 24 * time.Hour
+)
+
+// Listener получает только что сохранённые операции клиента
+type Listener interface {
+	TransactionsSynced(ctx context.Context, userID int, txs []storage.Transaction)
+}
+
+// Syncer загружает операции сохранённых счетов из банков, категоризирует и сохраняет новые
+type Syncer struct {
+	Repo       storage.Store
+	Accounts   *accounts.Service
+	Categories *categories.Service
+	Banks      map[string]*bankapi.BankClient
+	Log        *slog.Logger
+
+	listeners []Listener
+}
+
+func NewSyncer(repo storage.Store, accountSvc *accounts.Service, categorySvc *categories.Service, banks map[string]*bankapi.BankClient, logger *slog.Logger) *Syncer {
+	return &Syncer{Repo: repo, Accounts: accountSvc, Categories: categorySvc, Banks: banks, Log: logging.For(logger, "transactions")}
+}
+
+func (s *Syncer) AddListener(l Listener) {
+	s.listeners = append(s.listeners, l)
+}
+
+// Start запускает синхронизацию сразу и затем раз в interval
+func (s *Syncer) Start(interval time.Duration, stopCh <-chan struct{}) {
+	s.Log.Info("sync started", "interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.SyncAll(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				s.SyncAll(context.Background())
+			case <-stopCh:
+				s.Log.Info("stopping")
+				return
+			}
+		}
+	}()
+}
+
+func (s *Syncer) SyncAll(ctx context.Context) {
+	userIDs, err := s.Repo.GetUserIDsWithValidAccountConsents()
+	if err != nil {
+		s.Log.ErrorContext(ctx, "failed to load users", logging.Err(err))
+		return
+	}
+	for _, userID := range userIDs {
+		n, err := s.SyncUser(audit.WithUserID(ctx, userID), userID)
+		if err != nil {
+			s.Log.ErrorContext(ctx, "sync failed", "user_id", userID, logging.Err(err))
+			continue
+		}
+		if n > 0 {
+			s.Log.InfoContext(ctx, "synced", "user_id", userID, "new", n)
+		}
+	}
+}
+
+// SyncUser загружает новые операции по всем сохранённым счетам клиента.
+// Счета в accounts появляются после снимка балансов.
+func (s *Syncer) SyncUser(ctx context.Context, userID int) (int, error) {
+	accs, err := s.Repo.GetAccountsByClientUser(userID)
+	if err != nil {
+		return 0, err
+	}
+	matcher, err := s.Categories.Matcher(userID)
+	if err != nil {
+		return 0, err
+	}
+	codes := s.bankCodes()
+
+	var fresh []storage.Transaction
+	for _, acc := range accs {
+		bankCode := codes[acc.BankID]
+		if acc.ExternalID == nil || bankCode == "" {
+			continue
+		}
+		txs, err := s.syncAccount(ctx, userID, bankCode, acc, matcher)
+		if err != nil {
+			s.Log.ErrorContext(ctx, "account sync failed", "bank", bankCode, "account_id", acc.ID, logging.Err(err))
+		}
+		fresh = append(fresh, txs...)
+	}
+
+	s.Notify(ctx, userID, fresh)
+	return len(fresh), nil
+}
+
+// Notify передаёт слушателям операции, сохранённые в обход синхронизации (импорт выписки)
+func (s *Syncer) Notify(ctx context.Context, userID int, txs []storage.Transaction) {
+	if len(txs) == 0 {
+		return
+	}
+	for _, l := range s.listeners {
+		l.TransactionsSynced(ctx, userID, txs)
+	}
+}
+
+func (s *Syncer) syncAccount(ctx context.Context, userID int, bankCode string, acc storage.Account, matcher *categories.Matcher) ([]storage.Transaction, error) {
+	now := time.Now().UTC()
+	from := now.Add(-firstSync)
+	last, err := s.Repo.LastBookingDate(acc.ID)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		from = last.Add(-overlap)
+	}
+
+	var fresh []storage.Transaction
+	for page := 1; page <= maxPages; page++ {
+		raw, err := s.Accounts.FetchAccountTransactions(ctx, userID, bankCode, *acc.ExternalID,
+			from.Format(time.RFC3339), now.Format(time.RFC3339), strconv.Itoa(page), strconv.Itoa(pageSize))
+		if err != nil {
+			return fresh, err
+		}
+		items, more, err := parse(raw)
+		if err != nil {
+			return fresh, err
+		}
+		for _, it := range items {
+			t := it.toTransaction(acc)
+			matcher.Apply(&t)
+			inserted, err := s.Repo.InsertTransactionIfNew(&t)
+			if err != nil {
+				return fresh, err
+			}
+			if inserted {
+				fresh = append(fresh, t)
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return fresh, nil
+}
+
+func (s *Syncer) bankCodes() map[int]string {
+	codes := map[int]string{}
+	for code := range s.Banks {
+		if b, err := s.Repo.GetBankByCode(code); err == nil {
+			codes[b.ID] = code
+		}
+	}
+	return codes
+}
+
+// bankTransaction — операция в формате OpenBanking
+type bankTransaction struct {
+	TransactionID string `json:"transactionId"`
+	Amount        struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	} `json:"amount"`
+	CreditDebitIndicator   string `json:"creditDebitIndicator"`
+	Status                 string `json:"status"`
+	BookingDateTime        string `json:"bookingDateTime"`
+	ValueDateTime          string `json:"valueDateTime"`
+	TransactionInformation string `json:"transactionInformation"`
+	Merchant               *struct {
+		Name                 string `json:"name"`
+		MCCCode              string `json:"mccCode"`
+		MerchantCategoryCode string `json:"merchantCategoryCode"`
+	} `json:"merchant"`
+	CreditorAccount *struct {
+		Name string `json:"name"`
+	} `json:"creditorAccount"`
+	DebtorAccount *struct {
+		Name string `json:"name"`
+	} `json:"debtorAccount"`
+
+	amount float64
+	booked time.Time
+}
+
+// parse возвращает принятые операции страницы и признак следующей страницы.
+// Следующая страница есть, если банк дал ссылку links.next, а без links —
+// если страница заполнена целиком (считая отброшенные строки).
+func parse(raw map[string]interface{}) ([]bankTransaction, bool, error) {
+	buf, _ := json.Marshal(raw)
+	var parsed struct {
+		Data struct {
+			Transaction []bankTransaction `json:"transaction"`
+		} `json:"data"`
+		Links *struct {
+			Next string `json:"next"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(buf, &parsed); err != nil {
+		return nil, false, fmt.Errorf("unexpected transactions response: %w", err)
+	}
+	more := len(parsed.Data.Transaction) >= pageSize
+	if parsed.Links != nil {
+		more = parsed.Links.Next != ""
+	}
+
+	items := parsed.Data.Transaction[:0]
+	for _, it := range parsed.Data.Transaction {
+		if it.TransactionID == "" || strings.EqualFold(it.Status, "Rejected") {
+			continue
+		}
+		amount, err := strconv.ParseFloat(it.Amount.Amount, 64)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(it.CreditDebitIndicator, "Debit") {
+			amount = -amount
+		}
+		it.amount = amount
+
+		date := it.BookingDateTime
+		if date == "" {
+			date = it.ValueDateTime
+		}
+		booked, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			continue
+		}
+		it.booked = booked
+		items = append(items, it)
+	}
+	return items, more, nil
+}
+
+func (it bankTransaction) toTransaction(acc storage.Account) storage.Transaction {
+	id := it.TransactionID
+	t := storage.Transaction{
+		AccountID:   acc.ID,
+		ExternalID:  &id,
+		Amount:      it.amount,
+		BookingDate: it.booked,
+	}
+	if it.Amount.Currency != "" {
+		cur := it.Amount.Currency
+		t.Currency = &cur
+	} else {
+		t.Currency = &acc.Currency
+	}
+	if it.TransactionInformation != "" {
+		info := it.TransactionInformation
+		t.Description = &info
+	}
+
+	var counterparty, mcc string
+	if it.Merchant != nil {
+		counterparty = it.Merchant.Name
+		mcc = it.Merchant.MCCCode
+		if mcc == "" {
+			mcc = it.Merchant.MerchantCategoryCode
+		}
+	}
+	if counterparty == "" && it.amount < 0 && it.CreditorAccount != nil {
+		counterparty = it.CreditorAccount.Name
+	}
+	if counterparty == "" && it.amount > 0 && it.DebtorAccount != nil {
+		counterparty = it.DebtorAccount.Name
+	}
+	if counterparty != "" {
+		t.Counterparty = &counterparty
+	}
+	if mcc != "" {
+		t.MCC = &mcc
+	}
+	return t
+}
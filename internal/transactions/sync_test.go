@@ -0,0 +1,140 @@
+package transactions
+
+import (
+	"MoneyPilot/internal/accounts"
+	"MoneyPilot/internal/bankapi"
+	"MoneyPilot/internal/categories"
+	"MoneyPilot/internal/storage"
+	"MoneyPilot/internal/storage/memory"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// row — операция банка в формате OpenBanking
+func row(id, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"transactionId":        id,
+		"amount":               map[string]string{"amount": "100.00", "currency": "RUB"},
+		"creditDebitIndicator": "Debit",
+		"status":               status,
+		"bookingDateTime":      time.Now().UTC().Add(-time.Hour).Format(time.RFC3339),
+		"merchant":             map[string]string{"name": "Магнит", "mccCode": "5411"},
+	}
+}
+
+func fullPage(prefix string, rejected int) []map[string]interface{} {
+	rows := make([]map[string]interface{}, pageSize)
+	for i := range rows {
+		status := "Booked"
+		if i < rejected {
+			status = "Rejected"
+		}
+		rows[i] = row(fmt.Sprintf("%s-%d", prefix, i), status)
+	}
+	return rows
+}
+
+func TestSyncPaging(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages []map[string]interface{} // ответы банка по номерам страниц
+		want  int
+		calls int
+	}{
+		{
+			name: "filtered row on a full page",
+			pages: []map[string]interface{}{
+				{"data": map[string]interface{}{"transaction": fullPage("p1", 1)}},
+				{"data": map[string]interface{}{"transaction": []map[string]interface{}{row("p2-0", "Booked")}}},
+			},
+			want: pageSize, calls: 2,
+		},
+		{
+			name: "short page",
+			pages: []map[string]interface{}{
+				{"data": map[string]interface{}{"transaction": []map[string]interface{}{row("a", "Booked"), row("b", "Pending")}}},
+				{"data": map[string]interface{}{"transaction": []map[string]interface{}{row("c", "Booked")}}},
+			},
+			want: 2, calls: 1,
+		},
+		{
+			name: "next link",
+			pages: []map[string]interface{}{
+				{"data": map[string]interface{}{"transaction": []map[string]interface{}{row("a", "Booked")}}, "links": map[string]string{"next": "/page/2"}},
+				{"data": map[string]interface{}{"transaction": []map[string]interface{}{row("b", "Booked")}}, "links": map[string]string{"self": "/page/2"}},
+				{"data": map[string]interface{}{"transaction": []map[string]interface{}{row("c", "Booked")}}},
+			},
+			want: 2, calls: 2,
+		},
+		{
+			name: "full page without next link",
+			pages: []map[string]interface{}{
+				{"data": map[string]interface{}{"transaction": fullPage("p1", 0)}, "links": map[string]string{"self": "/page/1"}},
+				{"data": map[string]interface{}{"transaction": []map[string]interface{}{row("b", "Booked")}}},
+			},
+			want: pageSize, calls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/auth/bank-token", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"access_token":"token"}`))
+			})
+			mux.HandleFunc("/accounts/ext-1/transactions", func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				if page < 1 || page > len(tt.pages) {
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"transaction": []interface{}{}}})
+					return
+				}
+				json.NewEncoder(w).Encode(tt.pages[page-1])
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			repo := memory.New()
+			bank := repo.AddBank(storage.Bank{Code: "vbank"})
+			u := repo.AddUser(storage.User{ClientID: "team-1", BankID: &bank.ID})
+			repo.SaveAccountConsentByClientIdAndBank("team-1", "vbank", "acc-1", "team", nil, "approved", time.Now().Add(time.Hour))
+			ext := "ext-1"
+			acc := &storage.Account{UserID: u.ID, BankID: bank.ID, ExternalID: &ext, AccountNumber: "40817", Currency: "RUB"}
+			if err := repo.UpsertAccount(acc); err != nil {
+				t.Fatal(err)
+			}
+
+			// Redis недоступен: токен каждый раз берётся у тестового банка
+			rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+			defer rdb.Close()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			banks := map[string]*bankapi.BankClient{"vbank": {Name: "vbank", BaseURL: srv.URL}}
+			accountSvc := accounts.NewService(repo, bankapi.NewTokenService(rdb, logger), banks, srv.Client())
+			s := NewSyncer(repo, accountSvc, categories.NewService(repo, repo, logger), banks, logger)
+
+			n, err := s.SyncUser(context.Background(), u.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want || calls != tt.calls {
+				t.Errorf("synced %d in %d requests, want %d in %d", n, calls, tt.want, tt.calls)
+			}
+			txs, _ := repo.GetTransactionsAddedSince(acc.ID, time.Time{}, time.Now().Add(time.Hour))
+			for _, tx := range txs {
+				if tx.Category == nil || *tx.Category != categories.Groceries {
+					t.Fatalf("transaction %s category = %v, want groceries", *tx.ExternalID, tx.Category)
+				}
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package websockets
 
 import (
-	"log"
+	"MoneyPilot/internal/logging"
+	"MoneyPilot/internal/metrics"
+	"log/slog"
 	"net/http"
 	"sync"
 
@@ -11,13 +13,15 @@ import (
 
 // WebSocketHub управляет всеми подключениями
 type WebSocketHub struct {
-	clients map[*websocket.Conn]bool
+	clients map[*websocket.Conn]int // conn → user_id (0 — анонимное подключение)
 	mu      sync.Mutex
+	log     *slog.Logger
 }
 
-func NewHub() *WebSocketHub {
+func NewHub(logger *slog.Logger) *WebSocketHub {
 	return &WebSocketHub{
-		clients: make(map[*websocket.Conn]bool),
+		clients: make(map[*websocket.Conn]int),
+		log:     logging.For(logger, "ws"),
 	}
 }
 
@@ -29,23 +33,25 @@ var upgrader = websocket.Upgrader{
 func (h *WebSocketHub) HandleConnection(c *gin.Context) {
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Println("[ws] upgrade error:", err)
+		h.log.WarnContext(c.Request.Context(), "upgrade failed", logging.Err(err))
 		return
 	}
 
+	userID := c.GetInt("user_id") // есть, если подключились с ?token=
 	h.mu.Lock()
-	h.clients[conn] = true
+	h.clients[conn] = userID
 	h.mu.Unlock()
+	metrics.WebSocketClients.Inc()
 
-	log.Println("[ws] new client connected")
+	h.log.InfoContext(c.Request.Context(), "client connected", "user_id", userID)
 
 	go func() {
 		defer func() {
 			h.mu.Lock()
-			delete(h.clients, conn)
+			h.remove(conn)
 			h.mu.Unlock()
 			conn.Close()
-			log.Println("[ws] client disconnected")
+			h.log.Info("client disconnected", "user_id", userID)
 		}()
 
 		for {
@@ -63,9 +69,39 @@ func (h *WebSocketHub) Broadcast(message string) {
 	defer h.mu.Unlock()
 	for conn := range h.clients {
 		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
-			log.Println("[ws] write error:", err)
+			h.log.Warn("write failed, message dropped", "user_id", h.clients[conn], logging.Err(err))
+			metrics.WebSocketDropped.Inc()
 			conn.Close()
-			delete(h.clients, conn)
+			h.remove(conn)
 		}
 	}
 }
+
+// SendToUsers — отправка только подключениям указанных пользователей
+func (h *WebSocketHub) SendToUsers(userIDs []int, message string) {
+	targets := map[int]bool{}
+	for _, id := range userIDs {
+		targets[id] = true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn, userID := range h.clients {
+		if userID == 0 || !targets[userID] {
+			continue
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			h.log.Warn("write failed, message dropped", "user_id", userID, logging.Err(err))
+			metrics.WebSocketDropped.Inc()
+			conn.Close()
+			h.remove(conn)
+		}
+	}
+}
+
+// remove убирает подключение; вызывается под h.mu и может прийти дважды — из записи и из чтения
+func (h *WebSocketHub) remove(conn *websocket.Conn) {
+	if _, ok := h.clients[conn]; ok {
+		delete(h.clients, conn)
+		metrics.WebSocketClients.Dec()
+	}
+}
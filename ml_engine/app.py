@@ -9,8 +9,10 @@ from core.advisor import generate_advice
 from core.can_afford.can_afford_rule import can_afford_rule
 from models.spending_predictor import SpendingPredictor
 from services.go_api_client import GoApiClient
+from services.tracing import setup_tracing
 
 app = FastAPI(title="FinBalance ML Engine")
+setup_tracing(app)
 
 app.add_middleware(
     CORSMiddleware,
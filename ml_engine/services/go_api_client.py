@@ -9,6 +9,15 @@ class GoApiClient:
     def __init__(self, token: str):
         self.headers = {"Authorization": token}
         self.client = httpx.AsyncClient()
+        self.currency: Optional[str] = None
+
+    async def base_currency(self) -> str:
+        """Базовая валюта клиента: в неё Go API пересчитывает суммы, чтобы правила не складывали разные валюты."""
+        if self.currency is None:
+            resp = await self.client.get(f"{GO_API_BASE}/api/fx/base-currency", headers=self.headers)
+            resp.raise_for_status()
+            self.currency = resp.json()["currency"]
+        return self.currency
 
     async def get_accounts(self):
         resp = await self.client.get(f"{GO_API_BASE}/api/accounts", headers=self.headers)
@@ -18,7 +27,8 @@ class GoApiClient:
     async def get_balances(self, account_id: str, bank_code: str):
         resp = await self.client.get(
             f"{GO_API_BASE}/api/accounts/{account_id}/balances",
-            headers={**self.headers, "X-Bank-Code": bank_code}
+            headers={**self.headers, "X-Bank-Code": bank_code},
+            params={"currency": await self.base_currency()}
         )
         resp.raise_for_status()
         balances = resp.json().get("data", {}).get("balance", [])
@@ -45,7 +55,8 @@ class GoApiClient:
     ):
         params = {
             "page": page,
-            "limit": limit
+            "limit": limit,
+            "currency": await self.base_currency()
         }
         if date_from:
             params["from"] = date_from
@@ -0,0 +1,32 @@
+import os
+
+from opentelemetry import trace
+from opentelemetry.instrumentation.fastapi import FastAPIInstrumentor
+from opentelemetry.instrumentation.httpx import HTTPXClientInstrumentor
+from opentelemetry.sdk.resources import SERVICE_NAME, Resource
+from opentelemetry.sdk.trace import TracerProvider
+from opentelemetry.sdk.trace.export import BatchSpanProcessor, ConsoleSpanExporter
+
+
+def setup_tracing(app):
+    """
+    Трейсинг ML-движка: спаны входящих запросов FastAPI и исходящих запросов httpx.
+    httpx добавляет traceparent, поэтому вызовы GoApiClient продолжают трейс в Go API.
+    OTEL_TRACES_EXPORTER: otlp (адрес из OTEL_EXPORTER_OTLP_ENDPOINT) | stdout | none.
+    """
+    exporter = os.getenv("OTEL_TRACES_EXPORTER", "none")
+    if exporter != "none":
+        service = os.getenv("OTEL_SERVICE_NAME", "moneypilot-ml-engine")
+        provider = TracerProvider(resource=Resource.create({SERVICE_NAME: service}))
+        if exporter == "otlp":
+            from opentelemetry.exporter.otlp.proto.http.trace_exporter import OTLPSpanExporter
+            provider.add_span_processor(BatchSpanProcessor(OTLPSpanExporter()))
+        elif exporter in ("stdout", "console"):
+            provider.add_span_processor(BatchSpanProcessor(ConsoleSpanExporter()))
+        else:
+            raise ValueError(f"unknown traces exporter {exporter!r} (expected otlp, stdout or none)")
+        trace.set_tracer_provider(provider)
+
+    # пропагация W3C работает и без экспортёра: входящий traceparent уходит в Go API
+    FastAPIInstrumentor.instrument_app(app)
+    HTTPXClientInstrumentor().instrument()